@@ -5,6 +5,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/kelda/kelda/api"
@@ -32,6 +33,16 @@ type Client interface {
 	// QueryContainers retrieves the containers tracked by the Kelda daemon.
 	QueryContainers() ([]db.Container, error)
 
+	// QueryMachinesWhere retrieves the machines tracked by the Kelda daemon
+	// that match `q`.  The daemon filters the machines, so only the matching
+	// machines, and their selected fields, are sent.
+	QueryMachinesWhere(q api.Query) ([]db.Machine, error)
+
+	// QueryContainersWhere retrieves the containers tracked by the Kelda
+	// daemon that match `q`.  The daemon filters the containers, so only the
+	// matching containers, and their selected fields, are sent.
+	QueryContainersWhere(q api.Query) ([]db.Container, error)
+
 	// QueryEtcd retrieves the etcd information tracked by the Kelda daemon.
 	QueryEtcd() ([]db.Etcd, error)
 
@@ -56,6 +67,33 @@ type Client interface {
 	// QueryImages retrieves the image information tracked by the Kelda daemon.
 	QueryImages() ([]db.Image, error)
 
+	// QueryJobs retrieves the jobs, and the history of their runs, tracked by
+	// the Kelda daemon.
+	QueryJobs() ([]db.Job, error)
+
+	// QueryEvents retrieves the events tracked by the Kelda daemon.
+	QueryEvents() ([]db.Event, error)
+
+	// WatchMachines streams the machines tracked by the Kelda daemon,
+	// starting with a snapshot of every machine, followed by each change.
+	WatchMachines() (api.MachineWatcher, error)
+
+	// WatchContainers streams the containers tracked by the Kelda daemon,
+	// starting with a snapshot of every container, followed by each change.
+	// The daemon streams the leader's view of the containers, so unlike
+	// QueryContainers, the attributes that the workers track aren't merged
+	// in: Created, DockerID, Status, RestartCount, ExitCode, and OOMKilled
+	// may be missing or stale, and differ from what QueryContainers returns.
+	WatchContainers() (api.ContainerWatcher, error)
+
+	// WatchConnections streams the connections tracked by the Kelda daemon,
+	// starting with a snapshot of every connection, followed by each change.
+	WatchConnections() (api.ConnectionWatcher, error)
+
+	// WatchEvents streams the events tracked by the Kelda daemon, starting
+	// with a snapshot of the events that have already happened.
+	WatchEvents() (api.EventLogWatcher, error)
+
 	// SetSecret sets the value of a named secret in the cluster. The value is
 	// encrypted and stored in Vault.
 	SetSecret(name, value string) error
@@ -64,16 +102,34 @@ type Client interface {
 	// Only defined on the daemon.
 	Deploy(deployment string) error
 
+	// Plan computes the changes that deploying the given deployment would
+	// cause, without deploying it. Only defined on the daemon.
+	Plan(deployment string) (api.Plan, error)
+
+	// Scale sets the number of replicas of the container with the given
+	// hostname in the deployed blueprint. Only defined on the daemon.
+	Scale(hostname string, replicas int) error
+
+	// Rollout pauses, resumes, or aborts the rolling update of the given
+	// group of containers. Only defined on the daemon.
+	Rollout(action, group string) error
+
 	// Version retrieves the Kelda version of the remote daemon.
 	Version() (string, error)
+
+	// SetNamespace makes the client's queries and secrets apply to the
+	// deployment in `namespace`.  By default, the daemon applies them to the
+	// only namespace it's deploying.  Minions ignore the namespace.
+	SetNamespace(namespace string)
 }
 
 // Getter obtains a client connected to the given address.
 type Getter func(string, connection.Credentials) (Client, error)
 
 type clientImpl struct {
-	pbClient pb.APIClient
-	cc       *grpc.ClientConn
+	pbClient  pb.APIClient
+	cc        *grpc.ClientConn
+	namespace string
 }
 
 // New creates a new Kelda client connected to `lAddr`.
@@ -95,7 +151,7 @@ func New(lAddr string, creds connection.Credentials) (Client, error) {
 	}
 
 	pbClient := pb.NewAPIClient(cc)
-	return clientImpl{
+	return &clientImpl{
 		pbClient: pbClient,
 		cc:       cc,
 	}, nil
@@ -103,9 +159,30 @@ func New(lAddr string, creds connection.Credentials) (Client, error) {
 
 // Writes the result into `v` a pointer to a slice of database structs.  For example
 // *[]db.Machine.
-func query(pbClient pb.APIClient, table db.TableType, v interface{}) error {
+func query(pbClient pb.APIClient, namespace string, table db.TableType,
+	v interface{}) error {
+	return queryWhere(pbClient, namespace, table, api.Query{}, v)
+}
+
+// queryWhere is like query, but only writes the rows that match `q`.
+func queryWhere(pbClient pb.APIClient, namespace string, table db.TableType,
+	q api.Query, v interface{}) error {
+
+	var filters []string
+	for field, value := range q.Where {
+		filters = append(filters, field+"="+value)
+	}
+	sort.Strings(filters)
+
 	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
-	reply, err := pbClient.Query(ctx, &pb.DBQuery{Table: string(table)})
+	reply, err := pbClient.Query(ctx, &pb.DBQuery{
+		Table:     string(table),
+		Namespace: namespace,
+		Filters:   filters,
+		Fields:    q.Fields,
+		Offset:    int32(q.Offset),
+		Limit:     int32(q.Limit),
+	})
 	if err != nil {
 		return err
 	}
@@ -122,44 +199,72 @@ func (c clientImpl) Close() error {
 // QueryMachines retrieves the machines tracked by the Kelda daemon.
 func (c clientImpl) QueryMachines() ([]db.Machine, error) {
 	var rows []db.Machine
-	return rows, query(c.pbClient, db.MachineTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.MachineTable, &rows)
 }
 
 // QueryContainers retrieves the containers tracked by the Kelda daemon.
 func (c clientImpl) QueryContainers() ([]db.Container, error) {
 	var rows []db.Container
-	return rows, query(c.pbClient, db.ContainerTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.ContainerTable, &rows)
+}
+
+// QueryMachinesWhere retrieves the machines tracked by the Kelda daemon that
+// match `q`.
+func (c clientImpl) QueryMachinesWhere(q api.Query) ([]db.Machine, error) {
+	var rows []db.Machine
+	return rows, queryWhere(c.pbClient, c.namespace, db.MachineTable, q, &rows)
+}
+
+// QueryContainersWhere retrieves the containers tracked by the Kelda daemon
+// that match `q`.
+func (c clientImpl) QueryContainersWhere(q api.Query) ([]db.Container, error) {
+	var rows []db.Container
+	return rows, queryWhere(c.pbClient, c.namespace, db.ContainerTable, q,
+		&rows)
 }
 
 // QueryEtcd retrieves the etcd information tracked by the Kelda daemon.
 func (c clientImpl) QueryEtcd() ([]db.Etcd, error) {
 	var rows []db.Etcd
-	return rows, query(c.pbClient, db.EtcdTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.EtcdTable, &rows)
 }
 
 // QueryConnections retrieves the connection information tracked by the Kelda daemon.
 func (c clientImpl) QueryConnections() ([]db.Connection, error) {
 	var rows []db.Connection
-	return rows, query(c.pbClient, db.ConnectionTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.ConnectionTable, &rows)
 }
 
 // QueryLoadBalancers retrieves the load balancer information tracked by the
 // Kelda daemon.
 func (c clientImpl) QueryLoadBalancers() ([]db.LoadBalancer, error) {
 	var rows []db.LoadBalancer
-	return rows, query(c.pbClient, db.LoadBalancerTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.LoadBalancerTable, &rows)
 }
 
 // QueryBlueprints retrieves the blueprint information tracked by the Kelda daemon.
 func (c clientImpl) QueryBlueprints() ([]db.Blueprint, error) {
 	var rows []db.Blueprint
-	return rows, query(c.pbClient, db.BlueprintTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.BlueprintTable, &rows)
 }
 
 // QueryImages retrieves the image information tracked by the Kelda daemon.
 func (c clientImpl) QueryImages() ([]db.Image, error) {
 	var rows []db.Image
-	return rows, query(c.pbClient, db.ImageTable, &rows)
+	return rows, query(c.pbClient, c.namespace, db.ImageTable, &rows)
+}
+
+// QueryJobs retrieves the jobs, and the history of their runs, tracked by the
+// Kelda daemon.
+func (c clientImpl) QueryJobs() ([]db.Job, error) {
+	var rows []db.Job
+	return rows, query(c.pbClient, c.namespace, db.JobTable, &rows)
+}
+
+// QueryEvents retrieves the events tracked by the Kelda daemon.
+func (c clientImpl) QueryEvents() ([]db.Event, error) {
+	var rows []db.Event
+	return rows, query(c.pbClient, c.namespace, db.EventTable, &rows)
 }
 
 // QueryCounters retrieves the debugging counters tracked with the Kelda daemon.
@@ -194,7 +299,11 @@ func parseCountersReply(reply *pb.CountersReply) (counters []pb.Counter) {
 
 func (c clientImpl) SetSecret(name, value string) error {
 	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := c.pbClient.SetSecret(ctx, &pb.Secret{Name: name, Value: value})
+	_, err := c.pbClient.SetSecret(ctx, &pb.Secret{
+		Name:      name,
+		Value:     value,
+		Namespace: c.namespace,
+	})
 	return err
 }
 
@@ -205,6 +314,44 @@ func (c clientImpl) Deploy(deployment string) error {
 	return err
 }
 
+// Plan computes the changes that deploying the given deployment would cause,
+// without deploying it.
+func (c clientImpl) Plan(deployment string) (api.Plan, error) {
+	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
+	reply, err := c.pbClient.Plan(ctx, &pb.PlanRequest{Deployment: deployment})
+	if err != nil {
+		return api.Plan{}, err
+	}
+
+	var plan api.Plan
+	err = json.Unmarshal([]byte(reply.Plan), &plan)
+	return plan, err
+}
+
+// Scale sets the number of replicas of the container with the given hostname
+// in the deployed blueprint.
+func (c clientImpl) Scale(hostname string, replicas int) error {
+	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
+	_, err := c.pbClient.Scale(ctx, &pb.ScaleRequest{
+		Namespace: c.namespace,
+		Hostname:  hostname,
+		Replicas:  int32(replicas),
+	})
+	return err
+}
+
+// Rollout pauses, resumes, or aborts the rolling update of the given group of
+// containers in the deployed blueprint.
+func (c clientImpl) Rollout(action, group string) error {
+	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
+	_, err := c.pbClient.Rollout(ctx, &pb.RolloutRequest{
+		Namespace: c.namespace,
+		Group:     group,
+		Action:    action,
+	})
+	return err
+}
+
 // Version retrieves the Kelda version of the remote daemon.
 func (c clientImpl) Version() (string, error) {
 	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
@@ -215,6 +362,12 @@ func (c clientImpl) Version() (string, error) {
 	return version.Version, nil
 }
 
+// SetNamespace makes the client's queries and secrets apply to the deployment
+// in `namespace`.
+func (c *clientImpl) SetNamespace(namespace string) {
+	c.namespace = namespace
+}
+
 // daemonTimeoutError represents when we are unable to connect to the Kelda
 // daemon because of a timeout.
 type daemonTimeoutError struct {
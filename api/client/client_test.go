@@ -8,18 +8,35 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 
+	"github.com/kelda/kelda/api"
 	"github.com/kelda/kelda/api/pb"
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/db"
 )
 
 type mockAPIClient struct {
 	mockResponse string
 	mockError    error
+
+	// If non-nil, the namespace of each query is written here.
+	namespace *string
+
+	// If non-nil, each query is written here.
+	lastQuery *pb.DBQuery
+
+	// The events streamed to watches.
+	watchEvents []*pb.WatchEvent
 }
 
 func (c mockAPIClient) Query(ctx context.Context, in *pb.DBQuery,
 	opts ...grpc.CallOption) (*pb.QueryReply, error) {
 
+	if c.namespace != nil {
+		*c.namespace = in.Namespace
+	}
+	if c.lastQuery != nil {
+		*c.lastQuery = *in
+	}
 	return &pb.QueryReply{TableContents: c.mockResponse}, c.mockError
 }
 
@@ -41,6 +58,39 @@ func (c mockAPIClient) QueryMinionCounters(ctx context.Context, in *pb.
 	return &pb.CountersReply{}, nil
 }
 
+func (c mockAPIClient) Plan(ctx context.Context, in *pb.PlanRequest,
+	opts ...grpc.CallOption) (*pb.PlanReply, error) {
+
+	return &pb.PlanReply{Plan: c.mockResponse}, c.mockError
+}
+
+func (c mockAPIClient) Scale(ctx context.Context, in *pb.ScaleRequest,
+	opts ...grpc.CallOption) (*pb.ScaleReply, error) {
+
+	if c.namespace != nil {
+		*c.namespace = in.Namespace
+	}
+	return &pb.ScaleReply{}, c.mockError
+}
+
+func (c mockAPIClient) Rollout(ctx context.Context, in *pb.RolloutRequest,
+	opts ...grpc.CallOption) (*pb.RolloutReply, error) {
+
+	if c.namespace != nil {
+		*c.namespace = in.Namespace
+	}
+	return &pb.RolloutReply{}, c.mockError
+}
+
+func (c mockAPIClient) Watch(ctx context.Context, in *pb.WatchRequest,
+	opts ...grpc.CallOption) (pb.API_WatchClient, error) {
+
+	if c.namespace != nil {
+		*c.namespace = in.Namespace
+	}
+	return &mockWatchClient{ctx: ctx, events: c.watchEvents}, c.mockError
+}
+
 func (c mockAPIClient) Version(ctx context.Context, in *pb.VersionRequest,
 	opts ...grpc.CallOption) (*pb.VersionReply, error) {
 
@@ -94,7 +144,7 @@ func TestUnmarshalContainer(t *testing.T) {
 		{
 			DockerID: "docker-id",
 			Image:    "image",
-			Command:  []string{"cmd", "arg"},
+			Command:  blueprint.NewStrings("cmd", "arg"),
 		},
 	}
 	assert.Equal(t, exp, res)
@@ -115,6 +165,122 @@ func TestUnmarshalImage(t *testing.T) {
 	}, res)
 }
 
+func TestUnmarshalJob(t *testing.T) {
+	t.Parallel()
+
+	apiClient := mockAPIClient{
+		mockResponse: `[{"ID":1,"Hostname":"migrate","Schedule":"0 3 * * *",` +
+			`"Runs":[{"Number":2,"Status":"succeeded","Succeeded":1}]}]`,
+	}
+	c := clientImpl{pbClient: apiClient}
+	res, err := c.QueryJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Job{{
+		Hostname: "migrate",
+		Schedule: "0 3 * * *",
+		Runs: []db.JobRun{
+			{Number: 2, Status: db.JobSucceeded, Succeeded: 1},
+		},
+	}}, res)
+}
+
+func TestSetNamespace(t *testing.T) {
+	t.Parallel()
+
+	var namespace string
+	c := clientImpl{pbClient: mockAPIClient{
+		mockResponse: "[]",
+		namespace:    &namespace,
+	}}
+
+	_, err := c.QueryMachines()
+	assert.NoError(t, err)
+	assert.Equal(t, "", namespace)
+
+	c.SetNamespace("ns")
+	_, err = c.QueryMachines()
+	assert.NoError(t, err)
+	assert.Equal(t, "ns", namespace)
+}
+
+func TestQueryWhere(t *testing.T) {
+	t.Parallel()
+
+	var lastQuery pb.DBQuery
+	apiClient := mockAPIClient{
+		mockResponse: `[{"BlueprintID":"1","Status":"running"}]`,
+		lastQuery:    &lastQuery,
+	}
+	c := clientImpl{pbClient: apiClient}
+
+	containers, err := c.QueryContainersWhere(api.Query{
+		Where:  map[string]string{"Status": "running", "Minion": "10.0.0.5"},
+		Fields: []string{"BlueprintID", "Status"},
+		Offset: 10,
+		Limit:  5,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Container{{BlueprintID: "1", Status: "running"}},
+		containers)
+	assert.Equal(t, pb.DBQuery{
+		Table:   string(db.ContainerTable),
+		Filters: []string{"Minion=10.0.0.5", "Status=running"},
+		Fields:  []string{"BlueprintID", "Status"},
+		Offset:  10,
+		Limit:   5,
+	}, lastQuery)
+
+	apiClient.mockResponse = `[{"ID":1}]`
+	c = clientImpl{pbClient: apiClient}
+	machines, err := c.QueryMachinesWhere(api.Query{})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Machine{{ID: 1}}, machines)
+	assert.Equal(t, pb.DBQuery{Table: string(db.MachineTable)}, lastQuery)
+}
+
+func TestUnmarshalPlan(t *testing.T) {
+	t.Parallel()
+
+	apiClient := mockAPIClient{
+		mockResponse: `{"Boot":[{"Size":"m4.large"}],` +
+			`"AddContainers":[{"Hostname":"web"}]}`,
+	}
+	c := clientImpl{pbClient: apiClient}
+	res, err := c.Plan("{}")
+	assert.NoError(t, err)
+
+	var exp api.Plan
+	exp.Boot = []db.Machine{{Size: "m4.large"}}
+	exp.AddContainers = []db.Container{{Hostname: "web"}}
+	assert.Equal(t, exp, res)
+}
+
+func TestScale(t *testing.T) {
+	t.Parallel()
+
+	var namespace string
+	c := clientImpl{pbClient: mockAPIClient{namespace: &namespace}}
+	c.SetNamespace("ns")
+	assert.NoError(t, c.Scale("web", 3))
+	assert.Equal(t, "ns", namespace)
+
+	c = clientImpl{pbClient: mockAPIClient{mockError: assert.AnError}}
+	assert.Equal(t, assert.AnError, c.Scale("web", 3))
+}
+
+func TestRollout(t *testing.T) {
+	t.Parallel()
+
+	var namespace string
+	c := clientImpl{pbClient: mockAPIClient{namespace: &namespace}}
+	c.SetNamespace("ns")
+	assert.NoError(t, c.Rollout("pause", "web"))
+	assert.Equal(t, "ns", namespace)
+
+	c = clientImpl{pbClient: mockAPIClient{mockError: assert.AnError}}
+	assert.Equal(t, assert.AnError, c.Rollout("pause", "web"))
+}
+
 func TestUnmarshalError(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@
 
 package mocks
 
+import api "github.com/kelda/kelda/api"
 import db "github.com/kelda/kelda/db"
 import mock "github.com/stretchr/testify/mock"
 import pb "github.com/kelda/kelda/api/pb"
@@ -39,6 +40,27 @@ func (_m *Client) Deploy(deployment string) error {
 	return r0
 }
 
+// Plan provides a mock function with given fields: deployment
+func (_m *Client) Plan(deployment string) (api.Plan, error) {
+	ret := _m.Called(deployment)
+
+	var r0 api.Plan
+	if rf, ok := ret.Get(0).(func(string) api.Plan); ok {
+		r0 = rf(deployment)
+	} else {
+		r0 = ret.Get(0).(api.Plan)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(deployment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // QueryBlueprints provides a mock function with given fields:
 func (_m *Client) QueryBlueprints() ([]db.Blueprint, error) {
 	ret := _m.Called()
@@ -108,6 +130,29 @@ func (_m *Client) QueryContainers() ([]db.Container, error) {
 	return r0, r1
 }
 
+// QueryContainersWhere provides a mock function with given fields: q
+func (_m *Client) QueryContainersWhere(q api.Query) ([]db.Container, error) {
+	ret := _m.Called(q)
+
+	var r0 []db.Container
+	if rf, ok := ret.Get(0).(func(api.Query) []db.Container); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Container)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(api.Query) error); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // QueryCounters provides a mock function with given fields:
 func (_m *Client) QueryCounters() ([]pb.Counter, error) {
 	ret := _m.Called()
@@ -154,6 +199,29 @@ func (_m *Client) QueryEtcd() ([]db.Etcd, error) {
 	return r0, r1
 }
 
+// QueryEvents provides a mock function with given fields:
+func (_m *Client) QueryEvents() ([]db.Event, error) {
+	ret := _m.Called()
+
+	var r0 []db.Event
+	if rf, ok := ret.Get(0).(func() []db.Event); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // QueryImages provides a mock function with given fields:
 func (_m *Client) QueryImages() ([]db.Image, error) {
 	ret := _m.Called()
@@ -177,6 +245,29 @@ func (_m *Client) QueryImages() ([]db.Image, error) {
 	return r0, r1
 }
 
+// QueryJobs provides a mock function with given fields:
+func (_m *Client) QueryJobs() ([]db.Job, error) {
+	ret := _m.Called()
+
+	var r0 []db.Job
+	if rf, ok := ret.Get(0).(func() []db.Job); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // QueryLoadBalancers provides a mock function with given fields:
 func (_m *Client) QueryLoadBalancers() ([]db.LoadBalancer, error) {
 	ret := _m.Called()
@@ -223,6 +314,29 @@ func (_m *Client) QueryMachines() ([]db.Machine, error) {
 	return r0, r1
 }
 
+// QueryMachinesWhere provides a mock function with given fields: q
+func (_m *Client) QueryMachinesWhere(q api.Query) ([]db.Machine, error) {
+	ret := _m.Called(q)
+
+	var r0 []db.Machine
+	if rf, ok := ret.Get(0).(func(api.Query) []db.Machine); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Machine)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(api.Query) error); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // QueryMinionCounters provides a mock function with given fields: _a0
 func (_m *Client) QueryMinionCounters(_a0 string) ([]pb.Counter, error) {
 	ret := _m.Called(_a0)
@@ -246,6 +360,39 @@ func (_m *Client) QueryMinionCounters(_a0 string) ([]pb.Counter, error) {
 	return r0, r1
 }
 
+// Rollout provides a mock function with given fields: action, group
+func (_m *Client) Rollout(action string, group string) error {
+	ret := _m.Called(action, group)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(action, group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Scale provides a mock function with given fields: hostname, replicas
+func (_m *Client) Scale(hostname string, replicas int) error {
+	ret := _m.Called(hostname, replicas)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int) error); ok {
+		r0 = rf(hostname, replicas)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNamespace provides a mock function with given fields: namespace
+func (_m *Client) SetNamespace(namespace string) {
+	_m.Called(namespace)
+}
+
 // SetSecret provides a mock function with given fields: name, value
 func (_m *Client) SetSecret(name string, value string) error {
 	ret := _m.Called(name, value)
@@ -280,3 +427,95 @@ func (_m *Client) Version() (string, error) {
 
 	return r0, r1
 }
+
+// WatchConnections provides a mock function with given fields:
+func (_m *Client) WatchConnections() (api.ConnectionWatcher, error) {
+	ret := _m.Called()
+
+	var r0 api.ConnectionWatcher
+	if rf, ok := ret.Get(0).(func() api.ConnectionWatcher); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.ConnectionWatcher)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchContainers provides a mock function with given fields:
+func (_m *Client) WatchContainers() (api.ContainerWatcher, error) {
+	ret := _m.Called()
+
+	var r0 api.ContainerWatcher
+	if rf, ok := ret.Get(0).(func() api.ContainerWatcher); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.ContainerWatcher)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchEvents provides a mock function with given fields:
+func (_m *Client) WatchEvents() (api.EventLogWatcher, error) {
+	ret := _m.Called()
+
+	var r0 api.EventLogWatcher
+	if rf, ok := ret.Get(0).(func() api.EventLogWatcher); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.EventLogWatcher)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchMachines provides a mock function with given fields:
+func (_m *Client) WatchMachines() (api.MachineWatcher, error) {
+	ret := _m.Called()
+
+	var r0 api.MachineWatcher
+	if rf, ok := ret.Get(0).(func() api.MachineWatcher); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.MachineWatcher)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/json"
+
+	"golang.org/x/net/context"
+
+	"github.com/kelda/kelda/api"
+	"github.com/kelda/kelda/api/pb"
+	"github.com/kelda/kelda/db"
+)
+
+// WatchMachines streams the machines tracked by the Kelda daemon.
+func (c clientImpl) WatchMachines() (api.MachineWatcher, error) {
+	w, err := watch(c.pbClient, c.namespace, db.MachineTable)
+	if err != nil {
+		return nil, err
+	}
+	return machineWatcher{w}, nil
+}
+
+// WatchContainers streams the containers tracked by the Kelda daemon.  The
+// attributes that only the workers track come from the leader's copy of each
+// container, rather than from the workers as in QueryContainers.
+func (c clientImpl) WatchContainers() (api.ContainerWatcher, error) {
+	w, err := watch(c.pbClient, c.namespace, db.ContainerTable)
+	if err != nil {
+		return nil, err
+	}
+	return containerWatcher{w}, nil
+}
+
+// WatchConnections streams the connections tracked by the Kelda daemon.
+func (c clientImpl) WatchConnections() (api.ConnectionWatcher, error) {
+	w, err := watch(c.pbClient, c.namespace, db.ConnectionTable)
+	if err != nil {
+		return nil, err
+	}
+	return connectionWatcher{w}, nil
+}
+
+// WatchEvents streams the events tracked by the Kelda daemon.
+func (c clientImpl) WatchEvents() (api.EventLogWatcher, error) {
+	w, err := watch(c.pbClient, c.namespace, db.EventTable)
+	if err != nil {
+		return nil, err
+	}
+	return eventLogWatcher{w}, nil
+}
+
+type watcher struct {
+	stream pb.API_WatchClient
+	cancel context.CancelFunc
+}
+
+// Unlike queries, watches don't time out, so they're only ended by Stop.
+func watch(pbClient pb.APIClient, namespace string, table db.TableType) (
+	watcher, error) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := pbClient.Watch(ctx, &pb.WatchRequest{
+		Table:     string(table),
+		Namespace: namespace,
+	})
+	if err != nil {
+		cancel()
+		return watcher{}, err
+	}
+	return watcher{stream: stream, cancel: cancel}, nil
+}
+
+// next writes the rows of the next event into `v`, a pointer to a slice of
+// database structs, and returns the event's type.
+func (w watcher) next(v interface{}) (api.EventType, error) {
+	event, err := w.stream.Recv()
+	if err != nil {
+		return 0, err
+	}
+	return api.EventType(event.Type), json.Unmarshal([]byte(event.Rows), v)
+}
+
+func (w watcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+type machineWatcher struct {
+	watcher
+}
+
+func (w machineWatcher) Next() (event api.MachineEvent, err error) {
+	event.Type, err = w.next(&event.Machines)
+	return event, err
+}
+
+type containerWatcher struct {
+	watcher
+}
+
+func (w containerWatcher) Next() (event api.ContainerEvent, err error) {
+	event.Type, err = w.next(&event.Containers)
+	return event, err
+}
+
+type connectionWatcher struct {
+	watcher
+}
+
+func (w connectionWatcher) Next() (event api.ConnectionEvent, err error) {
+	event.Type, err = w.next(&event.Connections)
+	return event, err
+}
+
+type eventLogWatcher struct {
+	watcher
+}
+
+func (w eventLogWatcher) Next() (event api.EventLogEvent, err error) {
+	event.Type, err = w.next(&event.Events)
+	return event, err
+}
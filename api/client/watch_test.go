@@ -0,0 +1,164 @@
+package client
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/kelda/kelda/api"
+	"github.com/kelda/kelda/api/pb"
+	"github.com/kelda/kelda/db"
+)
+
+type mockWatchClient struct {
+	ctx    context.Context
+	events []*pb.WatchEvent
+
+	grpc.ClientStream
+}
+
+func (c *mockWatchClient) Recv() (*pb.WatchEvent, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(c.events) == 0 {
+		return nil, io.EOF
+	}
+
+	event := c.events[0]
+	c.events = c.events[1:]
+	return event, nil
+}
+
+func TestWatchContainers(t *testing.T) {
+	t.Parallel()
+
+	var namespace string
+	events := []*pb.WatchEvent{
+		{Type: pb.WatchEvent_SNAPSHOT,
+			Rows: `[{"BlueprintID":"1"},{"BlueprintID":"2"}]`},
+		{Type: pb.WatchEvent_DELETED, Rows: `[{"BlueprintID":"1"}]`},
+	}
+	c := clientImpl{
+		pbClient:  mockAPIClient{namespace: &namespace, watchEvents: events},
+		namespace: "ns",
+	}
+
+	w, err := c.WatchContainers()
+	assert.NoError(t, err)
+	assert.Equal(t, "ns", namespace)
+
+	event, err := w.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, api.ContainerEvent{
+		Type: api.Snapshot,
+		Containers: []db.Container{
+			{BlueprintID: "1"},
+			{BlueprintID: "2"},
+		},
+	}, event)
+
+	event, err = w.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, api.ContainerEvent{
+		Type:       api.Deleted,
+		Containers: []db.Container{{BlueprintID: "1"}},
+	}, event)
+
+	_, err = w.Next()
+	assert.Equal(t, io.EOF, err)
+
+	w.Stop()
+}
+
+func TestWatchModified(t *testing.T) {
+	t.Parallel()
+
+	c := clientImpl{pbClient: mockAPIClient{watchEvents: []*pb.WatchEvent{{
+		Type: pb.WatchEvent_MODIFIED,
+		Rows: `[{"BlueprintID":"1","Image":"old"},` +
+			`{"BlueprintID":"1","Image":"new"}]`,
+	}}}}
+
+	containers, err := c.WatchContainers()
+	assert.NoError(t, err)
+
+	event, err := containers.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, api.ContainerEvent{
+		Type: api.Modified,
+		Containers: []db.Container{
+			{BlueprintID: "1", Image: "old"},
+			{BlueprintID: "1", Image: "new"},
+		},
+	}, event)
+	containers.Stop()
+
+	// Connections have no key, so the old row is what identifies them.
+	c = clientImpl{pbClient: mockAPIClient{watchEvents: []*pb.WatchEvent{{
+		Type: pb.WatchEvent_MODIFIED,
+		Rows: `[{"From":["a"],"To":["b"],"MinPort":80,"MaxPort":80},` +
+			`{"From":["a"],"To":["b"],"MinPort":80,"MaxPort":81}]`,
+	}}}}
+
+	connections, err := c.WatchConnections()
+	assert.NoError(t, err)
+
+	connEvent, err := connections.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, api.ConnectionEvent{
+		Type: api.Modified,
+		Connections: []db.Connection{
+			{From: []string{"a"}, To: []string{"b"}, MinPort: 80, MaxPort: 80},
+			{From: []string{"a"}, To: []string{"b"}, MinPort: 80, MaxPort: 81},
+		},
+	}, connEvent)
+	connections.Stop()
+}
+
+func TestWatchEvents(t *testing.T) {
+	t.Parallel()
+
+	c := clientImpl{pbClient: mockAPIClient{watchEvents: []*pb.WatchEvent{
+		{Type: pb.WatchEvent_ADDED, Rows: `[{"Type":"Placed"}]`},
+	}}}
+
+	w, err := c.WatchEvents()
+	assert.NoError(t, err)
+
+	event, err := w.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, api.EventLogEvent{
+		Type:   api.Added,
+		Events: []db.Event{{Type: db.PlacedEvent}},
+	}, event)
+	w.Stop()
+}
+
+func TestWatchStop(t *testing.T) {
+	t.Parallel()
+
+	c := clientImpl{pbClient: mockAPIClient{watchEvents: []*pb.WatchEvent{
+		{Type: pb.WatchEvent_ADDED, Rows: `[{"ID":1}]`},
+	}}}
+
+	w, err := c.WatchMachines()
+	assert.NoError(t, err)
+	w.Stop()
+
+	_, err = w.Next()
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestWatchError(t *testing.T) {
+	t.Parallel()
+
+	c := clientImpl{pbClient: mockAPIClient{mockError: assert.AnError}}
+	w, err := c.WatchConnections()
+	assert.Nil(t, w)
+	assert.Equal(t, assert.AnError, err)
+}
@@ -20,6 +20,14 @@ It has these top-level messages:
 	MinionCountersRequest
 	CountersReply
 	Counter
+	PlanRequest
+	PlanReply
+	ScaleRequest
+	ScaleReply
+	WatchRequest
+	WatchEvent
+	RolloutRequest
+	RolloutReply
 */
 package pb
 
@@ -43,9 +51,37 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type WatchEvent_Type int32
+
+const (
+	WatchEvent_SNAPSHOT WatchEvent_Type = 0
+	WatchEvent_ADDED    WatchEvent_Type = 1
+	WatchEvent_MODIFIED WatchEvent_Type = 2
+	WatchEvent_DELETED  WatchEvent_Type = 3
+)
+
+var WatchEvent_Type_name = map[int32]string{
+	0: "SNAPSHOT",
+	1: "ADDED",
+	2: "MODIFIED",
+	3: "DELETED",
+}
+var WatchEvent_Type_value = map[string]int32{
+	"SNAPSHOT": 0,
+	"ADDED":    1,
+	"MODIFIED": 2,
+	"DELETED":  3,
+}
+
+func (x WatchEvent_Type) String() string {
+	return proto.EnumName(WatchEvent_Type_name, int32(x))
+}
+func (WatchEvent_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{17, 0} }
+
 type Secret struct {
-	Name  string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
-	Value string `protobuf:"bytes,2,opt,name=Value" json:"Value,omitempty"`
+	Name      string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
+	Value     string `protobuf:"bytes,2,opt,name=Value" json:"Value,omitempty"`
+	Namespace string `protobuf:"bytes,3,opt,name=Namespace" json:"Namespace,omitempty"`
 }
 
 func (m *Secret) Reset()                    { *m = Secret{} }
@@ -67,6 +103,13 @@ func (m *Secret) GetValue() string {
 	return ""
 }
 
+func (m *Secret) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
 type SecretReply struct {
 }
 
@@ -76,7 +119,17 @@ func (*SecretReply) ProtoMessage()               {}
 func (*SecretReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
 type DBQuery struct {
-	Table string `protobuf:"bytes,1,opt,name=Table" json:"Table,omitempty"`
+	Table     string `protobuf:"bytes,1,opt,name=Table" json:"Table,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=Namespace" json:"Namespace,omitempty"`
+	// Only rows whose fields equal the given values are returned.  Each
+	// filter has the form "Field=value", e.g. "Minion=10.0.0.5".
+	Filters []string `protobuf:"bytes,3,rep,name=Filters" json:"Filters,omitempty"`
+	// If set, only the given fields of each row are returned.
+	Fields []string `protobuf:"bytes,4,rep,name=Fields" json:"Fields,omitempty"`
+	// The matching rows are sorted by ID, and the first Offset are skipped.
+	// If Limit is set, at most Limit rows are returned.
+	Offset int32 `protobuf:"varint,5,opt,name=Offset" json:"Offset,omitempty"`
+	Limit  int32 `protobuf:"varint,6,opt,name=Limit" json:"Limit,omitempty"`
 }
 
 func (m *DBQuery) Reset()                    { *m = DBQuery{} }
@@ -91,6 +144,41 @@ func (m *DBQuery) GetTable() string {
 	return ""
 }
 
+func (m *DBQuery) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *DBQuery) GetFilters() []string {
+	if m != nil {
+		return m.Filters
+	}
+	return nil
+}
+
+func (m *DBQuery) GetFields() []string {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *DBQuery) GetOffset() int32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *DBQuery) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
 type QueryReply struct {
 	TableContents string `protobuf:"bytes,1,opt,name=TableContents" json:"TableContents,omitempty"`
 }
@@ -235,6 +323,170 @@ func (m *Counter) GetPrevValue() uint64 {
 	return 0
 }
 
+type PlanRequest struct {
+	Deployment string `protobuf:"bytes,1,opt,name=Deployment" json:"Deployment,omitempty"`
+}
+
+func (m *PlanRequest) Reset()                    { *m = PlanRequest{} }
+func (m *PlanRequest) String() string            { return proto.CompactTextString(m) }
+func (*PlanRequest) ProtoMessage()               {}
+func (*PlanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *PlanRequest) GetDeployment() string {
+	if m != nil {
+		return m.Deployment
+	}
+	return ""
+}
+
+type PlanReply struct {
+	Plan string `protobuf:"bytes,1,opt,name=Plan" json:"Plan,omitempty"`
+}
+
+func (m *PlanReply) Reset()                    { *m = PlanReply{} }
+func (m *PlanReply) String() string            { return proto.CompactTextString(m) }
+func (*PlanReply) ProtoMessage()               {}
+func (*PlanReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *PlanReply) GetPlan() string {
+	if m != nil {
+		return m.Plan
+	}
+	return ""
+}
+
+type ScaleRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=Namespace" json:"Namespace,omitempty"`
+	Hostname  string `protobuf:"bytes,2,opt,name=Hostname" json:"Hostname,omitempty"`
+	Replicas  int32  `protobuf:"varint,3,opt,name=Replicas" json:"Replicas,omitempty"`
+}
+
+func (m *ScaleRequest) Reset()                    { *m = ScaleRequest{} }
+func (m *ScaleRequest) String() string            { return proto.CompactTextString(m) }
+func (*ScaleRequest) ProtoMessage()               {}
+func (*ScaleRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *ScaleRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ScaleRequest) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *ScaleRequest) GetReplicas() int32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+type ScaleReply struct {
+}
+
+func (m *ScaleReply) Reset()                    { *m = ScaleReply{} }
+func (m *ScaleReply) String() string            { return proto.CompactTextString(m) }
+func (*ScaleReply) ProtoMessage()               {}
+func (*ScaleReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type WatchRequest struct {
+	Table     string `protobuf:"bytes,1,opt,name=Table" json:"Table,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=Namespace" json:"Namespace,omitempty"`
+}
+
+func (m *WatchRequest) Reset()                    { *m = WatchRequest{} }
+func (m *WatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()               {}
+func (*WatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *WatchRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+type WatchEvent struct {
+	Type WatchEvent_Type `protobuf:"varint,1,opt,name=type,enum=WatchEvent_Type" json:"type,omitempty"`
+	// The JSON encoded rows that the event applies to.  Snapshots contain
+	// every row in the table.  Modified events contain the old version of
+	// the row followed by the new one, and other events contain a single row.
+	Rows string `protobuf:"bytes,2,opt,name=Rows" json:"Rows,omitempty"`
+}
+
+func (m *WatchEvent) Reset()                    { *m = WatchEvent{} }
+func (m *WatchEvent) String() string            { return proto.CompactTextString(m) }
+func (*WatchEvent) ProtoMessage()               {}
+func (*WatchEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *WatchEvent) GetType() WatchEvent_Type {
+	if m != nil {
+		return m.Type
+	}
+	return WatchEvent_SNAPSHOT
+}
+
+func (m *WatchEvent) GetRows() string {
+	if m != nil {
+		return m.Rows
+	}
+	return ""
+}
+
+type RolloutRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=Namespace" json:"Namespace,omitempty"`
+	Group     string `protobuf:"bytes,2,opt,name=Group" json:"Group,omitempty"`
+	// One of pause, resume, or abort.
+	Action string `protobuf:"bytes,3,opt,name=Action" json:"Action,omitempty"`
+}
+
+func (m *RolloutRequest) Reset()                    { *m = RolloutRequest{} }
+func (m *RolloutRequest) String() string            { return proto.CompactTextString(m) }
+func (*RolloutRequest) ProtoMessage()               {}
+func (*RolloutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *RolloutRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *RolloutRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *RolloutRequest) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+type RolloutReply struct {
+}
+
+func (m *RolloutReply) Reset()                    { *m = RolloutReply{} }
+func (m *RolloutReply) String() string            { return proto.CompactTextString(m) }
+func (*RolloutReply) ProtoMessage()               {}
+func (*RolloutReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
 func init() {
 	proto.RegisterType((*Secret)(nil), "Secret")
 	proto.RegisterType((*SecretReply)(nil), "SecretReply")
@@ -248,6 +500,15 @@ func init() {
 	proto.RegisterType((*MinionCountersRequest)(nil), "MinionCountersRequest")
 	proto.RegisterType((*CountersReply)(nil), "CountersReply")
 	proto.RegisterType((*Counter)(nil), "Counter")
+	proto.RegisterType((*PlanRequest)(nil), "PlanRequest")
+	proto.RegisterType((*PlanReply)(nil), "PlanReply")
+	proto.RegisterType((*ScaleRequest)(nil), "ScaleRequest")
+	proto.RegisterType((*ScaleReply)(nil), "ScaleReply")
+	proto.RegisterType((*WatchRequest)(nil), "WatchRequest")
+	proto.RegisterType((*WatchEvent)(nil), "WatchEvent")
+	proto.RegisterType((*RolloutRequest)(nil), "RolloutRequest")
+	proto.RegisterType((*RolloutReply)(nil), "RolloutReply")
+	proto.RegisterEnum("WatchEvent_Type", WatchEvent_Type_name, WatchEvent_Type_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -266,9 +527,13 @@ type APIClient interface {
 	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionReply, error)
 	QueryCounters(ctx context.Context, in *CountersRequest, opts ...grpc.CallOption) (*CountersReply, error)
 	SetSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*SecretReply, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (API_WatchClient, error)
 	// Only defined on the daemon.
 	Deploy(ctx context.Context, in *DeployRequest, opts ...grpc.CallOption) (*DeployReply, error)
 	QueryMinionCounters(ctx context.Context, in *MinionCountersRequest, opts ...grpc.CallOption) (*CountersReply, error)
+	Plan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanReply, error)
+	Scale(ctx context.Context, in *ScaleRequest, opts ...grpc.CallOption) (*ScaleReply, error)
+	Rollout(ctx context.Context, in *RolloutRequest, opts ...grpc.CallOption) (*RolloutReply, error)
 }
 
 type aPIClient struct {
@@ -315,6 +580,38 @@ func (c *aPIClient) SetSecret(ctx context.Context, in *Secret, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *aPIClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (API_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/API/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type aPIWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *aPIClient) Deploy(ctx context.Context, in *DeployRequest, opts ...grpc.CallOption) (*DeployReply, error) {
 	out := new(DeployReply)
 	err := grpc.Invoke(ctx, "/API/Deploy", in, out, c.cc, opts...)
@@ -333,6 +630,33 @@ func (c *aPIClient) QueryMinionCounters(ctx context.Context, in *MinionCountersR
 	return out, nil
 }
 
+func (c *aPIClient) Plan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanReply, error) {
+	out := new(PlanReply)
+	err := grpc.Invoke(ctx, "/API/Plan", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Scale(ctx context.Context, in *ScaleRequest, opts ...grpc.CallOption) (*ScaleReply, error) {
+	out := new(ScaleReply)
+	err := grpc.Invoke(ctx, "/API/Scale", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Rollout(ctx context.Context, in *RolloutRequest, opts ...grpc.CallOption) (*RolloutReply, error) {
+	out := new(RolloutReply)
+	err := grpc.Invoke(ctx, "/API/Rollout", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for API service
 
 type APIServer interface {
@@ -341,9 +665,13 @@ type APIServer interface {
 	Version(context.Context, *VersionRequest) (*VersionReply, error)
 	QueryCounters(context.Context, *CountersRequest) (*CountersReply, error)
 	SetSecret(context.Context, *Secret) (*SecretReply, error)
+	Watch(*WatchRequest, API_WatchServer) error
 	// Only defined on the daemon.
 	Deploy(context.Context, *DeployRequest) (*DeployReply, error)
 	QueryMinionCounters(context.Context, *MinionCountersRequest) (*CountersReply, error)
+	Plan(context.Context, *PlanRequest) (*PlanReply, error)
+	Scale(context.Context, *ScaleRequest) (*ScaleReply, error)
+	Rollout(context.Context, *RolloutRequest) (*RolloutReply, error)
 }
 
 func RegisterAPIServer(s *grpc.Server, srv APIServer) {
@@ -422,6 +750,27 @@ func _API_SetSecret_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).Watch(m, &aPIWatchServer{stream})
+}
+
+type API_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type aPIWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _API_Deploy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeployRequest)
 	if err := dec(in); err != nil {
@@ -458,6 +807,60 @@ func _API_QueryMinionCounters_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_Plan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Plan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/API/Plan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Plan(ctx, req.(*PlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Scale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Scale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/API/Scale",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Scale(ctx, req.(*ScaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Rollout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RolloutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Rollout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/API/Rollout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Rollout(ctx, req.(*RolloutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _API_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "API",
 	HandlerType: (*APIServer)(nil),
@@ -486,39 +889,78 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "QueryMinionCounters",
 			Handler:    _API_QueryMinionCounters_Handler,
 		},
+		{
+			MethodName: "Plan",
+			Handler:    _API_Plan_Handler,
+		},
+		{
+			MethodName: "Scale",
+			Handler:    _API_Scale_Handler,
+		},
+		{
+			MethodName: "Rollout",
+			Handler:    _API_Rollout_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _API_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/pb.proto",
 }
 
 func init() { proto.RegisterFile("pb/pb.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 402 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x53, 0x4d, 0xaf, 0x9a, 0x40,
-	0x14, 0x05, 0xf1, 0x89, 0x5e, 0xc4, 0x67, 0x6f, 0x3f, 0x42, 0x48, 0xd3, 0x92, 0xc9, 0x5b, 0x90,
-	0x98, 0x8c, 0x09, 0xa6, 0xeb, 0xa6, 0xd5, 0x45, 0xbb, 0x68, 0x63, 0xb1, 0x71, 0x2f, 0x66, 0xd2,
-	0x98, 0x22, 0x43, 0x61, 0x68, 0xe2, 0x5f, 0xe9, 0xaf, 0x6d, 0x98, 0x19, 0x10, 0x7c, 0xee, 0xee,
-	0x3d, 0xf7, 0x23, 0x67, 0xce, 0xb9, 0x03, 0x4e, 0x9e, 0x2c, 0xf3, 0x84, 0xe6, 0x05, 0x17, 0x9c,
-	0x44, 0x30, 0xda, 0xb1, 0x63, 0xc1, 0x04, 0x22, 0x0c, 0xbf, 0x1f, 0xce, 0xcc, 0x33, 0x03, 0x33,
-	0x9c, 0xc4, 0x32, 0xc6, 0x57, 0xf0, 0xb0, 0x3f, 0xa4, 0x15, 0xf3, 0x06, 0x12, 0x54, 0x09, 0x71,
-	0xc1, 0x51, 0x33, 0x31, 0xcb, 0xd3, 0x0b, 0x79, 0x0f, 0xf6, 0xe6, 0xf3, 0x8f, 0x8a, 0x15, 0x97,
-	0xba, 0xff, 0xe7, 0x21, 0x49, 0x9b, 0x25, 0x2a, 0x21, 0x11, 0x80, 0x2c, 0xcb, 0x76, 0x7c, 0x02,
-	0x57, 0xc2, 0x6b, 0x9e, 0x09, 0x96, 0x89, 0x52, 0xf7, 0xf6, 0x41, 0xb2, 0x04, 0x77, 0xc3, 0xf2,
-	0x94, 0x5f, 0x62, 0xf6, 0xa7, 0x62, 0xa5, 0xc0, 0x77, 0x00, 0x0a, 0x38, 0xb3, 0x4c, 0xe8, 0x99,
-	0x0e, 0x52, 0x93, 0x6a, 0x06, 0x6a, 0x52, 0x73, 0x98, 0xed, 0x59, 0x51, 0x9e, 0x78, 0xa6, 0x17,
-	0x90, 0x10, 0xa6, 0x2d, 0x52, 0xf3, 0xf0, 0xc0, 0xd6, 0xb9, 0xde, 0xd6, 0xa4, 0xe4, 0x05, 0x3c,
-	0xae, 0x79, 0x95, 0x09, 0x56, 0x94, 0xcd, 0xf0, 0x02, 0x5e, 0x7f, 0x3b, 0x65, 0x27, 0x9e, 0xdd,
-	0x14, 0x6a, 0xd5, 0xbe, 0xf0, 0xb2, 0x21, 0x24, 0x63, 0xf2, 0x01, 0xdc, 0x6b, 0x9b, 0x7a, 0xf2,
-	0xf8, 0xa8, 0x01, 0xcf, 0x0c, 0xac, 0xd0, 0x89, 0xc6, 0x54, 0x77, 0xc4, 0x6d, 0x85, 0x1c, 0xc1,
-	0xd6, 0x20, 0xce, 0xc1, 0xda, 0xfe, 0xfe, 0xa5, 0x97, 0xd6, 0x61, 0xeb, 0xce, 0xe0, 0x9e, 0x3b,
-	0x56, 0x60, 0x86, 0x43, 0xed, 0x0e, 0xbe, 0x85, 0xc9, 0xb6, 0x60, 0x7f, 0x55, 0x65, 0x28, 0x2b,
-	0x57, 0x20, 0xfa, 0x37, 0x00, 0xeb, 0xd3, 0xf6, 0x2b, 0x06, 0xf0, 0xa0, 0x2c, 0x1b, 0x53, 0x6d,
-	0x9e, 0xef, 0xd0, 0xab, 0x4b, 0xc4, 0xc0, 0x45, 0xab, 0x0f, 0x3e, 0xd2, 0xbe, 0x96, 0xbe, 0x4b,
-	0xbb, 0x52, 0x12, 0x03, 0x57, 0xe0, 0xca, 0xe1, 0xe6, 0xdd, 0x38, 0xa7, 0x37, 0x4a, 0xf9, 0x33,
-	0xda, 0x13, 0x85, 0x18, 0xf8, 0x04, 0x93, 0x1d, 0x13, 0xfa, 0xfc, 0x6c, 0xaa, 0x02, 0x7f, 0x4a,
-	0xbb, 0xc7, 0x65, 0x60, 0x08, 0x23, 0x65, 0x2c, 0xce, 0x68, 0xef, 0x24, 0xfc, 0x29, 0xed, 0x3a,
-	0x6e, 0xe0, 0x47, 0x78, 0x29, 0x49, 0xf4, 0x9d, 0xc2, 0x37, 0xf4, 0xae, 0x75, 0xcf, 0x09, 0x25,
-	0x23, 0xf9, 0x27, 0x56, 0xff, 0x03, 0x00, 0x00, 0xff, 0xff, 0x64, 0xa0, 0x22, 0xf6, 0x22, 0x03,
-	0x00, 0x00,
+	// 741 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0x5f, 0x6f, 0x9b, 0x48,
+	0x10, 0x07, 0x63, 0xfc, 0x67, 0x00, 0x87, 0xdb, 0xcb, 0x45, 0x08, 0x9d, 0xee, 0xac, 0x55, 0x4e,
+	0x67, 0x29, 0xba, 0xcd, 0xc9, 0x51, 0x5f, 0xfa, 0x52, 0x39, 0xc1, 0x69, 0x22, 0xe5, 0x8f, 0x8b,
+	0xad, 0xf4, 0xa5, 0x2f, 0x98, 0x6c, 0x5a, 0x54, 0x02, 0xd4, 0xac, 0x53, 0xf9, 0xb5, 0xdf, 0xa3,
+	0x9f, 0xa4, 0x5f, 0xae, 0xda, 0x65, 0xc1, 0x60, 0xe5, 0x21, 0xea, 0xdb, 0xfc, 0x66, 0xd8, 0xd9,
+	0xd9, 0x99, 0xdf, 0x6f, 0x00, 0x23, 0x5b, 0x1e, 0x67, 0x4b, 0x92, 0xad, 0x52, 0x96, 0xe2, 0x19,
+	0x74, 0xe6, 0x34, 0x5c, 0x51, 0x86, 0x10, 0xb4, 0x6f, 0x82, 0x47, 0xea, 0xa8, 0x43, 0x75, 0xd4,
+	0xf7, 0x85, 0x8d, 0xf6, 0x41, 0xbf, 0x0b, 0xe2, 0x35, 0x75, 0x5a, 0xc2, 0x59, 0x00, 0xf4, 0x27,
+	0xf4, 0x79, 0x34, 0xcf, 0x82, 0x90, 0x3a, 0x9a, 0x88, 0x6c, 0x1d, 0xd8, 0x02, 0xa3, 0xc8, 0xe8,
+	0xd3, 0x2c, 0xde, 0xe0, 0xef, 0x2a, 0x74, 0xbd, 0xd3, 0x77, 0x6b, 0xba, 0xda, 0xf0, 0x74, 0x8b,
+	0x60, 0x19, 0x97, 0x77, 0x14, 0xa0, 0x99, 0xae, 0xb5, 0x93, 0x0e, 0x39, 0xd0, 0x3d, 0x8f, 0x62,
+	0x46, 0x57, 0xb9, 0xa3, 0x0d, 0xb5, 0x51, 0xdf, 0x2f, 0x21, 0x3a, 0x80, 0xce, 0x79, 0x44, 0xe3,
+	0xfb, 0xdc, 0x69, 0x8b, 0x80, 0x44, 0xdc, 0x7f, 0xfb, 0xf0, 0x90, 0x53, 0xe6, 0xe8, 0x43, 0x75,
+	0xa4, 0xfb, 0x12, 0xf1, 0xdb, 0xaf, 0xa2, 0xc7, 0x88, 0x39, 0x1d, 0xe1, 0x2e, 0x00, 0x1e, 0x03,
+	0x88, 0xe2, 0x44, 0xb5, 0xe8, 0x10, 0x2c, 0x51, 0xd4, 0x59, 0x9a, 0x30, 0x9a, 0xb0, 0x5c, 0x56,
+	0xda, 0x74, 0xe2, 0x63, 0xb0, 0x3c, 0x9a, 0xc5, 0xe9, 0xc6, 0xa7, 0x5f, 0xd6, 0x34, 0x67, 0xe8,
+	0x2f, 0x80, 0xc2, 0xf1, 0x48, 0x13, 0x26, 0xcf, 0xd4, 0x3c, 0xbc, 0x27, 0xe5, 0x01, 0xde, 0x13,
+	0x1b, 0x06, 0x77, 0x74, 0x95, 0x47, 0x69, 0x22, 0x13, 0xe0, 0x11, 0x98, 0x95, 0x87, 0xd7, 0xe1,
+	0x40, 0x57, 0x62, 0x99, 0xad, 0x84, 0xf8, 0x37, 0xd8, 0x3b, 0x4b, 0xd7, 0x09, 0xef, 0x40, 0x79,
+	0xf8, 0x08, 0xfe, 0xb8, 0x8e, 0x92, 0x28, 0x4d, 0x76, 0x02, 0x7c, 0xa4, 0x17, 0x69, 0x5e, 0x16,
+	0x24, 0x6c, 0xfc, 0x0a, 0xac, 0xed, 0x67, 0xc5, 0x93, 0x7b, 0xa1, 0x74, 0x38, 0xea, 0x50, 0x1b,
+	0x19, 0xe3, 0x1e, 0x91, 0x5f, 0xf8, 0x55, 0x04, 0x87, 0xd0, 0x95, 0x4e, 0x64, 0x83, 0x36, 0xfb,
+	0xfc, 0x51, 0x26, 0xe5, 0x66, 0x45, 0x9d, 0xd6, 0x73, 0xd4, 0xe1, 0x04, 0x69, 0xd7, 0xa8, 0x33,
+	0x5b, 0xd1, 0xa7, 0x22, 0xd2, 0x16, 0x91, 0xad, 0x03, 0xff, 0x07, 0xc6, 0x2c, 0x0e, 0x92, 0x97,
+	0x76, 0xf5, 0x6f, 0xe8, 0x17, 0x9f, 0xf3, 0x67, 0x20, 0x68, 0x73, 0x50, 0xbe, 0x95, 0xdb, 0xf8,
+	0x1e, 0xcc, 0x79, 0x18, 0xc4, 0xb4, 0x4c, 0xd8, 0x60, 0x9a, 0xba, 0xcb, 0x34, 0x17, 0x7a, 0xbc,
+	0x43, 0xc9, 0xf6, 0x25, 0x15, 0xe6, 0x31, 0x7e, 0x4d, 0x14, 0x06, 0xb9, 0x78, 0x90, 0xee, 0x57,
+	0x18, 0x9b, 0x00, 0xf2, 0x16, 0x3e, 0xdb, 0x53, 0x30, 0xdf, 0x07, 0x2c, 0xfc, 0x54, 0xde, 0xf9,
+	0x0b, 0x9c, 0xc7, 0xdf, 0x54, 0x00, 0x91, 0x64, 0xfa, 0x44, 0x13, 0x86, 0x0e, 0xa1, 0xcd, 0x36,
+	0x59, 0x91, 0x61, 0x30, 0xb6, 0xc9, 0x36, 0x44, 0x16, 0x9b, 0x8c, 0xfa, 0x22, 0xca, 0x1b, 0xe0,
+	0xa7, 0x5f, 0xf3, 0x72, 0x08, 0xdc, 0xc6, 0xaf, 0xa1, 0xcd, 0xbf, 0x40, 0x26, 0xf4, 0xe6, 0x37,
+	0x93, 0xd9, 0xfc, 0xe2, 0x76, 0x61, 0x2b, 0xa8, 0x0f, 0xfa, 0xc4, 0xf3, 0xa6, 0x9e, 0xad, 0xf2,
+	0xc0, 0xf5, 0xad, 0x77, 0x79, 0x7e, 0x39, 0xf5, 0xec, 0x16, 0x32, 0xa0, 0xeb, 0x4d, 0xaf, 0xa6,
+	0x8b, 0xa9, 0x67, 0x6b, 0xf8, 0x03, 0x0c, 0xfc, 0x34, 0x8e, 0xd3, 0x35, 0x7b, 0x59, 0xfb, 0xf6,
+	0x41, 0x7f, 0xbb, 0x4a, 0xd7, 0x59, 0xb9, 0x2b, 0x04, 0xe0, 0x62, 0x9c, 0x84, 0x8c, 0xf3, 0xb8,
+	0x58, 0x14, 0x12, 0xe1, 0x01, 0x98, 0x55, 0xf6, 0x2c, 0xde, 0x8c, 0x7f, 0x68, 0xa0, 0x4d, 0x66,
+	0x97, 0x68, 0x08, 0x7a, 0xb1, 0x2b, 0x7a, 0x44, 0x6e, 0x0d, 0xd7, 0x20, 0x5b, 0x81, 0x62, 0x05,
+	0x1d, 0x55, 0xd2, 0x40, 0x7b, 0xa4, 0x29, 0x23, 0xd7, 0x22, 0x75, 0x15, 0x61, 0x05, 0x9d, 0x80,
+	0x25, 0x0e, 0x97, 0x94, 0x47, 0x36, 0xd9, 0x11, 0x89, 0x3b, 0x20, 0x0d, 0x3d, 0x60, 0x05, 0x1d,
+	0x42, 0x7f, 0x4e, 0x99, 0x5c, 0x8b, 0x5d, 0x52, 0x18, 0xae, 0x49, 0xea, 0x6b, 0x4d, 0x41, 0xff,
+	0x82, 0x2e, 0x06, 0x81, 0x2c, 0x52, 0x1f, 0xb8, 0x6b, 0xd4, 0xe6, 0x83, 0x95, 0xff, 0x55, 0x34,
+	0x82, 0x4e, 0x41, 0x5a, 0x34, 0x20, 0x8d, 0xb5, 0xe1, 0x9a, 0xa4, 0xbe, 0x15, 0x14, 0xf4, 0x06,
+	0x7e, 0x17, 0xd5, 0x36, 0xd5, 0x8c, 0x0e, 0xc8, 0xb3, 0xf2, 0x7e, 0xa6, 0x72, 0x5c, 0x88, 0x00,
+	0x99, 0xa4, 0xa6, 0x23, 0x17, 0x48, 0x25, 0x13, 0xac, 0xa0, 0x7f, 0x40, 0x17, 0x74, 0x45, 0x16,
+	0xa9, 0x8b, 0xc3, 0x35, 0x48, 0x8d, 0xc5, 0xa2, 0xcd, 0x72, 0x40, 0x68, 0x8f, 0x34, 0x89, 0xe0,
+	0x5a, 0xa4, 0x3e, 0x3b, 0xac, 0x2c, 0x3b, 0xe2, 0x67, 0x72, 0xf2, 0x73, 0x00, 0xcd, 0x78, 0x9d,
+	0x36, 0x5b, 0x06, 0x00, 0x00,
 }
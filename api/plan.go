@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/kelda/kelda/cloud"
+	"github.com/kelda/kelda/db"
+)
+
+// Plan describes the changes that deploying a blueprint would cause to the
+// cloud and to the containers running in it.
+type Plan struct {
+	cloud.Plan
+
+	AddContainers    []db.Container
+	RemoveContainers []db.Container
+
+	// Containers that stay in the blueprint, but run on machines that will
+	// be terminated.
+	RescheduleContainers []db.Container
+}
@@ -0,0 +1,26 @@
+package api
+
+// A Query restricts which rows, and which of their fields, the API server
+// returns for a table.
+type Query struct {
+	// Only rows whose fields equal the given values are returned.  The keys
+	// are the names of the rows' fields, e.g. "Minion" or "Status".
+	Where map[string]string
+
+	// If non-empty, only these fields of each row are returned.  The rest of
+	// each row's fields are left unset.  Fields that aren't sent over the
+	// API, such as ID, can't be selected.
+	Fields []string
+
+	// The matching rows are sorted by ID, and the first Offset are skipped.
+	// If Limit is non-zero, at most Limit rows are returned.  Rows that the
+	// daemon proxies from the cluster, such as containers and connections,
+	// have no ID, so they're sorted by BlueprintID and then by their
+	// contents instead.
+	//
+	// The daemon still fetches the full table from the cluster's leader
+	// before it applies the query, so queries only reduce what's sent to the
+	// client.
+	Offset int
+	Limit  int
+}
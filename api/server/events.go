@@ -0,0 +1,97 @@
+package server
+
+import (
+	"time"
+
+	"github.com/kelda/kelda/connection"
+	"github.com/kelda/kelda/db"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ReplicateEvents periodically copies the events recorded by the leader of each
+// namespace into the daemon's database, so that the events of the containers
+// and images can be read along with those of the machines.  It's run by the
+// daemon, and never returns.
+func ReplicateEvents(conn db.Conn, creds connection.Credentials) {
+	trigger := conn.TriggerTick(10, db.BlueprintTable)
+	defer trigger.Stop()
+
+	for range trigger.C {
+		for _, namespace := range conn.GetBlueprintNamespaces() {
+			replicateEvents(conn, creds, namespace)
+		}
+	}
+}
+
+// replicateEvents copies the events that the leader of `namespace` recorded,
+// and that the daemon doesn't have yet.  Events are matched by their contents
+// rather than by the time of the last one copied, because the leader can
+// change, and the new leader's clock may be behind that of the old one.
+func replicateEvents(conn db.Conn, creds connection.Credentials,
+	namespace string) {
+
+	machines := conn.SelectFromMachine(func(m db.Machine) bool {
+		return m.Namespace == namespace
+	})
+	if len(machines) == 0 {
+		return
+	}
+
+	leaderClient, err := newLeaderClient(machines, creds)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Debug(
+			"Failed to connect to the leader")
+		return
+	}
+	defer leaderClient.Close()
+
+	events, err := leaderClient.QueryEvents()
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Warning(
+			"Failed to query events")
+		return
+	}
+
+	conn.Txn(db.EventTable).Run(func(view db.Database) error {
+		existing := view.SelectFromEvent(func(e db.Event) bool {
+			return e.Namespace == namespace
+		})
+
+		copied := map[eventKey]struct{}{}
+		for _, e := range existing {
+			copied[keyOf(e)] = struct{}{}
+		}
+
+		// Once the namespace has as many events as it keeps, events older
+		// than all of them would be removed as soon as they were logged.
+		var oldest time.Time
+		if len(existing) >= db.MaxEvents {
+			oldest = db.SortEvents(existing)[0].Time
+		}
+
+		for _, event := range db.SortEvents(events) {
+			event.Namespace = namespace
+			key := keyOf(event)
+			if _, ok := copied[key]; ok || event.Time.Before(oldest) {
+				continue
+			}
+
+			view.LogEvent(event)
+			copied[key] = struct{}{}
+		}
+		return nil
+	})
+}
+
+// eventKey identifies an event regardless of its ID, which differs between the
+// leader's database and the daemon's.
+type eventKey struct {
+	time                               int64
+	typ, objectType, objectID, message string
+}
+
+func keyOf(e db.Event) eventKey {
+	return eventKey{e.Time.UnixNano(), e.Type, e.ObjectType, e.ObjectID,
+		e.Message}
+}
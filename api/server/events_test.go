@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/api/client"
+	"github.com/kelda/kelda/api/client/mocks"
+	"github.com/kelda/kelda/connection"
+	"github.com/kelda/kelda/db"
+)
+
+func TestReplicateEvents(t *testing.T) {
+	mc := new(mocks.Client)
+	mc.On("Close").Return(nil)
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		return mc, nil
+	}
+
+	conn := db.New()
+	conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		m := view.InsertMachine()
+		m.Namespace = "ns"
+		view.Commit(m)
+		return nil
+	})
+
+	start := time.Unix(1500000000, 0)
+	placed := db.Event{Time: start, Type: db.PlacedEvent, ObjectID: "a"}
+	built := db.Event{Time: start.Add(time.Second), Type: db.BuiltEvent}
+	mc.On("QueryEvents").Return([]db.Event{built, placed}, nil).Once()
+
+	// Namespaces without machines have no leader to replicate from.
+	replicateEvents(conn, nil, "other")
+
+	replicateEvents(conn, nil, "ns")
+
+	checkEvents := func(exp ...db.Event) {
+		events := db.SortEvents(conn.SelectFromEvent(nil))
+		for i := range events {
+			events[i].ID = 0
+		}
+		assert.Equal(t, exp, events)
+	}
+	placed.Namespace = "ns"
+	built.Namespace = "ns"
+	checkEvents(placed, built)
+
+	// Events that were already replicated aren't copied again, but distinct
+	// events at the same time are.
+	placed.Namespace = ""
+	placedB := db.Event{Time: start, Type: db.PlacedEvent, ObjectID: "b"}
+	mc.On("QueryEvents").Return([]db.Event{placed, built, placedB}, nil).Once()
+	replicateEvents(conn, nil, "ns")
+
+	placed.Namespace = "ns"
+	placedB.Namespace = "ns"
+	checkEvents(placed, placedB, built)
+
+	// After a failover, the new leader's events are copied even if its clock
+	// is behind that of the old leader.
+	rescheduled := db.Event{Time: start.Add(-time.Hour),
+		Type: db.RescheduledEvent, ObjectID: "a"}
+	mc.On("QueryEvents").Return([]db.Event{rescheduled}, nil).Once()
+	replicateEvents(conn, nil, "ns")
+
+	rescheduled.Namespace = "ns"
+	checkEvents(rescheduled, placed, placedB, built)
+
+	// Failed queries leave the events alone.
+	mc.On("QueryEvents").Return(nil, errors.New("error")).Once()
+	replicateEvents(conn, nil, "ns")
+	checkEvents(rescheduled, placed, placedB, built)
+}
+
+func TestReplicateEventsFull(t *testing.T) {
+	mc := new(mocks.Client)
+	mc.On("Close").Return(nil)
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		return mc, nil
+	}
+
+	start := time.Unix(1500000000, 0)
+	conn := db.New()
+	conn.Txn(db.MachineTable, db.EventTable).Run(func(view db.Database) error {
+		m := view.InsertMachine()
+		m.Namespace = "ns"
+		view.Commit(m)
+
+		for i := 0; i < db.MaxEvents; i++ {
+			view.LogEvent(db.Event{Namespace: "ns",
+				Time: start.Add(time.Duration(i) * time.Second),
+				Type: db.MachineStatusEvent})
+		}
+		return nil
+	})
+	full := db.SortEvents(conn.SelectFromEvent(nil))
+
+	// Once the namespace is full, events older than all of its events aren't
+	// copied, as they would be removed right away.
+	old := db.Event{Time: start.Add(-time.Second), Type: db.PlacedEvent}
+	mc.On("QueryEvents").Return([]db.Event{old}, nil).Once()
+	replicateEvents(conn, nil, "ns")
+	assert.Equal(t, full, db.SortEvents(conn.SelectFromEvent(nil)))
+}
+
+func TestQueryDaemonEvents(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Txn(db.EventTable).Run(func(view db.Database) error {
+		view.LogEvent(db.Event{Namespace: "ns", Type: db.MachineStatusEvent})
+		view.LogEvent(db.Event{Namespace: "other", Type: db.BootFailedEvent})
+		return nil
+	})
+
+	events, err := server{conn: conn, runningOnDaemon: true}.queryFromDaemon(
+		db.EventTable, "ns")
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, db.MachineStatusEvent, events.([]db.Event)[0].Type)
+}
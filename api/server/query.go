@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kelda/kelda/api/pb"
+)
+
+// applyQuery returns the rows in `rows`, a slice of database rows, that match
+// the filters in `query`, sorted as described by rowLess and paginated.  If the
+// query selects
+// fields, each row is returned as a map from the selected fields to their
+// values.  Queries without filters, fields, or pagination return `rows`
+// unchanged.
+func applyQuery(rows interface{}, query *pb.DBQuery) (interface{}, error) {
+	if len(query.Filters) == 0 && len(query.Fields) == 0 &&
+		query.Offset == 0 && query.Limit == 0 {
+		return rows, nil
+	}
+
+	if query.Offset < 0 || query.Limit < 0 {
+		return nil, errors.New("offset and limit must not be negative")
+	}
+
+	slice := reflect.ValueOf(rows)
+	rowType := slice.Type().Elem()
+
+	filters := map[string]string{}
+	for _, filter := range query.Filters {
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed filter %q: must have the "+
+				"form Field=value", filter)
+		}
+		if _, ok := rowType.FieldByName(parts[0]); !ok {
+			return nil, fmt.Errorf("unknown field: %s", parts[0])
+		}
+		filters[parts[0]] = parts[1]
+	}
+
+	for _, field := range query.Fields {
+		structField, ok := rowType.FieldByName(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+
+		// Fields are projected from the rows' JSON encoding, so fields that
+		// aren't encoded would always come back empty.
+		if structField.Tag.Get("json") == "-" {
+			return nil, fmt.Errorf("field can't be selected: %s", field)
+		}
+	}
+
+	var matches []reflect.Value
+	for i := 0; i < slice.Len(); i++ {
+		row := slice.Index(i)
+		if rowMatches(row, filters) {
+			matches = append(matches, row)
+		}
+	}
+
+	keys := make([]string, len(matches))
+	for i, row := range matches {
+		rowJSON, err := json.Marshal(row.Interface())
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = string(rowJSON)
+	}
+	sort.Sort(rowSorter{matches, keys})
+
+	offset := int(query.Offset)
+	if offset > len(matches) {
+		offset = len(matches)
+	}
+	matches = matches[offset:]
+	if query.Limit > 0 && int(query.Limit) < len(matches) {
+		matches = matches[:query.Limit]
+	}
+
+	if len(query.Fields) == 0 {
+		result := reflect.MakeSlice(slice.Type(), 0, len(matches))
+		for _, row := range matches {
+			result = reflect.Append(result, row)
+		}
+		return result.Interface(), nil
+	}
+
+	projected := []map[string]json.RawMessage{}
+	for _, row := range matches {
+		selected, err := selectFields(row.Interface(), query.Fields)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, selected)
+	}
+	return projected, nil
+}
+
+// rowSorter orders rows so that pages of the same query don't overlap, even
+// across calls.  Rows are sorted by ID.  The daemon's proxied rows don't encode
+// their IDs, so they're all zero, and are sorted by BlueprintID if the table has
+// one, and then by their JSON encodings.
+type rowSorter struct {
+	rows []reflect.Value
+	json []string
+}
+
+func (s rowSorter) Len() int {
+	return len(s.rows)
+}
+
+func (s rowSorter) Swap(i, j int) {
+	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
+	s.json[i], s.json[j] = s.json[j], s.json[i]
+}
+
+func (s rowSorter) Less(i, j int) bool {
+	l, r := s.rows[i], s.rows[j]
+	if lID, rID := l.FieldByName("ID").Int(), r.FieldByName("ID").Int(); lID != rID {
+		return lID < rID
+	}
+
+	lBP, rBP := l.FieldByName("BlueprintID"), r.FieldByName("BlueprintID")
+	if lBP.IsValid() && lBP.String() != rBP.String() {
+		return lBP.String() < rBP.String()
+	}
+	return s.json[i] < s.json[j]
+}
+
+// rowMatches returns whether the fields of `row` have the values in `filters`.
+// Field values are compared by their string representation, so that numeric
+// and boolean fields can be filtered on as well.
+func rowMatches(row reflect.Value, filters map[string]string) bool {
+	for field, value := range filters {
+		if fmt.Sprint(row.FieldByName(field).Interface()) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// selectFields returns the JSON encoding of the given fields of `row`.  Fields
+// that are omitted from the row's encoding, such as empty fields tagged
+// `omitempty`, are omitted from the result.
+func selectFields(row interface{}, fields []string) (
+	map[string]json.RawMessage, error) {
+
+	rowJSON, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(rowJSON, &all); err != nil {
+		return nil, err
+	}
+
+	selected := map[string]json.RawMessage{}
+	for _, field := range fields {
+		if val, ok := all[field]; ok {
+			selected[field] = val
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	"github.com/kelda/kelda/api/pb"
+	"github.com/kelda/kelda/db"
+)
+
+func TestApplyQuery(t *testing.T) {
+	t.Parallel()
+
+	containers := []db.Container{
+		{ID: 3, BlueprintID: "c", Minion: "10.0.0.5", Status: "running"},
+		{ID: 1, BlueprintID: "a", Minion: "10.0.0.5", Status: "running"},
+		{ID: 2, BlueprintID: "b", Minion: "10.0.0.6", Status: "running"},
+		{ID: 4, BlueprintID: "d", Minion: "10.0.0.5", Status: "exited",
+			ExitCode: 1},
+	}
+
+	// Queries without options return the rows unchanged.
+	rows, err := applyQuery(containers, &pb.DBQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, containers, rows)
+
+	rows, err = applyQuery(containers, &pb.DBQuery{
+		Filters: []string{"Minion=10.0.0.5", "Status=running"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Container{containers[1], containers[0]}, rows)
+
+	// Non-string fields are compared by their string representation.
+	rows, err = applyQuery(containers, &pb.DBQuery{
+		Filters: []string{"ExitCode=1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Container{containers[3]}, rows)
+
+	rows, err = applyQuery(containers, &pb.DBQuery{Offset: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Container{containers[2], containers[0]}, rows)
+
+	rows, err = applyQuery(containers, &pb.DBQuery{Offset: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Container{}, rows)
+
+	rows, err = applyQuery(containers, &pb.DBQuery{
+		Filters: []string{"Minion=10.0.0.6"},
+		Fields:  []string{"BlueprintID", "Status", "DockerID"}})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	row := rows.([]map[string]json.RawMessage)[0]
+	assert.Equal(t, map[string]json.RawMessage{
+		"BlueprintID": json.RawMessage(`"b"`),
+		"Status":      json.RawMessage(`"running"`),
+	}, row)
+
+	_, err = applyQuery(containers, &pb.DBQuery{Filters: []string{"Minion"}})
+	assert.EqualError(t, err,
+		`malformed filter "Minion": must have the form Field=value`)
+
+	_, err = applyQuery(containers, &pb.DBQuery{Filters: []string{"Foo=bar"}})
+	assert.EqualError(t, err, "unknown field: Foo")
+
+	_, err = applyQuery(containers, &pb.DBQuery{Fields: []string{"Foo"}})
+	assert.EqualError(t, err, "unknown field: Foo")
+
+	// Fields that aren't part of the rows' JSON encoding can't be selected,
+	// but can still be filtered on.
+	_, err = applyQuery(containers, &pb.DBQuery{Fields: []string{"ID"}})
+	assert.EqualError(t, err, "field can't be selected: ID")
+
+	_, err = applyQuery(containers, &pb.DBQuery{Filters: []string{"ID=1"}})
+	assert.NoError(t, err)
+
+	_, err = applyQuery(containers, &pb.DBQuery{Limit: -1})
+	assert.EqualError(t, err, "offset and limit must not be negative")
+}
+
+func TestQueryFiltered(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		for _, role := range []db.Role{db.Master, db.Worker, db.Worker} {
+			m := view.InsertMachine()
+			m.Role = role
+			view.Commit(m)
+		}
+		return nil
+	})
+
+	reply, err := server{conn: conn}.Query(context.Background(), &pb.DBQuery{
+		Table:   string(db.MachineTable),
+		Filters: []string{"Role=Worker"},
+		Fields:  []string{"ID", "Role"},
+		Limit:   1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"ID":2,"Role":"Worker"}]`, reply.TableContents)
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 
@@ -21,6 +24,7 @@ import (
 	"github.com/kelda/kelda/version"
 
 	"github.com/docker/distribution/reference"
+	"github.com/robfig/cron"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
@@ -60,12 +64,13 @@ func Run(conn db.Conn, listenAddr string, runningOnDaemon bool,
 	go func(c chan os.Signal) {
 		sig := <-c
 		var activeMachinesCount = len(conn.SelectFromMachine(nil))
-		var namespace, _ = conn.GetBlueprintNamespace()
+		var namespaces = conn.GetBlueprintNamespaces()
 		if activeMachinesCount > 0 {
 			log.Warnf("\n%d machines will continue running after the Kelda"+
 				" daemon shuts down. If you'd like to stop them, restart"+
-				" the daemon and run `kelda stop %s`.\n",
-				activeMachinesCount, namespace)
+				" the daemon and run `kelda stop NAMESPACE` for each of"+
+				" the namespaces: %s.\n",
+				activeMachinesCount, strings.Join(namespaces, ", "))
 		}
 		log.Printf("Caught signal %s: shutting down.\n", sig)
 		sock.Close()
@@ -85,7 +90,11 @@ func (s server) SetSecret(ctx context.Context, msg *pb.Secret) (*pb.SecretReply,
 	// will get immediate feedback on whether or not the secret was successfully
 	// set.
 	if s.runningOnDaemon {
-		machines := s.conn.SelectFromMachine(nil)
+		machines, err := s.selectMachines(msg.Namespace)
+		if err != nil {
+			return &pb.SecretReply{}, err
+		}
+
 		leaderClient, err := newLeaderClient(machines, s.clientCreds)
 		if err != nil {
 			return &pb.SecretReply{}, err
@@ -107,14 +116,17 @@ func (s server) SetSecret(ctx context.Context, msg *pb.Secret) (*pb.SecretReply,
 // returns the requested table from its local database. If in daemon mode,
 // Query proxies certain table requests (e.g. Container and Connection) to the
 // cluster. This is necessary because some tables are only used on the minions,
-// and aren't synced back to the daemon.
+// and aren't synced back to the daemon.  Either way, the rows are filtered,
+// projected, and paginated as the query requests before they're returned.  The
+// daemon fetches proxied tables from the leader in full, and applies the query
+// itself.
 func (s server) Query(cts context.Context, query *pb.DBQuery) (*pb.QueryReply, error) {
 	var rows interface{}
 	var err error
 
 	table := db.TableType(query.Table)
 	if s.runningOnDaemon {
-		rows, err = s.queryFromDaemon(table)
+		rows, err = s.queryFromDaemon(table, query.Namespace)
 	} else {
 		rows, err = s.queryLocal(table)
 	}
@@ -123,6 +135,11 @@ func (s server) Query(cts context.Context, query *pb.DBQuery) (*pb.QueryReply, e
 		return nil, err
 	}
 
+	rows, err = applyQuery(rows, query)
+	if err != nil {
+		return nil, err
+	}
+
 	json, err := json.Marshal(rows)
 	if err != nil {
 		return nil, err
@@ -147,21 +164,44 @@ func (s server) queryLocal(table db.TableType) (interface{}, error) {
 		return s.conn.SelectFromBlueprint(nil), nil
 	case db.ImageTable:
 		return s.conn.SelectFromImage(nil), nil
+	case db.JobTable:
+		return s.conn.SelectFromJob(nil), nil
+	case db.EventTable:
+		return s.conn.SelectFromEvent(nil), nil
 	default:
 		return nil, fmt.Errorf("unrecognized table: %s", table)
 	}
 }
 
-func (s server) queryFromDaemon(table db.TableType) (
+func (s server) queryFromDaemon(table db.TableType, namespace string) (
 	interface{}, error) {
 
+	namespace, err := s.getNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := s.conn.SelectFromMachine(func(m db.Machine) bool {
+		return m.Namespace == namespace
+	})
+
 	switch table {
-	case db.MachineTable, db.BlueprintTable:
-		return s.queryLocal(table)
+	case db.MachineTable:
+		return machines, nil
+	case db.BlueprintTable:
+		return s.conn.SelectFromBlueprint(func(bp db.Blueprint) bool {
+			return bp.Namespace == namespace
+		}), nil
+	case db.EventTable:
+		// The leader's events are replicated into the daemon's database by
+		// ReplicateEvents.
+		return s.conn.SelectFromEvent(func(e db.Event) bool {
+			return e.Namespace == namespace
+		}), nil
 	}
 
 	var leaderClient client.Client
-	leaderClient, err := newLeaderClient(s.conn.SelectFromMachine(nil), s.clientCreds)
+	leaderClient, err = newLeaderClient(machines, s.clientCreds)
 	if err != nil {
 		return nil, err
 	}
@@ -169,18 +209,67 @@ func (s server) queryFromDaemon(table db.TableType) (
 
 	switch table {
 	case db.ContainerTable:
-		return s.getClusterContainers(leaderClient)
+		return s.getClusterContainers(leaderClient, machines)
 	case db.ConnectionTable:
 		return leaderClient.QueryConnections()
 	case db.LoadBalancerTable:
 		return leaderClient.QueryLoadBalancers()
 	case db.ImageTable:
 		return leaderClient.QueryImages()
+	case db.JobTable:
+		return leaderClient.QueryJobs()
 	default:
 		return nil, fmt.Errorf("unrecognized table: %s", table)
 	}
 }
 
+// getNamespace returns the namespace that a request for `namespace` applies
+// to.  Requests that don't specify a namespace apply to the only namespace with
+// machines, or if none have machines, to the only namespace with a blueprint.
+func (s server) getNamespace(namespace string) (string, error) {
+	if namespace != "" {
+		return namespace, nil
+	}
+
+	hasMachines := map[string]bool{}
+	for _, m := range s.conn.SelectFromMachine(nil) {
+		hasMachines[m.Namespace] = true
+	}
+
+	var active []string
+	namespaces := s.conn.GetBlueprintNamespaces()
+	for _, ns := range namespaces {
+		if hasMachines[ns] {
+			active = append(active, ns)
+		}
+	}
+
+	switch {
+	case len(active) == 1:
+		return active[0], nil
+	case len(active) > 1:
+		return "", fmt.Errorf("multiple namespaces are deployed (%s), so "+
+			"one must be specified", strings.Join(active, ", "))
+	case len(namespaces) == 1:
+		return namespaces[0], nil
+	default:
+		return "", nil
+	}
+}
+
+// selectMachines returns the machines in the namespace that a request for
+// `namespace` applies to.
+func (s server) selectMachines(namespace string) ([]db.Machine, error) {
+	namespace, err := s.getNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.conn.SelectFromMachine(func(m db.Machine) bool {
+		return m.Namespace == namespace
+	}), nil
+}
+
 func (s server) QueryMinionCounters(ctx context.Context, in *pb.MinionCountersRequest) (
 	*pb.CountersReply, error) {
 	if !s.runningOnDaemon {
@@ -216,16 +305,296 @@ func (s server) Deploy(cts context.Context, deployReq *pb.DeployRequest) (
 		return nil, errDaemonOnlyRPC
 	}
 
-	newBlueprint, err := blueprint.FromJSON(deployReq.Deployment)
+	newBlueprint, err := parseDeployment(deployReq.Deployment)
 	if err != nil {
 		return &pb.DeployReply{}, err
 	}
 
+	// Each namespace has its own blueprint, so deploying to one namespace
+	// leaves the others running.
+	s.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		bp, err := view.GetBlueprint(newBlueprint.Namespace)
+		if err != nil {
+			bp = view.InsertBlueprint()
+		} else if containersChanged(bp.Blueprint, newBlueprint) {
+			bp.Previous = bp.Blueprint
+		}
+
+		keepPaused(bp.Blueprint, newBlueprint)
+		bp.Blueprint = newBlueprint
+		view.Commit(bp)
+		return nil
+	})
+
+	// XXX: Remove this error when the Vagrant provider is done.
+	for _, machine := range newBlueprint.Machines {
+		if machine.Provider == string(db.Vagrant) {
+			err = errors.New("The Vagrant provider is still in development." +
+				" The blueprint will continue to run, but" +
+				" there may be some errors.")
+			return &pb.DeployReply{}, err
+		}
+	}
+
+	return &pb.DeployReply{}, nil
+}
+
+// Plan computes the changes that deploying a blueprint would cause, based on
+// the daemon's view of the cloud and the containers that the leader is running.
+// Nothing is deployed.
+func (s server) Plan(cts context.Context, planReq *pb.PlanRequest) (
+	*pb.PlanReply, error) {
+
+	if !s.runningOnDaemon {
+		return nil, errDaemonOnlyRPC
+	}
+
+	newBlueprint, err := parseDeployment(planReq.Deployment)
+	if err != nil {
+		return &pb.PlanReply{}, err
+	}
+
+	plan := api.Plan{Plan: cloud.PlanDeployment(s.conn, newBlueprint)}
+
+	// Until the namespace has a leader, no containers are running in it.
+	var currContainers []db.Container
+	machines := s.conn.SelectFromMachine(func(m db.Machine) bool {
+		return m.Namespace == newBlueprint.Namespace
+	})
+	if len(machines) > 0 {
+		leaderClient, err := newLeaderClient(machines, s.clientCreds)
+		if err != nil {
+			log.WithError(err).Debug("Failed to connect to the leader, " +
+				"so planning as if no containers are running")
+		} else {
+			defer leaderClient.Close()
+
+			currContainers, err = leaderClient.QueryContainers()
+			if err != nil {
+				return &pb.PlanReply{}, err
+			}
+		}
+	}
+
+	plan.AddContainers, plan.RemoveContainers, plan.RescheduleContainers =
+		planContainers(currContainers,
+			newBlueprint.ExpandReplicas().Containers, plan.Terminate)
+
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		return &pb.PlanReply{}, err
+	}
+	return &pb.PlanReply{Plan: string(planJSON)}, nil
+}
+
+// planContainers returns the containers that deploying `bpContainers` in place
+// of the running containers, `curr`, would add and remove.  It also returns the
+// running containers that must be rescheduled because their machine is in
+// `terminate`.
+func planContainers(curr []db.Container, bpContainers []blueprint.Container,
+	terminate []db.Machine) (add, remove, reschedule []db.Container) {
+
+	currIDs := map[string]bool{}
+	for _, dbc := range curr {
+		currIDs[dbc.BlueprintID] = true
+	}
+
+	bpIDs := map[string]bool{}
+	for _, c := range bpContainers {
+		bpIDs[c.ID] = true
+		if !currIDs[c.ID] {
+			add = append(add, db.Container{
+				BlueprintID: c.ID,
+				Hostname:    c.Hostname,
+				Image:       c.Image.Name,
+			})
+		}
+	}
+
+	terminated := map[string]bool{}
+	for _, m := range terminate {
+		if m.PrivateIP != "" {
+			terminated[m.PrivateIP] = true
+		}
+	}
+
+	for _, dbc := range curr {
+		switch {
+		case !bpIDs[dbc.BlueprintID]:
+			remove = append(remove, dbc)
+		case dbc.Minion != "" && terminated[dbc.Minion]:
+			reschedule = append(reschedule, dbc)
+		}
+	}
+	return add, remove, reschedule
+}
+
+// Scale sets the number of replicas of a container in the deployed blueprint.
+// Only the container's replicas change, so the rest of the deployment keeps
+// running undisturbed.
+func (s server) Scale(cts context.Context, scaleReq *pb.ScaleRequest) (
+	*pb.ScaleReply, error) {
+
+	if !s.runningOnDaemon {
+		return nil, errDaemonOnlyRPC
+	}
+
+	if scaleReq.Replicas < 1 {
+		return &pb.ScaleReply{}, errors.New("replicas must be at least 1")
+	}
+
+	namespace, err := s.getNamespace(scaleReq.Namespace)
+	if err != nil {
+		return &pb.ScaleReply{}, err
+	}
+
+	err = s.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		bp, err := view.GetBlueprint(namespace)
+		if err != nil {
+			return err
+		}
+
+		// Copy the containers so that the database's blueprint isn't
+		// modified unless the scaled blueprint is valid.
+		containers := append([]blueprint.Container{}, bp.Containers...)
+		found := false
+		for i, c := range containers {
+			if c.Hostname == scaleReq.Hostname {
+				containers[i].Replicas = int(scaleReq.Replicas)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("no container with hostname %s",
+				scaleReq.Hostname)
+		}
+
+		scaled := bp.Blueprint
+		scaled.Containers = containers
+		if err := scaled.Validate(); err != nil {
+			return err
+		}
+
+		bp.Blueprint = scaled
+		view.Commit(bp)
+		return nil
+	})
+	return &pb.ScaleReply{}, err
+}
+
+// Rollout pauses, resumes, or aborts the rolling update of a group of
+// containers in the deployed blueprint.  The pause state is kept in the daemon's
+// blueprint, rather than in the blueprints that clients deploy, so that
+// re-deploying a blueprint doesn't resume a paused rollout.
+func (s server) Rollout(cts context.Context, rolloutReq *pb.RolloutRequest) (
+	*pb.RolloutReply, error) {
+
+	if !s.runningOnDaemon {
+		return nil, errDaemonOnlyRPC
+	}
+
+	switch rolloutReq.Action {
+	case "pause", "resume", "abort":
+	default:
+		return &pb.RolloutReply{}, fmt.Errorf("unknown action: %s",
+			rolloutReq.Action)
+	}
+
+	namespace, err := s.getNamespace(rolloutReq.Namespace)
+	if err != nil {
+		return &pb.RolloutReply{}, err
+	}
+
+	err = s.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		bp, err := view.GetBlueprint(namespace)
+		if err != nil {
+			return err
+		}
+
+		updated, err := updateRollout(bp, rolloutReq.Action, rolloutReq.Group)
+		if err != nil {
+			return err
+		}
+
+		if containersChanged(bp.Blueprint, updated) {
+			bp.Previous = bp.Blueprint
+		}
+		bp.Blueprint = updated
+		view.Commit(bp)
+		return nil
+	})
+	return &pb.RolloutReply{}, err
+}
+
+// updateRollout returns the blueprint that applies `action` to the rolling update
+// of `group`.
+func updateRollout(dbBp db.Blueprint, action, group string) (
+	blueprint.Blueprint, error) {
+
+	bp := dbBp.Blueprint
+	prevContainers := map[string]blueprint.Container{}
+	for _, c := range dbBp.Previous.Containers {
+		prevContainers[c.Hostname] = c
+	}
+
+	var containers []blueprint.Container
+	var found, rolledBack bool
+	for _, c := range bp.Containers {
+		if c.RollingUpdate == nil || c.RollingUpdate.Group != group {
+			containers = append(containers, c)
+			continue
+		}
+		found = true
+
+		ru := *c.RollingUpdate
+		ru.Paused = action == "pause"
+		if prev, ok := prevContainers[c.Hostname]; ok && action == "abort" &&
+			prev.ID != c.ID {
+			c = prev
+			rolledBack = true
+		}
+		c.RollingUpdate = &ru
+		containers = append(containers, c)
+	}
+
+	switch {
+	case !found:
+		return blueprint.Blueprint{}, fmt.Errorf(
+			"no rolling update group named %s", group)
+	case action == "abort" && !rolledBack:
+		return blueprint.Blueprint{}, fmt.Errorf(
+			"group %s has no previous version to roll back to", group)
+	}
+
+	bp.Containers = containers
+	return bp, nil
+}
+
+// parseDeployment parses and validates a deployment sent by a client.
+func parseDeployment(deployment string) (blueprint.Blueprint, error) {
+	newBlueprint, err := blueprint.FromJSON(deployment)
+	if err != nil {
+		return blueprint.Blueprint{}, err
+	}
+
 	for _, c := range newBlueprint.Containers {
 		if _, err := reference.ParseAnyReference(c.Image.Name); err != nil {
-			return &pb.DeployReply{}, fmt.Errorf("could not parse "+
+			return blueprint.Blueprint{}, fmt.Errorf("could not parse "+
 				"container image %s: %s", c.Image.Name, err.Error())
 		}
+
+		if c.Job == nil || c.Job.Schedule == "" {
+			continue
+		}
+		if _, err := cron.ParseStandard(c.Job.Schedule); err != nil {
+			return blueprint.Blueprint{}, fmt.Errorf("could not parse "+
+				"schedule of job %s: %s", c.Hostname, err)
+		}
+	}
+
+	if cycle := newBlueprint.DependencyCycle(); cycle != nil {
+		return blueprint.Blueprint{}, fmt.Errorf("container dependency cycle: %s",
+			strings.Join(cycle, " -> "))
 	}
 
 	// Ensure that the region is valid
@@ -241,44 +610,51 @@ func (s server) Deploy(cts context.Context, deployReq *pb.DeployRequest) (
 			}
 		}
 		if !regionValid {
-			return &pb.DeployReply{}, fmt.Errorf("region: %s is "+
+			return blueprint.Blueprint{}, fmt.Errorf("region: %s is "+
 				"not supported for provider: %s", first.Region,
 				first.Provider)
 		}
 	}
 
-	s.conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
-		bp, err := view.GetBlueprint()
-		if err != nil {
-			bp = view.InsertBlueprint()
-		} else {
-			// If the namespace changed, remove all the machines from the
-			// database since those applied to the old namespace, and leaving
-			// them in the database will cause incorrect behavior such as
-			// sending the new blueprint to the old machines.
-			if bp.Namespace != newBlueprint.Namespace {
-				for _, dbm := range view.SelectFromMachine(nil) {
-					view.Remove(dbm)
-				}
-			}
-		}
+	return newBlueprint, nil
+}
 
-		bp.Blueprint = newBlueprint
-		view.Commit(bp)
-		return nil
-	})
+// containersChanged returns whether deploying `new` in place of `old` changes
+// the spec of any container.  Changes that don't, such as pausing a rolling
+// update, don't replace the previous blueprint that rolling updates are aborted
+// to.
+func containersChanged(old, new blueprint.Blueprint) bool {
+	var oldIDs, newIDs []string
+	for _, c := range old.Containers {
+		oldIDs = append(oldIDs, c.ID)
+	}
+	for _, c := range new.Containers {
+		newIDs = append(newIDs, c.ID)
+	}
+	sort.Strings(oldIDs)
+	sort.Strings(newIDs)
+	return !reflect.DeepEqual(oldIDs, newIDs)
+}
 
-	// XXX: Remove this error when the Vagrant provider is done.
-	for _, machine := range newBlueprint.Machines {
-		if machine.Provider == string(db.Vagrant) {
-			err = errors.New("The Vagrant provider is still in development." +
-				" The blueprint will continue to run, but" +
-				" there may be some errors.")
-			return &pb.DeployReply{}, err
+// keepPaused pauses the rolling updates in `new` whose group was paused in `old`,
+// so that re-deploying a blueprint doesn't resume a paused rollout.  Deployed
+// blueprints don't record whether a group is paused, so only the Rollout RPC
+// resumes it.
+func keepPaused(old, new blueprint.Blueprint) {
+	paused := map[string]bool{}
+	for _, c := range old.Containers {
+		if c.RollingUpdate != nil && c.RollingUpdate.Paused {
+			paused[c.RollingUpdate.Group] = true
 		}
 	}
 
-	return &pb.DeployReply{}, nil
+	for i, c := range new.Containers {
+		if c.RollingUpdate != nil && paused[c.RollingUpdate.Group] {
+			ru := *c.RollingUpdate
+			ru.Paused = true
+			new.Containers[i].RollingUpdate = &ru
+		}
+	}
 }
 
 func (s server) Version(_ context.Context, _ *pb.VersionRequest) (
@@ -286,14 +662,14 @@ func (s server) Version(_ context.Context, _ *pb.VersionRequest) (
 	return &pb.VersionReply{Version: version.Version}, nil
 }
 
-func (s server) getClusterContainers(leaderClient client.Client) (interface{}, error) {
+func (s server) getClusterContainers(leaderClient client.Client,
+	machines []db.Machine) (interface{}, error) {
 	leaderContainers, err := leaderClient.QueryContainers()
 	if err != nil {
 		return nil, err
 	}
 
-	workerContainers, err := queryWorkers(s.conn.SelectFromMachine(nil),
-		s.clientCreds)
+	workerContainers, err := queryWorkers(machines, s.clientCreds)
 	if err != nil {
 		return nil, err
 	}
@@ -362,6 +738,9 @@ func updateLeaderContainerAttrs(lContainers []db.Container, wContainers []db.Con
 			lc.Created = wc.Created
 			lc.DockerID = wc.DockerID
 			lc.Status = wc.Status
+			lc.RestartCount = wc.RestartCount
+			lc.ExitCode = wc.ExitCode
+			lc.OOMKilled = wc.OOMKilled
 		}
 		allContainers = append(allContainers, lc)
 	}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -62,9 +63,10 @@ func TestQueryMachinesDaemon(t *testing.T) {
 		return nil
 	})
 
-	exp := `[{"ID":1,"Role":"Master","Provider":"Amazon",` +
+	exp := `[{"ID":1,"Namespace":"","Role":"Master","Provider":"Amazon",` +
 		`"Region":"","Size":"size","DiskSize":0,"SSHKeys":null,"FloatingIP":"",` +
-		`"Preemptible":false,"CloudID":"","PublicIP":"8.8.8.8",` +
+		`"Preemptible":false,"Group":"","Drain":false,"CloudID":"",` +
+		`"PublicIP":"8.8.8.8",` +
 		`"PrivateIP":"9.9.9.9","Status":"connected","PublicKey":""}]`
 
 	checkQuery(t, server{conn, true, nil}, db.MachineTable, exp)
@@ -78,7 +80,7 @@ func TestQueryContainersCluster(t *testing.T) {
 		c := view.InsertContainer()
 		c.DockerID = "docker-id"
 		c.Image = "image"
-		c.Command = []string{"cmd", "arg"}
+		c.Command = blueprint.NewStrings("cmd", "arg")
 		view.Commit(c)
 
 		return nil
@@ -140,6 +142,36 @@ func TestQueryContainersDaemon(t *testing.T) {
 	checkQuery(t, server{conn, true, nil}, db.ContainerTable, exp)
 }
 
+func TestQueryContainersDaemonPaginated(t *testing.T) {
+	// The leader returns its containers in a different order each time, and
+	// they have no IDs once proxied, so the pages must not rely on either.
+	mc := new(mocks.Client)
+	mc.On("QueryContainers").Return([]db.Container{
+		{BlueprintID: "b"}, {BlueprintID: "a"}}, nil).Once()
+	mc.On("QueryContainers").Return([]db.Container{
+		{BlueprintID: "a"}, {BlueprintID: "b"}}, nil).Once()
+	mc.On("Close").Return(nil)
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		return mc, nil
+	}
+
+	s := server{db.New(), true, nil}
+	var pages []string
+	for offset := int32(0); offset < 2; offset++ {
+		reply, err := s.Query(context.Background(), &pb.DBQuery{
+			Table:  string(db.ContainerTable),
+			Fields: []string{"BlueprintID"},
+			Offset: offset,
+			Limit:  1,
+		})
+		assert.NoError(t, err)
+		pages = append(pages, reply.TableContents)
+	}
+	assert.Equal(t, []string{`[{"BlueprintID":"a"}]`, `[{"BlueprintID":"b"}]`},
+		pages)
+}
+
 func TestBadDeployment(t *testing.T) {
 	conn := db.New()
 	s := server{conn: conn, runningOnDaemon: true}
@@ -186,6 +218,32 @@ func testInvalidImage(t *testing.T, s server, img, expErr string) {
 	assert.EqualError(t, err, expErr)
 }
 
+func TestInvalidSchedule(t *testing.T) {
+	conn := db.New()
+	s := server{conn: conn, runningOnDaemon: true}
+
+	deployment := `{"Containers":[{"ID": "1", "Hostname": "report",
+		"Image": {"Name": "image"}, "Job": {"Schedule": "daily"}}]}`
+	_, err := s.Deploy(context.Background(),
+		&pb.DeployRequest{Deployment: deployment})
+	assert.EqualError(t, err, "could not parse schedule of job report: "+
+		"Expected exactly 5 fields, found 1: daily")
+}
+
+func TestDependencyCycle(t *testing.T) {
+	conn := db.New()
+	s := server{conn: conn, runningOnDaemon: true}
+
+	deployment := `{"Containers":[
+		{"ID": "1", "Hostname": "web", "Image": {"Name": "image"},
+			"DependsOn": ["db"]},
+		{"ID": "2", "Hostname": "db", "Image": {"Name": "image"},
+			"DependsOn": ["web"]}]}`
+	_, err := s.Deploy(context.Background(),
+		&pb.DeployRequest{Deployment: deployment})
+	assert.EqualError(t, err, "container dependency cycle: web -> db -> web")
+}
+
 func TestDeploy(t *testing.T) {
 	conn := db.New()
 	s := server{conn: conn, runningOnDaemon: true}
@@ -209,7 +267,7 @@ func TestDeploy(t *testing.T) {
 
 	var bp db.Blueprint
 	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
-		bp, err = view.GetBlueprint()
+		bp, err = view.GetBlueprint("")
 		assert.NoError(t, err)
 		return nil
 	})
@@ -242,7 +300,7 @@ func TestDeployUnsupportedRegion(t *testing.T) {
 		"for provider: Amazon")
 }
 
-func TestDeployChangeNamespace(t *testing.T) {
+func TestDeployNewNamespace(t *testing.T) {
 	t.Parallel()
 
 	conn := db.New()
@@ -252,6 +310,7 @@ func TestDeployChangeNamespace(t *testing.T) {
 		view.Commit(bp)
 
 		dbm := view.InsertMachine()
+		dbm.Namespace = "old"
 		view.Commit(dbm)
 		return nil
 	})
@@ -262,8 +321,230 @@ func TestDeployChangeNamespace(t *testing.T) {
 		&pb.DeployRequest{Deployment: newNamespaceBlueprint})
 	assert.NoError(t, err)
 
-	// The machines in the database should be removed.
-	assert.Empty(t, conn.SelectFromMachine(nil))
+	// Deploying to a new namespace shouldn't affect the old one.
+	assert.Len(t, conn.SelectFromMachine(nil), 1)
+	assert.Equal(t, []string{"new", "old"}, conn.GetBlueprintNamespaces())
+}
+
+func TestGetNamespace(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	s := server{conn: conn, runningOnDaemon: true}
+	insertNamespace := func(namespace string, withMachine bool) {
+		conn.Txn(db.BlueprintTable, db.MachineTable).Run(
+			func(view db.Database) error {
+				bp := view.InsertBlueprint()
+				bp.Namespace = namespace
+				view.Commit(bp)
+
+				if withMachine {
+					dbm := view.InsertMachine()
+					dbm.Namespace = namespace
+					view.Commit(dbm)
+				}
+				return nil
+			})
+	}
+
+	// No blueprints have been deployed.
+	ns, err := s.getNamespace("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", ns)
+
+	// The only namespace is the default.
+	insertNamespace("a", false)
+	ns, err = s.getNamespace("")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", ns)
+
+	// Namespaces with machines are preferred over those without.
+	insertNamespace("b", true)
+	ns, err = s.getNamespace("")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", ns)
+
+	insertNamespace("c", true)
+	_, err = s.getNamespace("")
+	assert.EqualError(t, err, "multiple namespaces are deployed (b, c), "+
+		"so one must be specified")
+
+	// An explicit namespace is always used.
+	ns, err = s.getNamespace("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", ns)
+
+	machines, err := s.selectMachines("c")
+	assert.NoError(t, err)
+	assert.Len(t, machines, 1)
+	assert.Equal(t, "c", machines[0].Namespace)
+}
+
+func TestQueryMachinesNamespace(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		for _, ns := range []string{"a", "b"} {
+			bp := view.InsertBlueprint()
+			bp.Namespace = ns
+			view.Commit(bp)
+
+			dbm := view.InsertMachine()
+			dbm.Namespace = ns
+			dbm.CloudID = ns
+			view.Commit(dbm)
+		}
+		return nil
+	})
+
+	reply, err := server{conn, true, nil}.Query(context.Background(),
+		&pb.DBQuery{Table: string(db.MachineTable), Namespace: "b"})
+	assert.NoError(t, err)
+	assert.Contains(t, reply.TableContents, `"CloudID":"b"`)
+	assert.NotContains(t, reply.TableContents, `"CloudID":"a"`)
+}
+
+func TestDeployRollingUpdate(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	s := server{conn: conn, runningOnDaemon: true}
+	deploy := func(bpStr string) db.Blueprint {
+		_, err := s.Deploy(context.Background(),
+			&pb.DeployRequest{Deployment: bpStr})
+		assert.NoError(t, err)
+
+		var bp db.Blueprint
+		conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+			bp, err = view.GetBlueprint("")
+			assert.NoError(t, err)
+			return nil
+		})
+		return bp
+	}
+
+	rollout := func(action string) error {
+		_, err := s.Rollout(context.Background(),
+			&pb.RolloutRequest{Group: "a", Action: action})
+		return err
+	}
+
+	v1 := `{"Containers":[{"ID":"1","Hostname":"a","Image":{"Name":"a"},` +
+		`"RollingUpdate":{"Group":"a"}}]}`
+	v2 := `{"Containers":[{"ID":"2","Hostname":"a","Image":{"Name":"a"},` +
+		`"RollingUpdate":{"Group":"a"}}]}`
+
+	bp := deploy(v1)
+	assert.Equal(t, blueprint.Blueprint{}, bp.Previous)
+
+	// Pausing the update doesn't change the containers, so the previous
+	// blueprint isn't replaced.
+	assert.NoError(t, rollout("pause"))
+	bp = deploy(v1)
+	assert.Equal(t, blueprint.Blueprint{}, bp.Previous)
+	assert.True(t, bp.Containers[0].RollingUpdate.Paused)
+
+	// The pause carries over to the next deployment.
+	bp = deploy(v2)
+	assert.Equal(t, "1", bp.Previous.Containers[0].ID)
+	assert.True(t, bp.Previous.Containers[0].RollingUpdate.Paused)
+	assert.Equal(t, "2", bp.Containers[0].ID)
+	assert.True(t, bp.Containers[0].RollingUpdate.Paused)
+
+	// Resuming lets the minions continue replacing the containers, and stays
+	// in effect when the blueprint is deployed again.
+	assert.NoError(t, rollout("resume"))
+	bp = deploy(v2)
+	assert.Equal(t, "2", bp.Containers[0].ID)
+	assert.False(t, bp.Containers[0].RollingUpdate.Paused)
+	assert.Equal(t, "1", bp.Previous.Containers[0].ID)
+
+	// Aborting rolls back to the previous containers, unpaused.
+	assert.NoError(t, rollout("pause"))
+	assert.NoError(t, rollout("abort"))
+	bp = deploy(v1)
+	assert.Equal(t, "1", bp.Containers[0].ID)
+	assert.False(t, bp.Containers[0].RollingUpdate.Paused)
+}
+
+func TestRollout(t *testing.T) {
+	t.Parallel()
+
+	container := func(id, hostname, group string, paused bool) blueprint.Container {
+		c := blueprint.Container{ID: id, Hostname: hostname,
+			Image: blueprint.Image{Name: hostname}}
+		if group != "" {
+			c.RollingUpdate = &blueprint.RollingUpdate{
+				Group: group, MaxSurge: 1, Paused: paused}
+		}
+		return c
+	}
+
+	conn := db.New()
+	reset := func(prev []blueprint.Container) {
+		conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+			for _, bp := range view.SelectFromBlueprint(nil) {
+				view.Remove(bp)
+			}
+			bp := view.InsertBlueprint()
+			bp.Namespace = "ns"
+			bp.Containers = []blueprint.Container{
+				container("web1-v2", "web1", "web", false),
+				container("web2-v2", "web2", "web", false),
+				container("db-v2", "db", "", false),
+			}
+			bp.Previous.Containers = prev
+			view.Commit(bp)
+			return nil
+		})
+	}
+	getBlueprint := func() (bp db.Blueprint) {
+		conn.Txn(db.BlueprintTable).Run(func(view db.Database) (err error) {
+			bp, err = view.GetBlueprint("ns")
+			assert.NoError(t, err)
+			return err
+		})
+		return bp
+	}
+
+	s := server{conn: conn, runningOnDaemon: true}
+	rollout := func(action, group string) error {
+		_, err := s.Rollout(context.Background(), &pb.RolloutRequest{
+			Namespace: "ns", Group: group, Action: action})
+		return err
+	}
+
+	prev := []blueprint.Container{
+		container("web1-v1", "web1", "", false),
+		container("db-v1", "db", "", false),
+	}
+	reset(prev)
+	assert.NoError(t, rollout("pause", "web"))
+	assert.Equal(t, []blueprint.Container{
+		container("web1-v2", "web1", "web", true),
+		container("web2-v2", "web2", "web", true),
+		container("db-v2", "db", "", false),
+	}, getBlueprint().Containers)
+
+	// Aborting reverts the containers that have a previous version, while
+	// keeping the rolling update so that they're reverted gradually.
+	assert.NoError(t, rollout("abort", "web"))
+	bp := getBlueprint()
+	assert.Equal(t, []blueprint.Container{
+		container("web1-v1", "web1", "web", false),
+		container("web2-v2", "web2", "web", false),
+		container("db-v2", "db", "", false),
+	}, bp.Containers)
+	assert.Equal(t, "web1-v2", bp.Previous.Containers[0].ID)
+
+	assert.EqualError(t, rollout("resume", "db"),
+		"no rolling update group named db")
+	assert.EqualError(t, rollout("restart", "web"), "unknown action: restart")
+
+	reset(nil)
+	assert.EqualError(t, rollout("abort", "web"),
+		"group web has no previous version to roll back to")
 }
 
 func TestVagrantDeployment(t *testing.T) {
@@ -290,7 +571,7 @@ func TestVagrantDeployment(t *testing.T) {
 
 	var bp db.Blueprint
 	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
-		bp, err = view.GetBlueprint()
+		bp, err = view.GetBlueprint("")
 		assert.NoError(t, err)
 		return nil
 	})
@@ -313,9 +594,12 @@ func TestUpdateLeaderContainerAttrs(t *testing.T) {
 
 	wContainers := []db.Container{
 		{
-			BlueprintID: "1",
-			Created:     created,
-			Status:      "running",
+			BlueprintID:  "1",
+			Created:      created,
+			Status:       "running",
+			RestartCount: 2,
+			ExitCode:     137,
+			OOMKilled:    true,
 		},
 	}
 
@@ -364,6 +648,145 @@ func TestDaemonOnlyEndpoints(t *testing.T) {
 
 	_, err = server{runningOnDaemon: false}.Deploy(nil, nil)
 	assert.EqualError(t, err, errDaemonOnlyRPC.Error())
+
+	_, err = server{runningOnDaemon: false}.Plan(nil, nil)
+	assert.EqualError(t, err, errDaemonOnlyRPC.Error())
+
+	_, err = server{runningOnDaemon: false}.Scale(nil, nil)
+	assert.EqualError(t, err, errDaemonOnlyRPC.Error())
+
+	_, err = server{runningOnDaemon: false}.Rollout(nil, nil)
+	assert.EqualError(t, err, errDaemonOnlyRPC.Error())
+}
+
+func TestPlan(t *testing.T) {
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		mc := new(mocks.Client)
+		mc.On("QueryContainers").Return([]db.Container{
+			{BlueprintID: "1", Hostname: "a", Minion: "10.0.0.1"},
+			{BlueprintID: "2", Hostname: "b", Minion: "10.0.0.2"},
+		}, nil)
+		mc.On("Close").Return(nil)
+		return mc, nil
+	}
+
+	conn := db.New()
+	conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		for ip, size := range map[string]string{
+			"10.0.0.1": "m4.2xlarge",
+			"10.0.0.2": "m4.large",
+		} {
+			m := view.InsertMachine()
+			m.Namespace = "ns"
+			m.Provider = db.Amazon
+			m.Region = "us-west-1"
+			m.Size = size
+			m.CloudID = ip
+			m.PrivateIP = ip
+			view.Commit(m)
+		}
+		return nil
+	})
+	s := server{conn: conn, runningOnDaemon: true}
+
+	// Resizing the machine running container "a" terminates it, so "a" must
+	// be rescheduled.
+	deployment := `{"Namespace":"ns","Machines":[` +
+		`{"Provider":"Amazon","Region":"us-west-1","Size":"m4.large"},` +
+		`{"Provider":"Amazon","Region":"us-west-1","Size":"m4.xlarge"}],` +
+		`"Containers":[` +
+		`{"ID":"1","Hostname":"a","Image":{"Name":"a"}},` +
+		`{"ID":"3","Hostname":"c","Image":{"Name":"c"}}]}`
+	reply, err := s.Plan(context.Background(),
+		&pb.PlanRequest{Deployment: deployment})
+	assert.NoError(t, err)
+
+	var plan api.Plan
+	assert.NoError(t, json.Unmarshal([]byte(reply.Plan), &plan))
+
+	assert.Len(t, plan.Boot, 1)
+	assert.Equal(t, "m4.xlarge", plan.Boot[0].Size)
+	assert.Len(t, plan.Terminate, 1)
+	assert.Equal(t, "10.0.0.1", plan.Terminate[0].PrivateIP)
+	assert.Len(t, plan.AddACLs, 1)
+	assert.Empty(t, plan.RemoveACLs)
+
+	assert.Equal(t, []db.Container{{BlueprintID: "3", Hostname: "c",
+		Image: "c"}}, plan.AddContainers)
+	assert.Equal(t, []db.Container{{BlueprintID: "2", Hostname: "b",
+		Minion: "10.0.0.2"}}, plan.RemoveContainers)
+	assert.Equal(t, []db.Container{{BlueprintID: "1", Hostname: "a",
+		Minion: "10.0.0.1"}}, plan.RescheduleContainers)
+
+	// Planning doesn't deploy anything.
+	assert.Empty(t, conn.SelectFromBlueprint(nil))
+	for _, m := range conn.SelectFromMachine(nil) {
+		assert.Equal(t, "", m.Status)
+	}
+
+	_, err = s.Plan(context.Background(), &pb.PlanRequest{
+		Deployment: `{"Containers":[{"ID":"1","Image":{"Name":"Bad"}}]}`})
+	assert.Error(t, err)
+}
+
+func TestScale(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		bp.Containers = []blueprint.Container{
+			{ID: "1", Hostname: "web", Image: blueprint.Image{Name: "nginx"}},
+			{ID: "2", Hostname: "web-2",
+				Image: blueprint.Image{Name: "nginx"}},
+			{ID: "3", Hostname: "job", Image: blueprint.Image{Name: "job"},
+				Job: &blueprint.Job{}},
+		}
+		view.Commit(bp)
+		return nil
+	})
+	s := server{conn: conn, runningOnDaemon: true}
+
+	getBlueprint := func() (bp db.Blueprint) {
+		conn.Txn(db.BlueprintTable).Run(func(view db.Database) (err error) {
+			bp, err = view.GetBlueprint("ns")
+			assert.NoError(t, err)
+			return err
+		})
+		return bp
+	}
+
+	_, err := s.Scale(context.Background(),
+		&pb.ScaleRequest{Hostname: "web-2", Replicas: 3})
+	assert.NoError(t, err)
+
+	bp := getBlueprint()
+	assert.Equal(t, 0, bp.Containers[0].Replicas)
+	assert.Equal(t, 3, bp.Containers[1].Replicas)
+
+	_, err = s.Scale(context.Background(),
+		&pb.ScaleRequest{Namespace: "ns", Hostname: "missing", Replicas: 2})
+	assert.EqualError(t, err, "no container with hostname missing")
+
+	_, err = s.Scale(context.Background(),
+		&pb.ScaleRequest{Hostname: "web", Replicas: 0})
+	assert.EqualError(t, err, "replicas must be at least 1")
+
+	// Scaled blueprints must still be valid, and aren't committed otherwise.
+	_, err = s.Scale(context.Background(),
+		&pb.ScaleRequest{Hostname: "web", Replicas: 2})
+	assert.EqualError(t, err,
+		`containers[0].replicas: hostname "web-2" used multiple times`)
+
+	_, err = s.Scale(context.Background(),
+		&pb.ScaleRequest{Hostname: "job", Replicas: 2})
+	assert.EqualError(t, err, "containers[2].replicas: jobs can't have replicas")
+
+	bp = getBlueprint()
+	assert.Equal(t, 0, bp.Containers[0].Replicas)
+	assert.Equal(t, 0, bp.Containers[2].Replicas)
 }
 
 func TestQueryImagesCluster(t *testing.T) {
@@ -397,6 +820,21 @@ func TestQueryImagesDaemon(t *testing.T) {
 	checkQuery(t, server{db.New(), true, nil}, db.ImageTable, exp)
 }
 
+func TestQueryJobsDaemon(t *testing.T) {
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		mc := new(mocks.Client)
+		mc.On("QueryJobs").Return([]db.Job{{
+			Hostname: "migrate",
+		}}, nil)
+		mc.On("Close").Return(nil)
+		return mc, nil
+	}
+
+	exp := `[{"Hostname":"migrate","Created":"0001-01-01T00:00:00Z"}]`
+	checkQuery(t, server{db.New(), true, nil}, db.JobTable, exp)
+}
+
 // The Daemon should get a connection to the leader of the cluster, and
 // forward the secret association.
 func TestSetSecretDaemon(t *testing.T) {
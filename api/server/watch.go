@@ -0,0 +1,223 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/kelda/kelda/api"
+	"github.com/kelda/kelda/api/pb"
+	"github.com/kelda/kelda/db"
+)
+
+// Watch streams the rows of a table: first a snapshot of every row, and then an
+// event for each row that's added, modified, or deleted.  Minions watch their
+// local database.  The daemon watches its own machines, blueprints, and events,
+// and proxies watches of the containers and connections to the leader, since
+// only the cluster tracks them.
+func (s server) Watch(req *pb.WatchRequest, stream pb.API_WatchServer) error {
+	table := db.TableType(req.Table)
+	if !s.runningOnDaemon {
+		return watchTable(stream, s.conn, table, func() (interface{}, error) {
+			return s.queryLocal(table)
+		})
+	}
+
+	namespace, err := s.getNamespace(req.Namespace)
+	if err != nil {
+		return err
+	}
+
+	switch table {
+	case db.MachineTable, db.BlueprintTable, db.EventTable:
+		return watchTable(stream, s.conn, table, func() (interface{}, error) {
+			return s.queryFromDaemon(table, namespace)
+		})
+	case db.ContainerTable, db.ConnectionTable:
+		return s.proxyWatch(stream, table, namespace)
+	default:
+		return fmt.Errorf("unwatchable table: %s", table)
+	}
+}
+
+// watchTable streams the rows returned by `query` to `stream`, and queries
+// them again whenever `table` changes, until the stream ends.
+func watchTable(stream pb.API_WatchServer, conn db.Conn, table db.TableType,
+	query func() (interface{}, error)) error {
+
+	rows, err := query()
+	if err != nil {
+		return err
+	}
+
+	curr, err := encodeRows(rows)
+	if err != nil {
+		return err
+	}
+
+	if err := sendRows(stream, api.Snapshot, rows); err != nil {
+		return err
+	}
+
+	trigger := conn.Trigger(table)
+	defer trigger.Stop()
+
+	for {
+		select {
+		case <-trigger.C:
+		case <-stream.Context().Done():
+			return nil
+		}
+
+		rows, err := query()
+		if err != nil {
+			return err
+		}
+
+		next, err := encodeRows(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range diffRows(curr, next) {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+		curr = next
+	}
+}
+
+// proxyWatch relays the leader's stream of `table` in `namespace` to `stream`.
+// Unlike Query, it doesn't merge in the container attributes that only the
+// workers track, because they change without the leader's rows changing, and
+// so wouldn't produce events.  The streamed containers have the leader's values
+// of those attributes instead.
+func (s server) proxyWatch(stream pb.API_WatchServer, table db.TableType,
+	namespace string) error {
+
+	machines := s.conn.SelectFromMachine(func(m db.Machine) bool {
+		return m.Namespace == namespace
+	})
+	leaderClient, err := newLeaderClient(machines, s.clientCreds)
+	if err != nil {
+		return err
+	}
+	defer leaderClient.Close()
+
+	var next func() (api.EventType, interface{}, error)
+	var stop func()
+	switch table {
+	case db.ContainerTable:
+		w, err := leaderClient.WatchContainers()
+		if err != nil {
+			return err
+		}
+		next = func() (api.EventType, interface{}, error) {
+			event, err := w.Next()
+			return event.Type, event.Containers, err
+		}
+		stop = w.Stop
+	case db.ConnectionTable:
+		w, err := leaderClient.WatchConnections()
+		if err != nil {
+			return err
+		}
+		next = func() (api.EventType, interface{}, error) {
+			event, err := w.Next()
+			return event.Type, event.Connections, err
+		}
+		stop = w.Stop
+	default:
+		return fmt.Errorf("unwatchable table: %s", table)
+	}
+
+	// Stopping the leader's watch unblocks Next once the client goes away.
+	go func() {
+		<-stream.Context().Done()
+		stop()
+	}()
+
+	for {
+		eventType, rows, err := next()
+		if stream.Context().Err() != nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := sendRows(stream, eventType, rows); err != nil {
+			return err
+		}
+	}
+}
+
+func sendRows(stream pb.API_WatchServer, eventType api.EventType,
+	rows interface{}) error {
+
+	rowsJSON, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(&pb.WatchEvent{
+		Type: pb.WatchEvent_Type(eventType),
+		Rows: string(rowsJSON),
+	})
+}
+
+// encodeRows returns the JSON encoding of each row in `rows`, a slice of
+// database rows, keyed by the rows' IDs.
+func encodeRows(rows interface{}) (map[int]string, error) {
+	encoded := map[int]string{}
+	slice := reflect.ValueOf(rows)
+	for i := 0; i < slice.Len(); i++ {
+		row := slice.Index(i)
+		rowJSON, err := json.Marshal(row.Interface())
+		if err != nil {
+			return nil, err
+		}
+		encoded[int(row.FieldByName("ID").Int())] = string(rowJSON)
+	}
+	return encoded, nil
+}
+
+// diffRows returns an event for each row that differs between `old` and `new`,
+// in order of the rows' IDs.  Some tables don't encode their IDs, so modified
+// events contain the old version of the row as well as the new one, so that
+// clients can tell which row changed.
+func diffRows(old, new map[int]string) (events []*pb.WatchEvent) {
+	var ids []int
+	for id := range old {
+		ids = append(ids, id)
+	}
+	for id := range new {
+		if _, ok := old[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		oldRow, inOld := old[id]
+		newRow, inNew := new[id]
+
+		var event *pb.WatchEvent
+		switch {
+		case !inOld:
+			event = &pb.WatchEvent{Type: pb.WatchEvent_ADDED, Rows: newRow}
+		case !inNew:
+			event = &pb.WatchEvent{Type: pb.WatchEvent_DELETED, Rows: oldRow}
+		case oldRow != newRow:
+			event = &pb.WatchEvent{Type: pb.WatchEvent_MODIFIED,
+				Rows: oldRow + "," + newRow}
+		default:
+			continue
+		}
+
+		event.Rows = "[" + event.Rows + "]"
+		events = append(events, event)
+	}
+	return events
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/kelda/kelda/api"
+	"github.com/kelda/kelda/api/client"
+	"github.com/kelda/kelda/api/client/mocks"
+	"github.com/kelda/kelda/api/pb"
+	"github.com/kelda/kelda/connection"
+	"github.com/kelda/kelda/db"
+)
+
+type mockWatchServer struct {
+	ctx    context.Context
+	events chan *pb.WatchEvent
+
+	grpc.ServerStream
+}
+
+func newMockWatchServer() (mockWatchServer, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return mockWatchServer{ctx: ctx, events: make(chan *pb.WatchEvent, 8)},
+		cancel
+}
+
+func (s mockWatchServer) Send(event *pb.WatchEvent) error {
+	s.events <- event
+	return nil
+}
+
+func (s mockWatchServer) Context() context.Context {
+	return s.ctx
+}
+
+type mockContainerWatcher struct {
+	events  chan api.ContainerEvent
+	stopped chan struct{}
+}
+
+func (w mockContainerWatcher) Next() (api.ContainerEvent, error) {
+	select {
+	case event := <-w.events:
+		return event, nil
+	case <-w.stopped:
+		return api.ContainerEvent{}, context.Canceled
+	}
+}
+
+func (w mockContainerWatcher) Stop() {
+	close(w.stopped)
+}
+
+// checkEvent asserts that the next event on `stream` has type `exp`, and
+// returns the BlueprintIDs of its containers.
+func checkEvent(t *testing.T, stream mockWatchServer, exp pb.WatchEvent_Type) (
+	ids []string) {
+
+	event := <-stream.events
+	assert.Equal(t, exp, event.Type)
+
+	var containers []db.Container
+	assert.NoError(t, json.Unmarshal([]byte(event.Rows), &containers))
+	for _, dbc := range containers {
+		ids = append(ids, dbc.BlueprintID)
+	}
+	return ids
+}
+
+func TestWatchLocal(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+		dbc := view.InsertContainer()
+		dbc.BlueprintID = "1"
+		view.Commit(dbc)
+		return nil
+	})
+
+	s := server{conn: conn}
+	stream, cancel := newMockWatchServer()
+	errC := make(chan error)
+	go func() {
+		errC <- s.Watch(&pb.WatchRequest{Table: string(db.ContainerTable)},
+			stream)
+	}()
+
+	assert.Equal(t, []string{"1"}, checkEvent(t, stream, pb.WatchEvent_SNAPSHOT))
+
+	conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+		dbc := view.SelectFromContainer(nil)[0]
+		dbc.Image = "new"
+		view.Commit(dbc)
+
+		dbc = view.InsertContainer()
+		dbc.BlueprintID = "2"
+		view.Commit(dbc)
+		return nil
+	})
+	assert.Equal(t, []string{"1", "1"},
+		checkEvent(t, stream, pb.WatchEvent_MODIFIED))
+	assert.Equal(t, []string{"2"}, checkEvent(t, stream, pb.WatchEvent_ADDED))
+
+	conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+		view.Remove(view.SelectFromContainer(func(dbc db.Container) bool {
+			return dbc.BlueprintID == "1"
+		})[0])
+		return nil
+	})
+	assert.Equal(t, []string{"1"}, checkEvent(t, stream, pb.WatchEvent_DELETED))
+
+	cancel()
+	assert.NoError(t, <-errC)
+}
+
+func TestWatchDaemonMachines(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	insertMachine := func(namespace, cloudID string) {
+		conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+			dbm := view.InsertMachine()
+			dbm.Namespace = namespace
+			dbm.CloudID = cloudID
+			view.Commit(dbm)
+			return nil
+		})
+	}
+	insertMachine("a", "1")
+
+	s := server{conn: conn, runningOnDaemon: true}
+	stream, cancel := newMockWatchServer()
+	errC := make(chan error)
+	go func() {
+		errC <- s.Watch(&pb.WatchRequest{
+			Table:     string(db.MachineTable),
+			Namespace: "a",
+		}, stream)
+	}()
+
+	checkMachines := func(exp pb.WatchEvent_Type) (cloudIDs []string) {
+		event := <-stream.events
+		assert.Equal(t, exp, event.Type)
+
+		var machines []db.Machine
+		assert.NoError(t, json.Unmarshal([]byte(event.Rows), &machines))
+		for _, dbm := range machines {
+			cloudIDs = append(cloudIDs, dbm.CloudID)
+		}
+		return cloudIDs
+	}
+	assert.Equal(t, []string{"1"}, checkMachines(pb.WatchEvent_SNAPSHOT))
+
+	// Machines in other namespaces aren't streamed.
+	insertMachine("b", "2")
+	insertMachine("a", "3")
+	assert.Equal(t, []string{"3"}, checkMachines(pb.WatchEvent_ADDED))
+
+	cancel()
+	assert.NoError(t, <-errC)
+}
+
+func TestWatchProxy(t *testing.T) {
+	watcher := mockContainerWatcher{
+		events:  make(chan api.ContainerEvent),
+		stopped: make(chan struct{}),
+	}
+	mc := new(mocks.Client)
+	mc.On("WatchContainers").Return(watcher, nil)
+	mc.On("Close").Return(nil)
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		return mc, nil
+	}
+
+	conn := db.New()
+	conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		view.InsertMachine()
+		return nil
+	})
+
+	s := server{conn: conn, runningOnDaemon: true}
+	stream, cancel := newMockWatchServer()
+	errC := make(chan error)
+	go func() {
+		errC <- s.Watch(&pb.WatchRequest{Table: string(db.ContainerTable)},
+			stream)
+	}()
+
+	watcher.events <- api.ContainerEvent{
+		Type:       api.Snapshot,
+		Containers: []db.Container{{BlueprintID: "1"}},
+	}
+	assert.Equal(t, []string{"1"}, checkEvent(t, stream, pb.WatchEvent_SNAPSHOT))
+
+	watcher.events <- api.ContainerEvent{
+		Type:       api.Added,
+		Containers: []db.Container{{BlueprintID: "2"}},
+	}
+	assert.Equal(t, []string{"2"}, checkEvent(t, stream, pb.WatchEvent_ADDED))
+
+	// Ending the client's watch stops the leader's.
+	cancel()
+	assert.NoError(t, <-errC)
+	<-watcher.stopped
+	mc.AssertCalled(t, "Close")
+}
+
+func TestWatchErrors(t *testing.T) {
+	t.Parallel()
+
+	stream, cancel := newMockWatchServer()
+	defer cancel()
+
+	err := server{conn: db.New()}.Watch(&pb.WatchRequest{Table: "foo"}, stream)
+	assert.EqualError(t, err, "unrecognized table: foo")
+
+	err = server{conn: db.New(), runningOnDaemon: true}.Watch(
+		&pb.WatchRequest{Table: string(db.ImageTable)}, stream)
+	assert.EqualError(t, err, "unwatchable table: db.Image")
+}
+
+func TestDiffRows(t *testing.T) {
+	t.Parallel()
+
+	events := diffRows(
+		map[int]string{1: "a", 2: "b", 3: "c"},
+		map[int]string{2: "b", 3: "C", 4: "d"})
+	assert.Equal(t, []*pb.WatchEvent{
+		{Type: pb.WatchEvent_DELETED, Rows: "[a]"},
+		{Type: pb.WatchEvent_MODIFIED, Rows: "[c,C]"},
+		{Type: pb.WatchEvent_ADDED, Rows: "[d]"},
+	}, events)
+
+	assert.Empty(t, diffRows(map[int]string{1: "a"}, map[int]string{1: "a"}))
+}
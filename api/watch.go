@@ -0,0 +1,107 @@
+package api
+
+import (
+	"github.com/kelda/kelda/db"
+)
+
+// EventType describes how a watched table changed.  The values match the
+// types of the WatchEvents streamed by the API server.
+type EventType int
+
+const (
+	// Snapshot events contain every row in the table.  They're sent when a
+	// watch starts.
+	Snapshot EventType = iota
+
+	// Added events contain a row that was inserted into the table.
+	Added
+
+	// Modified events contain the old version of a row that changed,
+	// followed by its new version.  The old version identifies the row for
+	// tables whose rows have no key, as described by each table's event.
+	Modified
+
+	// Deleted events contain the last version of a row that was removed from
+	// the table.
+	Deleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Snapshot:
+		return "snapshot"
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Deleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// A MachineEvent is a change to the machine table.  Machines are identified by
+// their IDs.
+type MachineEvent struct {
+	Type     EventType
+	Machines []db.Machine
+}
+
+// A ContainerEvent is a change to the container table.  Containers don't send
+// their IDs, so they're identified by their BlueprintIDs.
+type ContainerEvent struct {
+	Type       EventType
+	Containers []db.Container
+}
+
+// A ConnectionEvent is a change to the connection table.  Connections have no
+// key, so they're identified by their contents: modified and deleted
+// connections equal the client's copy of the old row.
+type ConnectionEvent struct {
+	Type        EventType
+	Connections []db.Connection
+}
+
+// An EventLogEvent is a change to the event table.  Like connections, events
+// have no key, and are identified by their contents.
+type EventLogEvent struct {
+	Type   EventType
+	Events []db.Event
+}
+
+// A MachineWatcher streams the changes to the machine table.
+type MachineWatcher interface {
+	// Next blocks until the table changes, and returns the change.
+	Next() (MachineEvent, error)
+
+	// Stop ends the watch.  Calls to Next after Stop return an error.
+	Stop()
+}
+
+// A ContainerWatcher streams the changes to the container table.
+type ContainerWatcher interface {
+	// Next blocks until the table changes, and returns the change.
+	Next() (ContainerEvent, error)
+
+	// Stop ends the watch.  Calls to Next after Stop return an error.
+	Stop()
+}
+
+// A ConnectionWatcher streams the changes to the connection table.
+type ConnectionWatcher interface {
+	// Next blocks until the table changes, and returns the change.
+	Next() (ConnectionEvent, error)
+
+	// Stop ends the watch.  Calls to Next after Stop return an error.
+	Stop()
+}
+
+// An EventLogWatcher streams the changes to the event table.
+type EventLogWatcher interface {
+	// Next blocks until the table changes, and returns the change.
+	Next() (EventLogEvent, error)
+
+	// Stop ends the watch.  Calls to Next after Stop return an error.
+	Stop()
+}
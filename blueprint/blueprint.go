@@ -8,8 +8,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/kelda/kelda/util"
+	"github.com/kelda/kelda/util/str"
+	"gopkg.in/yaml.v2"
 )
 
 // A Blueprint is an abstract representation of the policy language.
@@ -19,6 +22,8 @@ type Blueprint struct {
 	Connections   []Connection   `json:",omitempty"`
 	Placements    []Placement    `json:",omitempty"`
 	Machines      []Machine      `json:",omitempty"`
+	MachineGroups []MachineGroup `json:",omitempty"`
+	Volumes       []Volume       `json:",omitempty"`
 
 	AdminACL  []string `json:",omitempty"`
 	Namespace string   `json:",omitempty"`
@@ -31,6 +36,9 @@ type Placement struct {
 
 	Exclusive bool `json:",omitempty"`
 
+	// Container Constraints
+	OtherContainer string `json:",omitempty"`
+
 	// Machine Constraints
 	Provider   string `json:",omitempty"`
 	Size       string `json:",omitempty"`
@@ -49,15 +57,153 @@ type Image struct {
 type Container struct {
 	ID                string                    `json:",omitempty"`
 	Image             Image                     `json:",omitempty"`
-	Command           []string                  `json:",omitempty"`
+	Command           []ContainerValue          `json:",omitempty"`
 	Env               map[string]ContainerValue `json:",omitempty"`
 	FilepathToContent map[string]ContainerValue `json:",omitempty"`
 	Hostname          string                    `json:",omitempty"`
+
+	// The requests are reserved for the container when deciding which
+	// machine it's scheduled on, and the limits are the most the container
+	// may consume once booted. CPU is measured in cores, and RAM in GiB, the
+	// same units used by the machine descriptions. Zero means unspecified.
+	CPURequest float64 `json:",omitempty"`
+	CPULimit   float64 `json:",omitempty"`
+	RAMRequest float64 `json:",omitempty"`
+	RAMLimit   float64 `json:",omitempty"`
+
+	VolumeMounts []VolumeMount `json:",omitempty"`
+	HealthCheck  *HealthCheck  `json:",omitempty"`
+
+	// RestartPolicy decides whether the container is restarted when it
+	// exits.  The empty string is equivalent to RestartAlways.
+	RestartPolicy string `json:",omitempty"`
+
+	// RollingUpdate, if set, replaces the containers in its group a few at a
+	// time when their spec changes, rather than all at once.
+	RollingUpdate *RollingUpdate `json:",omitempty"`
+
+	// Job, if set, runs the container to completion rather than as a
+	// long-running service.
+	Job *Job `json:",omitempty"`
+
+	// DependsOn lists the hostnames of the containers that must be ready
+	// before this container boots.  Jobs are ready once they've succeeded.
+	DependsOn []string `json:",omitempty"`
+
+	// Replicas is the number of copies of the container to deploy.  Zero is
+	// treated as one.  See ExpandReplicas.
+	Replicas int `json:",omitempty"`
+}
+
+// ReplicaHostnames returns the hostnames of the copies of the container.  The
+// first copy keeps the container's hostname, and the others have it suffixed
+// with their number, e.g. web, web-2, and web-3.
+func (c Container) ReplicaHostnames() []string {
+	hostnames := []string{c.Hostname}
+	for i := 2; i <= c.Replicas; i++ {
+		hostnames = append(hostnames, fmt.Sprintf("%s-%d", c.Hostname, i))
+	}
+	return hostnames
+}
+
+// A Job runs copies of a container until Completions of them have exited
+// successfully, with at most Parallelism running at once.  Each copy that fails
+// is retried up to RetryLimit times, after which the run of the job fails.  Zero
+// Completions and Parallelism are treated as one.  A job with a Schedule, a
+// standard five field cron expression such as "0 3 * * *", runs each time the
+// schedule fires rather than once.
+type Job struct {
+	Completions int    `json:",omitempty"`
+	Parallelism int    `json:",omitempty"`
+	RetryLimit  int    `json:",omitempty"`
+	Schedule    string `json:",omitempty"`
+}
+
+// A RollingUpdate limits how many containers in Group may be replaced at once.
+// While replacing a container, Kelda boots up to MaxSurge new containers
+// alongside the old ones, and stops up to MaxUnavailable old containers before
+// their replacements are running (and healthy, if they have a health check).
+// If both are zero, MaxUnavailable is treated as one.  A Paused update leaves
+// the containers that haven't been replaced yet untouched.
+type RollingUpdate struct {
+	Group          string `json:",omitempty"`
+	MaxUnavailable int    `json:",omitempty"`
+	MaxSurge       int    `json:",omitempty"`
+	Paused         bool   `json:",omitempty"`
+}
+
+const (
+	// RestartAlways restarts a container whenever it exits.
+	RestartAlways = "always"
+
+	// RestartOnFailure restarts a container only if it exits with a non-zero
+	// status.
+	RestartOnFailure = "on-failure"
+
+	// RestartNever leaves a container stopped once it exits.
+	RestartNever = "never"
+)
+
+const (
+	// HTTPHealthCheck succeeds if a GET request to Path on Port returns a 2xx
+	// or 3xx status code.
+	HTTPHealthCheck = "http"
+
+	// TCPHealthCheck succeeds if a connection can be opened to Port.
+	TCPHealthCheck = "tcp"
+
+	// ExecHealthCheck succeeds if Command exits with status zero when run in
+	// the container.
+	ExecHealthCheck = "exec"
+)
+
+// A HealthCheck is periodically run against a container to determine whether it
+// should receive traffic from load balancers and DNS.  A container is unhealthy
+// once FailureThreshold consecutive checks fail, and healthy again after the
+// next check that succeeds.  Zero values are replaced with defaults.
+type HealthCheck struct {
+	Type    string   `json:",omitempty"`
+	Port    int      `json:",omitempty"`
+	Path    string   `json:",omitempty"`
+	Command []string `json:",omitempty"`
+
+	IntervalSeconds  int `json:",omitempty"`
+	TimeoutSeconds   int `json:",omitempty"`
+	FailureThreshold int `json:",omitempty"`
+}
+
+const (
+	// HostVolume is a Volume stored in a directory on the machine it's
+	// first used on.
+	HostVolume = "host"
+
+	// BlockVolume is a Volume backed by the cloud provider's block storage.
+	BlockVolume = "block"
+)
+
+// A Volume is storage whose contents outlive the containers that mount it.
+// Host volumes are bound to the machine they're first used on, while block
+// volumes may be re-attached to another machine if theirs goes away.
+type Volume struct {
+	Name    string `json:",omitempty"`
+	Type    string `json:",omitempty"`
+	SizeGiB int    `json:",omitempty"`
+
+	// The cloud in which block volumes are created.  Containers mounting a
+	// block volume may only be placed on workers in this cloud.
+	Provider string `json:",omitempty"`
+	Region   string `json:",omitempty"`
+}
+
+// A VolumeMount mounts the Volume with the given name at MountPath.
+type VolumeMount struct {
+	Volume    string `json:",omitempty"`
+	MountPath string `json:",omitempty"`
 }
 
 // ContainerValue is a wrapper for the possible values that can be used in
-// the container Env and FilepathToContent maps. The only permissible types
-// are Secret, RuntimeValue, and string.
+// the container Command, and the Env and FilepathToContent maps. The only
+// permissible types are Secret, RuntimeValue, and string.
 type ContainerValue struct {
 	Value interface{}
 }
@@ -68,14 +214,42 @@ type Secret struct {
 	NameOfSecret string
 }
 
-// ContainerPubIPKey is the RuntimeValue resource key for the public IP of the
-// machine running the container. In other words, it's the IP at which the
-// container can be reached from the public internet.
-const ContainerPubIPKey = "host.ip"
+// The RuntimeValue resource keys.
+const (
+	// ContainerPubIPKey is the public IP of the machine running the
+	// container. In other words, it's the IP at which the container can be
+	// reached from the public internet.
+	ContainerPubIPKey = "host.ip"
+
+	// HostPrivateIPKey is the private IP of the machine running the container.
+	HostPrivateIPKey = "host.privateIP"
+
+	// HostFloatingIPKey is the floating IP assigned to the machine running the
+	// container, or the empty string if it doesn't have one.
+	HostFloatingIPKey = "host.floatingIP"
+
+	// HostProviderKey is the cloud provider of the machine running the
+	// container.
+	HostProviderKey = "host.provider"
+
+	// HostRegionKey is the region of the machine running the container.
+	HostRegionKey = "host.region"
+
+	// HostSizeKey is the instance size of the machine running the container.
+	HostSizeKey = "host.size"
+
+	// ContainerIPKey is the container's own IP on the Kelda overlay network.
+	ContainerIPKey = "container.ip"
+)
+
+// RuntimeValueKeys are the resource keys that RuntimeValues may reference.
+var RuntimeValueKeys = []string{ContainerPubIPKey, HostPrivateIPKey,
+	HostFloatingIPKey, HostProviderKey, HostRegionKey, HostSizeKey,
+	ContainerIPKey}
 
 // RuntimeValue represents metadata about a container that is only known when
-// the container is about to be booted. Currently, the only valid resource key
-// is defined by the ContainerPubIPKey constant.
+// the container is about to be booted. The valid resource keys are listed in
+// RuntimeValueKeys.
 type RuntimeValue struct {
 	ResourceKey string
 }
@@ -93,8 +267,29 @@ type Connection struct {
 	To      []string `json:",omitempty"`
 	MinPort int      `json:",omitempty"`
 	MaxPort int      `json:",omitempty"`
+
+	// Protocol restricts the connection to a single IP protocol.  If it's
+	// empty, both TCP and UDP are allowed on the port range, as is ICMP.
+	Protocol string `json:",omitempty"`
+
+	// ContainerPort remaps connections from the public internet onto different
+	// ports of the containers in `To`.  Traffic to MinPort is forwarded to
+	// ContainerPort, and the rest of the range is shifted by the same amount.
+	// If it's zero, traffic is forwarded to the same port it arrived on.
+	ContainerPort int `json:",omitempty"`
 }
 
+const (
+	// TCPProtocol allows TCP traffic on a connection's ports.
+	TCPProtocol = "tcp"
+
+	// UDPProtocol allows UDP traffic on a connection's ports.
+	UDPProtocol = "udp"
+
+	// ICMPProtocol allows ICMP traffic.  Its connections have no ports.
+	ICMPProtocol = "icmp"
+)
+
 // A ConnectionSlice allows for slices of Collections to be used in joins
 type ConnectionSlice []Connection
 
@@ -110,6 +305,18 @@ type Machine struct {
 	Preemptible bool     `json:",omitempty"`
 }
 
+// A MachineGroup is a set of identical worker machines that the daemon's
+// autoscaler resizes between Min and Max.  It adds a machine when containers
+// can't be scheduled, and removes a machine whose containers fit on the other
+// workers once Cooldown seconds have passed since the group was last resized.
+type MachineGroup struct {
+	Name     string  `json:",omitempty"`
+	Machine  Machine `json:",omitempty"`
+	Min      int     `json:",omitempty"`
+	Max      int     `json:",omitempty"`
+	Cooldown int     `json:",omitempty"`
+}
+
 // PublicInternetLabel is a magic label that allows connections to or from the public
 // network.
 const PublicInternetLabel = "public"
@@ -122,8 +329,42 @@ func FromFile(filename string) (Blueprint, error) {
 }
 
 // FromFileWithArgs gets a Blueprint handle from a file on disk, passing the
-// given arguments to the node process.
+// given arguments to the node process.  Files ending in .json, .yaml, or .yml
+// are treated as already compiled blueprints, and are validated rather than
+// run by node.
 func FromFileWithArgs(filename string, cmdLineArgs []string) (Blueprint, error) {
+	var parse func(string) (Blueprint, error)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		parse = FromJSON
+	case ".yaml", ".yml":
+		parse = FromYAML
+	default:
+		return compileJS(filename, cmdLineArgs)
+	}
+
+	if len(cmdLineArgs) != 0 {
+		return Blueprint{}, errors.New(
+			"only JavaScript blueprints accept arguments")
+	}
+
+	contents, err := util.ReadFile(filename)
+	if err != nil {
+		return Blueprint{}, err
+	}
+
+	bp, err := parse(contents)
+	if err != nil {
+		return Blueprint{}, err
+	}
+
+	if err := bp.Validate(); err != nil {
+		return Blueprint{}, fmt.Errorf("invalid blueprint: %s", err)
+	}
+	return bp, nil
+}
+
+func compileJS(filename string, cmdLineArgs []string) (Blueprint, error) {
 	nodeBinary, err := util.GetNodeBinary()
 	if err != nil {
 		return Blueprint{}, err
@@ -179,6 +420,123 @@ func FromJSON(jsonStr string) (bp Blueprint, err error) {
 	return bp, err
 }
 
+// FromYAML gets a Blueprint handle from the YAML form of the deployment
+// representation.  It accepts the same field names as FromJSON.
+func FromYAML(yamlStr string) (Blueprint, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &parsed); err != nil {
+		return Blueprint{}, fmt.Errorf("unable to parse blueprint: %s", err)
+	}
+
+	jsonBytes, err := json.Marshal(yamlToJSON(parsed))
+	if err != nil {
+		return Blueprint{}, fmt.Errorf("unable to parse blueprint: %s", err)
+	}
+	return FromJSON(string(jsonBytes))
+}
+
+// yamlToJSON converts the maps produced by the YAML parser, which may have
+// keys of any type, into maps with string keys that can be marshalled as JSON.
+func yamlToJSON(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, elem := range v {
+			converted[fmt.Sprint(key)] = yamlToJSON(elem)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, elem := range v {
+			converted[i] = yamlToJSON(elem)
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+// ExpandReplicas returns a copy of the blueprint in which each container is
+// replaced by its replicas.  The replicas are members of every load balancer
+// and connection that the container is, and share its placement constraints
+// and dependents, so that scaling a container doesn't require changing them.
+// The first replica keeps the container's ID, so that scaling up doesn't
+// replace it.
+func (bp Blueprint) ExpandReplicas() Blueprint {
+	replicas := map[string][]string{}
+	var containers []Container
+	for _, c := range bp.Containers {
+		hostnames := c.ReplicaHostnames()
+		replicas[c.Hostname] = hostnames
+		for i, hostname := range hostnames {
+			replica := c
+			replica.Replicas = 0
+			replica.Hostname = hostname
+			if i > 0 {
+				replica.ID = fmt.Sprintf("%s-%d", c.ID, i+1)
+			}
+			containers = append(containers, replica)
+		}
+	}
+
+	expand := func(hostnames []string) []string {
+		var expanded []string
+		for _, hostname := range hostnames {
+			if r, ok := replicas[hostname]; ok {
+				expanded = append(expanded, r...)
+			} else {
+				expanded = append(expanded, hostname)
+			}
+		}
+		return expanded
+	}
+
+	for i := range containers {
+		containers[i].DependsOn = expand(containers[i].DependsOn)
+	}
+
+	var loadBalancers []LoadBalancer
+	for _, lb := range bp.LoadBalancers {
+		lb.Hostnames = expand(lb.Hostnames)
+		loadBalancers = append(loadBalancers, lb)
+	}
+
+	var connections []Connection
+	for _, conn := range bp.Connections {
+		conn.From = expand(conn.From)
+		conn.To = expand(conn.To)
+		connections = append(connections, conn)
+	}
+
+	var placements []Placement
+	for _, p := range bp.Placements {
+		others := []string{""}
+		if p.OtherContainer != "" {
+			others = expand([]string{p.OtherContainer})
+		}
+
+		for _, target := range expand([]string{p.TargetContainer}) {
+			for _, other := range others {
+				// Replicas don't constrain their own placement.
+				if target == other && target != p.TargetContainer {
+					continue
+				}
+
+				replica := p
+				replica.TargetContainer = target
+				replica.OtherContainer = other
+				placements = append(placements, replica)
+			}
+		}
+	}
+
+	bp.Containers = containers
+	bp.LoadBalancers = loadBalancers
+	bp.Connections = connections
+	bp.Placements = placements
+	return bp
+}
+
 // String returns the Blueprint in its deployment representation.
 func (bp Blueprint) String() string {
 	jsonBytes, err := json.Marshal(bp)
@@ -203,6 +561,16 @@ func NewString(str string) ContainerValue {
 	return ContainerValue{str}
 }
 
+// NewStrings returns a slice of ContainerValues representing the given strings.
+// It's convenient for building a Container's Command.
+func NewStrings(strs ...string) []ContainerValue {
+	var vals []ContainerValue
+	for _, str := range strs {
+		vals = append(vals, NewString(str))
+	}
+	return vals
+}
+
 // String returns a human-readable representation of the ContainerValue. This
 // makes the database logs easier to read.
 func (cv ContainerValue) String() string {
@@ -268,7 +636,7 @@ func unmarshalAsRuntimeValue(jsonBytes []byte) (RuntimeValue, error) {
 		return runtimeValue, errors.New("missing required field: ResourceKey")
 	}
 
-	if runtimeValue.ResourceKey != ContainerPubIPKey {
+	if !str.SliceContains(RuntimeValueKeys, runtimeValue.ResourceKey) {
 		return runtimeValue, fmt.Errorf("undefined resource key: %s",
 			runtimeValue.ResourceKey)
 	}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/util"
 )
 
 func TestMissingNode(t *testing.T) {
@@ -16,6 +19,75 @@ func TestMissingNode(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestFromCompiledFile(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+
+	exp := Blueprint{
+		Containers: []Container{{
+			ID:       "web",
+			Hostname: "web",
+			Image:    Image{Name: "nginx"},
+			Env: map[string]ContainerValue{
+				"key":    NewSecret("secret"),
+				"public": NewRuntimeValue(ContainerPubIPKey),
+			},
+			CPURequest: 0.5,
+		}},
+		Connections: []Connection{{
+			From:    []string{PublicInternetLabel},
+			To:      []string{"web"},
+			MinPort: 80,
+			MaxPort: 80,
+		}},
+	}
+
+	util.WriteFile("bp.json", []byte(exp.String()), 0644)
+	bp, err := FromFile("bp.json")
+	assert.NoError(t, err)
+	assert.Equal(t, exp, bp)
+
+	util.WriteFile("bp.yaml", []byte(`
+containers:
+- id: web
+  hostname: web
+  image:
+    name: nginx
+  env:
+    key:
+      nameOfSecret: secret
+    public:
+      resourceKey: host.ip
+  cpuRequest: 0.5
+connections:
+- from: [public]
+  to: [web]
+  minPort: 80
+  maxPort: 80
+`), 0644)
+	bp, err = FromFile("bp.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, exp, bp)
+
+	_, err = FromFileWithArgs("bp.yaml", []string{"arg"})
+	assert.EqualError(t, err, "only JavaScript blueprints accept arguments")
+
+	util.WriteFile("bad.yml", []byte(`
+connections:
+- from: [public]
+  to: [web]
+`), 0644)
+	_, err = FromFile("bad.yml")
+	assert.EqualError(t, err, `invalid blueprint: connections[0].to[0]: `+
+		`undefined hostname "web"`)
+
+	util.WriteFile("bad.json", []byte(`{"containers": {}}`), 0644)
+	_, err = FromFile("bad.json")
+	assert.Error(t, err)
+
+	_, err = FromFile("missing.json")
+	assert.Error(t, err)
+}
+
 func TestContainerValueString(t *testing.T) {
 	t.Parallel()
 
@@ -75,16 +147,17 @@ func TestRuntimeValueJSON(t *testing.T) {
 	err := json.Unmarshal([]byte(`{"resourceKey": "undefined"}`), &unmarshalled)
 	assert.Contains(t, err.Error(), "undefined resource key: undefined")
 
-	// Test the success case.
-	resourceKey := ContainerPubIPKey
-	resourceKeyJSON := fmt.Sprintf(`{"resourceKey": "%s"}`, resourceKey)
-
-	assert.NoError(t, json.Unmarshal([]byte(resourceKeyJSON), &unmarshalled))
+	// Test the success cases.
+	for _, resourceKey := range RuntimeValueKeys {
+		resourceKeyJSON := fmt.Sprintf(`{"resourceKey": "%s"}`, resourceKey)
+		assert.NoError(t, json.Unmarshal([]byte(resourceKeyJSON),
+			&unmarshalled))
 
-	runtimeValue, ok := unmarshalled.Value.(RuntimeValue)
-	assert.True(t, ok)
-	assert.Equal(t, resourceKey, runtimeValue.ResourceKey)
-	checkMarshalAndUnmarshal(t, unmarshalled)
+		runtimeValue, ok := unmarshalled.Value.(RuntimeValue)
+		assert.True(t, ok)
+		assert.Equal(t, resourceKey, runtimeValue.ResourceKey)
+		checkMarshalAndUnmarshal(t, unmarshalled)
+	}
 }
 
 // checkMarshalAndUnmarshal checks that that the given ContainerValue marshals
@@ -97,3 +170,77 @@ func checkMarshalAndUnmarshal(t *testing.T, toMarshal ContainerValue) {
 	assert.NoError(t, json.Unmarshal(jsonBytes, &unmarshalled))
 	assert.Equal(t, toMarshal, unmarshalled)
 }
+
+func TestExpandReplicas(t *testing.T) {
+	t.Parallel()
+
+	bp := Blueprint{
+		Containers: []Container{
+			{ID: "1", Hostname: "web", Replicas: 3,
+				DependsOn: []string{"db"}},
+			{ID: "2", Hostname: "db"},
+			{ID: "3", Hostname: "proxy", DependsOn: []string{"web"}},
+		},
+		LoadBalancers: []LoadBalancer{
+			{Name: "lb", Hostnames: []string{"web"}},
+		},
+		Connections: []Connection{
+			{From: []string{PublicInternetLabel}, To: []string{"web"}},
+			{From: []string{"web"}, To: []string{"db"}},
+		},
+		Placements: []Placement{
+			{TargetContainer: "web", Exclusive: true, Provider: "Amazon"},
+			{TargetContainer: "web", OtherContainer: "web",
+				Exclusive: true},
+			{TargetContainer: "db", OtherContainer: "web"},
+		},
+		Namespace: "ns",
+	}
+
+	exp := Blueprint{
+		Containers: []Container{
+			{ID: "1", Hostname: "web", DependsOn: []string{"db"}},
+			{ID: "1-2", Hostname: "web-2", DependsOn: []string{"db"}},
+			{ID: "1-3", Hostname: "web-3", DependsOn: []string{"db"}},
+			{ID: "2", Hostname: "db"},
+			{ID: "3", Hostname: "proxy",
+				DependsOn: []string{"web", "web-2", "web-3"}},
+		},
+		LoadBalancers: []LoadBalancer{
+			{Name: "lb", Hostnames: []string{"web", "web-2", "web-3"}},
+		},
+		Connections: []Connection{
+			{From: []string{PublicInternetLabel},
+				To: []string{"web", "web-2", "web-3"}},
+			{From: []string{"web", "web-2", "web-3"}, To: []string{"db"}},
+		},
+		Placements: []Placement{
+			{TargetContainer: "web", Exclusive: true, Provider: "Amazon"},
+			{TargetContainer: "web-2", Exclusive: true, Provider: "Amazon"},
+			{TargetContainer: "web-3", Exclusive: true, Provider: "Amazon"},
+			{TargetContainer: "web", OtherContainer: "web", Exclusive: true},
+			{TargetContainer: "web", OtherContainer: "web-2",
+				Exclusive: true},
+			{TargetContainer: "web", OtherContainer: "web-3",
+				Exclusive: true},
+			{TargetContainer: "web-2", OtherContainer: "web",
+				Exclusive: true},
+			{TargetContainer: "web-2", OtherContainer: "web-3",
+				Exclusive: true},
+			{TargetContainer: "web-3", OtherContainer: "web",
+				Exclusive: true},
+			{TargetContainer: "web-3", OtherContainer: "web-2",
+				Exclusive: true},
+			{TargetContainer: "db", OtherContainer: "web"},
+			{TargetContainer: "db", OtherContainer: "web-2"},
+			{TargetContainer: "db", OtherContainer: "web-3"},
+		},
+		Namespace: "ns",
+	}
+	assert.Equal(t, exp, bp.ExpandReplicas())
+
+	// The original blueprint isn't modified.
+	assert.Equal(t, 3, bp.Containers[0].Replicas)
+	assert.Equal(t, []string{"web"}, bp.Containers[2].DependsOn)
+	assert.Equal(t, []string{"web"}, bp.LoadBalancers[0].Hostnames)
+}
@@ -0,0 +1,469 @@
+package blueprint
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/kelda/kelda/util/str"
+
+	"github.com/robfig/cron"
+)
+
+// The roles that machines may have.  These mirror db.Role, which can't be
+// imported here because the db depends on the blueprint.
+const (
+	masterRole = "Master"
+	workerRole = "Worker"
+)
+
+var hostnameRegex = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+
+// The namespace is written into cloud resource names and shell commands, so it
+// is limited to characters that are safe in both.
+var namespaceRegex = regexp.MustCompile("^[a-z0-9-]*$")
+
+// maxRemappedPorts is the largest port range that may be forwarded to different
+// container ports.  The minions forward each port of such a range with its own
+// iptables rule.
+const maxRemappedPorts = 100
+
+// Validate checks that the blueprint is internally consistent.  It performs
+// the same checks as the JavaScript bindings do before outputting a
+// blueprint, so that blueprints written by other tools are held to the same
+// standard.  The returned error names the path of the offending field, e.g.
+// `connections[2].to[0]`.
+func (bp Blueprint) Validate() error {
+	if bp.Namespace != strings.ToLower(bp.Namespace) {
+		return fmt.Errorf("namespace: %q contains uppercase letters. "+
+			"Namespaces must be lowercase", bp.Namespace)
+	}
+	if !namespaceRegex.MatchString(bp.Namespace) {
+		return fmt.Errorf("namespace: %q is not a valid namespace. "+
+			"Namespaces must only contain lowercase characters, numbers "+
+			"and hyphens", bp.Namespace)
+	}
+
+	hostnames := map[string]bool{PublicInternetLabel: true}
+	addHostname := func(path, hostname string) error {
+		if !hostnameRegex.MatchString(hostname) || len(hostname) > 253 {
+			return fmt.Errorf("%s: %q is not a valid hostname. Hostnames "+
+				"must only contain lowercase characters, numbers and "+
+				"hyphens, and cannot start or end with a hyphen",
+				path, hostname)
+		}
+		if hostnames[hostname] {
+			return fmt.Errorf("%s: hostname %q used multiple times",
+				path, hostname)
+		}
+		hostnames[hostname] = true
+		return nil
+	}
+
+	containers := map[string]Container{}
+	for i, c := range bp.Containers {
+		path := fmt.Sprintf("containers[%d].hostname", i)
+		if err := addHostname(path, c.Hostname); err != nil {
+			return err
+		}
+		containers[c.Hostname] = c
+	}
+
+	// The replicas' hostnames are checked once the containers' are known,
+	// so that conflicts are blamed on the replicas.
+	for i, c := range bp.Containers {
+		path := fmt.Sprintf("containers[%d].replicas", i)
+		for _, hostname := range c.ReplicaHostnames()[1:] {
+			if err := addHostname(path, hostname); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, lb := range bp.LoadBalancers {
+		path := fmt.Sprintf("loadBalancers[%d].name", i)
+		if err := addHostname(path, lb.Name); err != nil {
+			return err
+		}
+
+		for j, hostname := range lb.Hostnames {
+			if _, ok := containers[hostname]; !ok {
+				return fmt.Errorf("loadBalancers[%d].hostnames[%d]: "+
+					"undefined container %q", i, j, hostname)
+			}
+		}
+	}
+
+	for i, conn := range bp.Connections {
+		path := fmt.Sprintf("connections[%d]", i)
+		if err := validateConnection(path, conn, hostnames); err != nil {
+			return err
+		}
+	}
+
+	for i, p := range bp.Placements {
+		if _, ok := containers[p.TargetContainer]; !ok {
+			return fmt.Errorf("placements[%d].targetContainer: "+
+				"undefined container %q", i, p.TargetContainer)
+		}
+
+		_, ok := containers[p.OtherContainer]
+		if p.OtherContainer != "" && !ok {
+			return fmt.Errorf("placements[%d].otherContainer: "+
+				"undefined container %q", i, p.OtherContainer)
+		}
+	}
+
+	for i, c := range bp.Containers {
+		for j, hostname := range c.DependsOn {
+			if _, ok := containers[hostname]; !ok {
+				return fmt.Errorf("containers[%d].dependsOn[%d]: "+
+					"undefined container %q", i, j, hostname)
+			}
+		}
+	}
+
+	if cycle := bp.DependencyCycle(); cycle != nil {
+		return fmt.Errorf("containers: dependency cycle %s",
+			strings.Join(cycle, " -> "))
+	}
+
+	if err := validateContainers(bp); err != nil {
+		return err
+	}
+	return validateMachines(bp)
+}
+
+// DependencyCycle returns the hostnames of a cycle of containers that each
+// depend on the next, starting and ending with the same hostname, or nil if the
+// dependencies are acyclic.
+func (bp Blueprint) DependencyCycle() []string {
+	dependsOn := map[string][]string{}
+	var hostnames []string
+	for _, c := range bp.Containers {
+		dependsOn[c.Hostname] = c.DependsOn
+		hostnames = append(hostnames, c.Hostname)
+	}
+
+	// A depth first search that finds a cycle if it reaches a container
+	// that's still on the stack.
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(hostname string) []string
+	visit = func(hostname string) []string {
+		switch state[hostname] {
+		case visited:
+			return nil
+		case visiting:
+			for i, h := range stack {
+				if h == hostname {
+					return append(stack[i:], hostname)
+				}
+			}
+		}
+
+		state[hostname] = visiting
+		stack = append(stack, hostname)
+		for _, dep := range dependsOn[hostname] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[hostname] = visited
+		return nil
+	}
+
+	for _, hostname := range hostnames {
+		if cycle := visit(hostname); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func validateConnection(path string, conn Connection,
+	hostnames map[string]bool) error {
+
+	for i, from := range conn.From {
+		if !hostnames[from] {
+			return fmt.Errorf("%s.from[%d]: undefined hostname %q",
+				path, i, from)
+		}
+	}
+
+	for i, to := range conn.To {
+		if !hostnames[to] {
+			return fmt.Errorf("%s.to[%d]: undefined hostname %q",
+				path, i, to)
+		}
+	}
+
+	switch conn.Protocol {
+	case "", TCPProtocol, UDPProtocol:
+	case ICMPProtocol:
+		if conn.MinPort != 0 || conn.MaxPort != 0 {
+			return fmt.Errorf("%s: ICMP connections do not have ports", path)
+		}
+	default:
+		return fmt.Errorf("%s.protocol: must be one of %s, %s, or %s, "+
+			"not %q", path, TCPProtocol, UDPProtocol, ICMPProtocol,
+			conn.Protocol)
+	}
+
+	if conn.MinPort < 0 || conn.MaxPort > 65535 || conn.MinPort > conn.MaxPort {
+		return fmt.Errorf("%s: invalid port range %d-%d",
+			path, conn.MinPort, conn.MaxPort)
+	}
+
+	if conn.ContainerPort != 0 {
+		if !str.SliceContains(conn.From, PublicInternetLabel) {
+			return fmt.Errorf("%s.containerPort: may only be set for "+
+				"connections from %s", path, PublicInternetLabel)
+		}
+
+		maxPort := conn.ContainerPort + conn.MaxPort - conn.MinPort
+		if conn.ContainerPort < 0 || maxPort > 65535 {
+			return fmt.Errorf("%s.containerPort: %d is not valid for "+
+				"port range %d-%d", path, conn.ContainerPort,
+				conn.MinPort, conn.MaxPort)
+		}
+
+		if conn.ContainerPort != conn.MinPort &&
+			conn.MaxPort-conn.MinPort >= maxRemappedPorts {
+			return fmt.Errorf("%s.containerPort: port range %d-%d is "+
+				"too large to remap. Remapped ranges may have at most "+
+				"%d ports", path, conn.MinPort, conn.MaxPort,
+				maxRemappedPorts)
+		}
+	}
+	return nil
+}
+
+func validateContainers(bp Blueprint) error {
+	volumes := map[string]bool{}
+	for _, v := range bp.Volumes {
+		volumes[v.Name] = true
+	}
+
+	// The minions match containers to their blueprint entries by ID, so
+	// replicas' IDs must be unique as well.
+	ids := map[string]bool{}
+	dockerfiles := map[string]string{}
+	rollingUpdates := map[string]RollingUpdate{}
+	for i, c := range bp.Containers {
+		path := fmt.Sprintf("containers[%d]", i)
+
+		if c.ID == "" {
+			return fmt.Errorf("%s.id: must not be empty", path)
+		}
+		replicaIDs := []string{c.ID}
+		for j := 2; j <= c.Replicas; j++ {
+			replicaIDs = append(replicaIDs, fmt.Sprintf("%s-%d", c.ID, j))
+		}
+		for _, id := range replicaIDs {
+			if ids[id] {
+				return fmt.Errorf("%s.id: %q used multiple times",
+					path, id)
+			}
+			ids[id] = true
+		}
+
+		dockerfile, ok := dockerfiles[c.Image.Name]
+		if ok && dockerfile != c.Image.Dockerfile {
+			return fmt.Errorf("%s.image: %s has differing Dockerfiles",
+				path, c.Image.Name)
+		}
+		dockerfiles[c.Image.Name] = c.Image.Dockerfile
+
+		if c.Replicas < 0 {
+			return fmt.Errorf("%s.replicas: must not be negative", path)
+		}
+
+		if c.CPULimit != 0 && c.CPULimit < c.CPURequest {
+			return fmt.Errorf("%s.cpuLimit: lower than the cpuRequest", path)
+		}
+		if c.RAMLimit != 0 && c.RAMLimit < c.RAMRequest {
+			return fmt.Errorf("%s.ramLimit: lower than the ramRequest", path)
+		}
+
+		for j, arg := range c.Command {
+			path := fmt.Sprintf("%s.command[%d]", path, j)
+			if err := validateRuntimeValue(path, arg); err != nil {
+				return err
+			}
+		}
+
+		if err := validateRuntimeValues(path+".env", c.Env); err != nil {
+			return err
+		}
+		err := validateRuntimeValues(path+".filepathToContent",
+			c.FilepathToContent)
+		if err != nil {
+			return err
+		}
+
+		for j, vm := range c.VolumeMounts {
+			if !volumes[vm.Volume] {
+				return fmt.Errorf("%s.volumeMounts[%d].volume: "+
+					"undefined volume %q", path, j, vm.Volume)
+			}
+		}
+
+		if c.Job != nil {
+			if err := validateJob(path+".job", *c.Job); err != nil {
+				return err
+			}
+			if c.RollingUpdate != nil {
+				return fmt.Errorf("%s.rollingUpdate: jobs can't have "+
+					"rolling updates", path)
+			}
+			if c.RestartPolicy != "" {
+				return fmt.Errorf("%s.restartPolicy: jobs can't have "+
+					"restart policies", path)
+			}
+			if c.Replicas > 1 {
+				return fmt.Errorf("%s.replicas: jobs can't have "+
+					"replicas", path)
+			}
+		}
+
+		if c.RollingUpdate == nil {
+			continue
+		}
+
+		group := c.RollingUpdate.Group
+		ru, ok := rollingUpdates[group]
+		if ok && !reflect.DeepEqual(ru, *c.RollingUpdate) {
+			return fmt.Errorf("%s.rollingUpdate: group %q has differing "+
+				"settings", path, group)
+		}
+		rollingUpdates[group] = *c.RollingUpdate
+	}
+	return nil
+}
+
+func validateJob(path string, job Job) error {
+	if job.Completions < 0 || job.Parallelism < 0 || job.RetryLimit < 0 {
+		return fmt.Errorf("%s: completions, parallelism, and retryLimit "+
+			"must not be negative", path)
+	}
+
+	if job.Schedule == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(job.Schedule); err != nil {
+		return fmt.Errorf("%s.schedule: %s", path, err)
+	}
+	return nil
+}
+
+func validateRuntimeValues(path string,
+	values map[string]ContainerValue) error {
+
+	for key, val := range values {
+		if err := validateRuntimeValue(path+"."+key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRuntimeValue(path string, val ContainerValue) error {
+	rv, ok := val.Value.(RuntimeValue)
+	if ok && !str.SliceContains(RuntimeValueKeys, rv.ResourceKey) {
+		return fmt.Errorf("%s: undefined resource key %q", path, rv.ResourceKey)
+	}
+	return nil
+}
+
+func validateMachines(bp Blueprint) error {
+	var masters, workers int
+	for i, m := range bp.Machines {
+		switch m.Role {
+		case masterRole:
+			masters++
+		case workerRole:
+			workers++
+		default:
+			return fmt.Errorf("machines[%d].role: must be %s or %s, not %q",
+				i, masterRole, workerRole, m.Role)
+		}
+
+		first := bp.Machines[0]
+		if m.Provider != first.Provider || m.Region != first.Region {
+			return fmt.Errorf("machines[%d]: all machines must have the "+
+				"same provider and region. Found providers '%s' in "+
+				"region '%s' and '%s' in region '%s'", i, first.Provider,
+				first.Region, m.Provider, m.Region)
+		}
+	}
+
+	groups := map[string]bool{}
+	for i, g := range bp.MachineGroups {
+		path := fmt.Sprintf("machineGroups[%d]", i)
+		switch {
+		case g.Name == "":
+			return fmt.Errorf("%s.name: must not be empty", path)
+		case groups[g.Name]:
+			return fmt.Errorf("%s.name: %q used multiple times", path, g.Name)
+		case g.Min < 0 || g.Max < 1 || g.Min > g.Max:
+			return fmt.Errorf("%s: must have 0 <= min <= max and max >= 1, "+
+				"not min %d and max %d", path, g.Min, g.Max)
+		case g.Cooldown < 0:
+			return fmt.Errorf("%s.cooldown: must not be negative", path)
+		case g.Machine.Role != "" && g.Machine.Role != workerRole:
+			return fmt.Errorf("%s.machine.role: must be %s, not %q", path,
+				workerRole, g.Machine.Role)
+		}
+		groups[g.Name] = true
+
+		if len(bp.Machines) == 0 {
+			continue
+		}
+		first := bp.Machines[0]
+		if g.Machine.Provider != first.Provider ||
+			g.Machine.Region != first.Region {
+			return fmt.Errorf("%s.machine: all machines must have the "+
+				"same provider and region. Found providers '%s' in "+
+				"region '%s' and '%s' in region '%s'", path,
+				first.Provider, first.Region, g.Machine.Provider,
+				g.Machine.Region)
+		}
+	}
+
+	// Machine groups may start empty, and grow once containers need them.
+	workers += len(bp.MachineGroups)
+	hasMachines := len(bp.Machines) > 0 || len(bp.MachineGroups) > 0
+	if hasMachines && (masters == 0 || workers == 0) {
+		return fmt.Errorf("machines: must include at least one %s and one "+
+			"%s", masterRole, workerRole)
+	}
+
+	for i, v := range bp.Volumes {
+		if v.Type != BlockVolume {
+			continue
+		}
+
+		var inCloud bool
+		for _, m := range bp.Machines {
+			inCloud = inCloud || (m.Role == workerRole &&
+				m.Provider == v.Provider && m.Region == v.Region)
+		}
+		for _, g := range bp.MachineGroups {
+			inCloud = inCloud || (g.Machine.Provider == v.Provider &&
+				g.Machine.Region == v.Region)
+		}
+		if !inCloud {
+			return fmt.Errorf("volumes[%d]: block volume %q is in %s "+
+				"region '%s', which has no worker machines", i, v.Name,
+				v.Provider, v.Region)
+		}
+	}
+	return nil
+}
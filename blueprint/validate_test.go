@@ -0,0 +1,354 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := func() Blueprint {
+		return Blueprint{
+			Containers: []Container{
+				{ID: "1", Hostname: "web", Image: Image{Name: "nginx"}},
+				{ID: "2", Hostname: "db", Image: Image{Name: "mysql"}},
+			},
+			LoadBalancers: []LoadBalancer{
+				{Name: "web-lb", Hostnames: []string{"web"}},
+			},
+			Connections: []Connection{
+				{From: []string{PublicInternetLabel},
+					To:      []string{"web-lb"},
+					MinPort: 80, MaxPort: 80, ContainerPort: 8080},
+				{From: []string{"web"}, To: []string{"db"},
+					MinPort: 3306, MaxPort: 3306},
+				{From: []string{PublicInternetLabel}, To: []string{"web"},
+					Protocol: ICMPProtocol},
+			},
+			Placements: []Placement{
+				{TargetContainer: "web", OtherContainer: "db"},
+			},
+			Machines: []Machine{
+				{Provider: "Amazon", Region: "us-west-1",
+					Role: masterRole},
+				{Provider: "Amazon", Region: "us-west-1",
+					Role: workerRole},
+			},
+			Volumes: []Volume{
+				{Name: "data", Type: BlockVolume, Provider: "Amazon",
+					Region: "us-west-1"},
+			},
+			Namespace: "test",
+		}
+	}
+	assert.NoError(t, valid().Validate())
+	assert.NoError(t, Blueprint{}.Validate())
+
+	tests := []struct {
+		mutate func(*Blueprint)
+		expErr string
+	}{
+		{
+			func(bp *Blueprint) { bp.Namespace = "Test" },
+			`namespace: "Test" contains uppercase letters. ` +
+				"Namespaces must be lowercase",
+		},
+		{
+			func(bp *Blueprint) { bp.Namespace = "$(reboot)" },
+			`namespace: "$(reboot)" is not a valid namespace. ` +
+				"Namespaces must only contain lowercase characters, " +
+				"numbers and hyphens",
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[1].Hostname = "web" },
+			`containers[1].hostname: hostname "web" used multiple times`,
+		},
+		{
+			func(bp *Blueprint) { bp.LoadBalancers[0].Name = "public" },
+			`loadBalancers[0].name: hostname "public" used multiple times`,
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[0].Hostname = "Web" },
+			`containers[0].hostname: "Web" is not a valid hostname. ` +
+				"Hostnames must only contain lowercase characters, " +
+				"numbers and hyphens, and cannot start or end with " +
+				"a hyphen",
+		},
+		{
+			func(bp *Blueprint) { bp.LoadBalancers[0].Hostnames[0] = "foo" },
+			`loadBalancers[0].hostnames[0]: undefined container "foo"`,
+		},
+		{
+			func(bp *Blueprint) { bp.Connections[1].To[0] = "foo" },
+			`connections[1].to[0]: undefined hostname "foo"`,
+		},
+		{
+			func(bp *Blueprint) { bp.Connections[1].MaxPort = 80 },
+			"connections[1]: invalid port range 3306-80",
+		},
+		{
+			func(bp *Blueprint) { bp.Connections[1].MaxPort = 65536 },
+			"connections[1]: invalid port range 3306-65536",
+		},
+		{
+			func(bp *Blueprint) { bp.Connections[1].Protocol = "sctp" },
+			`connections[1].protocol: must be one of tcp, udp, or icmp, ` +
+				`not "sctp"`,
+		},
+		{
+			func(bp *Blueprint) { bp.Connections[2].MaxPort = 1 },
+			"connections[2]: ICMP connections do not have ports",
+		},
+		{
+			func(bp *Blueprint) { bp.Connections[1].ContainerPort = 1 },
+			"connections[1].containerPort: may only be set for " +
+				"connections from public",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Connections[0].MaxPort = 90
+				bp.Connections[0].ContainerPort = 65530
+			},
+			"connections[0].containerPort: 65530 is not valid for " +
+				"port range 80-90",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Connections[0].MaxPort = 1000
+				bp.Connections[0].ContainerPort = 8080
+			},
+			"connections[0].containerPort: port range 80-1000 is too " +
+				"large to remap. Remapped ranges may have at most 100 " +
+				"ports",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Connections[0].MaxPort = 1000
+				bp.Connections[0].ContainerPort = 80
+			},
+			"",
+		},
+		{
+			func(bp *Blueprint) { bp.Placements[0].OtherContainer = "foo" },
+			`placements[0].otherContainer: undefined container "foo"`,
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[1].Image.Name = "nginx" },
+			"",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[1].Image = Image{
+					Name: "nginx", Dockerfile: "a"}
+			},
+			"containers[1].image: nginx has differing Dockerfiles",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].CPURequest = 2
+				bp.Containers[0].CPULimit = 1
+			},
+			"containers[0].cpuLimit: lower than the cpuRequest",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].VolumeMounts = []VolumeMount{
+					{Volume: "foo"}}
+			},
+			`containers[0].volumeMounts[0].volume: undefined volume "foo"`,
+		},
+		{
+			func(bp *Blueprint) {
+				ru := RollingUpdate{Group: "a"}
+				bp.Containers[0].RollingUpdate = &ru
+				bp.Containers[1].RollingUpdate = &RollingUpdate{
+					Group: "a", MaxSurge: 1}
+			},
+			`containers[1].rollingUpdate: group "a" has differing settings`,
+		},
+		{
+			func(bp *Blueprint) {
+				c := &bp.Containers[0]
+				c.Env = map[string]ContainerValue{
+					"ip": NewRuntimeValue(ContainerIPKey)}
+				c.FilepathToContent = map[string]ContainerValue{
+					"/region": NewRuntimeValue("region")}
+			},
+			`containers[0].filepathToContent./region: undefined resource ` +
+				`key "region"`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[1].Command = []ContainerValue{
+					NewString("--ip"), NewRuntimeValue("ip")}
+			},
+			`containers[1].command[1]: undefined resource key "ip"`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[1].Job = &Job{Completions: -1}
+			},
+			"containers[1].job: completions, parallelism, and retryLimit " +
+				"must not be negative",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[1].Job = &Job{Schedule: "0 3 * *"}
+			},
+			"containers[1].job.schedule: Expected exactly 5 fields, " +
+				"found 4: 0 3 * *",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[1].Job = &Job{Schedule: "0 3 * * *"}
+				bp.Containers[1].RestartPolicy = RestartNever
+			},
+			"containers[1].restartPolicy: jobs can't have restart policies",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[1].Job = &Job{}
+				bp.Containers[1].Replicas = 2
+			},
+			"containers[1].replicas: jobs can't have replicas",
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[0].Replicas = 3 },
+			"",
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[0].Replicas = -1 },
+			"containers[0].replicas: must not be negative",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].Replicas = 3
+				bp.Containers[1].Hostname = "web-3"
+			},
+			`containers[0].replicas: hostname "web-3" used multiple times`,
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[1].ID = "" },
+			"containers[1].id: must not be empty",
+		},
+		{
+			func(bp *Blueprint) { bp.Containers[1].ID = "1" },
+			`containers[1].id: "1" used multiple times`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].Replicas = 2
+				bp.Containers[1].ID = "1-2"
+			},
+			`containers[1].id: "1-2" used multiple times`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].DependsOn = []string{"db", "cache"}
+			},
+			`containers[0].dependsOn[1]: undefined container "cache"`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].DependsOn = []string{"db"}
+				bp.Containers[1].DependsOn = []string{"web"}
+			},
+			"containers: dependency cycle web -> db -> web",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Containers[0].DependsOn = []string{"web"}
+			},
+			"containers: dependency cycle web -> web",
+		},
+		{
+			func(bp *Blueprint) { bp.Machines[1].Role = "" },
+			`machines[1].role: must be Master or Worker, not ""`,
+		},
+		{
+			func(bp *Blueprint) { bp.Machines[1].Role = masterRole },
+			"machines: must include at least one Master and one Worker",
+		},
+		{
+			func(bp *Blueprint) { bp.Machines[1].Region = "us-east-1" },
+			"machines[1]: all machines must have the same provider and " +
+				"region. Found providers 'Amazon' in region " +
+				"'us-west-1' and 'Amazon' in region 'us-east-1'",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Machines[1].Role = masterRole
+				bp.MachineGroups = []MachineGroup{{Name: "workers",
+					Machine: Machine{Provider: "Amazon",
+						Region: "us-west-1"},
+					Max: 3}}
+			},
+			"",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.MachineGroups = []MachineGroup{{Max: 1}}
+			},
+			"machineGroups[0].name: must not be empty",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Machines = nil
+				bp.MachineGroups = []MachineGroup{
+					{Name: "a", Max: 1}, {Name: "a", Max: 1}}
+			},
+			`machineGroups[1].name: "a" used multiple times`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.MachineGroups = []MachineGroup{
+					{Name: "a", Min: 3, Max: 2}}
+			},
+			"machineGroups[0]: must have 0 <= min <= max and max >= 1, " +
+				"not min 3 and max 2",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.MachineGroups = []MachineGroup{{Name: "a", Max: 1,
+					Machine: Machine{Role: masterRole}}}
+			},
+			`machineGroups[0].machine.role: must be Worker, not "Master"`,
+		},
+		{
+			func(bp *Blueprint) {
+				bp.Machines = nil
+				bp.MachineGroups = []MachineGroup{{Name: "a", Max: 1}}
+			},
+			"machines: must include at least one Master and one Worker",
+		},
+		{
+			func(bp *Blueprint) {
+				bp.MachineGroups = []MachineGroup{{Name: "a", Max: 1,
+					Machine: Machine{Provider: "Google",
+						Region: "us-west-1"}}}
+			},
+			"machineGroups[0].machine: all machines must have the " +
+				"same provider and region. Found providers 'Amazon' " +
+				"in region 'us-west-1' and 'Google' in region " +
+				"'us-west-1'",
+		},
+		{
+			func(bp *Blueprint) { bp.Volumes[0].Provider = "Google" },
+			`volumes[0]: block volume "data" is in Google region ` +
+				"'us-west-1', which has no worker machines",
+		},
+	}
+	for _, test := range tests {
+		bp := valid()
+		test.mutate(&bp)
+
+		err := bp.Validate()
+		if test.expErr == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.expErr)
+		}
+	}
+}
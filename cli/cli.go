@@ -20,8 +20,12 @@ var commands = map[string]command.SubCommand{
 	"ps":   command.NewShowCommand(),
 	"show": command.NewShowCommand(),
 
+	"plan":       command.NewPlanCommand(),
+	"rollout":    &command.Rollout{},
 	"secret":     &command.Secret{},
 	"run":        command.NewRunCommand(),
+	"scale":      command.NewScaleCommand(),
+	"events":     command.NewEventsCommand(),
 	"init":       &command.Init{},
 	"ssh":        command.NewSSHCommand(),
 	"stop":       command.NewStopCommand(),
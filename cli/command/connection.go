@@ -30,9 +30,18 @@ type connectionHelper struct {
 	creds  connection.Credentials
 	client client.Client
 
+	// The namespace that the client's requests apply to. If empty, the
+	// daemon picks the namespace.
+	namespace string
+
 	connectionFlags
 }
 
+func (ch *connectionHelper) installNamespaceFlag(flags *flag.FlagSet,
+	usage string) {
+	flags.StringVar(&ch.namespace, "namespace", "", usage)
+}
+
 func (ch *connectionHelper) BeforeRun() (err error) {
 	// Load the credentials that will be used by Kelda clients and servers.
 	ch.creds, err = tlsIO.ReadCredentials(cliPath.DefaultTLSDir)
@@ -48,5 +57,8 @@ func (ch *connectionHelper) AfterRun() error {
 
 func (ch *connectionHelper) setupClient(getter client.Getter) (err error) {
 	ch.client, err = getter(ch.host, ch.creds)
+	if err == nil && ch.namespace != "" {
+		ch.client.SetNamespace(ch.namespace)
+	}
 	return err
 }
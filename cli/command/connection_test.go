@@ -43,6 +43,17 @@ func TestSetupClient(t *testing.T) {
 	}
 	err = cmd.setupClient(newClient)
 	assert.NotNil(t, err)
+
+	// Test that the client is scoped to the requested namespace.
+	expClient = &mocks.Client{}
+	expClient.On("SetNamespace", "ns").Return().Once()
+	newClient = func(host string, _ connection.Credentials) (client.Client, error) {
+		return expClient, nil
+	}
+	cmd = connectionHelper{namespace: "ns"}
+	err = cmd.setupClient(newClient)
+	assert.NoError(t, err)
+	expClient.AssertExpectations(t)
 }
 
 func TestConnectionFlagsHostEnv(t *testing.T) {
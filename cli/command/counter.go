@@ -39,6 +39,8 @@ type Counters struct {
 // InstallFlags sets up parsing for command line flags.
 func (cmd *Counters) InstallFlags(flags *flag.FlagSet) {
 	cmd.connectionHelper.InstallFlags(flags)
+	cmd.installNamespaceFlag(flags, "the namespace whose machines -all "+
+		"fetches counters from")
 	flags.BoolVar(&cmd.all, "all", false,
 		"whether to fetch counters from all sources")
 	flags.Usage = func() {
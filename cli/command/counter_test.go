@@ -35,6 +35,11 @@ func TestParse(t *testing.T) {
 
 	assert.NoError(t, counters.Parse([]string{"host1", "host2"}))
 	assert.Equal(t, []string{"host1", "host2"}, counters.targets)
+
+	counters = &Counters{}
+	assert.NoError(t, parseHelper(counters, []string{"-namespace", "ns", "-all"}))
+	assert.True(t, counters.all)
+	assert.Equal(t, "ns", counters.namespace)
 }
 
 func TestRunQueryDaemon(t *testing.T) {
@@ -16,6 +16,7 @@ import (
 	"github.com/kelda/kelda/api/server"
 	cliPath "github.com/kelda/kelda/cli/path"
 	"github.com/kelda/kelda/cloud"
+	"github.com/kelda/kelda/cloud/autoscaler"
 	"github.com/kelda/kelda/cloud/foreman"
 	tlsIO "github.com/kelda/kelda/connection/tls/io"
 	"github.com/kelda/kelda/connection/tls/rsa"
@@ -28,6 +29,10 @@ import (
 
 // Daemon contains the options for running the Kelda daemon.
 type Daemon struct {
+	// The file in which the daemon's state is persisted. If empty, the
+	// state is only kept in memory.
+	statePath string
+
 	*connectionFlags
 }
 
@@ -44,6 +49,11 @@ var daemonExplanation = "Start the kelda daemon, which listens for kelda API req
 // InstallFlags sets up parsing for command line flags
 func (dCmd *Daemon) InstallFlags(flags *flag.FlagSet) {
 	dCmd.connectionFlags.InstallFlags(flags)
+	flags.StringVar(&dCmd.statePath, "state", cliPath.DefaultStatePath,
+		"the file in which to persist the deployed blueprints and "+
+			"machines across daemon restarts. If empty, they are only "+
+			"kept in memory, and blueprints must be run again after a "+
+			"restart.")
 	flags.Usage = func() {
 		util.PrintUsageString(daemonCommands, daemonExplanation, flags)
 	}
@@ -103,7 +113,17 @@ func (dCmd *Daemon) Run() int {
 	}
 
 	conn := db.New()
+	if dCmd.statePath != "" {
+		if err := conn.Restore(dCmd.statePath); err != nil {
+			log.WithError(err).WithField("path", dCmd.statePath).Error(
+				"Failed to restore daemon state. Remove the file to " +
+					"start with an empty state.")
+			return 1
+		}
+		go conn.Persist(dCmd.statePath)
+	}
 	go server.Run(conn, dCmd.host, true, creds)
+	go server.ReplicateEvents(conn, creds)
 
 	ca, err := tlsIO.ReadCA(cliPath.DefaultTLSDir)
 	if err != nil {
@@ -112,8 +132,9 @@ func (dCmd *Daemon) Run() int {
 		return 1
 	}
 
-	go foreman.Run(conn, creds)
+	foreman.Init(creds)
 	go cloud.SyncCredentials(conn, sshKey, ca)
+	go autoscaler.Run(conn, creds)
 	cloud.Run(conn, getPublicKey(sshKey))
 	return 0
 }
@@ -123,6 +123,7 @@ kelda debug-logs -i ~/.ssh/kelda 09ed35808a0b`
 // InstallFlags sets up parsing for command line flags.
 func (dCmd *Debug) InstallFlags(flags *flag.FlagSet) {
 	dCmd.connectionHelper.InstallFlags(flags)
+	dCmd.installNamespaceFlag(flags, "the namespace to fetch debug logs from")
 	flags.StringVar(&dCmd.privateKey, "i", "",
 		"path to the private key to use when connecting to the host")
 	flags.StringVar(&dCmd.outPath, "o", "",
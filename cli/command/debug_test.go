@@ -101,6 +101,13 @@ func TestDebugFlags(t *testing.T) {
 			machines: true,
 			ids:      []string{},
 		}, "")
+	checkDebugParsing(t, []string{"-namespace", "ns", "-machines"},
+		Debug{
+			connectionHelper: connectionHelper{namespace: "ns"},
+			tar:              true,
+			machines:         true,
+			ids:              []string{},
+		}, "")
 	checkDebugParsing(t, []string{}, Debug{},
 		"must supply at least one ID or set option")
 	checkDebugParsing(t, []string{"-i", "key"}, Debug{},
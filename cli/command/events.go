@@ -0,0 +1,109 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kelda/kelda/api"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util"
+)
+
+// Events contains the options for printing the events of a deployment.
+type Events struct {
+	follow bool
+
+	connectionHelper
+}
+
+// NewEventsCommand creates a new Events command instance.
+func NewEventsCommand() *Events {
+	return &Events{}
+}
+
+var eventsCommands = `kelda events [OPTIONS]`
+var eventsExplanation = `Print the events of a deployment, oldest first.
+
+Events record the machines changing status, the cloud provider failing to boot
+or stop machines, the containers being placed on and moved between machines,
+and the images being built.  Only the most recent events are kept.
+
+To print new events as they happen:
+kelda events -f`
+
+// InstallFlags sets up parsing for command line flags.
+func (eCmd *Events) InstallFlags(flags *flag.FlagSet) {
+	eCmd.connectionHelper.InstallFlags(flags)
+	eCmd.installNamespaceFlag(flags, "the namespace to print the events of")
+
+	flags.BoolVar(&eCmd.follow, "f", false, "follow new events")
+
+	flags.Usage = func() {
+		util.PrintUsageString(eventsCommands, eventsExplanation, flags)
+	}
+}
+
+// Parse parses the command line arguments for the events command.
+func (eCmd *Events) Parse(args []string) error {
+	return nil
+}
+
+// Run prints the events.
+func (eCmd *Events) Run() int {
+	if err := eCmd.run(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (eCmd *Events) run(out io.Writer) error {
+	if !eCmd.follow {
+		events, err := eCmd.client.QueryEvents()
+		if err != nil {
+			return fmt.Errorf("unable to query events: %s", err)
+		}
+		writeEvents(out, events)
+		return nil
+	}
+
+	w, err := eCmd.client.WatchEvents()
+	if err != nil {
+		return fmt.Errorf("unable to watch events: %s", err)
+	}
+	defer w.Stop()
+
+	for {
+		event, err := w.Next()
+		if err != nil {
+			return fmt.Errorf("unable to watch events: %s", err)
+		}
+
+		// Events are only modified or deleted when they're pruned, so
+		// there's nothing new to print.
+		if event.Type == api.Snapshot || event.Type == api.Added {
+			writeEvents(out, event.Events)
+		}
+	}
+}
+
+// writeEvents prints `events`, oldest first, one per line.  The columns have
+// fixed widths so that followed events line up with those already printed.
+func writeEvents(out io.Writer, events []db.Event) {
+	for _, event := range db.SortEvents(events) {
+		object := event.ObjectType
+		if event.ObjectID != "" {
+			id := event.ObjectID
+			if event.ObjectType != "Image" {
+				id = util.ShortUUID(id)
+			}
+			object += " " + id
+		}
+
+		fmt.Fprintf(out, "%s  %-13s  %-22s  %s\n",
+			event.Time.Local().Format("2006-01-02 15:04:05"), event.Type,
+			object, event.Message)
+	}
+}
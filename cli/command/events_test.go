@@ -0,0 +1,102 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/api"
+	clientMock "github.com/kelda/kelda/api/client/mocks"
+	"github.com/kelda/kelda/db"
+)
+
+type mockEventLogWatcher struct {
+	events []api.EventLogEvent
+}
+
+func (w *mockEventLogWatcher) Next() (api.EventLogEvent, error) {
+	if len(w.events) == 0 {
+		return api.EventLogEvent{}, io.EOF
+	}
+
+	event := w.events[0]
+	w.events = w.events[1:]
+	return event, nil
+}
+
+func (w *mockEventLogWatcher) Stop() {}
+
+var testEventTime = time.Date(2017, 7, 14, 2, 40, 0, 0, time.Local)
+
+func TestEventsParse(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewEventsCommand()
+	assert.NoError(t, parseHelper(cmd, []string{"-f", "-namespace", "ns"}))
+	assert.True(t, cmd.follow)
+	assert.Equal(t, "ns", cmd.namespace)
+}
+
+func TestEventsRun(t *testing.T) {
+	t.Parallel()
+
+	c := new(clientMock.Client)
+	c.On("QueryEvents").Return([]db.Event{{
+		Time:       testEventTime.Add(time.Second),
+		Type:       db.BuiltEvent,
+		ObjectType: "Image",
+		ObjectID:   "my-long-image-name",
+		Message:    "built 1234",
+	}, {
+		Time:       testEventTime,
+		Type:       db.MachineStatusEvent,
+		ObjectType: "Machine",
+		ObjectID:   "i-0123456789abcdef",
+		Message:    "connecting -> connected",
+	}}, nil).Once()
+
+	var out bytes.Buffer
+	cmd := &Events{connectionHelper: connectionHelper{client: c}}
+	assert.NoError(t, cmd.run(&out))
+	assert.Equal(t, "2017-07-14 02:40:00  StatusChanged  Machine i-0123456789"+
+		"    connecting -> connected\n"+
+		"2017-07-14 02:40:01  Built          Image my-long-image-name"+
+		"  built 1234\n", out.String())
+
+	c.On("QueryEvents").Return(nil, assert.AnError).Once()
+	assert.EqualError(t, cmd.run(&out),
+		"unable to query events: "+assert.AnError.Error())
+}
+
+func TestEventsFollow(t *testing.T) {
+	t.Parallel()
+
+	event := func(seconds time.Duration, message string) db.Event {
+		return db.Event{
+			Time:       testEventTime.Add(seconds * time.Second),
+			Type:       db.MachineStatusEvent,
+			ObjectType: "Machine",
+			Message:    message,
+		}
+	}
+
+	c := new(clientMock.Client)
+	c.On("WatchEvents").Return(&mockEventLogWatcher{[]api.EventLogEvent{
+		{Type: api.Snapshot, Events: []db.Event{event(0, "booting")}},
+		{Type: api.Added, Events: []db.Event{event(1, "connecting")}},
+
+		// Pruned events aren't printed.
+		{Type: api.Deleted, Events: []db.Event{event(0, "booting")}},
+	}}, nil)
+
+	var out bytes.Buffer
+	cmd := &Events{follow: true, connectionHelper: connectionHelper{client: c}}
+	assert.EqualError(t, cmd.run(&out), "unable to watch events: EOF")
+	assert.Equal(t,
+		"2017-07-14 02:40:00  StatusChanged  Machine                 booting\n"+
+			"2017-07-14 02:40:01  StatusChanged  Machine"+
+			"                 connecting\n", out.String())
+}
@@ -14,6 +14,8 @@ var inspCommands = "kelda inspect BLUEPRINT OUTPUT_FORMAT [BLUEPRINT_ARGS...]"
 var inspExplanation = `Visualize a blueprint.
 
 OUTPUT_FORMAT can be pdf, ascii, or graphviz.
+BLUEPRINT may be a JavaScript blueprint, or an already compiled blueprint in a
+.json, .yaml, or .yml file.
 BLUEPRINT_ARGS are the command line arguments that should be passed to the blueprint,
 similar to when the blueprint is run with 'kelda run'.
 
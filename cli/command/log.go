@@ -43,6 +43,7 @@ kelda logs -f 09ed35808a0b`
 // InstallFlags sets up parsing for command line flags.
 func (lCmd *Log) InstallFlags(flags *flag.FlagSet) {
 	lCmd.connectionHelper.InstallFlags(flags)
+	lCmd.installNamespaceFlag(flags, "the namespace containing the target")
 
 	flags.StringVar(&lCmd.privateKey, "i", "",
 		"path to the private key to use when connecting to the host")
@@ -0,0 +1,181 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kelda/kelda/api"
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util"
+)
+
+// Plan contains the options for previewing the deployment of a blueprint.
+type Plan struct {
+	blueprint     string
+	blueprintArgs []string
+
+	connectionHelper
+}
+
+// NewPlanCommand creates a new Plan command instance.
+func NewPlanCommand() *Plan {
+	return &Plan{}
+}
+
+var planCommands = `kelda plan [OPTIONS] BLUEPRINT [BLUEPRINT_ARGS...]`
+var planExplanation = `Show what running a blueprint would change, without deploying it.
+
+BLUEPRINT and BLUEPRINT_ARGS are the same as for kelda run.  The daemon uses its
+current view of the cloud to compute the machines that would be booted,
+terminated, or assigned a new floating IP, the changes to the ACLs, and the
+containers that would be added, removed, or rescheduled.`
+
+// InstallFlags sets up parsing for command line flags.
+func (pCmd *Plan) InstallFlags(flags *flag.FlagSet) {
+	pCmd.connectionHelper.InstallFlags(flags)
+	pCmd.installNamespaceFlag(flags, "the namespace to plan the deployment "+
+		"for, instead of the blueprint's namespace")
+
+	flags.Usage = func() {
+		util.PrintUsageString(planCommands, planExplanation, flags)
+	}
+}
+
+// Parse parses the command line arguments for the plan command.
+func (pCmd *Plan) Parse(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no blueprint specified")
+	}
+
+	pCmd.blueprint = args[0]
+	pCmd.blueprintArgs = args[1:]
+	return nil
+}
+
+// Run prints the changes that deploying the blueprint would cause.
+func (pCmd *Plan) Run() int {
+	compiled, err := compile(pCmd.blueprint, pCmd.blueprintArgs)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	if pCmd.namespace != "" {
+		compiled.Namespace = pCmd.namespace
+	}
+
+	plan, err := pCmd.client.Plan(compiled.String())
+	if err != nil {
+		log.WithError(err).Error("Unable to plan deployment.")
+		return 1
+	}
+
+	writePlan(os.Stdout, plan)
+	return 0
+}
+
+func writePlan(fd io.Writer, plan api.Plan) {
+	hasMachines := len(plan.Boot)+len(plan.Terminate)+len(plan.UpdateIPs) > 0
+	hasACLs := len(plan.AddACLs)+len(plan.RemoveACLs) > 0
+	hasContainers := len(plan.AddContainers)+len(plan.RemoveContainers)+
+		len(plan.RescheduleContainers) > 0
+
+	if !hasMachines && !hasACLs && !hasContainers {
+		fmt.Fprintln(fd, "No changes.")
+		return
+	}
+
+	var sections []func()
+	if hasMachines {
+		sections = append(sections, func() { writeMachinePlan(fd, plan) })
+	}
+	if hasACLs {
+		sections = append(sections, func() { writeACLPlan(fd, plan) })
+	}
+	if hasContainers {
+		sections = append(sections, func() { writeContainerPlan(fd, plan) })
+	}
+
+	for i, writeSection := range sections {
+		if i > 0 {
+			fmt.Fprintln(fd)
+		}
+		writeSection()
+	}
+}
+
+func writeMachinePlan(fd io.Writer, plan api.Plan) {
+	w := tabwriter.NewWriter(fd, 0, 0, 4, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "CHANGE\tMACHINE\tROLE\tPROVIDER\tREGION\tSIZE\tPUBLIC IP")
+
+	write := func(change string, machines []db.Machine, ip func(db.Machine) string) {
+		for _, m := range db.SortMachines(machines) {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", change,
+				util.ShortUUID(m.CloudID), m.Role, m.Provider, m.Region,
+				m.Size, ip(m))
+		}
+	}
+
+	publicIP := func(m db.Machine) string { return m.PublicIP }
+	write("boot", plan.Boot, func(m db.Machine) string { return m.FloatingIP })
+	write("terminate", plan.Terminate, publicIP)
+	write("update IP", plan.UpdateIPs, func(m db.Machine) string {
+		if m.FloatingIP == "" {
+			return fmt.Sprintf("%s (no floating IP)", m.PublicIP)
+		}
+		return fmt.Sprintf("%s -> %s", m.PublicIP, m.FloatingIP)
+	})
+}
+
+func writeACLPlan(fd io.Writer, plan api.Plan) {
+	w := tabwriter.NewWriter(fd, 0, 0, 4, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "CHANGE\tACL\tPORTS\tPROTOCOLS")
+
+	write := func(change string, acls []acl.ACL) {
+		for _, a := range acls {
+			ports := fmt.Sprintf("%d-%d", a.MinPort, a.MaxPort)
+			switch {
+			case a.MinPort == 0 && a.MaxPort == 0:
+				ports = ""
+			case a.MinPort == a.MaxPort:
+				ports = fmt.Sprintf("%d", a.MinPort)
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", change, a.CidrIP, ports,
+				strings.Join(a.Protocols(), ","))
+		}
+	}
+
+	write("add", plan.AddACLs)
+	write("remove", plan.RemoveACLs)
+}
+
+func writeContainerPlan(fd io.Writer, plan api.Plan) {
+	w := tabwriter.NewWriter(fd, 0, 0, 4, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "CHANGE\tCONTAINER\tHOSTNAME\tIMAGE\tMINION")
+
+	write := func(change string, containers []db.Container) {
+		sort.Slice(containers, func(i, j int) bool {
+			return containers[i].BlueprintID < containers[j].BlueprintID
+		})
+		for _, c := range containers {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", change,
+				util.ShortUUID(c.BlueprintID), c.Hostname, c.Image,
+				c.Minion)
+		}
+	}
+
+	write("add", plan.AddContainers)
+	write("remove", plan.RemoveContainers)
+	write("reschedule", plan.RescheduleContainers)
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/api"
+	clientMock "github.com/kelda/kelda/api/client/mocks"
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/db"
+)
+
+func TestPlanParse(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewPlanCommand()
+	assert.NoError(t, parseHelper(cmd, []string{"-namespace", "ns",
+		"bp.js", "arg"}))
+	assert.Equal(t, "bp.js", cmd.blueprint)
+	assert.Equal(t, []string{"arg"}, cmd.blueprintArgs)
+	assert.Equal(t, "ns", cmd.namespace)
+
+	assert.EqualError(t, parseHelper(NewPlanCommand(), nil),
+		"no blueprint specified")
+}
+
+func TestPlanRun(t *testing.T) {
+	compile = func(path string, args []string) (blueprint.Blueprint, error) {
+		return blueprint.Blueprint{Namespace: "blueprint"}, nil
+	}
+
+	c := new(clientMock.Client)
+	c.On("Plan", `{"Namespace":"staging"}`).Return(api.Plan{}, nil).Once()
+
+	cmd := &Plan{
+		blueprint:        "bp.js",
+		connectionHelper: connectionHelper{client: c, namespace: "staging"},
+	}
+	assert.Equal(t, 0, cmd.Run())
+	c.AssertExpectations(t)
+
+	c = new(clientMock.Client)
+	c.On("Plan", `{"Namespace":"blueprint"}`).Return(api.Plan{},
+		assert.AnError)
+	cmd = &Plan{blueprint: "bp.js", connectionHelper: connectionHelper{client: c}}
+	assert.Equal(t, 1, cmd.Run())
+}
+
+func TestWritePlan(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	writePlan(&b, api.Plan{})
+	assert.Equal(t, "No changes.\n", b.String())
+
+	var plan api.Plan
+	plan.Boot = []db.Machine{{Role: db.Worker, Provider: db.Amazon,
+		Region: "us-west-1", Size: "m4.large"}}
+	plan.Terminate = []db.Machine{{CloudID: "i-1", Role: db.Worker,
+		Provider: db.Amazon, Region: "us-west-1", Size: "m3.medium",
+		PublicIP: "8.8.8.8"}}
+	plan.UpdateIPs = []db.Machine{{CloudID: "i-2", Role: db.Master,
+		Provider: db.Amazon, Region: "us-west-1", Size: "m4.large",
+		PublicIP: "1.1.1.1", FloatingIP: "2.2.2.2"}}
+	plan.AddACLs = []acl.ACL{{CidrIP: "0.0.0.0/0", MinPort: 80, MaxPort: 80,
+		Protocol: "tcp"}}
+	plan.RemoveACLs = []acl.ACL{{CidrIP: "1.2.3.4/32", MinPort: 1,
+		MaxPort: 65535}}
+	plan.AddContainers = []db.Container{{BlueprintID: "1", Hostname: "web",
+		Image: "nginx"}}
+	plan.RescheduleContainers = []db.Container{{BlueprintID: "2",
+		Hostname: "db", Image: "postgres", Minion: "10.0.0.1"}}
+
+	b.Reset()
+	writePlan(&b, plan)
+
+	exp := `CHANGE_______MACHINE____ROLE______PROVIDER____REGION_______` +
+		`SIZE_________PUBLIC_IP
+boot____________________Worker____Amazon______us-west-1____m4.large_____
+terminate____i-1________Worker____Amazon______us-west-1____m3.medium____8.8.8.8
+update_IP____i-2________Master____Amazon______us-west-1____m4.large_____` +
+		`1.1.1.1_->_2.2.2.2
+
+CHANGE____ACL___________PORTS______PROTOCOLS
+add_______0.0.0.0/0_____80_________tcp
+remove____1.2.3.4/32____1-65535____tcp,udp,icmp
+
+CHANGE________CONTAINER____HOSTNAME____IMAGE_______MINION
+add___________1____________web_________nginx_______
+reschedule____2____________db__________postgres____10.0.0.1
+`
+	assert.Equal(t, exp, strings.Replace(b.String(), " ", "_", -1))
+}
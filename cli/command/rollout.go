@@ -0,0 +1,59 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kelda/kelda/util"
+)
+
+// Rollout contains the options for controlling rolling updates.
+type Rollout struct {
+	action, group string
+
+	connectionHelper
+}
+
+var rolloutCommands = "kelda rollout pause|resume|abort GROUP"
+var rolloutExplanation = `Control the rolling update of a group of containers.
+
+pause stops the update from replacing any more containers, and resume continues
+it.  abort rolls the containers in the group back to their spec from the
+previous deployment, one batch at a time.`
+
+// InstallFlags sets up parsing for command line flags.
+func (rCmd *Rollout) InstallFlags(flags *flag.FlagSet) {
+	rCmd.connectionHelper.InstallFlags(flags)
+	rCmd.installNamespaceFlag(flags, "the namespace of the group to update")
+	flags.Usage = func() {
+		util.PrintUsageString(rolloutCommands, rolloutExplanation, flags)
+	}
+}
+
+// Parse parses the command line arguments for the rollout command.
+func (rCmd *Rollout) Parse(args []string) error {
+	if len(args) != 2 {
+		return errors.New("an action and group must be supplied")
+	}
+
+	rCmd.action = args[0]
+	rCmd.group = args[1]
+	switch rCmd.action {
+	case "pause", "resume", "abort":
+		return nil
+	default:
+		return fmt.Errorf("unknown action: %s", rCmd.action)
+	}
+}
+
+// Run modifies the rolling update of the given group in the deployed blueprint.
+func (rCmd *Rollout) Run() int {
+	if err := rCmd.client.Rollout(rCmd.action, rCmd.group); err != nil {
+		log.WithError(err).Errorf("Failed to %s rollout", rCmd.action)
+		return 1
+	}
+	return 0
+}
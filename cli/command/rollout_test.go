@@ -0,0 +1,42 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	clientMock "github.com/kelda/kelda/api/client/mocks"
+)
+
+func TestRolloutParse(t *testing.T) {
+	t.Parallel()
+
+	cmd := &Rollout{}
+	assert.NoError(t, parseHelper(cmd,
+		[]string{"-namespace", "ns", "pause", "web"}))
+	assert.Equal(t, "pause", cmd.action)
+	assert.Equal(t, "web", cmd.group)
+	assert.Equal(t, "ns", cmd.namespace)
+
+	assert.EqualError(t, cmd.Parse([]string{"pause"}),
+		"an action and group must be supplied")
+	assert.EqualError(t, cmd.Parse([]string{"restart", "web"}),
+		"unknown action: restart")
+}
+
+func TestRollout(t *testing.T) {
+	t.Parallel()
+
+	c := &clientMock.Client{}
+	c.On("Rollout", "pause", "web").Return(nil)
+	c.On("Rollout", "abort", "db").Return(assert.AnError)
+
+	cmd := &Rollout{action: "pause", group: "web"}
+	cmd.client = c
+	assert.Zero(t, cmd.Run())
+	c.AssertCalled(t, "Rollout", "pause", "web")
+
+	cmd = &Rollout{action: "abort", group: "db"}
+	cmd.client = c
+	assert.Equal(t, 1, cmd.Run())
+}
@@ -37,7 +37,13 @@ func NewRunCommand() *Run {
 var runCommands = `kelda run [OPTIONS] BLUEPRINT [BLUEPRINT_ARGS...]`
 var runExplanation = `Compile a blueprint, and deploy the system it describes.
 
-BLUEPRINT_ARGS are the command line arguments that should be passed to the blueprint.
+BLUEPRINT is either a JavaScript blueprint, or an already compiled blueprint in
+a .json, .yaml, or .yml file.  BLUEPRINT_ARGS are the command line arguments
+that should be passed to a JavaScript blueprint.
+
+Each namespace is deployed independently, so running a blueprint only changes
+the deployment in the blueprint's namespace. The namespace can be overridden
+with the -namespace flag.
 
 Confirmation is required if deploying the blueprint would change an existing
 deployment. Confirmation can be skipped with the -f flag.`
@@ -45,6 +51,8 @@ deployment. Confirmation can be skipped with the -f flag.`
 // InstallFlags sets up parsing for command line flags.
 func (rCmd *Run) InstallFlags(flags *flag.FlagSet) {
 	rCmd.connectionHelper.InstallFlags(flags)
+	rCmd.installNamespaceFlag(flags, "the namespace to deploy to, instead "+
+		"of the blueprint's namespace")
 
 	flags.StringVar(&rCmd.blueprint, "blueprint", "", "the blueprint to run")
 	flags.BoolVar(&rCmd.force, "f", false, "deploy without confirming changes")
@@ -81,8 +89,14 @@ func (rCmd *Run) Run() int {
 		log.Error(err)
 		return 1
 	}
+	if rCmd.namespace != "" {
+		compiled.Namespace = rCmd.namespace
+	}
 	deployment := compiled.String()
 
+	// Compare against the deployment in the blueprint's namespace, rather
+	// than whichever namespace the daemon would default to.
+	rCmd.client.SetNamespace(compiled.Namespace)
 	curr, err := getCurrentDeployment(rCmd.client)
 	if err != nil && err != errNoBlueprint {
 		log.WithError(err).Error("Unable to get current deployment.")
@@ -119,8 +133,8 @@ func (rCmd *Run) Run() int {
 		return 1
 	}
 
-	fmt.Println("Your blueprint is being deployed. " +
-		"Check its status with `kelda show`.")
+	fmt.Printf("Your blueprint is being deployed. Check its status with "+
+		"`kelda show -namespace %s`.\n", compiled.Namespace)
 	log.Debug("Successfully started run")
 	return 0
 }
@@ -201,6 +201,7 @@ func TestPromptsUser(t *testing.T) {
 		c.On("QueryBlueprints").Return([]db.Blueprint{{
 			Blueprint: blueprint.Blueprint{Namespace: "old"},
 		}}, nil)
+		c.On("SetNamespace", "").Return()
 		c.On("Deploy", "{}").Return(nil)
 
 		util.WriteFile("test.js", []byte(""), 0644)
@@ -218,6 +219,26 @@ func TestPromptsUser(t *testing.T) {
 	}
 }
 
+func TestRunNamespace(t *testing.T) {
+	compile = func(path string, args []string) (blueprint.Blueprint, error) {
+		return blueprint.Blueprint{Namespace: "blueprint"}, nil
+	}
+
+	// The current deployment should be queried from, and the new deployment
+	// sent to, the namespace given by the flag rather than the blueprint.
+	c := new(clientMock.Client)
+	c.On("SetNamespace", "staging").Return().Once()
+	c.On("QueryBlueprints").Return(nil, nil)
+	c.On("Deploy", `{"Namespace":"staging"}`).Return(nil).Once()
+
+	runCmd := &Run{
+		connectionHelper: connectionHelper{client: c, namespace: "staging"},
+		blueprint:        "test.js",
+	}
+	assert.Equal(t, 0, runCmd.Run())
+	c.AssertExpectations(t)
+}
+
 func TestRunFlags(t *testing.T) {
 	t.Parallel()
 
@@ -229,6 +250,9 @@ func TestRunFlags(t *testing.T) {
 	checkRunParsing(t, []string{"-f", expBlueprint},
 		Run{force: true, blueprint: expBlueprint,
 			blueprintArgs: []string{}}, nil)
+	checkRunParsing(t, []string{"-namespace", "ns", expBlueprint},
+		Run{blueprint: expBlueprint, blueprintArgs: []string{},
+			connectionHelper: connectionHelper{namespace: "ns"}}, nil)
 	checkRunParsing(t, []string{}, Run{}, errors.New("no blueprint specified"))
 }
 
@@ -263,4 +287,5 @@ func checkRunParsing(t *testing.T, args []string, expFlags Run, expErr error) {
 	assert.Equal(t, expFlags.blueprint, runCmd.blueprint)
 	assert.Equal(t, expFlags.blueprintArgs, runCmd.blueprintArgs)
 	assert.Equal(t, expFlags.force, runCmd.force)
+	assert.Equal(t, expFlags.namespace, runCmd.namespace)
 }
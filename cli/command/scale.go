@@ -0,0 +1,74 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kelda/kelda/util"
+)
+
+// Scale contains the options for changing the number of replicas of a
+// container.
+type Scale struct {
+	hostname string
+	replicas int
+
+	connectionHelper
+}
+
+// NewScaleCommand creates a new Scale command instance.
+func NewScaleCommand() *Scale {
+	return &Scale{}
+}
+
+var scaleCommands = `kelda scale [OPTIONS] HOSTNAME REPLICAS`
+var scaleExplanation = `Change the number of replicas of a deployed container.
+
+The container with the given HOSTNAME in the deployed blueprint is scaled to
+REPLICAS copies, without redeploying the blueprint.  Replicas after the first
+are given the hostnames HOSTNAME-2, HOSTNAME-3, and so on.  Running the
+blueprint again resets the replicas to the number that it specifies.`
+
+// InstallFlags sets up parsing for command line flags.
+func (sCmd *Scale) InstallFlags(flags *flag.FlagSet) {
+	sCmd.connectionHelper.InstallFlags(flags)
+	sCmd.installNamespaceFlag(flags, "the namespace of the container to scale")
+
+	flags.Usage = func() {
+		util.PrintUsageString(scaleCommands, scaleExplanation, flags)
+	}
+}
+
+// Parse parses the command line arguments for the scale command.
+func (sCmd *Scale) Parse(args []string) error {
+	if len(args) != 2 {
+		return errors.New("a hostname and number of replicas must be supplied")
+	}
+
+	replicas, err := strconv.Atoi(args[1])
+	if err != nil || replicas < 1 {
+		return fmt.Errorf("replicas must be a positive integer: %s", args[1])
+	}
+
+	sCmd.hostname = args[0]
+	sCmd.replicas = replicas
+	return nil
+}
+
+// Run scales the container.
+func (sCmd *Scale) Run() int {
+	if err := sCmd.client.Scale(sCmd.hostname, sCmd.replicas); err != nil {
+		log.WithError(err).Error("Unable to scale container.")
+		return 1
+	}
+
+	log.WithFields(log.Fields{
+		"hostname": sCmd.hostname,
+		"replicas": sCmd.replicas,
+	}).Debug("Scaled container")
+	return 0
+}
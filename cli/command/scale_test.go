@@ -0,0 +1,43 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	clientMock "github.com/kelda/kelda/api/client/mocks"
+)
+
+func TestScaleParse(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewScaleCommand()
+	assert.NoError(t, parseHelper(cmd, []string{"-namespace", "ns", "web", "3"}))
+	assert.Equal(t, "web", cmd.hostname)
+	assert.Equal(t, 3, cmd.replicas)
+	assert.Equal(t, "ns", cmd.namespace)
+
+	assert.EqualError(t, parseHelper(NewScaleCommand(), []string{"web"}),
+		"a hostname and number of replicas must be supplied")
+	assert.EqualError(t, parseHelper(NewScaleCommand(), []string{"web", "0"}),
+		"replicas must be a positive integer: 0")
+	assert.EqualError(t, parseHelper(NewScaleCommand(), []string{"web", "a"}),
+		"replicas must be a positive integer: a")
+}
+
+func TestScaleRun(t *testing.T) {
+	t.Parallel()
+
+	c := new(clientMock.Client)
+	c.On("Scale", "web", 3).Return(nil).Once()
+	cmd := &Scale{hostname: "web", replicas: 3,
+		connectionHelper: connectionHelper{client: c}}
+	assert.Equal(t, 0, cmd.Run())
+	c.AssertExpectations(t)
+
+	c = new(clientMock.Client)
+	c.On("Scale", "web", 3).Return(assert.AnError)
+	cmd = &Scale{hostname: "web", replicas: 3,
+		connectionHelper: connectionHelper{client: c}}
+	assert.Equal(t, 1, cmd.Run())
+}
@@ -22,6 +22,7 @@ be run before any containers referencing the associated secret can be started.`
 // InstallFlags sets up parsing for command line flags.
 func (secretCmd *Secret) InstallFlags(flags *flag.FlagSet) {
 	secretCmd.connectionHelper.InstallFlags(flags)
+	secretCmd.installNamespaceFlag(flags, "the namespace to set the secret in")
 	flags.Usage = func() {
 		util.PrintUsageString(secretCommands, secretExplanation, flags)
 	}
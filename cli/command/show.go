@@ -20,7 +20,7 @@ import (
 // An arbitrary length to truncate container commands to.
 const truncLength = 30
 
-// Show contains the options for querying machines and containers.
+// Show contains the options for querying machines, containers, and jobs.
 type Show struct {
 	noTruncate bool
 
@@ -33,11 +33,13 @@ func NewShowCommand() *Show {
 }
 
 var showCommands = "kelda show [OPTIONS]"
-var showExplanation = "Display the status of kelda-managed machines and containers."
+var showExplanation = "Display the status of kelda-managed machines, containers, " +
+	"and jobs."
 
 // InstallFlags sets up parsing for command line flags
 func (pCmd *Show) InstallFlags(flags *flag.FlagSet) {
 	pCmd.connectionHelper.InstallFlags(flags)
+	pCmd.installNamespaceFlag(flags, "the namespace to show")
 	flags.BoolVar(&pCmd.noTruncate, "no-trunc", false, "do not truncate container"+
 		" command output")
 	flags.Usage = func() {
@@ -50,7 +52,7 @@ func (pCmd *Show) Parse(args []string) error {
 	return nil
 }
 
-// Run retrieves and prints all machines and containers.
+// Run retrieves and prints all machines, containers, and jobs.
 func (pCmd *Show) Run() int {
 	if err := pCmd.run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
@@ -86,9 +88,11 @@ func (pCmd *Show) run() (err error) {
 	var connections []db.Connection
 	var containers []db.Container
 	var images []db.Image
+	var jobs []db.Job
 	connectionErr := make(chan error)
 	containerErr := make(chan error)
 	imagesErr := make(chan error)
+	jobsErr := make(chan error)
 
 	go func() {
 		connections, err = pCmd.client.QueryConnections()
@@ -105,6 +109,11 @@ func (pCmd *Show) run() (err error) {
 		imagesErr <- err
 	}()
 
+	go func() {
+		jobs, err = pCmd.client.QueryJobs()
+		jobsErr <- err
+	}()
+
 	if err := <-connectionErr; err != nil {
 		return fmt.Errorf("unable to query connections: %s", err)
 	}
@@ -114,9 +123,17 @@ func (pCmd *Show) run() (err error) {
 	if err := <-imagesErr; err != nil {
 		return fmt.Errorf("unable to query images: %s", err)
 	}
+	if err := <-jobsErr; err != nil {
+		return fmt.Errorf("unable to query jobs: %s", err)
+	}
 
 	writeContainers(os.Stdout, containers, machines, connections, images,
-		!pCmd.noTruncate)
+		jobs, !pCmd.noTruncate)
+
+	if len(jobs) > 0 {
+		fmt.Println()
+		writeJobs(os.Stdout, jobs)
+	}
 
 	return nil
 }
@@ -140,7 +157,8 @@ func writeMachines(fd io.Writer, machines []db.Machine) {
 }
 
 func writeContainers(fd io.Writer, containers []db.Container, machines []db.Machine,
-	connections []db.Connection, images []db.Image, truncate bool) {
+	connections []db.Connection, images []db.Image, jobs []db.Job,
+	truncate bool) {
 	w := tabwriter.NewWriter(fd, 0, 0, 4, ' ', 0)
 	defer w.Flush()
 	fmt.Fprintln(w, "CONTAINER\tMACHINE\tCOMMAND\tHOSTNAME"+
@@ -167,6 +185,8 @@ func writeContainers(fd io.Writer, containers []db.Container, machines []db.Mach
 	}
 	sort.Strings(machineIDs)
 
+	ready := db.ReadyHostnames(containers, jobs)
+
 	imageStatusMap := map[string]string{}
 	for _, img := range images {
 		imageStatusMap[img.Name] = img.Status
@@ -186,11 +206,17 @@ func writeContainers(fd io.Writer, containers []db.Container, machines []db.Mach
 			container := containerStr(dbc.Image, dbc.Command, truncate)
 
 			var status string
+			waiting := dbc.WaitingFor(ready)
 			switch {
 			case dbc.Status != "":
-				status = dbc.Status
+				status = containerStatus(dbc)
 			case dbc.Minion != "":
 				status = "scheduled"
+			case len(waiting) != 0:
+				status = "waiting for dependencies: " +
+					strings.Join(waiting, ", ")
+			case dbc.Unschedulable != "":
+				status = "unschedulable"
 			default:
 				if imgStatus, ok := imageStatusMap[dbc.Image]; ok {
 					status = imgStatus
@@ -215,6 +241,81 @@ func writeContainers(fd io.Writer, containers []db.Container, machines []db.Mach
 	}
 }
 
+// writeJobs lists the runs of each job, most recent first.
+func writeJobs(fd io.Writer, jobs []db.Job) {
+	w := tabwriter.NewWriter(fd, 0, 0, 4, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "JOB\tSCHEDULE\tRUN\tSTATUS\tCOMPLETIONS\tSTARTED\tDURATION")
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Hostname < jobs[j].Hostname
+	})
+
+	for _, job := range jobs {
+		if len(job.Runs) == 0 {
+			fmt.Fprintf(w, "%v\t%v\t\tpending\t\t\t\n", job.Hostname,
+				job.Schedule)
+			continue
+		}
+
+		completions := job.Completions
+		if completions == 0 {
+			completions = 1
+		}
+
+		for i := len(job.Runs) - 1; i >= 0; i-- {
+			run := job.Runs[i]
+
+			status := run.Status
+			if run.Failed == 1 {
+				status += " (1 failure)"
+			} else if run.Failed > 1 {
+				status += fmt.Sprintf(" (%d failures)", run.Failed)
+			}
+
+			started := fmt.Sprintf("%s ago",
+				units.HumanDuration(time.Since(run.Started)))
+
+			duration := ""
+			if !run.Finished.IsZero() {
+				duration = units.HumanDuration(
+					run.Finished.Sub(run.Started))
+			}
+
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+				job.Hostname, job.Schedule, run.Number, status,
+				fmt.Sprintf("%d/%d", run.Succeeded, completions),
+				started, duration)
+		}
+	}
+}
+
+// containerStatus describes the status reported by the worker running `dbc`,
+// along with its health and how it last exited, if known.
+func containerStatus(dbc db.Container) string {
+	var details []string
+	if dbc.Health != "" {
+		details = append(details, dbc.Health)
+	}
+	if dbc.ExitCode != 0 {
+		details = append(details, fmt.Sprintf("exit code %d", dbc.ExitCode))
+	}
+	if dbc.OOMKilled {
+		details = append(details, "OOM killed")
+	}
+	if dbc.RestartCount == 1 {
+		details = append(details, "1 restart")
+	} else if dbc.RestartCount > 1 {
+		details = append(details,
+			fmt.Sprintf("%d restarts", dbc.RestartCount))
+	}
+
+	if len(details) == 0 {
+		return dbc.Status
+	}
+	return fmt.Sprintf("%s (%s)", dbc.Status, strings.Join(details, ", "))
+}
+
 func connToPorts(connections []db.Connection) map[string][]string {
 	hostnamePublicPorts := map[string][]string{}
 	for _, c := range connections {
@@ -227,6 +328,17 @@ func connToPorts(connections []db.Connection) map[string][]string {
 			if c.MinPort != c.MaxPort {
 				portStr += fmt.Sprintf("-%d", c.MaxPort)
 			}
+			if c.ContainerPort != 0 && c.ContainerPort != c.MinPort {
+				portStr += fmt.Sprintf("->%d", c.ContainerPort)
+			}
+
+			switch c.Protocol {
+			case "":
+			case blueprint.ICMPProtocol:
+				portStr = c.Protocol
+			default:
+				portStr += "/" + c.Protocol
+			}
 			hostnamePublicPorts[to] = append(hostnamePublicPorts[to],
 				portStr)
 		}
@@ -234,12 +346,18 @@ func connToPorts(connections []db.Connection) map[string][]string {
 	return hostnamePublicPorts
 }
 
-func containerStr(image string, args []string, truncate bool) string {
+func containerStr(image string, args []blueprint.ContainerValue,
+	truncate bool) string {
+
 	if image == "" {
 		return ""
 	}
 
-	container := fmt.Sprintf("%s %s", image, strings.Join(args, " "))
+	var argStrs []string
+	for _, arg := range args {
+		argStrs = append(argStrs, arg.String())
+	}
+	container := fmt.Sprintf("%s %s", image, strings.Join(argStrs, " "))
 	if truncate && len(container) > truncLength {
 		return container[:truncLength] + "..."
 	}
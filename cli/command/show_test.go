@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/kelda/kelda/api/client/mocks"
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/db"
 )
 
@@ -44,6 +45,7 @@ func TestShowErrors(t *testing.T) {
 	mockClient.On("QueryMachines").Return([]db.Machine{{Status: db.Connected}}, nil)
 	mockClient.On("QueryContainers").Return(nil, mockErr)
 	mockClient.On("QueryImages").Return(nil, nil)
+	mockClient.On("QueryJobs").Return(nil, nil)
 	cmd := &Show{false, connectionHelper{client: mockClient}}
 	assert.EqualError(t, cmd.run(), "unable to query containers: error")
 
@@ -53,6 +55,7 @@ func TestShowErrors(t *testing.T) {
 	mockClient.On("QueryMachines").Return([]db.Machine{{Status: db.Connected}}, nil)
 	mockClient.On("QueryConnections").Return(nil, mockErr)
 	mockClient.On("QueryImages").Return(nil, nil)
+	mockClient.On("QueryJobs").Return(nil, nil)
 	cmd = &Show{false, connectionHelper{client: mockClient}}
 	assert.EqualError(t, cmd.run(), "unable to query connections: error")
 }
@@ -89,10 +92,43 @@ func TestShowSuccess(t *testing.T) {
 	mockClient.On("QueryMachines").Return(nil, nil)
 	mockClient.On("QueryConnections").Return(nil, nil)
 	mockClient.On("QueryImages").Return(nil, nil)
+	mockClient.On("QueryJobs").Return(nil, nil)
 	cmd := &Show{false, connectionHelper{client: mockClient}}
 	assert.Equal(t, 0, cmd.Run())
 }
 
+func TestJobOutput(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now().Add(-2 * time.Hour)
+	jobs := []db.Job{
+		{Hostname: "report", Schedule: "0 3 * * *"},
+		{Hostname: "migrate", Completions: 2, Runs: []db.JobRun{
+			{Number: 1, Status: db.JobFailed, Started: started,
+				Finished: started.Add(time.Minute), Booted: 2,
+				Succeeded: 1, Failed: 1},
+			{Number: 2, Status: db.JobRunning, Started: started,
+				Booted: 2, Succeeded: 1},
+		}},
+	}
+
+	var b bytes.Buffer
+	writeJobs(&b, jobs)
+	result := strings.Replace(b.String(), " ", "_", -1)
+
+	ago := strings.Replace(fmt.Sprintf("%s ago",
+		units.HumanDuration(time.Since(started))), " ", "_", -1)
+	exp := "JOB________SCHEDULE_____RUN____STATUS________________" +
+		"COMPLETIONS____STARTED________DURATION\n" +
+		"migrate_________________2______running_______________1/2____________" +
+		ago + "____\n" +
+		"migrate_________________1______failed_(1_failure)____1/2____________" +
+		ago + "____About_a_minute\n" +
+		"report_____0_3_*_*_*___________pending__________________________" +
+		"___________________\n"
+	assert.Equal(t, exp, result)
+}
+
 func TestMachineOutput(t *testing.T) {
 	t.Parallel()
 
@@ -139,7 +175,8 @@ func checkContainerOutput(t *testing.T, containers []db.Container,
 	truncate bool, exp string) {
 
 	var b bytes.Buffer
-	writeContainers(&b, containers, machines, connections, images, truncate)
+	writeContainers(&b, containers, machines, connections, images, nil,
+		truncate)
 
 	/* By replacing space with underscore, we make the spaces explicit and whitespace
 	* errors easier to debug. */
@@ -152,16 +189,16 @@ func TestContainerOutput(t *testing.T) {
 
 	containers := []db.Container{
 		{ID: 1, BlueprintID: "3", Minion: "3.3.3.3", IP: "1.2.3.4",
-			Image: "image1", Command: []string{"cmd", "1"},
+			Image: "image1", Command: blueprint.NewStrings("cmd", "1"),
 			Hostname: "notpublic", Status: "running"},
 		{ID: 2, BlueprintID: "1", Minion: "1.1.1.1", Image: "image2",
 			Status: "scheduled", Hostname: "frompublic1"},
 		{ID: 3, BlueprintID: "4", Minion: "1.1.1.1", Image: "image3",
-			Command:  []string{"cmd"},
+			Command:  blueprint.NewStrings("cmd"),
 			Hostname: "frompublic2",
 			Status:   "scheduled"},
 		{ID: 4, BlueprintID: "7", Minion: "2.2.2.2", Image: "image1",
-			Command:  []string{"cmd", "3", "4"},
+			Command:  blueprint.NewStrings("cmd", "3", "4"),
 			Hostname: "frompublic3"},
 		{ID: 5, BlueprintID: "8", Image: "image1"},
 	}
@@ -196,6 +233,31 @@ ________________________________________________________________________________
 `
 	checkContainerOutput(t, containers, machines, connections, nil, true, expected)
 
+	// The health of containers with health checks is shown with their status.
+	containers = []db.Container{
+		{ID: 1, BlueprintID: "3", Minion: "3.3.3.3", Image: "image1",
+			Status: "running", Health: db.ContainerUnhealthy},
+	}
+	expected = `CONTAINER____MACHINE____COMMAND____HOSTNAME____` +
+		`STATUS_________________CREATED____PUBLIC_IP
+3_______________________image1_________________running_(unhealthy)_______________
+`
+	checkContainerOutput(t, containers, nil, nil, nil, true, expected)
+
+	// Testing writeContainers with a container that crashed.
+	containers = []db.Container{
+		{ID: 1, BlueprintID: "3", Minion: "3.3.3.3", Image: "image1",
+			Status: "backing off", ExitCode: 137, OOMKilled: true,
+			RestartCount: 2},
+	}
+	expected = `CONTAINER____MACHINE____COMMAND____HOSTNAME____` +
+		`STATUS_________________________________________________` +
+		`CREATED____PUBLIC_IP
+3_______________________image1_________________` +
+		`backing_off_(exit_code_137,_OOM_killed,_2_restarts)_______________
+`
+	checkContainerOutput(t, containers, nil, nil, nil, true, expected)
+
 	// Testing writeContainers with created time values.
 	mockTime := time.Now()
 	humanDuration := units.HumanDuration(time.Since(mockTime))
@@ -204,7 +266,7 @@ ________________________________________________________________________________
 
 	containers = []db.Container{
 		{ID: 1, BlueprintID: "3", Minion: "3.3.3.3", IP: "1.2.3.4",
-			Image: "image1", Command: []string{"cmd", "1"},
+			Image: "image1", Command: blueprint.NewStrings("cmd", "1"),
 			Status: "running", Created: mockTime.UTC()},
 	}
 	machines = []db.Machine{}
@@ -226,7 +288,7 @@ ________________________________________________________________________________
 
 	containers = []db.Container{
 		{ID: 1, BlueprintID: "3", Minion: "3.3.3.3", IP: "1.2.3.4",
-			Image: "image1", Command: []string{"cmd", "1"},
+			Image: "image1", Command: blueprint.NewStrings("cmd", "1"),
 			Status: "running", Created: mockTime.UTC()},
 	}
 	machines = []db.Machine{}
@@ -242,8 +304,9 @@ ________________________________________________________________________________
 	// Test that long outputs are truncated when `truncate` is true
 	containers = []db.Container{
 		{ID: 1, BlueprintID: "3", Minion: "3.3.3.3", IP: "1.2.3.4",
-			Image: "image1", Command: []string{"cmd", "1", "&&", "cmd",
-				"91283403472903847293014320984723908473248-23843984"},
+			Image: "image1",
+			Command: blueprint.NewStrings("cmd", "1", "&&", "cmd",
+				"91283403472903847293014320984723908473248-23843984"),
 			Status: "running", Created: mockTime.UTC()},
 	}
 	machines = []db.Machine{}
@@ -342,12 +405,61 @@ func TestContainerOutputCustomImage(t *testing.T) {
 	checkContainerOutput(t, containers, nil, nil, images, true, exp)
 }
 
+func TestContainerOutputUnschedulable(t *testing.T) {
+	t.Parallel()
+
+	containers := []db.Container{{BlueprintID: "1", Image: "web",
+		Hostname: "web", Unschedulable: "no room"}}
+
+	var b bytes.Buffer
+	writeContainers(&b, containers, nil, nil, nil, nil, true)
+	exp := `CONTAINER____MACHINE____COMMAND____HOSTNAME____STATUS` +
+		`___________CREATED____PUBLIC_IP
+1_______________________web________web_________unschedulable_______________
+`
+	assert.Equal(t, exp, strings.Replace(b.String(), " ", "_", -1))
+}
+
+func TestContainerOutputDependencies(t *testing.T) {
+	t.Parallel()
+
+	containers := []db.Container{
+		{BlueprintID: "1", Image: "web", Hostname: "web",
+			DependsOn: []string{"db", "migrate"}},
+		{BlueprintID: "2", Image: "db", Hostname: "db", Minion: "foo",
+			Status: db.ContainerRunning},
+	}
+	jobs := []db.Job{{Hostname: "migrate"}}
+
+	var b bytes.Buffer
+	writeContainers(&b, containers, nil, nil, nil, jobs, true)
+	exp := `CONTAINER____MACHINE____COMMAND____HOSTNAME____STATUS` +
+		`_______________________________CREATED____PUBLIC_IP
+1_______________________web________web_________waiting_for_dependencies:_migrate` +
+		`_______________
+2_______________________db_________db__________running___________________________` +
+		`______________
+`
+	assert.Equal(t, exp, strings.Replace(b.String(), " ", "_", -1))
+
+	// Containers may boot once their dependencies are ready.
+	jobs[0].Runs = []db.JobRun{{Status: db.JobSucceeded}}
+	b.Reset()
+	writeContainers(&b, containers, nil, nil, nil, jobs, true)
+	exp = `CONTAINER____MACHINE____COMMAND____HOSTNAME____STATUS` +
+		`_____CREATED____PUBLIC_IP
+1_______________________web________web_______________________________
+2_______________________db_________db__________running_______________
+`
+	assert.Equal(t, exp, strings.Replace(b.String(), " ", "_", -1))
+}
+
 func TestContainerStr(t *testing.T) {
 	t.Parallel()
 	assert.Equal(t, "", containerStr("", nil, false))
-	assert.Equal(t, "", containerStr("", []string{"arg0"}, false))
+	assert.Equal(t, "", containerStr("", blueprint.NewStrings("arg0"), false))
 	assert.Equal(t, "container arg0 arg1",
-		containerStr("container", []string{"arg0", "arg1"}, false))
+		containerStr("container", blueprint.NewStrings("arg0", "arg1"), false))
 }
 
 func TestPublicIPStr(t *testing.T) {
@@ -363,3 +475,24 @@ func TestPublicIPStr(t *testing.T) {
 		publicIPStr(db.Machine{PublicIP: "1.2.3.4", FloatingIP: "8.8.8.8"},
 			[]string{"70", "80-88"}))
 }
+
+func TestConnToPorts(t *testing.T) {
+	t.Parallel()
+
+	public := []string{blueprint.PublicInternetLabel}
+	a := []string{"a"}
+	ports := connToPorts([]db.Connection{
+		{From: public, To: a, MinPort: 80, MaxPort: 80},
+		{From: public, To: a, MinPort: 53, MaxPort: 53,
+			Protocol: blueprint.UDPProtocol},
+		{From: public, To: a, MinPort: 8000, MaxPort: 8080,
+			Protocol: blueprint.TCPProtocol},
+		{From: public, To: a, Protocol: blueprint.ICMPProtocol},
+		{From: public, To: a, MinPort: 443, MaxPort: 443,
+			ContainerPort: 8443},
+		{From: []string{"b"}, To: a, MinPort: 22, MaxPort: 22},
+	})
+	assert.Equal(t, map[string][]string{
+		"a": {"80", "53/udp", "8000-8080/tcp", "icmp", "443->8443"},
+	}, ports)
+}
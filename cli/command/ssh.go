@@ -46,6 +46,7 @@ kelda ssh 8879fd2dbcee echo foo`
 // InstallFlags sets up parsing for command line flags.
 func (sCmd *SSH) InstallFlags(flags *flag.FlagSet) {
 	sCmd.connectionHelper.InstallFlags(flags)
+	sCmd.installNamespaceFlag(flags, "the namespace containing the target")
 	flags.StringVar(&sCmd.privateKey, "i", "",
 		"path to the private key to use when connecting to the host")
 	flags.BoolVar(&sCmd.allocatePTY, "t", false,
@@ -13,7 +13,6 @@ import (
 
 // Stop contains the options for stopping namespaces.
 type Stop struct {
-	namespace      string
 	onlyContainers bool
 	force          bool
 
@@ -31,8 +30,8 @@ var stopExplanation = `Stop a deployment.
 
 This will free all resources (e.g. VMs) associated with the deployment.
 
-If no namespace is specified, stop the deployment running in the namespace that is
-currently tracked by the daemon.
+The namespace can be given either as an argument or with the -namespace flag.
+If no namespace is specified, stop the only deployment managed by the daemon.
 
 Confirmation is required, but can be skipped with the -f flag.`
 
@@ -40,7 +39,7 @@ Confirmation is required, but can be skipped with the -f flag.`
 func (sCmd *Stop) InstallFlags(flags *flag.FlagSet) {
 	sCmd.connectionHelper.InstallFlags(flags)
 
-	flags.StringVar(&sCmd.namespace, "namespace", "", "the namespace to stop")
+	sCmd.installNamespaceFlag(flags, "the namespace to stop")
 	flags.BoolVar(&sCmd.onlyContainers, "containers", false,
 		"only destroy containers")
 	flags.BoolVar(&sCmd.force, "f", false, "stop without confirming")
@@ -84,11 +84,13 @@ func TestStopFlags(t *testing.T) {
 
 	expNamespace := "namespace"
 	checkStopParsing(t, []string{"-namespace", expNamespace},
-		Stop{namespace: expNamespace}, nil)
+		Stop{connectionHelper: connectionHelper{namespace: expNamespace}}, nil)
 	checkStopParsing(t, []string{"-f"}, Stop{force: true}, nil)
 	checkStopParsing(t, []string{"-f", expNamespace},
-		Stop{force: true, namespace: expNamespace}, nil)
-	checkStopParsing(t, []string{expNamespace}, Stop{namespace: expNamespace}, nil)
+		Stop{force: true,
+			connectionHelper: connectionHelper{namespace: expNamespace}}, nil)
+	checkStopParsing(t, []string{expNamespace},
+		Stop{connectionHelper: connectionHelper{namespace: expNamespace}}, nil)
 	checkStopParsing(t, []string{}, Stop{}, nil)
 }
 
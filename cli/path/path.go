@@ -18,6 +18,14 @@ var (
 	// DefaultSSHKeyPath is the default filepath where the private SSH key used
 	// to access Kelda will be stored.
 	DefaultSSHKeyPath = filepath.Join(keldaHome, "ssh_key")
+
+	// DefaultStatePath is the default filepath where the daemon persists its
+	// deployed blueprints and machines across restarts.
+	DefaultStatePath = filepath.Join(keldaHome, "daemon.db")
+
+	// DefaultInventoryPath is the default filepath of the inventory of hosts
+	// that the static provider boots machines on.
+	DefaultInventoryPath = filepath.Join(keldaHome, "inventory.json")
 )
 
 var (
@@ -1,10 +1,24 @@
 package acl
 
+import "github.com/kelda/kelda/blueprint"
+
 // ACL represents allowed traffic to a machine.
 type ACL struct {
 	CidrIP  string
 	MinPort int
 	MaxPort int
+
+	// The IP protocol allowed by the ACL.  Empty allows TCP, UDP, and ICMP.
+	Protocol string
+}
+
+// Protocols returns the IP protocols that the ACL allows.
+func (acl ACL) Protocols() []string {
+	if acl.Protocol == "" {
+		return []string{blueprint.TCPProtocol, blueprint.UDPProtocol,
+			blueprint.ICMPProtocol}
+	}
+	return []string{acl.Protocol}
 }
 
 // Slice is an alias for []ACL to allow for joins
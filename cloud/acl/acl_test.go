@@ -7,9 +7,49 @@ import (
 )
 
 func TestSlice(t *testing.T) {
-	acl := ACL{"1.2.3.4", 1, 2}
+	acl := ACL{"1.2.3.4", 1, 2, ""}
 	slice := Slice([]ACL{acl})
 
 	assert.Equal(t, slice.Len(), 1)
 	assert.Equal(t, slice.Get(0), acl)
 }
+
+func TestProtocols(t *testing.T) {
+	assert.Equal(t, []string{"tcp", "udp", "icmp"}, ACL{}.Protocols())
+	assert.Equal(t, []string{"udp"}, ACL{Protocol: "udp"}.Protocols())
+}
+
+func TestIPTablesScript(t *testing.T) {
+	exp := `set -e
+iptables -N KELDA-ACL 2>/dev/null || iptables -F KELDA-ACL
+iptables -A KELDA-ACL -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
+iptables -A KELDA-ACL -s 10.0.0.0/24 -j ACCEPT
+iptables -A KELDA-ACL -s 1.2.3.4/32 -p tcp -m conntrack --ctorigdstport 80:80 -j ACCEPT
+iptables -A KELDA-ACL -s 5.6.7.8/32 -p tcp -m conntrack --ctorigdstport 1:65535 -j ACCEPT
+iptables -A KELDA-ACL -s 5.6.7.8/32 -p udp -m conntrack --ctorigdstport 1:65535 -j ACCEPT
+iptables -A KELDA-ACL -s 5.6.7.8/32 -p icmp -j ACCEPT
+iptables -A KELDA-ACL -j DROP
+iptables -C INPUT -i eth0 -j KELDA-ACL 2>/dev/null || ` +
+		`iptables -I INPUT -i eth0 -j KELDA-ACL
+iptables -C FORWARD -i eth0 -j KELDA-ACL 2>/dev/null || ` +
+		`iptables -I FORWARD -i eth0 -j KELDA-ACL`
+
+	assert.Equal(t, exp, IPTablesScript("eth0", []string{"10.0.0.0/24"}, []ACL{
+		{CidrIP: "1.2.3.4/32", MinPort: 80, MaxPort: 80, Protocol: "tcp"},
+		{CidrIP: "5.6.7.8/32", MinPort: 1, MaxPort: 65535},
+	}))
+}
+
+func TestIPTablesScriptRemapped(t *testing.T) {
+	// A public connection on port 80 that's remapped to port 8080 in the
+	// container only yields an ACL for the public port.  By the time FORWARD
+	// sees the packet, DNAT has rewritten its destination to 8080, so the rule
+	// must match the connection's original port instead.
+	script := IPTablesScript("eth0", nil, []ACL{
+		{CidrIP: "0.0.0.0/0", MinPort: 80, MaxPort: 80, Protocol: "tcp"},
+	})
+	assert.Contains(t, script, "iptables -A KELDA-ACL -s 0.0.0.0/0 -p tcp "+
+		"-m conntrack --ctorigdstport 80:80 -j ACCEPT")
+	assert.NotContains(t, script, "--dport")
+	assert.Contains(t, script, "iptables -I FORWARD -i eth0 -j KELDA-ACL")
+}
@@ -0,0 +1,54 @@
+package acl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kelda/kelda/blueprint"
+)
+
+// The iptables chain that holds the ACL rules of a machine.
+const iptablesChain = "KELDA-ACL"
+
+// IPTablesScript generates a shell script that replaces the rules of the ACL
+// chain with those allowed by `acls`, and drops all other traffic that enters
+// through `iface`.  Traffic from the `trusted` CIDRs is always allowed so that
+// the machines can reach each other.  The script is idempotent.
+//
+// The chain filters both INPUT and FORWARD.  FORWARD sees public traffic after
+// the minion's PREROUTING DNAT has rewritten it to the container's port, so
+// ports are matched against the connection's original destination port rather
+// than the packet's, which keeps remapped public ports reachable.
+func IPTablesScript(iface string, trusted []string, acls []ACL) string {
+	lines := []string{
+		"set -e",
+		fmt.Sprintf("iptables -N %s 2>/dev/null || iptables -F %s",
+			iptablesChain, iptablesChain),
+		fmt.Sprintf("iptables -A %s -m conntrack "+
+			"--ctstate ESTABLISHED,RELATED -j ACCEPT", iptablesChain),
+	}
+	for _, cidr := range trusted {
+		lines = append(lines, fmt.Sprintf("iptables -A %s -s %s -j ACCEPT",
+			iptablesChain, cidr))
+	}
+
+	for _, acl := range acls {
+		for _, proto := range acl.Protocols() {
+			rule := fmt.Sprintf("iptables -A %s -s %s -p %s",
+				iptablesChain, acl.CidrIP, proto)
+			if proto != blueprint.ICMPProtocol {
+				rule += fmt.Sprintf(" -m conntrack "+
+					"--ctorigdstport %d:%d", acl.MinPort, acl.MaxPort)
+			}
+			lines = append(lines, rule+" -j ACCEPT")
+		}
+	}
+	lines = append(lines, fmt.Sprintf("iptables -A %s -j DROP", iptablesChain))
+
+	for _, chain := range []string{"INPUT", "FORWARD"} {
+		rule := fmt.Sprintf("%s -i %s -j %s", chain, iface, iptablesChain)
+		lines = append(lines, fmt.Sprintf("iptables -C %s 2>/dev/null || "+
+			"iptables -I %s", rule, rule))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/cloud/acl"
 	"github.com/kelda/kelda/cloud/amazon/client"
 	"github.com/kelda/kelda/cloud/cfg"
@@ -548,34 +549,24 @@ func syncACLs(desiredACLs []acl.ACL, desiredGroupID string,
 
 	var desiredRangeRules []*ec2.IpPermission
 	for _, acl := range desiredACLs {
-		desiredRangeRules = append(desiredRangeRules, &ec2.IpPermission{
-			FromPort: aws.Int64(int64(acl.MinPort)),
-			ToPort:   aws.Int64(int64(acl.MaxPort)),
-			IpRanges: []*ec2.IpRange{
-				{
-					CidrIp: aws.String(acl.CidrIP),
-				},
-			},
-			IpProtocol: aws.String("tcp"),
-		}, &ec2.IpPermission{
-			FromPort: aws.Int64(int64(acl.MinPort)),
-			ToPort:   aws.Int64(int64(acl.MaxPort)),
-			IpRanges: []*ec2.IpRange{
-				{
-					CidrIp: aws.String(acl.CidrIP),
-				},
-			},
-			IpProtocol: aws.String("udp"),
-		}, &ec2.IpPermission{
-			FromPort: aws.Int64(-1),
-			ToPort:   aws.Int64(-1),
-			IpRanges: []*ec2.IpRange{
-				{
-					CidrIp: aws.String(acl.CidrIP),
+		for _, protocol := range acl.Protocols() {
+			// Amazon represents "all ICMP types" with a port range of -1.
+			minPort, maxPort := int64(acl.MinPort), int64(acl.MaxPort)
+			if protocol == blueprint.ICMPProtocol {
+				minPort, maxPort = -1, -1
+			}
+
+			desiredRangeRules = append(desiredRangeRules, &ec2.IpPermission{
+				FromPort: aws.Int64(minPort),
+				ToPort:   aws.Int64(maxPort),
+				IpRanges: []*ec2.IpRange{
+					{
+						CidrIp: aws.String(acl.CidrIP),
+					},
 				},
-			},
-			IpProtocol: aws.String("icmp"),
-		})
+				IpProtocol: aws.String(protocol),
+			})
+		}
 	}
 
 	_, toAdd, rangesToRemove := join.HashJoin(ipPermSlice(desiredRangeRules),
@@ -598,21 +589,15 @@ func logACLs(add bool, perms []*ec2.IpPermission) {
 
 	for _, perm := range perms {
 		if len(perm.IpRanges) != 0 {
-			// Each rule has three variants (TCP, UDP, and ICMP), but
-			// we only want to log once.
-			protocol := *perm.IpProtocol
-			if protocol != "tcp" {
-				continue
-			}
-
-			cidrIP := *perm.IpRanges[0].CidrIp
-			ports := fmt.Sprintf("%d", *perm.FromPort)
-			if *perm.FromPort != *perm.ToPort {
-				ports += fmt.Sprintf("-%d", *perm.ToPort)
+			aclStr := *perm.IpRanges[0].CidrIp
+			if perm.FromPort != nil && perm.ToPort != nil {
+				aclStr += fmt.Sprintf(":%d", *perm.FromPort)
+				if *perm.FromPort != *perm.ToPort {
+					aclStr += fmt.Sprintf("-%d", *perm.ToPort)
+				}
 			}
-			log.WithField("ACL",
-				fmt.Sprintf("%s:%s", cidrIP, ports)).
-				Debugf("Amazon: %s ACL", action)
+			aclStr += "/" + resolveString(perm.IpProtocol)
+			log.WithField("ACL", aclStr).Debugf("Amazon: %s ACL", action)
 		} else {
 			log.WithField("Group",
 				*perm.UserIdGroupPairs[0].GroupName).
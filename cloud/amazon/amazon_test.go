@@ -285,6 +285,32 @@ func TestNewACLs(t *testing.T) {
 	}
 }
 
+func TestSyncACLsProtocol(t *testing.T) {
+	t.Parallel()
+
+	add, _, remove := syncACLs([]acl.ACL{
+		{CidrIP: "foo", MinPort: 53, MaxPort: 53, Protocol: "udp"},
+		{CidrIP: "foo", Protocol: "icmp"},
+	}, "", nil)
+	assert.Empty(t, remove)
+
+	sort.Sort(ipPermSlice(add))
+	assert.Equal(t, []*ec2.IpPermission{
+		{
+			IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("foo")}},
+			FromPort:   aws.Int64(-1),
+			ToPort:     aws.Int64(-1),
+			IpProtocol: aws.String("icmp"),
+		},
+		{
+			IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("foo")}},
+			FromPort:   aws.Int64(53),
+			ToPort:     aws.Int64(53),
+			IpProtocol: aws.String("udp"),
+		},
+	}, add)
+}
+
 func TestBoot(t *testing.T) {
 	t.Parallel()
 
@@ -32,6 +32,8 @@ type Client interface {
 	DisassociateAddress(associationID string) error
 
 	DescribeVolumes() ([]*ec2.Volume, error)
+	CreateVolume(*ec2.CreateVolumeInput) (*ec2.Volume, error)
+	AttachVolume(volumeID, instanceID, device string) error
 }
 
 type awsClient struct {
@@ -179,6 +181,20 @@ func (ac awsClient) DescribeVolumes() ([]*ec2.Volume, error) {
 	return resp.Volumes, err
 }
 
+func (ac awsClient) CreateVolume(in *ec2.CreateVolumeInput) (*ec2.Volume, error) {
+	c.Inc("Create Volume")
+	return ac.client.CreateVolume(in)
+}
+
+func (ac awsClient) AttachVolume(volumeID, instanceID, device string) error {
+	c.Inc("Attach Volume")
+	_, err := ac.client.AttachVolume(&ec2.AttachVolumeInput{
+		VolumeId:   &volumeID,
+		InstanceId: &instanceID,
+		Device:     &device})
+	return err
+}
+
 // New creates a new Client.
 func New(region string) Client {
 	c.Inc("New Client")
@@ -24,6 +24,20 @@ func (_m *Client) AssociateAddress(id string, allocationID string) error {
 	return r0
 }
 
+// AttachVolume provides a mock function with given fields: volumeID, instanceID, device
+func (_m *Client) AttachVolume(volumeID string, instanceID string, device string) error {
+	ret := _m.Called(volumeID, instanceID, device)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(volumeID, instanceID, device)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AuthorizeSecurityGroup provides a mock function with given fields: name, src, ranges
 func (_m *Client) AuthorizeSecurityGroup(name string, src string, ranges []*ec2.IpPermission) error {
 	ret := _m.Called(name, src, ranges)
@@ -73,6 +87,29 @@ func (_m *Client) CreateSecurityGroup(name string, description string) (string,
 	return r0, r1
 }
 
+// CreateVolume provides a mock function with given fields: _a0
+func (_m *Client) CreateVolume(_a0 *ec2.CreateVolumeInput) (*ec2.Volume, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *ec2.Volume
+	if rf, ok := ret.Get(0).(func(*ec2.CreateVolumeInput) *ec2.Volume); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ec2.Volume)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*ec2.CreateVolumeInput) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteSecurityGroup provides a mock function with given fields: id
 func (_m *Client) DeleteSecurityGroup(id string) error {
 	ret := _m.Called(id)
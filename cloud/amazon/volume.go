@@ -0,0 +1,156 @@
+package amazon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kelda/kelda/db"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	namespaceTag = "kelda-namespace"
+	volumeTag    = "kelda-volume"
+)
+
+// The suffixes of the device names Amazon recommends for EBS volumes attached
+// to HVM instances.  Depending on the kernel, the device will appear as either
+// /dev/sdX or /dev/xvdX.
+const deviceLetters = "fghijklmnop"
+
+// ListVolumes returns the EBS volumes that belong to the namespace.
+func (prvdr *Provider) ListVolumes() ([]db.Volume, error) {
+	ebsVolumes, err := prvdr.DescribeVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := prvdr.instancesByCloudID()
+	if err != nil {
+		return nil, err
+	}
+
+	cloudIDs := map[string]string{}
+	for cloudID, inst := range instances {
+		cloudIDs[resolveString(inst.InstanceId)] = cloudID
+	}
+
+	var volumes []db.Volume
+	for _, ebs := range ebsVolumes {
+		tags := map[string]string{}
+		for _, tag := range ebs.Tags {
+			tags[resolveString(tag.Key)] = resolveString(tag.Value)
+		}
+
+		if tags[namespaceTag] != prvdr.namespace || tags[volumeTag] == "" {
+			continue
+		}
+
+		volume := db.Volume{
+			Name:    tags[volumeTag],
+			CloudID: resolveString(ebs.VolumeId),
+		}
+		if ebs.Size != nil {
+			volume.SizeGiB = int(*ebs.Size)
+		}
+
+		if len(ebs.Attachments) > 0 {
+			att := ebs.Attachments[0]
+			volume.MachineCloudID = cloudIDs[resolveString(att.InstanceId)]
+			volume.Device = resolveString(att.Device)
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+// CreateVolume creates an EBS volume in the availability zone of the instance
+// it will be attached to.
+func (prvdr *Provider) CreateVolume(volume db.Volume) error {
+	instances, err := prvdr.instancesByCloudID()
+	if err != nil {
+		return err
+	}
+
+	inst, ok := instances[volume.MachineCloudID]
+	if !ok || inst.Placement == nil {
+		return fmt.Errorf("no instance with ID %s", volume.MachineCloudID)
+	}
+
+	_, err = prvdr.Client.CreateVolume(&ec2.CreateVolumeInput{
+		AvailabilityZone: inst.Placement.AvailabilityZone,
+		Size:             aws.Int64(int64(volume.SizeGiB)),
+		VolumeType:       aws.String(ec2.VolumeTypeGp2),
+		TagSpecifications: []*ec2.TagSpecification{{
+			ResourceType: aws.String(ec2.ResourceTypeVolume),
+			Tags: []*ec2.Tag{
+				{Key: aws.String(namespaceTag),
+					Value: aws.String(prvdr.namespace)},
+				{Key: aws.String(volumeTag),
+					Value: aws.String(volume.Name)},
+			},
+		}},
+	})
+	return err
+}
+
+// AttachVolume attaches the EBS volume to the instance with its MachineCloudID
+// using the first unused device name.
+func (prvdr *Provider) AttachVolume(volume db.Volume) error {
+	instances, err := prvdr.instancesByCloudID()
+	if err != nil {
+		return err
+	}
+
+	inst, ok := instances[volume.MachineCloudID]
+	if !ok {
+		return fmt.Errorf("no instance with ID %s", volume.MachineCloudID)
+	}
+
+	used := map[string]struct{}{}
+	for _, bdm := range inst.BlockDeviceMappings {
+		name := resolveString(bdm.DeviceName)
+		name = strings.TrimPrefix(name, "/dev/xvd")
+		name = strings.TrimPrefix(name, "/dev/sd")
+		used[name] = struct{}{}
+	}
+
+	for _, letter := range deviceLetters {
+		if _, ok := used[string(letter)]; ok {
+			continue
+		}
+
+		return prvdr.Client.AttachVolume(volume.CloudID,
+			resolveString(inst.InstanceId), "/dev/xvd"+string(letter))
+	}
+	return errors.New("no free device names")
+}
+
+// instancesByCloudID returns the running instances in the namespace, keyed by
+// the CloudID of their db.Machine.
+func (prvdr *Provider) instancesByCloudID() (map[string]*ec2.Instance, error) {
+	insts, err := prvdr.DescribeInstances([]*ec2.Filter{{
+		Name:   aws.String("instance.group-name"),
+		Values: []*string{aws.String(prvdr.namespace)},
+	}, {
+		Name:   aws.String("instance-state-name"),
+		Values: []*string{aws.String(ec2.InstanceStateNameRunning)}}})
+	if err != nil {
+		return nil, err
+	}
+
+	instances := map[string]*ec2.Instance{}
+	for _, res := range insts.Reservations {
+		for _, inst := range res.Instances {
+			cloudID := resolveString(inst.SpotInstanceRequestId)
+			if cloudID == "" {
+				cloudID = resolveString(inst.InstanceId)
+			}
+			instances[cloudID] = inst
+		}
+	}
+	return instances, nil
+}
@@ -0,0 +1,137 @@
+package amazon
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/kelda/kelda/cloud/amazon/client/mocks"
+	"github.com/kelda/kelda/db"
+)
+
+func volumeTestClient() *mocks.Client {
+	mappings := []*ec2.InstanceBlockDeviceMapping{
+		{DeviceName: aws.String("/dev/sda1")},
+		{DeviceName: aws.String("/dev/sdf")},
+	}
+
+	mc := new(mocks.Client)
+	mc.On("DescribeInstances", mock.Anything).Return(
+		&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{{
+				Instances: []*ec2.Instance{{
+					InstanceId:            aws.String("inst1"),
+					SpotInstanceRequestId: aws.String("spot1"),
+					Placement: &ec2.Placement{
+						AvailabilityZone: aws.String("zone"),
+					},
+					BlockDeviceMappings: mappings,
+				}, {
+					InstanceId: aws.String("inst2"),
+				}},
+			}},
+		}, nil)
+	return mc
+}
+
+func TestListVolumes(t *testing.T) {
+	t.Parallel()
+
+	tags := func(namespace, name string) []*ec2.Tag {
+		return []*ec2.Tag{
+			{Key: aws.String(namespaceTag), Value: aws.String(namespace)},
+			{Key: aws.String(volumeTag), Value: aws.String(name)},
+		}
+	}
+
+	mc := volumeTestClient()
+	mc.On("DescribeVolumes").Return([]*ec2.Volume{{
+		VolumeId: aws.String("vol-1"),
+		Size:     aws.Int64(10),
+		Tags:     tags(testNamespace, "attached"),
+		Attachments: []*ec2.VolumeAttachment{{
+			InstanceId: aws.String("inst1"),
+			Device:     aws.String("/dev/xvdf"),
+		}},
+	}, {
+		VolumeId: aws.String("vol-2"),
+		Size:     aws.Int64(20),
+		Tags:     tags(testNamespace, "detached"),
+	}, {
+		VolumeId: aws.String("vol-3"),
+		Tags:     tags("other", "data"),
+	}, {
+		// A volume that isn't managed by Kelda, such as a root disk.
+		VolumeId: aws.String("vol-4"),
+	}}, nil)
+
+	amazonProvider := newAmazon(testNamespace, testRegion)
+	amazonProvider.Client = mc
+
+	volumes, err := amazonProvider.ListVolumes()
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Volume{
+		{Name: "attached", SizeGiB: 10, CloudID: "vol-1",
+			MachineCloudID: "spot1", Device: "/dev/xvdf"},
+		{Name: "detached", SizeGiB: 20, CloudID: "vol-2"},
+	}, volumes)
+}
+
+func TestCreateVolume(t *testing.T) {
+	t.Parallel()
+
+	mc := volumeTestClient()
+	mc.On("CreateVolume", mock.Anything).Return(&ec2.Volume{}, nil)
+
+	amazonProvider := newAmazon(testNamespace, testRegion)
+	amazonProvider.Client = mc
+
+	err := amazonProvider.CreateVolume(db.Volume{Name: "data", SizeGiB: 10,
+		MachineCloudID: "spot1"})
+	assert.NoError(t, err)
+	mc.AssertCalled(t, "CreateVolume", &ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String("zone"),
+		Size:             aws.Int64(10),
+		VolumeType:       aws.String(ec2.VolumeTypeGp2),
+		TagSpecifications: []*ec2.TagSpecification{{
+			ResourceType: aws.String(ec2.ResourceTypeVolume),
+			Tags: []*ec2.Tag{
+				{Key: aws.String(namespaceTag),
+					Value: aws.String(testNamespace)},
+				{Key: aws.String(volumeTag), Value: aws.String("data")},
+			},
+		}},
+	})
+
+	err = amazonProvider.CreateVolume(db.Volume{Name: "data",
+		MachineCloudID: "missing"})
+	assert.EqualError(t, err, "no instance with ID missing")
+}
+
+func TestAttachVolume(t *testing.T) {
+	t.Parallel()
+
+	mc := volumeTestClient()
+	mc.On("AttachVolume", "vol-1", "inst1", "/dev/xvdg").Return(nil)
+	mc.On("AttachVolume", "vol-2", "inst2", "/dev/xvdf").Return(nil)
+
+	amazonProvider := newAmazon(testNamespace, testRegion)
+	amazonProvider.Client = mc
+
+	// /dev/sdf is already in use by inst1, so the next device is chosen.
+	err := amazonProvider.AttachVolume(db.Volume{CloudID: "vol-1",
+		MachineCloudID: "spot1"})
+	assert.NoError(t, err)
+
+	err = amazonProvider.AttachVolume(db.Volume{CloudID: "vol-2",
+		MachineCloudID: "inst2"})
+	assert.NoError(t, err)
+	mc.AssertExpectations(t)
+
+	err = amazonProvider.AttachVolume(db.Volume{CloudID: "vol-3",
+		MachineCloudID: "missing"})
+	assert.EqualError(t, err, "no instance with ID missing")
+}
@@ -0,0 +1,348 @@
+// Package autoscaler resizes the machine groups of each namespace's blueprint
+// based on the containers that the namespace's leader can't schedule.
+package autoscaler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kelda/kelda/api/client"
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/cloud/machine"
+	"github.com/kelda/kelda/connection"
+	"github.com/kelda/kelda/counter"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util/str"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultCooldown is how long a machine group must wait after it was last
+// resized before it shrinks, unless its blueprint sets a different cooldown.
+const defaultCooldown = 5 * time.Minute
+
+var c = counter.New("Autoscaler")
+
+// Saved in a variable so that it can be mocked by the unit tests.
+var newLeaderClient = client.Leader
+
+// Run periodically resizes the machine groups in every namespace.
+func Run(conn db.Conn, creds connection.Credentials) {
+	trigger := conn.TriggerTick(30, db.BlueprintTable)
+	defer trigger.Stop()
+
+	for range trigger.C {
+		for _, namespace := range conn.GetBlueprintNamespaces() {
+			runOnce(conn, creds, namespace, time.Now())
+		}
+	}
+}
+
+func runOnce(conn db.Conn, creds connection.Credentials, namespace string,
+	now time.Time) {
+
+	var bp db.Blueprint
+	var machines []db.Machine
+	err := conn.Txn(db.BlueprintTable, db.MachineTable).Run(
+		func(view db.Database) (err error) {
+			bp, err = view.GetBlueprint(namespace)
+			machines = selectMachines(view, namespace)
+			return err
+		})
+	if err != nil || len(bp.MachineGroups) == 0 || len(machines) == 0 {
+		return
+	}
+
+	// The containers are only known to the leader, so the groups can't be
+	// resized until it's up.
+	leaderClient, err := newLeaderClient(machines, creds)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Debug(
+			"Failed to connect to the leader")
+		return
+	}
+	defer leaderClient.Close()
+
+	containers, err := leaderClient.QueryContainers()
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Warning(
+			"Failed to query containers")
+		return
+	}
+
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		bp, err := view.GetBlueprint(namespace)
+		if err != nil {
+			return err
+		}
+
+		resized, drain, ok := resize(bp, selectMachines(view, namespace),
+			containers, now)
+		if !ok {
+			return nil
+		}
+
+		view.Commit(resized)
+		for _, dbm := range drain {
+			dbm.Drain = true
+			view.Commit(dbm)
+		}
+		return nil
+	})
+}
+
+// resize decides how to resize at most one of the machine groups in `bp`.  A
+// group grows by a machine when containers can't be scheduled, and one of them
+// would fit on a new machine from the group.  It shrinks by a machine when
+// every container is scheduled, its cooldown has passed, and one of its
+// machines can be drained without leaving its containers without room on the
+// other workers.  Machines running containers that the scheduler can't place
+// freely aren't drained.  Groups aren't resized until all of their machines have
+// connected, so that new machines get a chance to run the containers that
+// they were booted for.  The returned blueprint has the groups' new sizes,
+// and the returned machines should be drained.
+func resize(bp db.Blueprint, machines []db.Machine, containers []db.Container,
+	now time.Time) (db.Blueprint, []db.Machine, bool) {
+
+	pending := pendingContainers(machines, containers)
+	for _, group := range bp.MachineGroups {
+		size := bp.GroupSize(group)
+		members := groupMachines(machines, group.Name)
+		if !settled(members, size) {
+			continue
+		}
+
+		if len(pending) > 0 {
+			if size < group.Max && anyFits(group.Machine, pending) {
+				c.Inc("Scale Up")
+				log.WithFields(log.Fields{
+					"group": group.Name,
+					"size":  size + 1,
+				}).Info("Growing machine group for pending containers")
+				return setSize(bp, group, size+1, now), nil, true
+			}
+			continue
+		}
+
+		cooldown := time.Duration(group.Cooldown) * time.Second
+		if group.Cooldown == 0 {
+			cooldown = defaultCooldown
+		}
+		if size <= group.Min || now.Sub(bp.GroupsScaled[group.Name]) < cooldown {
+			continue
+		}
+
+		pinned := pinnedContainers(bp.Blueprint)
+		if dbm, ok := drainable(members, machines, containers, pinned); ok {
+			c.Inc("Scale Down")
+			log.WithFields(log.Fields{
+				"group":   group.Name,
+				"size":    size - 1,
+				"machine": dbm,
+			}).Info("Shrinking machine group")
+			return setSize(bp, group, size-1, now), []db.Machine{dbm}, true
+		}
+	}
+	return bp, nil, false
+}
+
+// pendingContainers returns the containers that are waiting for room on a
+// worker.  Until the namespace has a connected worker, the leader doesn't try
+// to schedule containers, so all of them are waiting.
+func pendingContainers(machines []db.Machine, containers []db.Container) (
+	pending []db.Container) {
+
+	hasWorkers := len(connectedWorkers(machines)) > 0
+	for _, dbc := range containers {
+		if dbc.Minion == "" && (dbc.Unschedulable != "" || !hasWorkers) {
+			pending = append(pending, dbc)
+		}
+	}
+	return pending
+}
+
+// settled returns whether a group's machines are all connected, and match its
+// size.
+func settled(members []db.Machine, size int) bool {
+	if len(members) != size {
+		return false
+	}
+
+	for _, dbm := range members {
+		if dbm.Drain || dbm.Status != db.Connected {
+			return false
+		}
+	}
+	return true
+}
+
+// anyFits returns whether any of `containers` would fit on an empty `bpm`.
+// Machines of unknown size are assumed to have room for any container.
+func anyFits(bpm blueprint.Machine, containers []db.Container) bool {
+	cpu, ram, ok := machine.Capacity(db.ProviderName(bpm.Provider), bpm.Size)
+	if !ok {
+		return true
+	}
+
+	for _, dbc := range containers {
+		if dbc.CPURequest <= cpu && dbc.RAMRequest <= ram {
+			return true
+		}
+	}
+	return false
+}
+
+// pinnedContainers returns the hostnames of the containers whose placement
+// depends on more than their resource requests, namely those with placement
+// constraints, or that accept public traffic and so claim ports on their
+// machine.  The autoscaler can't tell whether another worker could run them.
+func pinnedContainers(bp blueprint.Blueprint) map[string]bool {
+	bp = bp.ExpandReplicas()
+
+	pinned := map[string]bool{}
+	for _, p := range bp.Placements {
+		pinned[p.TargetContainer] = true
+		if p.OtherContainer != "" {
+			pinned[p.OtherContainer] = true
+		}
+	}
+
+	for _, conn := range bp.Connections {
+		if str.SliceContains(conn.From, blueprint.PublicInternetLabel) &&
+			conn.Protocol != blueprint.ICMPProtocol {
+			for _, to := range conn.To {
+				pinned[to] = true
+			}
+		}
+	}
+	return pinned
+}
+
+// drainable returns the group member whose containers fit within the spare
+// resources of the other connected workers, preferring those that run the
+// fewest containers.  Containers that mount volumes are bound to their
+// machines, and `pinned` containers may not be able to move, so members
+// running either can't be drained.
+func drainable(members, machines []db.Machine, containers []db.Container,
+	pinned map[string]bool) (db.Machine, bool) {
+
+	byMinion := map[string][]db.Container{}
+	for _, dbc := range containers {
+		if dbc.Minion != "" {
+			byMinion[dbc.Minion] = append(byMinion[dbc.Minion], dbc)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		ci := len(byMinion[members[i].PrivateIP])
+		cj := len(byMinion[members[j].PrivateIP])
+		if ci != cj {
+			return ci < cj
+		}
+		return members[i].CloudID < members[j].CloudID
+	})
+
+	for _, candidate := range members {
+		if fitsElsewhere(candidate, connectedWorkers(machines), byMinion,
+			pinned) {
+			return candidate, true
+		}
+	}
+	return db.Machine{}, false
+}
+
+// fitsElsewhere returns whether the containers running on `candidate` can be
+// packed, by their resource requests, into the spare resources of the other
+// `workers`.
+func fitsElsewhere(candidate db.Machine, workers []db.Machine,
+	byMinion map[string][]db.Container, pinned map[string]bool) bool {
+
+	type spare struct {
+		cpu, ram float64
+		unknown  bool
+	}
+
+	var others []*spare
+	for _, w := range workers {
+		if w.PrivateIP == candidate.PrivateIP {
+			continue
+		}
+
+		cpu, ram, ok := machine.Capacity(w.Provider, w.Size)
+		s := spare{cpu: cpu, ram: ram, unknown: !ok}
+		for _, dbc := range byMinion[w.PrivateIP] {
+			s.cpu -= dbc.CPURequest
+			s.ram -= dbc.RAMRequest
+		}
+		others = append(others, &s)
+	}
+
+	for _, dbc := range byMinion[candidate.PrivateIP] {
+		if len(dbc.VolumeMounts) > 0 || pinned[dbc.Hostname] {
+			return false
+		}
+
+		placed := false
+		for _, s := range others {
+			fits := dbc.CPURequest <= s.cpu && dbc.RAMRequest <= s.ram
+			if s.unknown || fits {
+				s.cpu -= dbc.CPURequest
+				s.ram -= dbc.RAMRequest
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return false
+		}
+	}
+	return true
+}
+
+// setSize returns a copy of `bp` with `group` resized to `size` at `now`.  The
+// maps are copied, so that the database's copy isn't modified before the
+// blueprint is committed.
+func setSize(bp db.Blueprint, group blueprint.MachineGroup, size int,
+	now time.Time) db.Blueprint {
+
+	sizes := map[string]int{}
+	for name, s := range bp.GroupSizes {
+		sizes[name] = s
+	}
+	sizes[group.Name] = size
+
+	scaled := map[string]time.Time{}
+	for name, t := range bp.GroupsScaled {
+		scaled[name] = t
+	}
+	scaled[group.Name] = now
+
+	bp.GroupSizes = sizes
+	bp.GroupsScaled = scaled
+	return bp
+}
+
+func connectedWorkers(machines []db.Machine) (workers []db.Machine) {
+	for _, dbm := range machines {
+		if dbm.Role == db.Worker && dbm.Status == db.Connected &&
+			!dbm.Drain && dbm.PrivateIP != "" {
+			workers = append(workers, dbm)
+		}
+	}
+	return workers
+}
+
+func groupMachines(machines []db.Machine, group string) (members []db.Machine) {
+	for _, dbm := range machines {
+		if dbm.Group == group {
+			members = append(members, dbm)
+		}
+	}
+	return members
+}
+
+func selectMachines(view db.Database, namespace string) []db.Machine {
+	return view.SelectFromMachine(func(dbm db.Machine) bool {
+		return dbm.Namespace == namespace
+	})
+}
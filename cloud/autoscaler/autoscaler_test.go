@@ -0,0 +1,250 @@
+package autoscaler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/api/client"
+	"github.com/kelda/kelda/api/client/mocks"
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/connection"
+	"github.com/kelda/kelda/db"
+)
+
+var testGroup = blueprint.MachineGroup{
+	Name: "group",
+	Machine: blueprint.Machine{
+		Provider: string(db.Vagrant),
+		Size:     "4,2",
+	},
+	Min:      1,
+	Max:      3,
+	Cooldown: 60,
+}
+
+func testMachine(cloudID, privateIP, group string) db.Machine {
+	return db.Machine{
+		CloudID:   cloudID,
+		PrivateIP: privateIP,
+		Provider:  db.Vagrant,
+		Size:      "4,2",
+		Role:      db.Worker,
+		Status:    db.Connected,
+		Group:     group,
+	}
+}
+
+func TestResizeUp(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	bp := db.Blueprint{Blueprint: blueprint.Blueprint{
+		MachineGroups: []blueprint.MachineGroup{testGroup}}}
+	machines := []db.Machine{
+		testMachine("static", "1", ""),
+		testMachine("member", "2", "group"),
+	}
+	containers := []db.Container{
+		{BlueprintID: "placed", Minion: "1", CPURequest: 2},
+		{BlueprintID: "pending", Unschedulable: "no room", CPURequest: 1},
+	}
+
+	resized, drain, ok := resize(bp, machines, containers, now)
+	assert.True(t, ok)
+	assert.Empty(t, drain)
+	assert.Equal(t, 2, resized.GroupSize(testGroup))
+	assert.Equal(t, now, resized.GroupsScaled["group"])
+
+	// The blueprint's maps aren't modified in place.
+	assert.Nil(t, bp.GroupSizes)
+
+	// Groups that haven't finished booting their machines aren't resized.
+	_, _, ok = resize(resized, machines, containers, now)
+	assert.False(t, ok)
+
+	booting := testMachine("booting", "3", "group")
+	booting.Status = db.Connecting
+	_, _, ok = resize(resized, append(machines, booting), containers, now)
+	assert.False(t, ok)
+
+	// Groups don't grow past their maximum.
+	max := resized
+	max.GroupSizes = map[string]int{"group": 3}
+	members := append(machines, testMachine("3", "3", "group"),
+		testMachine("4", "4", "group"))
+	_, _, ok = resize(max, members, containers, now)
+	assert.False(t, ok)
+
+	// Containers that wouldn't fit on a new machine don't grow the group.
+	_, _, ok = resize(bp, machines, []db.Container{
+		{BlueprintID: "huge", Unschedulable: "no room", CPURequest: 8},
+	}, now)
+	assert.False(t, ok)
+
+	// Containers that the leader just hasn't tried to schedule yet don't grow
+	// the group.
+	_, _, ok = resize(bp, machines, []db.Container{{BlueprintID: "new"}}, now)
+	assert.False(t, ok)
+
+	// Unless there's no worker to schedule them on.
+	empty := testGroup
+	empty.Min = 0
+	bp.MachineGroups = []blueprint.MachineGroup{empty}
+	resized, _, ok = resize(bp, nil, []db.Container{{BlueprintID: "new"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, resized.GroupSize(empty))
+}
+
+func TestResizeDown(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	bp := db.Blueprint{
+		Blueprint: blueprint.Blueprint{
+			MachineGroups: []blueprint.MachineGroup{testGroup}},
+		GroupSizes:   map[string]int{"group": 3},
+		GroupsScaled: map[string]time.Time{"group": now.Add(-time.Hour)},
+	}
+	machines := []db.Machine{
+		testMachine("static", "1", ""),
+		testMachine("a", "2", "group"),
+		testMachine("b", "3", "group"),
+		testMachine("c", "4", "group"),
+	}
+	containers := []db.Container{
+		{BlueprintID: "1", Minion: "1", CPURequest: 2},
+		{BlueprintID: "2", Minion: "2", CPURequest: 1},
+		{BlueprintID: "3", Minion: "2", CPURequest: 1},
+		{BlueprintID: "4", Minion: "3", CPURequest: 1},
+		{BlueprintID: "5", Minion: "4", CPURequest: 1},
+	}
+
+	// The least loaded member is drained, since its container fits on the
+	// other member that has room.
+	resized, drain, ok := resize(bp, machines, containers, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2, resized.GroupSize(testGroup))
+	assert.Equal(t, now, resized.GroupsScaled["group"])
+	assert.Len(t, drain, 1)
+	assert.Equal(t, "b", drain[0].CloudID)
+
+	// Members whose containers mount volumes aren't drained.
+	withVolumes := append([]db.Container{}, containers...)
+	withVolumes[3].VolumeMounts = []blueprint.VolumeMount{{Volume: "data"}}
+	_, drain, ok = resize(bp, machines, withVolumes, now)
+	assert.True(t, ok)
+	assert.Equal(t, "c", drain[0].CloudID)
+
+	// Nor are members whose containers have placement constraints, or accept
+	// public traffic, as the other workers may not be able to run them.  The
+	// busier member is drained instead.
+	pinned := append([]db.Container{}, containers...)
+	pinned[3].Hostname = "pinned"
+	pinned[4].Hostname = "public"
+	constrained := bp
+	constrained.Blueprint.Placements = []blueprint.Placement{
+		{TargetContainer: "pinned", Provider: string(db.Vagrant)}}
+	constrained.Blueprint.Connections = []blueprint.Connection{{
+		From:    []string{blueprint.PublicInternetLabel},
+		To:      []string{"public"},
+		MinPort: 80, MaxPort: 80}}
+	_, drain, ok = resize(constrained, machines, pinned, now)
+	assert.True(t, ok)
+	assert.Equal(t, "a", drain[0].CloudID)
+
+	pinned[1].Hostname = "pinned"
+	_, _, ok = resize(constrained, machines, pinned, now)
+	assert.False(t, ok)
+
+	// ICMP doesn't claim any ports.
+	pinned[1].Hostname = ""
+
+	constrained.Blueprint.Connections[0].Protocol = blueprint.ICMPProtocol
+	_, drain, ok = resize(constrained, machines, pinned, now)
+	assert.True(t, ok)
+	assert.Equal(t, "c", drain[0].CloudID)
+
+	// Members aren't drained if their containers wouldn't fit elsewhere.
+	full := append([]db.Container{}, containers...)
+	full[3].CPURequest = 2
+	full[4].CPURequest = 2
+	_, _, ok = resize(bp, machines, full, now)
+	assert.False(t, ok)
+
+	// Groups wait for their cooldown before shrinking.
+	cooling := bp
+	cooling.GroupsScaled = map[string]time.Time{"group": now.Add(-time.Second)}
+	_, _, ok = resize(cooling, machines, containers, now)
+	assert.False(t, ok)
+
+	// Groups don't shrink while containers are pending.
+	pending := append(containers, db.Container{BlueprintID: "6",
+		Unschedulable: "constraints", CPURequest: 8})
+	_, _, ok = resize(bp, machines, pending, now)
+	assert.False(t, ok)
+
+	// Groups don't shrink past their minimum.
+	min := bp
+	min.GroupSizes = map[string]int{"group": 1}
+	_, _, ok = resize(min, machines[:2], containers[:3], now)
+	assert.False(t, ok)
+}
+
+func TestRunOnce(t *testing.T) {
+	conn := db.New()
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		bp.MachineGroups = []blueprint.MachineGroup{testGroup}
+		bp.GroupSizes = map[string]int{"group": 2}
+		view.Commit(bp)
+
+		for _, m := range []db.Machine{
+			testMachine("static", "1", ""),
+			testMachine("a", "2", "group"),
+			testMachine("b", "3", "group"),
+		} {
+			m.ID = view.InsertMachine().ID
+			m.Namespace = "ns"
+			view.Commit(m)
+		}
+		return nil
+	})
+
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		return nil, errors.New("no leader")
+	}
+	runOnce(conn, nil, "ns", time.Now())
+	assert.Equal(t, 2, getBlueprint(conn).GroupSize(testGroup))
+
+	newLeaderClient = func(_ []db.Machine, _ connection.Credentials) (
+		client.Client, error) {
+		mc := new(mocks.Client)
+		mc.On("QueryContainers").Return([]db.Container{
+			{BlueprintID: "1", Minion: "1", CPURequest: 1},
+			{BlueprintID: "2", Minion: "2", CPURequest: 1},
+		}, nil)
+		mc.On("Close").Return(nil)
+		return mc, nil
+	}
+	runOnce(conn, nil, "ns", time.Now())
+
+	assert.Equal(t, 1, getBlueprint(conn).GroupSize(testGroup))
+	drained := conn.SelectFromMachine(func(m db.Machine) bool {
+		return m.Drain
+	})
+	assert.Len(t, drained, 1)
+	assert.Equal(t, "b", drained[0].CloudID)
+}
+
+func getBlueprint(conn db.Conn) (bp db.Blueprint) {
+	conn.Txn(db.BlueprintTable).Run(func(view db.Database) (err error) {
+		bp, err = view.GetBlueprint("ns")
+		return err
+	})
+	return bp
+}
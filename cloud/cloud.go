@@ -2,14 +2,17 @@ package cloud
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/cloud/acl"
 	"github.com/kelda/kelda/cloud/amazon"
 	"github.com/kelda/kelda/cloud/digitalocean"
+	"github.com/kelda/kelda/cloud/docker"
 	"github.com/kelda/kelda/cloud/foreman"
 	"github.com/kelda/kelda/cloud/google"
+	"github.com/kelda/kelda/cloud/static"
 	"github.com/kelda/kelda/cloud/vagrant"
 	"github.com/kelda/kelda/counter"
 	"github.com/kelda/kelda/db"
@@ -30,6 +33,17 @@ type provider interface {
 
 	UpdateFloatingIPs([]db.Machine) error
 
+	// Returns the block volumes in this namespace and region, along with the
+	// machine each is attached to.
+	ListVolumes() ([]db.Volume, error)
+
+	// Creates a volume near the machine with the volume's MachineCloudID.  The
+	// volume is attached by a later call to AttachVolume once it's ready.
+	CreateVolume(db.Volume) error
+
+	// Attaches the volume to the machine with its MachineCloudID.
+	AttachVolume(db.Volume) error
+
 	// The Cleanup() function will be called occaisionally in those regions that have
 	// no machines running, and no machines expected to be running in the future.
 	// The provider may use this method to free up resources that are only necessary
@@ -57,33 +71,105 @@ var adminKey string
 
 const defaultDiskSize = 32
 
-// Run continually checks 'conn' for cloud changes and recreates the cloud as
-// needed.
+// Run continually checks 'conn' for new namespaces, and starts the clouds and
+// foreman that deploy each of them.  `kelda stop` deploys an empty blueprint,
+// and the clouds are what terminate the namespace's machines, so a namespace is
+// only retired once its blueprint is empty and its machines are gone.
 func Run(conn db.Conn, adminSSHKey string) {
 	adminKey = adminSSHKey
 
-	var ns string
-	stop := make(chan struct{})
+	namespaces := map[string]namespace{}
 	for range conn.TriggerTick(60, db.BlueprintTable, db.MachineTable).C {
-		newns, _ := conn.GetBlueprintNamespace()
-		if newns == ns {
+		updateNamespaces(conn, namespaces)
+	}
+}
+
+// A namespace whose foreman and clouds are running.
+type namespace struct {
+	// Closing stop stops the namespace's foreman and clouds.
+	stop chan struct{}
+
+	// listed is closed once each of the namespace's clouds has run, and thus
+	// added the machines it found to the machine table.
+	listed chan struct{}
+}
+
+// updateNamespaces starts the namespaces in the blueprint table that aren't in
+// `namespaces`, and retires those that have nothing left to deploy.
+func updateNamespaces(conn db.Conn, namespaces map[string]namespace) {
+	for _, ns := range conn.GetBlueprintNamespaces() {
+		if _, ok := namespaces[ns]; ok || ns == "" {
 			continue
 		}
 
-		log.Debugf("Namespace change from \"%s\", to \"%s\".", ns, newns)
-		ns = newns
+		log.Debugf("Start namespace \"%s\".", ns)
+		namespaces[ns] = startNamespace(conn, ns)
+	}
 
-		if ns != "" {
-			close(stop)
-			stop = make(chan struct{})
-			startClouds(conn, ns, stop)
+	for name, ns := range namespaces {
+		// Until each cloud has run, the machine table may be missing
+		// machines that the clouds have yet to stop.
+		select {
+		case <-ns.listed:
+		default:
+			continue
+		}
+
+		if retireNamespace(conn, name) {
+			log.Debugf("Stop namespace \"%s\".", name)
+			close(ns.stop)
+			delete(namespaces, name)
 		}
 	}
 }
 
-func startClouds(conn db.Conn, ns string, stop chan struct{}) {
+// retireNamespace removes the blueprint of `ns` if it's empty and the namespace
+// has no machines left, and returns whether it did.  Otherwise, the namespace
+// would be deployed forever, and with its blueprint persisted, come back each
+// time the daemon restarts.
+func retireNamespace(conn db.Conn, ns string) (retired bool) {
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		bp, err := view.GetBlueprint(ns)
+		if err != nil {
+			// The blueprint is already gone.
+			retired = true
+			return nil
+		}
+
+		empty := blueprint.Blueprint{Namespace: ns}
+		if bp.Blueprint.String() != empty.String() {
+			return nil
+		}
+
+		machines := view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Namespace == ns
+		})
+		if len(machines) > 0 {
+			return nil
+		}
+
+		view.Remove(bp)
+		retired = true
+		return nil
+	})
+	return retired
+}
+
+// startNamespace starts the foreman of `ns`, along with a cloud for each
+// provider and region.
+func startNamespace(conn db.Conn, ns string) namespace {
+	stop := make(chan struct{})
+	go runForeman(conn, ns, stop)
+	return namespace{stop: stop, listed: startClouds(conn, ns, stop)}
+}
+
+// startClouds starts a cloud for each provider and region in `ns`.  The
+// returned channel is closed once each of the clouds has run once.
+func startClouds(conn db.Conn, ns string, stop chan struct{}) chan struct{} {
+	var listed sync.WaitGroup
 	for _, p := range db.AllProviders {
 		for _, r := range ValidRegions(p) {
+			listed.Add(1)
 			go func(p db.ProviderName, r string) {
 				cld := cloud{
 					conn:         conn,
@@ -91,13 +177,22 @@ func startClouds(conn db.Conn, ns string, stop chan struct{}) {
 					region:       r,
 					providerName: p,
 				}
-				cld.run(stop)
+				cld.run(stop, listed.Done)
 			}(p, r)
 		}
 	}
+
+	done := make(chan struct{})
+	go func() {
+		listed.Wait()
+		close(done)
+	}()
+	return done
 }
 
-func (cld *cloud) run(stop <-chan struct{}) {
+// run runs the cloud until `stop` is closed.  `ran` is called after the first
+// run.
+func (cld *cloud) run(stop <-chan struct{}, ran func()) {
 	log.Debugf("Start Cloud %s", cld)
 
 	// Note that we need to be fairly conservative about running due to request
@@ -112,11 +207,14 @@ func (cld *cloud) run(stop <-chan struct{}) {
 	// We choose a fast tick in those regions that have machines, and a slow tick in
 	// those regions that are empty.  In the event the stop channel is closed, the
 	// function returns.
-	for {
+	for first := true; ; first = false {
 		tick := slow.C
 		if cld.runOnce() {
 			tick = fast.C
 		}
+		if first {
+			ran()
+		}
 
 		select {
 		case <-stop:
@@ -195,8 +293,11 @@ func (cld *cloud) runOnce() (active bool) {
 		// ACLs must be processed after Kelda learns about what machines
 		// are in the cloud.  If we didn't, inter-machine ACLs could get
 		// removed when the Kelda controller restarts, even if there are
-		// running cloud machines that still need to communicate.
+		// running cloud machines that still need to communicate.  Volumes
+		// wait for the same reason, so that they aren't attached to a new
+		// machine while their current one is merely unknown.
 		cld.syncACLs(jr.acls)
+		cld.syncVolumes()
 	} else {
 		cld.updateCloud(jr)
 	}
@@ -205,13 +306,13 @@ func (cld *cloud) runOnce() (active bool) {
 }
 
 // usedByCurrentBlueprint returns whether this cloud provider is used by machines
-// in the blueprint that is currently active.
+// in the blueprint that is currently active in this cloud's namespace.
 func (cld *cloud) usedByCurrentBlueprint() bool {
 	var bp db.Blueprint
 	var err error
 	cld.conn.Txn(db.BlueprintTable).Run(
 		func(view db.Database) error {
-			bp, err = view.GetBlueprint()
+			bp, err = view.GetBlueprint(cld.namespace)
 			return nil
 		})
 	if err != nil {
@@ -224,7 +325,12 @@ func (cld *cloud) usedByCurrentBlueprint() bool {
 		// conservatively assume that it is.
 		return true
 	}
-	return len(cld.desiredMachines(bp.Blueprint.Machines)) > 0
+
+	bpms := bp.Blueprint.Machines
+	for _, group := range bp.MachineGroups {
+		bpms = append(bpms, group.Machine)
+	}
+	return len(cld.desiredMachines(bpms)) > 0
 }
 
 // desiredMachines takes a list of all machines specified by a blueprint, and returns
@@ -245,6 +351,7 @@ func (cld *cloud) desiredMachines(bpms []blueprint.Machine) []db.Machine {
 		}
 
 		dbm := db.Machine{
+			Namespace:   cld.namespace,
 			Region:      region,
 			FloatingIP:  bpm.FloatingIP,
 			Role:        role,
@@ -268,6 +375,23 @@ func (cld *cloud) desiredMachines(bpms []blueprint.Machine) []db.Machine {
 	return dbms
 }
 
+// desiredGroupMachines returns the machines in this cloud's provider and region
+// that the blueprint's machine groups should have, given their current sizes.
+func (cld *cloud) desiredGroupMachines(bp db.Blueprint) []db.Machine {
+	var dbms []db.Machine
+	for _, group := range bp.MachineGroups {
+		bpm := group.Machine
+		bpm.Role = string(db.Worker)
+		for _, dbm := range cld.desiredMachines([]blueprint.Machine{bpm}) {
+			dbm.Group = group.Name
+			for i := 0; i < bp.GroupSize(group); i++ {
+				dbms = append(dbms, dbm)
+			}
+		}
+	}
+	return dbms
+}
+
 func sanitizeMachines(machines []db.Machine) []db.Machine {
 	// As a defensive measure, we only copy over the fields that the underlying
 	// provider should care about instead of passing `machines` to updateCloud
@@ -310,12 +434,24 @@ func (cld *cloud) updateCloud(jr joinResult) {
 		var err error
 		bootIDs, err = cld.provider.Boot(sanitizeMachines(jr.boot))
 		logAttempt(len(jr.boot), "boot", err)
+		if err != nil {
+			msg := fmt.Sprintf("failed to boot %d machines in %s: %s",
+				len(jr.boot), cld, err)
+			cld.logFailure(db.Event{Type: db.BootFailedEvent,
+				ObjectType: "Machine", Message: msg})
+		}
 	}
 
 	if len(jr.terminate) > 0 {
 		err := cld.provider.Stop(sanitizeMachines(jr.terminate))
 		logAttempt(len(jr.terminate), "stop", err)
 		if err != nil {
+			msg := fmt.Sprintf("failed to stop: %s", err)
+			for _, dbm := range jr.terminate {
+				cld.logFailure(db.Event{Type: db.StopFailedEvent,
+					ObjectType: "Machine", ObjectID: dbm.CloudID,
+					Message: msg})
+			}
 			jr.terminate = nil // Don't wait if we errored.
 		}
 	}
@@ -368,6 +504,16 @@ func (cld *cloud) updateCloud(jr joinResult) {
 	log.Debug("Finished waiting for updates.")
 }
 
+// logFailure records `event`, a failed cloud provider update, in the cloud's
+// namespace.
+func (cld *cloud) logFailure(event db.Event) {
+	event.Namespace = cld.namespace
+	cld.conn.Txn(db.EventTable).Run(func(view db.Database) error {
+		view.LogEvent(event)
+		return nil
+	})
+}
+
 func (cld *cloud) syncACLs(unresolvedACLs []acl.ACL) {
 	var acls []acl.ACL
 	for _, acl := range unresolvedACLs {
@@ -398,6 +544,10 @@ func newProviderImpl(p db.ProviderName, namespace, region string) (provider, err
 		return digitalocean.New(namespace, region)
 	case db.Vagrant:
 		return vagrant.New(namespace)
+	case db.Docker:
+		return docker.New(namespace)
+	case db.Static:
+		return static.New(namespace)
 	default:
 		panic("Unimplemented")
 	}
@@ -413,6 +563,10 @@ func validRegionsImpl(p db.ProviderName) []string {
 		return digitalocean.Regions
 	case db.Vagrant:
 		return []string{""} // Vagrant has no regions
+	case db.Docker:
+		return []string{""} // Docker has no regions
+	case db.Static:
+		return []string{""} // Static hosts have no regions
 	default:
 		panic("Unimplemented")
 	}
@@ -425,6 +579,7 @@ func (cld *cloud) String() string {
 // Stored in variables so they may be mocked out
 var newProvider = newProviderImpl
 var getMachineRole = foreman.GetMachineRole
+var runForeman = foreman.Run
 
 // ValidRegions returns a list of supported regions for a given cloud provider
 var ValidRegions = validRegionsImpl
@@ -37,7 +37,13 @@ type fakeProvider struct {
 	updatedIPs   []db.Machine
 	aclRequests  []acl.ACL
 
+	volumes         map[string]db.Volume
+	createdVolumes  []db.Volume
+	attachedVolumes []db.Volume
+
 	listError error
+	bootError error
+	stopError error
 }
 
 func fakeValidRegions(p db.ProviderName) []string {
@@ -49,6 +55,8 @@ func (p *fakeProvider) clearLogs() {
 	p.stopRequests = nil
 	p.aclRequests = nil
 	p.updatedIPs = nil
+	p.createdVolumes = nil
+	p.attachedVolumes = nil
 }
 
 func (p *fakeProvider) List() ([]db.Machine, error) {
@@ -64,6 +72,10 @@ func (p *fakeProvider) List() ([]db.Machine, error) {
 }
 
 func (p *fakeProvider) Boot(bootSet []db.Machine) ([]string, error) {
+	if p.bootError != nil {
+		return nil, p.bootError
+	}
+
 	var ids []string
 	for _, toBoot := range bootSet {
 		// Record the boot request before we mutate it with implementation
@@ -91,6 +103,10 @@ func (p *fakeProvider) Boot(bootSet []db.Machine) ([]string, error) {
 }
 
 func (p *fakeProvider) Stop(machines []db.Machine) error {
+	if p.stopError != nil {
+		return p.stopError
+	}
+
 	for _, machine := range machines {
 		delete(p.machines, machine.CloudID)
 		p.stopRequests = append(p.stopRequests, machine.CloudID)
@@ -113,6 +129,36 @@ func (p *fakeProvider) UpdateFloatingIPs(machines []db.Machine) error {
 	return nil
 }
 
+func (p *fakeProvider) ListVolumes() ([]db.Volume, error) {
+	var volumes []db.Volume
+	for _, v := range p.volumes {
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+func (p *fakeProvider) CreateVolume(v db.Volume) error {
+	p.createdVolumes = append(p.createdVolumes, v)
+
+	p.idCounter++
+	p.volumes[v.Name] = db.Volume{
+		Name:    v.Name,
+		SizeGiB: v.SizeGiB,
+		CloudID: "vol-" + strconv.Itoa(p.idCounter),
+	}
+	return nil
+}
+
+func (p *fakeProvider) AttachVolume(v db.Volume) error {
+	p.attachedVolumes = append(p.attachedVolumes, v)
+
+	curr := p.volumes[v.Name]
+	curr.MachineCloudID = v.MachineCloudID
+	curr.Device = "/dev/" + v.Name
+	p.volumes[v.Name] = curr
+	return nil
+}
+
 func (p *fakeProvider) Cleanup() error {
 	return nil
 }
@@ -184,6 +230,7 @@ func TestCloudRunOnceProviderInitializationFailure(t *testing.T) {
 	// as the test cloud.
 	conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
 		bp := view.InsertBlueprint()
+		bp.Namespace = "test"
 		bp.Blueprint.Machines = []blueprint.Machine{{
 			Provider: string(providerName),
 			Region:   testRegion,
@@ -224,7 +271,7 @@ func TestCloudRunOnceProviderInitializationFailure(t *testing.T) {
 	// is logged at debug instead of error level.
 	hook.Reset()
 	conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
-		bp, _ := view.GetBlueprint()
+		bp, _ := view.GetBlueprint("test")
 		bp.Blueprint.Machines = []blueprint.Machine{{
 			Provider: string(FakeVagrant),
 			Region:   testRegion,
@@ -300,6 +347,42 @@ func TestCloudRunOnce(t *testing.T) {
 	})
 }
 
+func TestUpdateCloudFailures(t *testing.T) {
+	cld := newTestCloud(FakeAmazon, testRegion, "ns")
+	providerInst := cld.provider.(*fakeProvider)
+	providerInst.bootError = errors.New("no capacity")
+	providerInst.stopError = errors.New("denied")
+
+	cld.updateCloud(joinResult{
+		boot: []db.Machine{{Provider: FakeAmazon, Region: testRegion}},
+		terminate: []db.Machine{{
+			Provider: FakeAmazon,
+			Region:   testRegion,
+			CloudID:  "a",
+		}},
+	})
+
+	events := db.SortEvents(cld.conn.SelectFromEvent(nil))
+	assert.Len(t, events, 2)
+	for i := range events {
+		events[i].Time = time.Time{}
+		events[i].ID = 0
+	}
+	assert.Equal(t, []db.Event{{
+		Namespace:  "ns",
+		Type:       db.BootFailedEvent,
+		ObjectType: "Machine",
+		Message: fmt.Sprintf("failed to boot 1 machines in %s: no capacity",
+			cld),
+	}, {
+		Namespace:  "ns",
+		Type:       db.StopFailedEvent,
+		ObjectType: "Machine",
+		ObjectID:   "a",
+		Message:    "failed to stop: denied",
+	}}, events)
+}
+
 func TestACLs(t *testing.T) {
 	myIP = func() (string, error) {
 		return "5.6.7.8", nil
@@ -321,7 +404,7 @@ func TestACLs(t *testing.T) {
 
 func TestStartClouds(t *testing.T) {
 	stop := make(chan struct{})
-	startClouds(db.New(), "ns", stop)
+	listed := startClouds(db.New(), "ns", stop)
 
 	// Give the clouds time to be created.
 	for i := 0; i < 20 && len(instantiatedProviders) < 3; i++ {
@@ -340,9 +423,151 @@ func TestStartClouds(t *testing.T) {
 		"FakeAmazon-Fake region-ns",
 		"FakeAmazon-Fake region-ns",
 		"FakeVagrant-Fake region-ns"}, locations)
+
+	// Each cloud runs once before waiting for a trigger.
+	select {
+	case <-listed:
+	case <-time.After(10 * time.Second):
+		t.Error("clouds didn't run")
+	}
 	close(stop)
 }
 
+func TestUpdateNamespaces(t *testing.T) {
+	mock()
+
+	var foremen []string
+	var foremenLock sync.Mutex
+	runForeman = func(conn db.Conn, ns string, stop <-chan struct{}) {
+		foremenLock.Lock()
+		foremen = append(foremen, ns)
+		foremenLock.Unlock()
+	}
+
+	conn := db.New()
+	setNamespaces := func(namespaces ...string) {
+		conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+			for _, bp := range view.SelectFromBlueprint(nil) {
+				view.Remove(bp)
+			}
+			for _, ns := range namespaces {
+				bp := view.InsertBlueprint()
+				bp.Namespace = ns
+				bp.Containers = []blueprint.Container{{ID: "c"}}
+				view.Commit(bp)
+			}
+			return nil
+		})
+	}
+
+	namespaces := map[string]namespace{}
+	setNamespaces("prod", "staging")
+	updateNamespaces(conn, namespaces)
+	assert.Len(t, namespaces, 2)
+	prodStop, stagingStop := namespaces["prod"].stop, namespaces["staging"].stop
+
+	// Deploying to another namespace doesn't affect the running ones.
+	setNamespaces("prod", "staging", "test")
+	updateNamespaces(conn, namespaces)
+	assert.Len(t, namespaces, 3)
+	assert.Equal(t, prodStop, namespaces["prod"].stop)
+	assert.Equal(t, stagingStop, namespaces["staging"].stop)
+
+	// Updating the namespaces again doesn't restart them.
+	updateNamespaces(conn, namespaces)
+	assert.Len(t, namespaces, 3)
+	assert.Equal(t, stagingStop, namespaces["staging"].stop)
+
+	for i := 0; i < 20; i++ {
+		foremenLock.Lock()
+		started := len(foremen)
+		foremenLock.Unlock()
+		if started == 3 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	foremenLock.Lock()
+	sort.Strings(foremen)
+	assert.Equal(t, []string{"prod", "staging", "test"}, foremen)
+	foremenLock.Unlock()
+
+	for _, ns := range namespaces {
+		close(ns.stop)
+	}
+}
+
+func TestRetireNamespace(t *testing.T) {
+	conn := db.New()
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		view.Commit(bp)
+
+		m := view.InsertMachine()
+		m.Namespace = "ns"
+		view.Commit(m)
+		return nil
+	})
+
+	listed := make(chan struct{})
+	ns := namespace{stop: make(chan struct{}), listed: listed}
+	namespaces := map[string]namespace{"ns": ns}
+
+	// The namespace isn't retired until its clouds have listed their machines.
+	conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		view.Remove(view.SelectFromMachine(nil)[0])
+		return nil
+	})
+	updateNamespaces(conn, namespaces)
+	assert.Len(t, namespaces, 1)
+	close(listed)
+
+	// Namespaces that have machines, or whose blueprint isn't empty, are kept.
+	conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		m := view.InsertMachine()
+		m.Namespace = "ns"
+		view.Commit(m)
+		return nil
+	})
+	updateNamespaces(conn, namespaces)
+	assert.Len(t, namespaces, 1)
+
+	var machine db.Machine
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		machine = view.SelectFromMachine(nil)[0]
+		view.Remove(machine)
+
+		bp, _ := view.GetBlueprint("ns")
+		bp.Containers = []blueprint.Container{{ID: "c"}}
+		view.Commit(bp)
+		return nil
+	})
+	updateNamespaces(conn, namespaces)
+	assert.Len(t, namespaces, 1)
+
+	select {
+	case <-ns.stop:
+		t.Fatal("namespace stopped while it still had a blueprint")
+	default:
+	}
+
+	// Once the blueprint is emptied, the namespace is stopped, and its
+	// blueprint is removed so that it isn't restored when the daemon restarts.
+	conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		bp, _ := view.GetBlueprint("ns")
+		bp.Containers = nil
+		view.Commit(bp)
+		return nil
+	})
+	updateNamespaces(conn, namespaces)
+	assert.Empty(t, namespaces)
+	assert.Empty(t, conn.GetBlueprintNamespaces())
+
+	_, more := <-ns.stop
+	assert.False(t, more)
+}
+
 func TestNewProviderFailure(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
@@ -373,6 +598,7 @@ func TestDesiredMachines(t *testing.T) {
 		SSHKeys:     []string{"foo"},
 	}})
 	assert.Equal(t, []db.Machine{{
+		Namespace:   "ns",
 		Provider:    FakeAmazon,
 		Region:      testRegion,
 		Size:        "m4.lage",
@@ -383,6 +609,46 @@ func TestDesiredMachines(t *testing.T) {
 		SSHKeys:     []string{"foo", "bar"}}}, res)
 }
 
+func TestDesiredGroupMachines(t *testing.T) {
+	cld := newTestCloud(FakeAmazon, testRegion, "ns")
+	adminKey = ""
+
+	group := blueprint.MachineGroup{
+		Name: "group",
+		Machine: blueprint.Machine{
+			Provider: string(FakeAmazon),
+			Region:   testRegion,
+			Size:     "m4.large",
+		},
+		Min: 1,
+		Max: 2,
+	}
+	other := group
+	other.Name = "other"
+	other.Machine.Provider = "Google"
+
+	bp := db.Blueprint{Blueprint: blueprint.Blueprint{
+		MachineGroups: []blueprint.MachineGroup{group, other}}}
+	exp := db.Machine{
+		Namespace: "ns",
+		Provider:  FakeAmazon,
+		Region:    testRegion,
+		Size:      "m4.large",
+		Role:      db.Worker,
+		Group:     "group",
+		DiskSize:  defaultDiskSize,
+	}
+
+	// Groups start at their minimum size, and stay within their bounds.
+	assert.Equal(t, []db.Machine{exp}, cld.desiredGroupMachines(bp))
+
+	bp.GroupSizes = map[string]int{"group": 2}
+	assert.Equal(t, []db.Machine{exp, exp}, cld.desiredGroupMachines(bp))
+
+	bp.GroupSizes = map[string]int{"group": 5}
+	assert.Equal(t, []db.Machine{exp, exp}, cld.desiredGroupMachines(bp))
+}
+
 var instantiatedProviders []fakeProvider
 
 func mock() {
@@ -400,6 +666,7 @@ func mock() {
 			namespace:    namespace,
 			machines:     make(map[string]db.Machine),
 			roles:        make(map[string]db.Role),
+			volumes:      make(map[string]db.Volume),
 		}
 		ret.clearLogs()
 
@@ -30,6 +30,10 @@ type Client interface {
 	ListFirewalls(*godo.ListOptions) ([]godo.Firewall, *godo.Response, error)
 	AddRules(string, []godo.InboundRule) (*godo.Response, error)
 	RemoveRules(string, []godo.InboundRule) (*godo.Response, error)
+
+	ListVolumes(*godo.ListOptions) ([]godo.Volume, *godo.Response, error)
+	CreateVolume(*godo.VolumeCreateRequest) (*godo.Volume, *godo.Response, error)
+	AttachVolume(string, int) (*godo.Action, *godo.Response, error)
 }
 
 type client struct {
@@ -38,6 +42,8 @@ type client struct {
 	floatingIPActions godo.FloatingIPActionsService
 	acls              godo.FirewallsService
 	tags              godo.TagsService
+	storage           godo.StorageService
+	storageActions    godo.StorageActionsService
 }
 
 var c = counter.New("Digital Ocean")
@@ -136,6 +142,28 @@ func (client client) ListFirewalls(opt *godo.ListOptions) ([]godo.Firewall,
 	return client.acls.List(context.Background(), opt)
 }
 
+func (client client) ListVolumes(opt *godo.ListOptions) ([]godo.Volume,
+	*godo.Response, error) {
+
+	c.Inc("List Volumes")
+	return client.storage.ListVolumes(context.Background(),
+		&godo.ListVolumeParams{ListOptions: opt})
+}
+
+func (client client) CreateVolume(req *godo.VolumeCreateRequest) (*godo.Volume,
+	*godo.Response, error) {
+
+	c.Inc("Create Volume")
+	return client.storage.CreateVolume(context.Background(), req)
+}
+
+func (client client) AttachVolume(id string, dropletID int) (*godo.Action,
+	*godo.Response, error) {
+
+	c.Inc("Attach Volume")
+	return client.storageActions.Attach(context.Background(), id, dropletID)
+}
+
 // New creates a new DigitalOcean client.
 func New(oauthClient *http.Client) Client {
 	api := godo.NewClient(oauthClient)
@@ -145,5 +173,7 @@ func New(oauthClient *http.Client) Client {
 		floatingIPActions: api.FloatingIPActions,
 		acls:              api.Firewalls,
 		tags:              api.Tags,
+		storage:           api.Storage,
+		storageActions:    api.StorageActions,
 	}
 }
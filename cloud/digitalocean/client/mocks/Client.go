@@ -65,6 +65,38 @@ func (_m *Client) AssignFloatingIP(_a0 string, _a1 int) (*godo.Action, *godo.Res
 	return r0, r1, r2
 }
 
+// AttachVolume provides a mock function with given fields: _a0, _a1
+func (_m *Client) AttachVolume(_a0 string, _a1 int) (*godo.Action, *godo.Response, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *godo.Action
+	if rf, ok := ret.Get(0).(func(string, int) *godo.Action); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*godo.Action)
+		}
+	}
+
+	var r1 *godo.Response
+	if rf, ok := ret.Get(1).(func(string, int) *godo.Response); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*godo.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, int) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateDroplets provides a mock function with given fields: _a0
 func (_m *Client) CreateDroplets(_a0 *godo.DropletMultiCreateRequest) ([]godo.Droplet, *godo.Response, error) {
 	ret := _m.Called(_a0)
@@ -161,6 +193,38 @@ func (_m *Client) CreateTag(_a0 string) (*godo.Tag, *godo.Response, error) {
 	return r0, r1, r2
 }
 
+// CreateVolume provides a mock function with given fields: _a0
+func (_m *Client) CreateVolume(_a0 *godo.VolumeCreateRequest) (*godo.Volume, *godo.Response, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *godo.Volume
+	if rf, ok := ret.Get(0).(func(*godo.VolumeCreateRequest) *godo.Volume); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*godo.Volume)
+		}
+	}
+
+	var r1 *godo.Response
+	if rf, ok := ret.Get(1).(func(*godo.VolumeCreateRequest) *godo.Response); ok {
+		r1 = rf(_a0)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*godo.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*godo.VolumeCreateRequest) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // DeleteDroplet provides a mock function with given fields: _a0
 func (_m *Client) DeleteDroplet(_a0 int) (*godo.Response, error) {
 	ret := _m.Called(_a0)
@@ -335,6 +399,38 @@ func (_m *Client) ListFloatingIPs(_a0 *godo.ListOptions) ([]godo.FloatingIP, *go
 	return r0, r1, r2
 }
 
+// ListVolumes provides a mock function with given fields: _a0
+func (_m *Client) ListVolumes(_a0 *godo.ListOptions) ([]godo.Volume, *godo.Response, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []godo.Volume
+	if rf, ok := ret.Get(0).(func(*godo.ListOptions) []godo.Volume); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]godo.Volume)
+		}
+	}
+
+	var r1 *godo.Response
+	if rf, ok := ret.Get(1).(func(*godo.ListOptions) *godo.Response); ok {
+		r1 = rf(_a0)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*godo.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*godo.ListOptions) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // RemoveRules provides a mock function with given fields: _a0, _a1
 func (_m *Client) RemoveRules(_a0 string, _a1 []godo.InboundRule) (*godo.Response, error) {
 	ret := _m.Called(_a0, _a1)
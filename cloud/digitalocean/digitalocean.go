@@ -30,13 +30,6 @@ var c = counter.New("Digital Ocean")
 var apiKeyPath = ".digitalocean/key"
 
 var (
-	// When creating firewall rules, the API requires that each rule have a protocol
-	// associated with it. It accepts the ones listed below, and we want to allow
-	// traffic only based on IP and port, so allow them all.
-	//
-	// https://developers.digitalocean.com/documentation/v2/#add-rules-to-a-firewall
-	protocols = []string{"tcp", "udp", "icmp"}
-
 	allIPs = &godo.Destinations{
 		Addresses: []string{"0.0.0.0/0", "::/0"},
 	}
@@ -481,8 +474,13 @@ func (prvdr Provider) syncACLs(desired []acl.ACL, current []godo.InboundRule) (
 func toRules(acls []acl.ACL) (rules []godo.InboundRule) {
 	icmpSources := map[string]struct{}{}
 
+	// When creating firewall rules, the API requires that each rule have a
+	// protocol associated with it, so ACLs that allow any protocol are split
+	// into a rule for each.
+	//
+	// https://developers.digitalocean.com/documentation/v2/#add-rules-to-a-firewall
 	for _, acl := range acls {
-		for _, proto := range protocols {
+		for _, proto := range acl.Protocols() {
 			portRange := fmt.Sprintf("%d-%d", acl.MinPort, acl.MaxPort)
 			if acl.MinPort == acl.MaxPort {
 				portRange = fmt.Sprintf("%d", acl.MinPort)
@@ -0,0 +1,80 @@
+package digitalocean
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kelda/kelda/db"
+
+	"github.com/digitalocean/godo"
+)
+
+// The path at which DigitalOcean exposes an attached volume, given its name.
+const devicePathPrefix = "/dev/disk/by-id/scsi-0DO_Volume_"
+
+// ListVolumes returns the block storage volumes that belong to the namespace.
+func (prvdr Provider) ListVolumes() ([]db.Volume, error) {
+	var volumes []db.Volume
+	volumeListOpt := &godo.ListOptions{Page: 1, PerPage: 200}
+	for {
+		doVolumes, resp, err := prvdr.Client.ListVolumes(volumeListOpt)
+		if err != nil {
+			return nil, fmt.Errorf("list volumes: %s", err)
+		}
+
+		for _, v := range doVolumes {
+			if v.Description != prvdr.getTag() {
+				continue
+			}
+
+			volume := db.Volume{
+				Name:    strings.TrimPrefix(v.Name, prvdr.volumeName("")),
+				SizeGiB: int(v.SizeGigaBytes),
+				CloudID: v.ID,
+			}
+
+			if len(v.DropletIDs) > 0 {
+				volume.MachineCloudID = strconv.Itoa(v.DropletIDs[0])
+				volume.Device = devicePathPrefix + v.Name
+			}
+			volumes = append(volumes, volume)
+		}
+
+		if resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+
+		volumeListOpt.Page++
+	}
+	return volumes, nil
+}
+
+// CreateVolume creates a block storage volume in the provider's region.
+func (prvdr Provider) CreateVolume(volume db.Volume) error {
+	_, _, err := prvdr.Client.CreateVolume(&godo.VolumeCreateRequest{
+		Region:        prvdr.region,
+		Name:          prvdr.volumeName(volume.Name),
+		Description:   prvdr.getTag(),
+		SizeGigaBytes: int64(volume.SizeGiB),
+	})
+	return err
+}
+
+// AttachVolume attaches the volume to the droplet with its MachineCloudID.
+func (prvdr Provider) AttachVolume(volume db.Volume) error {
+	dropletID, err := strconv.Atoi(volume.MachineCloudID)
+	if err != nil {
+		return fmt.Errorf("malformed droplet ID %s: %s",
+			volume.MachineCloudID, err)
+	}
+
+	_, _, err = prvdr.Client.AttachVolume(volume.CloudID, dropletID)
+	return err
+}
+
+// Volume names must be unique within a region, so they're prefixed with the
+// namespace's tag.
+func (prvdr Provider) volumeName(name string) string {
+	return prvdr.getTag() + "-" + name
+}
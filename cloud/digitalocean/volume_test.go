@@ -0,0 +1,77 @@
+package digitalocean
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/cloud/digitalocean/client/mocks"
+	"github.com/kelda/kelda/db"
+)
+
+func TestListVolumes(t *testing.T) {
+	mc := new(mocks.Client)
+	prvdr := Provider{Client: mc, namespace: "namespace", region: "region"}
+
+	respLast := &godo.Response{Links: &godo.Links{}}
+	mc.On("ListVolumes", &godo.ListOptions{Page: 1, PerPage: 200}).Return(
+		[]godo.Volume{
+			{
+				ID:            "id-1",
+				Name:          "namespace-region-attached",
+				Description:   "namespace-region",
+				SizeGigaBytes: 10,
+				DropletIDs:    []int{123},
+			},
+			{
+				ID:            "id-2",
+				Name:          "namespace-region-detached",
+				Description:   "namespace-region",
+				SizeGigaBytes: 20,
+			},
+			{
+				ID:          "id-3",
+				Name:        "other",
+				Description: "other-region",
+			},
+		}, respLast, nil).Once()
+
+	volumes, err := prvdr.ListVolumes()
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Volume{
+		{Name: "attached", SizeGiB: 10, CloudID: "id-1",
+			MachineCloudID: "123",
+			Device: "/dev/disk/by-id/" +
+				"scsi-0DO_Volume_namespace-region-attached"},
+		{Name: "detached", SizeGiB: 20, CloudID: "id-2"},
+	}, volumes)
+
+	mc.On("ListVolumes", &godo.ListOptions{Page: 1, PerPage: 200}).Return(
+		nil, nil, errors.New("err")).Once()
+	_, err = prvdr.ListVolumes()
+	assert.EqualError(t, err, "list volumes: err")
+}
+
+func TestCreateAttachVolume(t *testing.T) {
+	mc := new(mocks.Client)
+	prvdr := Provider{Client: mc, namespace: "namespace", region: "region"}
+
+	mc.On("CreateVolume", &godo.VolumeCreateRequest{
+		Region:        "region",
+		Name:          "namespace-region-data",
+		Description:   "namespace-region",
+		SizeGigaBytes: 10,
+	}).Return(&godo.Volume{}, nil, nil).Once()
+	err := prvdr.CreateVolume(db.Volume{Name: "data", SizeGiB: 10})
+	assert.NoError(t, err)
+
+	mc.On("AttachVolume", "id-1", 123).Return(nil, nil, nil).Once()
+	err = prvdr.AttachVolume(db.Volume{CloudID: "id-1", MachineCloudID: "123"})
+	assert.NoError(t, err)
+
+	err = prvdr.AttachVolume(db.Volume{CloudID: "id-1", MachineCloudID: "bad"})
+	assert.Error(t, err)
+	mc.AssertExpectations(t)
+}
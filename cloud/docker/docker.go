@@ -0,0 +1,383 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	dkc "github.com/fsouza/go-dockerclient"
+
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/cloud/cfg"
+	"github.com/kelda/kelda/cloud/machine"
+	"github.com/kelda/kelda/counter"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util"
+)
+
+const (
+	namespaceLabel = "kelda.namespace"
+	sizeLabel      = "kelda.size"
+
+	// The CPU quota of a container is expressed as a fraction of this period.
+	cpuPeriod = 100000
+)
+
+// machineImage is named after a hash of its Dockerfile, so that machines boot
+// from a fresh image when the Dockerfile changes.
+var machineImage = "kelda-machine:" + fmt.Sprintf("%x",
+	sha256.Sum256([]byte(machineDockerfile)))[:12]
+
+var c = counter.New("Docker")
+
+// The Provider object represents a connection to the local Docker daemon.  Each
+// machine is a privileged container running its own Docker daemon.
+type Provider struct {
+	client
+	namespace string
+
+	// The ACL script that was last applied to each machine, so that SetACLs
+	// only execs into machines whose rules changed.
+	aclScripts map[string]string
+}
+
+type client interface {
+	Ping() error
+	ListContainers(dkc.ListContainersOptions) ([]dkc.APIContainers, error)
+	CreateContainer(dkc.CreateContainerOptions) (*dkc.Container, error)
+	UploadToContainer(string, dkc.UploadToContainerOptions) error
+	StartContainer(string, *dkc.HostConfig) error
+	RemoveContainer(dkc.RemoveContainerOptions) error
+	InspectImage(string) (*dkc.Image, error)
+	BuildImage(dkc.BuildImageOptions) error
+	FilteredListNetworks(dkc.NetworkFilterOpts) ([]dkc.Network, error)
+	CreateNetwork(dkc.CreateNetworkOptions) (*dkc.Network, error)
+	RemoveNetwork(string) error
+	CreateExec(dkc.CreateExecOptions) (*dkc.Exec, error)
+	StartExec(string, dkc.StartExecOptions) error
+	InspectExec(string) (*dkc.ExecInspect, error)
+}
+
+// Allow mocking out for the unit tests.
+var newClient = func() (client, error) {
+	return dkc.NewClientFromEnv()
+}
+
+// New creates a new Docker provider.  It fails if the local Docker daemon can't
+// be reached.
+func New(namespace string) (*Provider, error) {
+	dk, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dk.Ping(); err != nil {
+		return nil, fmt.Errorf("docker daemon unreachable: %s", err)
+	}
+
+	return &Provider{
+		client:     dk,
+		namespace:  namespace,
+		aclScripts: map[string]string{},
+	}, nil
+}
+
+// Boot creates a machine container for each machine in `bootSet`.
+func (prvdr *Provider) Boot(bootSet []db.Machine) ([]string, error) {
+	for _, m := range bootSet {
+		if m.Preemptible {
+			return nil, errors.New(
+				"docker does not support preemptible instances")
+		}
+	}
+
+	if len(bootSet) == 0 {
+		return nil, nil
+	}
+
+	if err := prvdr.buildImage(); err != nil {
+		return nil, fmt.Errorf("build machine image: %s", err)
+	}
+
+	if _, err := prvdr.createNetwork(); err != nil {
+		return nil, fmt.Errorf("create network: %s", err)
+	}
+
+	var ids []string
+	for _, m := range bootSet {
+		id, err := prvdr.bootMachine(m)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (prvdr *Provider) bootMachine(m db.Machine) (string, error) {
+	hostConfig := &dkc.HostConfig{
+		Privileged:  true,
+		NetworkMode: prvdr.networkName(),
+		Tmpfs:       map[string]string{"/run": "", "/run/lock": ""},
+		Binds: []string{
+			"/sys/fs/cgroup:/sys/fs/cgroup:ro",
+			"/lib/modules:/lib/modules:ro",
+		},
+	}
+	if cpu, ram, ok := machine.Capacity(db.Docker, m.Size); ok {
+		hostConfig.CPUPeriod = cpuPeriod
+		hostConfig.CPUQuota = int64(cpu * cpuPeriod)
+		hostConfig.Memory = int64(ram * 1024 * 1024 * 1024)
+	}
+
+	c.Inc("Create Container")
+	container, err := prvdr.CreateContainer(dkc.CreateContainerOptions{
+		Config: &dkc.Config{
+			Image:      machineImage,
+			StopSignal: "SIGRTMIN+3",
+			Volumes:    map[string]struct{}{"/var/lib/docker": {}},
+			Labels: map[string]string{
+				namespaceLabel: prvdr.namespace,
+				sizeLabel:      m.Size,
+			},
+		},
+		HostConfig: hostConfig,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = prvdr.upload(container.ID, "/user-data", cfg.Ubuntu(m, ""))
+	if err == nil {
+		c.Inc("Start Container")
+		err = prvdr.StartContainer(container.ID, nil)
+	}
+
+	if err != nil {
+		prvdr.remove(container.ID)
+		return "", err
+	}
+	return container.ID, nil
+}
+
+// List queries the Docker daemon for the machine containers in the namespace.
+func (prvdr *Provider) List() ([]db.Machine, error) {
+	c.Inc("List")
+	containers, err := prvdr.ListContainers(dkc.ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"label": {prvdr.namespaceFilter()}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	machines := []db.Machine{}
+	for _, container := range containers {
+		// Looking up a missing network yields an empty IP.
+		ip := container.Networks.Networks[prvdr.networkName()].IPAddress
+
+		machines = append(machines, db.Machine{
+			Provider:  db.Docker,
+			CloudID:   container.ID,
+			PublicIP:  ip,
+			PrivateIP: ip,
+			Size:      container.Labels[sizeLabel],
+		})
+	}
+	return machines, nil
+}
+
+// Stop removes the containers of `machines`, along with their volumes.
+func (prvdr *Provider) Stop(machines []db.Machine) error {
+	for _, m := range machines {
+		if err := prvdr.remove(m.CloudID); err != nil {
+			return err
+		}
+		delete(prvdr.aclScripts, m.CloudID)
+	}
+	return nil
+}
+
+// SetACLs restricts the traffic that reaches the machines from outside of their
+// network to that allowed by `acls`.  The rules are applied with iptables inside
+// each machine.
+func (prvdr *Provider) SetACLs(acls []acl.ACL) error {
+	network, err := prvdr.getNetwork()
+	if err != nil || network == nil {
+		return err
+	}
+
+	var subnets []string
+	for _, config := range network.IPAM.Config {
+		subnets = append(subnets, config.Subnet)
+	}
+	script := acl.IPTablesScript("eth0", subnets, acls)
+
+	machines, err := prvdr.List()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range machines {
+		if prvdr.aclScripts[m.CloudID] == script {
+			continue
+		}
+
+		if err := prvdr.exec(m.CloudID, "sh", "-c", script); err != nil {
+			return fmt.Errorf("set ACLs on %s: %s", m.CloudID, err)
+		}
+		prvdr.aclScripts[m.CloudID] = script
+	}
+	return nil
+}
+
+// UpdateFloatingIPs is not supported.
+func (prvdr *Provider) UpdateFloatingIPs([]db.Machine) error {
+	return errors.New("docker provider does not support floating IPs")
+}
+
+// ListVolumes returns no volumes, as block volumes aren't supported.
+func (prvdr *Provider) ListVolumes() ([]db.Volume, error) {
+	return nil, nil
+}
+
+// CreateVolume is not supported.
+func (prvdr *Provider) CreateVolume(db.Volume) error {
+	return errors.New("docker provider does not support block volumes")
+}
+
+// AttachVolume is not supported.
+func (prvdr *Provider) AttachVolume(db.Volume) error {
+	return errors.New("docker provider does not support block volumes")
+}
+
+// Cleanup removes the namespace's network.  It's intended to be called when
+// there are no machines running or expected to be running soon.
+func (prvdr *Provider) Cleanup() error {
+	network, err := prvdr.getNetwork()
+	if err != nil || network == nil {
+		return err
+	}
+
+	c.Inc("Remove Network")
+	return prvdr.RemoveNetwork(network.ID)
+}
+
+// buildImage builds the machine image, unless it already exists.
+func (prvdr *Provider) buildImage() error {
+	if _, err := prvdr.InspectImage(machineImage); err == nil {
+		return nil
+	} else if err != dkc.ErrNoSuchImage {
+		return err
+	}
+
+	tar, err := util.ToTar("Dockerfile", 0644, machineDockerfile)
+	if err != nil {
+		return err
+	}
+
+	c.Inc("Build Image")
+	return prvdr.BuildImage(dkc.BuildImageOptions{
+		Name:         machineImage,
+		InputStream:  tar,
+		OutputStream: ioutil.Discard,
+	})
+}
+
+// createNetwork creates the bridge network that the namespace's machines are
+// attached to, unless it already exists.
+func (prvdr *Provider) createNetwork() (*dkc.Network, error) {
+	network, err := prvdr.getNetwork()
+	if err != nil || network != nil {
+		return network, err
+	}
+
+	c.Inc("Create Network")
+	return prvdr.CreateNetwork(dkc.CreateNetworkOptions{
+		Name:           prvdr.networkName(),
+		Driver:         "bridge",
+		CheckDuplicate: true,
+		Labels:         map[string]string{namespaceLabel: prvdr.namespace},
+	})
+}
+
+// getNetwork returns the namespace's network, or nil if it doesn't exist.
+func (prvdr *Provider) getNetwork() (*dkc.Network, error) {
+	c.Inc("List Networks")
+	networks, err := prvdr.FilteredListNetworks(dkc.NetworkFilterOpts{
+		"label": {prvdr.namespaceFilter(): true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, network := range networks {
+		if network.Name == prvdr.networkName() {
+			return &network, nil
+		}
+	}
+	return nil, nil
+}
+
+func (prvdr *Provider) upload(id, dst, content string) error {
+	dir, name := path.Split(dst)
+	tar, err := util.ToTar(name, 0644, content)
+	if err != nil {
+		return err
+	}
+
+	c.Inc("Upload")
+	return prvdr.UploadToContainer(id, dkc.UploadToContainerOptions{
+		InputStream: tar,
+		Path:        dir,
+	})
+}
+
+func (prvdr *Provider) exec(id string, cmd ...string) error {
+	c.Inc("Exec")
+	exec, err := prvdr.CreateExec(dkc.CreateExecOptions{
+		Container:    id,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := prvdr.StartExec(exec.ID, dkc.StartExecOptions{
+		OutputStream: ioutil.Discard,
+		ErrorStream:  ioutil.Discard,
+	}); err != nil {
+		return err
+	}
+
+	inspect, err := prvdr.InspectExec(exec.ID)
+	if err != nil {
+		return err
+	}
+
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("exit code %d", inspect.ExitCode)
+	}
+	return nil
+}
+
+func (prvdr *Provider) remove(id string) error {
+	c.Inc("Remove Container")
+	return prvdr.RemoveContainer(dkc.RemoveContainerOptions{
+		ID:            id,
+		Force:         true,
+		RemoveVolumes: true,
+	})
+}
+
+func (prvdr *Provider) networkName() string {
+	return "kelda-" + prvdr.namespace
+}
+
+func (prvdr *Provider) namespaceFilter() string {
+	return namespaceLabel + "=" + prvdr.namespace
+}
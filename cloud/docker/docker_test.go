@@ -0,0 +1,360 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	dkc "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/db"
+)
+
+func TestNew(t *testing.T) {
+	fake := newFakeClient()
+	newClient = func() (client, error) { return fake, nil }
+
+	prvdr, err := New("ns")
+	assert.NoError(t, err)
+	assert.Equal(t, "ns", prvdr.namespace)
+
+	fake.pingErr = errors.New("ping")
+	_, err = New("ns")
+	assert.EqualError(t, err, "docker daemon unreachable: ping")
+
+	newClient = func() (client, error) { return nil, errors.New("client") }
+	_, err = New("ns")
+	assert.EqualError(t, err, "client")
+}
+
+func TestBoot(t *testing.T) {
+	fake := newFakeClient()
+	prvdr := newTestProvider(fake)
+
+	_, err := prvdr.Boot([]db.Machine{{Preemptible: true}})
+	assert.EqualError(t, err, "docker does not support preemptible instances")
+	assert.Zero(t, fake.builds)
+
+	ids, err := prvdr.Boot([]db.Machine{{Size: "2,1"}, {Size: "4,2"}})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 2)
+	assert.Equal(t, 1, fake.builds)
+	assert.Contains(t, fake.networks, "kelda-ns")
+
+	dbc := fake.containers[ids[0]]
+	assert.True(t, dbc.started)
+	assert.Equal(t, machineImage, dbc.opts.Config.Image)
+	assert.Equal(t, "ns", dbc.opts.Config.Labels[namespaceLabel])
+	assert.True(t, dbc.opts.HostConfig.Privileged)
+	assert.Equal(t, "kelda-ns", dbc.opts.HostConfig.NetworkMode)
+	assert.Equal(t, int64(cpuPeriod), dbc.opts.HostConfig.CPUQuota)
+	assert.Equal(t, int64(2*1024*1024*1024), dbc.opts.HostConfig.Memory)
+	assert.Equal(t, "/", dbc.uploadPath)
+	assert.Contains(t, dbc.upload, "user-data")
+
+	// The image and network are reused.
+	_, err = prvdr.Boot([]db.Machine{{Size: "2,1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fake.builds)
+	assert.Len(t, fake.networks, 1)
+
+	// Containers that fail to start are removed.
+	fake.startErr = errors.New("start")
+	_, err = prvdr.Boot([]db.Machine{{Size: "2,1"}})
+	assert.EqualError(t, err, "start")
+	assert.Len(t, fake.containers, 3)
+}
+
+func TestListStop(t *testing.T) {
+	fake := newFakeClient()
+	prvdr := newTestProvider(fake)
+
+	ids, err := prvdr.Boot([]db.Machine{{Size: "2,1"}, {Size: "4,2"}})
+	assert.NoError(t, err)
+
+	// Containers in other namespaces are ignored.
+	other := newTestProvider(fake)
+	other.namespace = "other"
+	_, err = other.Boot([]db.Machine{{Size: "2,1"}})
+	assert.NoError(t, err)
+
+	machines, err := prvdr.List()
+	assert.NoError(t, err)
+	assert.Len(t, machines, 2)
+	for _, m := range machines {
+		assert.Equal(t, db.Docker, m.Provider)
+		assert.Equal(t, fake.containers[m.CloudID].ip, m.PublicIP)
+		assert.Equal(t, m.PublicIP, m.PrivateIP)
+		assert.Equal(t, fake.containers[m.CloudID].opts.Config.Labels[sizeLabel],
+			m.Size)
+	}
+
+	assert.NoError(t, prvdr.Stop([]db.Machine{{CloudID: ids[0]}}))
+	machines, err = prvdr.List()
+	assert.NoError(t, err)
+	assert.Len(t, machines, 1)
+	assert.Equal(t, ids[1], machines[0].CloudID)
+
+	assert.Error(t, prvdr.Stop([]db.Machine{{CloudID: ids[0]}}))
+}
+
+func TestSetACLs(t *testing.T) {
+	fake := newFakeClient()
+	prvdr := newTestProvider(fake)
+
+	// There's nothing to do before any machines boot.
+	assert.NoError(t, prvdr.SetACLs(nil))
+	assert.Empty(t, fake.execs)
+
+	ids, err := prvdr.Boot([]db.Machine{{Size: "2,1"}})
+	assert.NoError(t, err)
+
+	acls := []acl.ACL{{CidrIP: "1.2.3.4/32", MinPort: 80, MaxPort: 80}}
+	assert.NoError(t, prvdr.SetACLs(acls))
+	script := acl.IPTablesScript("eth0", []string{"10.0.0.0/24"}, acls)
+	assert.Equal(t, [][]string{{ids[0], "sh", "-c", script}}, fake.execs)
+
+	// The rules are only reapplied when they change.
+	assert.NoError(t, prvdr.SetACLs(acls))
+	assert.Len(t, fake.execs, 1)
+
+	fake.exitCode = 1
+	err = prvdr.SetACLs(nil)
+	assert.EqualError(t, err, fmt.Sprintf("set ACLs on %s: exit code 1", ids[0]))
+	assert.Len(t, fake.execs, 2)
+}
+
+func TestSetACLsRemapped(t *testing.T) {
+	fake := newFakeClient()
+	prvdr := newTestProvider(fake)
+
+	ids, err := prvdr.Boot([]db.Machine{{Size: "2,1"}})
+	assert.NoError(t, err)
+
+	// Public port 80 is remapped to 8080 in the container.  The minion's DNAT
+	// rewrites the port before FORWARD filters the packet, so the rules must
+	// allow the original public port rather than the packet's port.
+	acls := []acl.ACL{{CidrIP: "0.0.0.0/0", MinPort: 80, MaxPort: 80,
+		Protocol: "tcp"}}
+	assert.NoError(t, prvdr.SetACLs(acls))
+	assert.Len(t, fake.execs, 1)
+	assert.Equal(t, ids[0], fake.execs[0][0])
+
+	script := fake.execs[0][3]
+	assert.Contains(t, script, "-p tcp -m conntrack --ctorigdstport 80:80 "+
+		"-j ACCEPT")
+	assert.NotContains(t, script, "--dport")
+	assert.Contains(t, script, "iptables -I FORWARD -i eth0 -j KELDA-ACL")
+}
+
+func TestCleanup(t *testing.T) {
+	fake := newFakeClient()
+	prvdr := newTestProvider(fake)
+
+	assert.NoError(t, prvdr.Cleanup())
+
+	_, err := prvdr.Boot([]db.Machine{{Size: "2,1"}})
+	assert.NoError(t, err)
+	assert.Len(t, fake.networks, 1)
+
+	assert.NoError(t, prvdr.Cleanup())
+	assert.Empty(t, fake.networks)
+}
+
+func TestUnsupported(t *testing.T) {
+	prvdr := newTestProvider(newFakeClient())
+	assert.Error(t, prvdr.UpdateFloatingIPs(nil))
+	assert.Error(t, prvdr.CreateVolume(db.Volume{}))
+	assert.Error(t, prvdr.AttachVolume(db.Volume{}))
+
+	volumes, err := prvdr.ListVolumes()
+	assert.NoError(t, err)
+	assert.Empty(t, volumes)
+}
+
+func newTestProvider(fake *fakeClient) *Provider {
+	return &Provider{client: fake, namespace: "ns",
+		aclScripts: map[string]string{}}
+}
+
+type fakeContainer struct {
+	opts       dkc.CreateContainerOptions
+	ip         string
+	started    bool
+	upload     string
+	uploadPath string
+}
+
+type fakeClient struct {
+	containers map[string]*fakeContainer
+	networks   map[string]dkc.Network
+	images     map[string]bool
+	execs      [][]string
+
+	builds   int
+	nextID   int
+	exitCode int
+	pingErr  error
+	startErr error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		containers: map[string]*fakeContainer{},
+		networks:   map[string]dkc.Network{},
+		images:     map[string]bool{},
+	}
+}
+
+func (fake *fakeClient) Ping() error {
+	return fake.pingErr
+}
+
+func (fake *fakeClient) ListContainers(opts dkc.ListContainersOptions) (
+	[]dkc.APIContainers, error) {
+
+	var res []dkc.APIContainers
+	for id, dbc := range fake.containers {
+		labels := dbc.opts.Config.Labels
+		if opts.Filters["label"][0] != namespaceLabel+"="+labels[namespaceLabel] {
+			continue
+		}
+
+		networks := map[string]dkc.ContainerNetwork{
+			dbc.opts.HostConfig.NetworkMode: {IPAddress: dbc.ip},
+		}
+		res = append(res, dkc.APIContainers{
+			ID:       id,
+			Labels:   labels,
+			Networks: dkc.NetworkList{Networks: networks},
+		})
+	}
+	return res, nil
+}
+
+func (fake *fakeClient) CreateContainer(opts dkc.CreateContainerOptions) (
+	*dkc.Container, error) {
+
+	if !fake.images[opts.Config.Image] {
+		return nil, dkc.ErrNoSuchImage
+	}
+
+	if _, ok := fake.networks[opts.HostConfig.NetworkMode]; !ok {
+		return nil, errors.New("no such network")
+	}
+
+	fake.nextID++
+	id := fmt.Sprintf("container%d", fake.nextID)
+	fake.containers[id] = &fakeContainer{
+		opts: opts,
+		ip:   fmt.Sprintf("10.0.0.%d", fake.nextID),
+	}
+	return &dkc.Container{ID: id}, nil
+}
+
+func (fake *fakeClient) UploadToContainer(id string,
+	opts dkc.UploadToContainerOptions) error {
+
+	dbc, ok := fake.containers[id]
+	if !ok {
+		return &dkc.NoSuchContainer{ID: id}
+	}
+
+	tar, err := ioutil.ReadAll(opts.InputStream)
+	if err != nil {
+		return err
+	}
+	dbc.upload = string(tar)
+	dbc.uploadPath = opts.Path
+	return nil
+}
+
+func (fake *fakeClient) StartContainer(id string, _ *dkc.HostConfig) error {
+	if fake.startErr != nil {
+		return fake.startErr
+	}
+
+	dbc, ok := fake.containers[id]
+	if !ok {
+		return &dkc.NoSuchContainer{ID: id}
+	}
+	dbc.started = true
+	return nil
+}
+
+func (fake *fakeClient) RemoveContainer(opts dkc.RemoveContainerOptions) error {
+	if _, ok := fake.containers[opts.ID]; !ok {
+		return &dkc.NoSuchContainer{ID: opts.ID}
+	}
+	delete(fake.containers, opts.ID)
+	return nil
+}
+
+func (fake *fakeClient) InspectImage(name string) (*dkc.Image, error) {
+	if !fake.images[name] {
+		return nil, dkc.ErrNoSuchImage
+	}
+	return &dkc.Image{ID: name}, nil
+}
+
+func (fake *fakeClient) BuildImage(opts dkc.BuildImageOptions) error {
+	fake.builds++
+	fake.images[opts.Name] = true
+	return nil
+}
+
+func (fake *fakeClient) FilteredListNetworks(opts dkc.NetworkFilterOpts) (
+	[]dkc.Network, error) {
+
+	var res []dkc.Network
+	for _, network := range fake.networks {
+		label := namespaceLabel + "=" + network.Labels[namespaceLabel]
+		if opts["label"][label] {
+			res = append(res, network)
+		}
+	}
+	return res, nil
+}
+
+func (fake *fakeClient) CreateNetwork(opts dkc.CreateNetworkOptions) (
+	*dkc.Network, error) {
+
+	if _, ok := fake.networks[opts.Name]; ok {
+		return nil, dkc.ErrNetworkAlreadyExists
+	}
+
+	network := dkc.Network{
+		Name:   opts.Name,
+		ID:     opts.Name,
+		Labels: opts.Labels,
+		IPAM: dkc.IPAMOptions{
+			Config: []dkc.IPAMConfig{{Subnet: "10.0.0.0/24"}},
+		},
+	}
+	fake.networks[opts.Name] = network
+	return &network, nil
+}
+
+func (fake *fakeClient) RemoveNetwork(id string) error {
+	if _, ok := fake.networks[id]; !ok {
+		return &dkc.NoSuchNetwork{ID: id}
+	}
+	delete(fake.networks, id)
+	return nil
+}
+
+func (fake *fakeClient) CreateExec(opts dkc.CreateExecOptions) (*dkc.Exec, error) {
+	fake.execs = append(fake.execs, append([]string{opts.Container}, opts.Cmd...))
+	return &dkc.Exec{ID: "exec"}, nil
+}
+
+func (fake *fakeClient) StartExec(string, dkc.StartExecOptions) error {
+	return nil
+}
+
+func (fake *fakeClient) InspectExec(string) (*dkc.ExecInspect, error) {
+	return &dkc.ExecInspect{ExitCode: fake.exitCode}, nil
+}
@@ -0,0 +1,31 @@
+package docker
+
+// machineDockerfile builds the image that each machine container runs.  It boots
+// systemd so that the cloud config can install Docker and start the minion as it
+// would on a virtual machine.  The cloud config is uploaded to /user-data before
+// the container starts, and run once by the kelda-init unit.
+const machineDockerfile = `FROM ubuntu:16.04
+
+ENV container docker
+
+RUN apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y \
+	apt-transport-https ca-certificates curl iptables kmod lsb-release \
+	openssh-server software-properties-common sudo systemd \
+	&& rm -rf /var/lib/apt/lists/*
+
+RUN printf '%s\n' \
+	'[Unit]' \
+	'Description=Kelda machine initialization' \
+	'After=network-online.target' \
+	'ConditionPathExists=/user-data' \
+	'[Service]' \
+	'Type=oneshot' \
+	'ExecStart=/bin/bash /user-data' \
+	'[Install]' \
+	'WantedBy=multi-user.target' \
+	> /etc/systemd/system/kelda-init.service \
+	&& systemctl enable kelda-init.service ssh.service
+
+STOPSIGNAL SIGRTMIN+3
+CMD ["/sbin/init"]
+`
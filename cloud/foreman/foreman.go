@@ -44,23 +44,41 @@ var statusLock = &sync.Mutex{}
 
 var c = counter.New("Foreman")
 
-// Run checks for updates to the machine table and starts and stops minion threads
-// in response.
-func Run(conn db.Conn, creds connection.Credentials) {
+// Init sets the credentials that the foreman uses to connect to its minions.
+// It must be called before Run.
+func Init(creds connection.Credentials) {
 	credentials = creds
+}
+
+// Run checks for updates to the machines in `namespace` and starts and stops
+// minion threads in response, until `stop` is closed.  Each namespace has its
+// own foreman, so that the minions of one namespace are never configured with
+// the machines or blueprint of another.
+func Run(conn db.Conn, namespace string, stop <-chan struct{}) {
 	// A map from cloud ID to the stop channel for the corresponding minion thread.
 	minionChans := make(map[string]chan struct{})
 
-	for range conn.Trigger(db.MachineTable).C {
+	trigger := conn.Trigger(db.MachineTable)
+	defer trigger.Stop()
+
+	for {
+		select {
+		case <-stop:
+			updateMinions(conn, namespace, nil, minionChans)
+			return
+		case <-trigger.C:
+		}
+
 		machines := conn.SelectFromMachine(func(m db.Machine) bool {
-			return m.PublicIP != "" && m.PrivateIP != "" &&
-				m.CloudID != "" && m.Status != db.Stopping
+			return m.Namespace == namespace && m.PublicIP != "" &&
+				m.PrivateIP != "" && m.CloudID != "" &&
+				m.Status != db.Stopping
 		})
-		updateMinions(conn, machines, minionChans)
+		updateMinions(conn, namespace, machines, minionChans)
 	}
 }
 
-func updateMinions(conn db.Conn, machines []db.Machine,
+func updateMinions(conn db.Conn, namespace string, machines []db.Machine,
 	minionChans map[string]chan struct{}) {
 
 	seen := make(map[string]struct{})
@@ -70,7 +88,7 @@ func updateMinions(conn db.Conn, machines []db.Machine,
 		if !ok {
 			stop := make(chan struct{})
 			minionChans[m.CloudID] = stop
-			go newMinion(conn, m.CloudID, stop)
+			go newMinion(conn, namespace, m.CloudID, stop)
 		}
 
 		seen[m.CloudID] = struct{}{}
@@ -86,10 +104,13 @@ func updateMinions(conn db.Conn, machines []db.Machine,
 
 var newMinion = newMinionImpl
 
-func newMinionImpl(conn db.Conn, cloudID string, stop chan struct{}) {
+func newMinionImpl(conn db.Conn, namespace, cloudID string,
+	stop chan struct{}) {
+
 	// Threads that aren't currently connected to their minion should run more often.
 	frequentTick := time.NewTicker(5 * time.Second)
-	tableTrigger := conn.TriggerTick(60, db.BlueprintTable, db.MachineTable)
+	tableTrigger := conn.TriggerTick(60, db.BlueprintTable, db.MachineTable,
+		db.VolumeTable)
 	defer frequentTick.Stop()
 	defer tableTrigger.Stop()
 
@@ -120,25 +141,32 @@ func newMinionImpl(conn db.Conn, cloudID string, stop chan struct{}) {
 		}
 
 		var currConfig pb.MinionConfig
-		currConfig, connected = runOnce(waitForMachinesCutoff, conn, cloudID)
+		currConfig, connected = runOnce(waitForMachinesCutoff, conn,
+			namespace, cloudID)
 		setMinionStatus(cloudID, currConfig, connected)
 	}
 }
 
-func runOnce(waitForMachinesCutoff time.Time, conn db.Conn, cloudID string) (
-	currConfig pb.MinionConfig, connected bool) {
+func runOnce(waitForMachinesCutoff time.Time, conn db.Conn, namespace,
+	cloudID string) (currConfig pb.MinionConfig, connected bool) {
 	currConfig = pb.MinionConfig{}
 	connected = false
 
 	var blueprint string
 	var machines []db.Machine
-	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
-		bp, _ := view.GetBlueprint()
+	var volumes []db.Volume
+	conn.Txn(db.BlueprintTable, db.MachineTable,
+		db.VolumeTable).Run(func(view db.Database) error {
+		bp, _ := view.GetBlueprint(namespace)
 		blueprint = bp.Blueprint.String()
 
 		machines = view.SelectFromMachine(func(m db.Machine) bool {
-			return m.CloudID != "" && m.PublicIP != "" &&
-				m.PrivateIP != "" && m.Status != db.Stopping
+			return m.Namespace == namespace && m.CloudID != "" &&
+				m.PublicIP != "" && m.PrivateIP != "" &&
+				m.Status != db.Stopping
+		})
+		volumes = view.SelectFromVolume(func(v db.Volume) bool {
+			return v.MachineCloudID == cloudID && v.Device != ""
 		})
 		return nil
 	})
@@ -182,7 +210,7 @@ func runOnce(waitForMachinesCutoff time.Time, conn db.Conn, cloudID string) (
 		return
 	}
 
-	newConfig := makeConfig(machines, minionMachine, blueprint)
+	newConfig := makeConfig(machines, minionMachine, blueprint, volumes)
 	if !reflect.DeepEqual(currConfig, newConfig) {
 		err = cli.setMinion(newConfig)
 		if err != nil {
@@ -205,7 +233,7 @@ func clusterReady(machines []db.Machine) bool {
 }
 
 func makeConfig(machines []db.Machine, minionMachine db.Machine,
-	blueprint string) pb.MinionConfig {
+	blueprint string, volumes []db.Volume) pb.MinionConfig {
 
 	minionIPToPublicKey := map[string]string{}
 	var etcdIPs []string
@@ -219,6 +247,14 @@ func makeConfig(machines []db.Machine, minionMachine db.Machine,
 		}
 	}
 
+	var volumeDevices map[string]string
+	for _, v := range volumes {
+		if volumeDevices == nil {
+			volumeDevices = map[string]string{}
+		}
+		volumeDevices[v.Name] = v.Device
+	}
+
 	return pb.MinionConfig{
 		FloatingIP:          minionMachine.FloatingIP,
 		PrivateIP:           minionMachine.PrivateIP,
@@ -230,6 +266,7 @@ func makeConfig(machines []db.Machine, minionMachine db.Machine,
 		EtcdMembers:         etcdIPs,
 		AuthorizedKeys:      minionMachine.SSHKeys,
 		MinionIPToPublicKey: minionIPToPublicKey,
+		Volumes:             volumeDevices,
 	}
 }
 
@@ -19,7 +19,8 @@ type clients struct {
 
 func TestUpdateMinions(t *testing.T) {
 	newMinionCalls := 0
-	newMinion = func(conn db.Conn, cloudID string, stop chan struct{}) {
+	newMinion = func(conn db.Conn, namespace, cloudID string,
+		stop chan struct{}) {
 		newMinionCalls++
 	}
 	conn := db.New()
@@ -30,7 +31,7 @@ func TestUpdateMinions(t *testing.T) {
 		{PublicIP: "2.2.2.2", CloudID: "ID2"},
 	}
 
-	updateMinions(conn, machines, minionChans)
+	updateMinions(conn, "ns", machines, minionChans)
 	// Give the minions time to be created.
 	for i := 0; i < 20 && newMinionCalls < 2; i++ {
 		time.Sleep(500 * time.Millisecond)
@@ -43,7 +44,7 @@ func TestUpdateMinions(t *testing.T) {
 	// Removed machine.
 	machines = []db.Machine{{PublicIP: "2.2.2.2", CloudID: "ID2"}}
 	expectStop := minionChans["ID1"]
-	updateMinions(conn, machines, minionChans)
+	updateMinions(conn, "ns", machines, minionChans)
 	assert.Equal(t, 2, newMinionCalls)
 	assert.NotContains(t, minionChans, "ID1")
 	assert.Contains(t, minionChans, "ID2")
@@ -53,7 +54,7 @@ func TestUpdateMinions(t *testing.T) {
 	// Create a new thread when a machine is replaced by a machine with the same IP.
 	machines = []db.Machine{{PublicIP: "2.2.2.2", CloudID: "ID22"}}
 	expectStop = minionChans["ID2"]
-	updateMinions(conn, machines, minionChans)
+	updateMinions(conn, "ns", machines, minionChans)
 
 	for i := 0; i < 20 && newMinionCalls < 3; i++ {
 		time.Sleep(500 * time.Millisecond)
@@ -66,14 +67,14 @@ func TestUpdateMinions(t *testing.T) {
 
 	machines = []db.Machine{}
 	expectStop = minionChans["ID22"]
-	updateMinions(conn, machines, minionChans)
+	updateMinions(conn, "ns", machines, minionChans)
 	assert.Equal(t, 3, newMinionCalls)
 	assert.Len(t, minionChans, 0)
 	_, more = <-expectStop
 	assert.False(t, more)
 
 	machines = []db.Machine{{PublicIP: "3.3.3.3", CloudID: "ID3"}}
-	updateMinions(conn, machines, minionChans)
+	updateMinions(conn, "ns", machines, minionChans)
 
 	for i := 0; i < 20 && newMinionCalls < 4; i++ {
 		time.Sleep(500 * time.Millisecond)
@@ -100,7 +101,7 @@ func TestMakeConfig(t *testing.T) {
 	}
 	allMachines := []db.Machine{machine1, machine2}
 
-	config := makeConfig(allMachines, machine1, `{"Namespace":"ns"}`)
+	config := makeConfig(allMachines, machine1, `{"Namespace":"ns"}`, nil)
 	assert.Equal(t, "10.10.10.10", config.PrivateIP)
 	assert.Equal(t, `{"Namespace":"ns"}`, config.Blueprint)
 	assert.Len(t, config.EtcdMembers, 1)
@@ -109,7 +110,7 @@ func TestMakeConfig(t *testing.T) {
 	assert.Contains(t, config.MinionIPToPublicKey, "20.20.20.20")
 	assert.Equal(t, "pubKey", config.MinionIPToPublicKey["20.20.20.20"])
 
-	config = makeConfig(allMachines, machine2, `{"Namespace":"ns"}`)
+	config = makeConfig(allMachines, machine2, `{"Namespace":"ns"}`, nil)
 	assert.Equal(t, "20.20.20.20", config.PrivateIP)
 	assert.Equal(t, `{"Namespace":"ns"}`, config.Blueprint)
 	assert.Len(t, config.EtcdMembers, 1)
@@ -127,7 +128,7 @@ func TestMakeConfig(t *testing.T) {
 
 	allMachines = append(allMachines, machine3)
 
-	config = makeConfig(allMachines, machine1, `{"Namespace":"ns"}`)
+	config = makeConfig(allMachines, machine1, `{"Namespace":"ns"}`, nil)
 	assert.Equal(t, "10.10.10.10", config.PrivateIP)
 	assert.Equal(t, `{"Namespace":"ns"}`, config.Blueprint)
 	assert.Len(t, config.EtcdMembers, 2)
@@ -139,12 +140,17 @@ func TestMakeConfig(t *testing.T) {
 
 	allMachines = []db.Machine{machine1, machine3}
 
-	config = makeConfig(allMachines, machine1, `{"Namespace":"ns"}`)
+	config = makeConfig(allMachines, machine1, `{"Namespace":"ns"}`, nil)
 	assert.Equal(t, "10.10.10.10", config.PrivateIP)
 	assert.Equal(t, `{"Namespace":"ns"}`, config.Blueprint)
 	assert.Len(t, config.EtcdMembers, 1)
 	assert.Contains(t, config.EtcdMembers, "30.30.30.30")
 	assert.Len(t, config.MinionIPToPublicKey, 0)
+	assert.Nil(t, config.Volumes)
+
+	volumes := []db.Volume{{Name: "data", Device: "/dev/xvdf"}}
+	config = makeConfig(allMachines, machine1, `{"Namespace":"ns"}`, volumes)
+	assert.Equal(t, map[string]string{"data": "/dev/xvdf"}, config.Volumes)
 }
 
 func TestClusterReady(t *testing.T) {
@@ -177,7 +183,7 @@ func TestForemanRunOnce(t *testing.T) {
 		"1.1.1.1": pb.MinionConfig_WORKER,
 	})
 
-	config, connected := runOnce(time.Time{}, conn, "ID1")
+	config, connected := runOnce(time.Time{}, conn, "ns", "ID1")
 	assert.False(t, connected)
 	assert.Equal(t, db.Role(db.None), db.PBToRole(config.Role))
 
@@ -188,17 +194,22 @@ func TestForemanRunOnce(t *testing.T) {
 		m.Size = "size1"
 		m.PrivateIP = "10.10.10.10"
 		m.CloudID = "ID1"
+		m.Namespace = "ns"
 		view.Commit(m)
 		return nil
 	})
 
+	// The foremen of other namespaces ignore the machine.
+	_, connected = runOnce(time.Time{}, conn, "other", "ID1")
+	assert.False(t, connected)
+
 	clients.newClientError = true
-	config, connected = runOnce(time.Time{}, conn, "ID1")
+	config, connected = runOnce(time.Time{}, conn, "ns", "ID1")
 	assert.False(t, connected)
 	assert.Equal(t, db.Role(db.None), db.PBToRole(config.Role))
 
 	clients.newClientError = false
-	config, connected = runOnce(time.Time{}, conn, "ID1")
+	config, connected = runOnce(time.Time{}, conn, "ns", "ID1")
 	assert.True(t, connected)
 	assert.Equal(t, db.Role(db.Worker), db.PBToRole(config.Role))
 
@@ -207,7 +218,7 @@ func TestForemanRunOnce(t *testing.T) {
 	assert.Equal(t, "size1", minionConf.Size)
 
 	clients.getMinionError = true
-	config, connected = runOnce(time.Time{}, conn, "ID1")
+	config, connected = runOnce(time.Time{}, conn, "ns", "ID1")
 	assert.False(t, connected)
 	assert.Equal(t, db.Role(db.None), db.PBToRole(config.Role))
 }
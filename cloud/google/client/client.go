@@ -24,6 +24,10 @@ type Client interface {
 	InsertInstance(zone string, instance *compute.Instance) (
 		*compute.Operation, error)
 	DeleteInstance(zone, operation string) (*compute.Operation, error)
+	ListDisks(zone, description string) (*compute.DiskList, error)
+	InsertDisk(zone string, disk *compute.Disk) (*compute.Operation, error)
+	AttachDisk(zone, instance string, disk *compute.AttachedDisk) (
+		*compute.Operation, error)
 	AddAccessConfig(zone, instance, networkInterface string,
 		accessConfig *compute.AccessConfig) (*compute.Operation, error)
 	DeleteAccessConfig(zone, instance, accessConfig,
@@ -117,6 +121,23 @@ func (ci *client) DeleteInstance(zone, instance string) (*compute.Operation,
 	return ci.gce.Instances.Delete(ci.projID, zone, instance).Do()
 }
 
+func (ci *client) ListDisks(zone, desc string) (*compute.DiskList, error) {
+	c.Inc("List Disks")
+	return ci.gce.Disks.List(ci.projID, zone).Filter(descFilter(desc)).Do()
+}
+
+func (ci *client) InsertDisk(zone string, disk *compute.Disk) (
+	*compute.Operation, error) {
+	c.Inc("Insert Disk")
+	return ci.gce.Disks.Insert(ci.projID, zone, disk).Do()
+}
+
+func (ci *client) AttachDisk(zone, instance string, disk *compute.AttachedDisk) (
+	*compute.Operation, error) {
+	c.Inc("Attach Disk")
+	return ci.gce.Instances.AttachDisk(ci.projID, zone, instance, disk).Do()
+}
+
 func (ci *client) AddAccessConfig(zone, instance, networkInterface string,
 	accessConfig *compute.AccessConfig) (*compute.Operation, error) {
 	c.Inc("Add Access Config")
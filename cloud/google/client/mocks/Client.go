@@ -33,6 +33,29 @@ func (_m *Client) AddAccessConfig(zone string, instance string, networkInterface
 	return r0, r1
 }
 
+// AttachDisk provides a mock function with given fields: zone, instance, disk
+func (_m *Client) AttachDisk(zone string, instance string, disk *compute.AttachedDisk) (*compute.Operation, error) {
+	ret := _m.Called(zone, instance, disk)
+
+	var r0 *compute.Operation
+	if rf, ok := ret.Get(0).(func(string, string, *compute.AttachedDisk) *compute.Operation); ok {
+		r0 = rf(zone, instance, disk)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*compute.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, *compute.AttachedDisk) error); ok {
+		r1 = rf(zone, instance, disk)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteAccessConfig provides a mock function with given fields: zone, instance, accessConfig, networkInterface
 func (_m *Client) DeleteAccessConfig(zone string, instance string, accessConfig string, networkInterface string) (*compute.Operation, error) {
 	ret := _m.Called(zone, instance, accessConfig, networkInterface)
@@ -217,6 +240,29 @@ func (_m *Client) GetZoneOperation(zone string, operation string) (*compute.Oper
 	return r0, r1
 }
 
+// InsertDisk provides a mock function with given fields: zone, disk
+func (_m *Client) InsertDisk(zone string, disk *compute.Disk) (*compute.Operation, error) {
+	ret := _m.Called(zone, disk)
+
+	var r0 *compute.Operation
+	if rf, ok := ret.Get(0).(func(string, *compute.Disk) *compute.Operation); ok {
+		r0 = rf(zone, disk)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*compute.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *compute.Disk) error); ok {
+		r1 = rf(zone, disk)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertFirewall provides a mock function with given fields: firewall
 func (_m *Client) InsertFirewall(firewall *compute.Firewall) (*compute.Operation, error) {
 	ret := _m.Called(firewall)
@@ -286,6 +332,29 @@ func (_m *Client) InsertNetwork(network *compute.Network) (*compute.Operation, e
 	return r0, r1
 }
 
+// ListDisks provides a mock function with given fields: zone, description
+func (_m *Client) ListDisks(zone string, description string) (*compute.DiskList, error) {
+	ret := _m.Called(zone, description)
+
+	var r0 *compute.DiskList
+	if rf, ok := ret.Get(0).(func(string, string) *compute.DiskList); ok {
+		r0 = rf(zone, description)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*compute.DiskList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(zone, description)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListFirewalls provides a mock function with given fields: description
 func (_m *Client) ListFirewalls(description string) (*compute.FirewallList, error) {
 	ret := _m.Called(description)
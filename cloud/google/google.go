@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/cloud/acl"
 	"github.com/kelda/kelda/cloud/cfg"
 	"github.com/kelda/kelda/cloud/google/client"
@@ -250,13 +251,25 @@ func (prvdr Provider) instanceConfig(name, size, cloudConfig string,
 }
 
 func (prvdr *Provider) parseACL(fw *compute.Firewall) (gACL, error) {
-	if len(fw.SourceRanges) != 1 || len(fw.Allowed) != 3 {
+	if len(fw.SourceRanges) != 1 ||
+		(len(fw.Allowed) != 1 && len(fw.Allowed) != 3) {
 		return gACL{}, errors.New("malformed firewall")
 	}
 
+	acl := gACL{name: fw.Name}
+	acl.CidrIP = fw.SourceRanges[0]
+
+	// Firewalls for ACLs without a protocol allow TCP, UDP, and ICMP.
+	if len(fw.Allowed) == 1 {
+		acl.Protocol = fw.Allowed[0].IPProtocol
+		if acl.Protocol == blueprint.ICMPProtocol {
+			return acl, nil
+		}
+	}
+
 	var portsStr string
 	for _, allowed := range fw.Allowed {
-		if allowed.IPProtocol == "icmp" {
+		if allowed.IPProtocol == blueprint.ICMPProtocol {
 			continue
 		}
 
@@ -280,9 +293,6 @@ func (prvdr *Provider) parseACL(fw *compute.Firewall) (gACL, error) {
 		ports = append(ports, portInt)
 	}
 
-	acl := gACL{name: fw.Name}
-	acl.CidrIP = fw.SourceRanges[0]
-
 	switch len(ports) {
 	case 1:
 		acl.MinPort, acl.MaxPort = ports[0], ports[0]
@@ -349,6 +359,9 @@ func (prvdr *Provider) planSetACLs(cloudFWs []*compute.Firewall, acls []acl.ACL)
 		ip = strings.Replace(ip, "/", "-", -1)
 		name := fmt.Sprintf("%s-%s-%d-%d", prvdr.network, ip,
 			a.MinPort, a.MaxPort)
+		if a.Protocol != "" {
+			name += "-" + a.Protocol
+		}
 		gacls = append(gacls, gACL{name: name, ACL: a})
 	}
 
@@ -361,20 +374,23 @@ func (prvdr *Provider) planSetACLs(cloudFWs []*compute.Firewall, acls []acl.ACL)
 	for _, a := range adds {
 		acl := a.(gACL)
 		ports := fmt.Sprintf("%d-%d", acl.MinPort, acl.MaxPort)
+
+		var allowed []*compute.FirewallAllowed
+		for _, protocol := range acl.Protocols() {
+			fwAllowed := &compute.FirewallAllowed{IPProtocol: protocol}
+			if protocol != blueprint.ICMPProtocol {
+				fwAllowed.Ports = []string{ports}
+			}
+			allowed = append(allowed, fwAllowed)
+		}
+
 		add = append(add, &compute.Firewall{
 			Name:         acl.name,
 			Network:      prvdr.networkURL(),
 			Description:  prvdr.network,
 			SourceRanges: []string{acl.CidrIP},
-			Allowed: []*compute.FirewallAllowed{{
-				IPProtocol: "tcp",
-				Ports:      []string{ports},
-			}, {
-				IPProtocol: "udp",
-				Ports:      []string{ports},
-			}, {
-				IPProtocol: "icmp",
-			}}})
+			Allowed:      allowed,
+		})
 	}
 
 	return
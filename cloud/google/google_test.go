@@ -3,6 +3,7 @@ package google
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
@@ -204,6 +205,34 @@ func TestParseACL(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, gACL{"name", acl.ACL{CidrIP: "1.2.3.4/32",
 		MinPort: 1, MaxPort: 2}}, gacl)
+
+	// Single protocol
+	gacl, err = gce.parseACL(&compute.Firewall{
+		Name:         "name",
+		SourceRanges: []string{"1.2.3.4/32"},
+		Allowed: []*compute.FirewallAllowed{{
+			IPProtocol: "udp",
+			Ports:      []string{"53"},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, gACL{"name", acl.ACL{CidrIP: "1.2.3.4/32",
+		MinPort: 53, MaxPort: 53, Protocol: "udp"}}, gacl)
+
+	gacl, err = gce.parseACL(&compute.Firewall{
+		Name:         "name",
+		SourceRanges: []string{"1.2.3.4/32"},
+		Allowed:      []*compute.FirewallAllowed{{IPProtocol: "icmp"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, gACL{"name", acl.ACL{CidrIP: "1.2.3.4/32",
+		Protocol: "icmp"}}, gacl)
+
+	_, err = gce.parseACL(&compute.Firewall{
+		SourceRanges: []string{"1.2.3.4/32"},
+		Allowed:      []*compute.FirewallAllowed{{IPProtocol: "tcp"}},
+	})
+	assert.EqualError(t, err, "malformed firewall")
 }
 
 func TestSetACLs(t *testing.T) {
@@ -292,16 +321,31 @@ func TestPlanSetACLs(t *testing.T) {
 			IPProtocol: "tcp",
 			Ports:      []string{"1-2"},
 		}},
+	}, {
+		Name:         "network-5-6-7-8-32-0-0-icmp",
+		SourceRanges: []string{"5.6.7.8/32"},
+		Allowed:      []*compute.FirewallAllowed{{IPProtocol: "icmp"}},
 	}}, []acl.ACL{{
 		CidrIP:  "5.6.7.8/32",
 		MinPort: 1,
 		MaxPort: 2,
+	}, {
+		CidrIP:   "5.6.7.8/32",
+		Protocol: "icmp",
 	}, {
 		CidrIP:  "9.9.9.9/32",
 		MinPort: 3,
 		MaxPort: 4,
+	}, {
+		CidrIP:   "9.9.9.9/32",
+		MinPort:  53,
+		MaxPort:  53,
+		Protocol: "udp",
 	}})
 	assert.Equal(t, []string{"Unparseable", "Delete"}, remove)
+
+	// The firewalls to add aren't in any particular order.
+	sort.Slice(add, func(i, j int) bool { return add[i].Name < add[j].Name })
 	assert.Equal(t, []*compute.Firewall{{
 		Name:         "network-9-9-9-9-32-3-4",
 		Network:      gce.networkURL(),
@@ -316,6 +360,15 @@ func TestPlanSetACLs(t *testing.T) {
 		}, {
 			IPProtocol: "icmp",
 		}},
+	}, {
+		Name:         "network-9-9-9-9-32-53-53-udp",
+		Network:      gce.networkURL(),
+		Description:  gce.network,
+		SourceRanges: []string{"9.9.9.9/32"},
+		Allowed: []*compute.FirewallAllowed{{
+			IPProtocol: "udp",
+			Ports:      []string{"53-53"},
+		}},
 	}}, add)
 }
 
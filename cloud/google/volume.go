@@ -0,0 +1,71 @@
+package google
+
+import (
+	"path"
+	"strings"
+
+	"github.com/kelda/kelda/db"
+
+	compute "google.golang.org/api/compute/v1"
+)
+
+// The path at which Google exposes an attached disk, given its device name.
+const devicePathPrefix = "/dev/disk/by-id/google-"
+
+// ListVolumes returns the persistent disks that belong to the namespace.
+func (prvdr *Provider) ListVolumes() ([]db.Volume, error) {
+	disks, err := prvdr.ListDisks(prvdr.zone, prvdr.network)
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []db.Volume
+	for _, disk := range disks.Items {
+		name := strings.TrimPrefix(disk.Name, prvdr.diskName(""))
+		volume := db.Volume{
+			Name:    name,
+			SizeGiB: int(disk.SizeGb),
+			CloudID: disk.SelfLink,
+		}
+
+		if len(disk.Users) > 0 {
+			volume.MachineCloudID = path.Base(disk.Users[0])
+			volume.Device = devicePathPrefix + name
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+// CreateVolume creates a persistent disk, and blocks until it's ready.
+func (prvdr *Provider) CreateVolume(volume db.Volume) error {
+	op, err := prvdr.InsertDisk(prvdr.zone, &compute.Disk{
+		Name:        prvdr.diskName(volume.Name),
+		Description: prvdr.network,
+		SizeGb:      int64(volume.SizeGiB),
+	})
+	if err != nil {
+		return err
+	}
+	return prvdr.operationWait(op)
+}
+
+// AttachVolume attaches the persistent disk to the instance with its
+// MachineCloudID, and blocks until the disk is attached.
+func (prvdr *Provider) AttachVolume(volume db.Volume) error {
+	op, err := prvdr.AttachDisk(prvdr.zone, volume.MachineCloudID,
+		&compute.AttachedDisk{
+			Source:     volume.CloudID,
+			DeviceName: volume.Name,
+		})
+	if err != nil {
+		return err
+	}
+	return prvdr.operationWait(op)
+}
+
+// Disk names must be unique within a zone, so they're prefixed with the name of
+// the namespace's network.
+func (prvdr *Provider) diskName(volumeName string) string {
+	return prvdr.network + "-" + volumeName
+}
@@ -0,0 +1,66 @@
+package google
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	compute "google.golang.org/api/compute/v1"
+
+	"github.com/kelda/kelda/db"
+)
+
+func TestListVolumes(t *testing.T) {
+	mc, gce := getProvider()
+	mc.On("ListDisks", "zone-1", gce.network).Return(&compute.DiskList{
+		Items: []*compute.Disk{
+			{
+				Name:     "network-attached",
+				SizeGb:   10,
+				SelfLink: "disks/network-attached",
+				Users:    []string{"zones/zone-1/instances/name-1"},
+			},
+			{
+				Name:     "network-detached",
+				SizeGb:   20,
+				SelfLink: "disks/network-detached",
+			},
+		},
+	}, nil)
+
+	volumes, err := gce.ListVolumes()
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Volume{
+		{Name: "attached", SizeGiB: 10, CloudID: "disks/network-attached",
+			MachineCloudID: "name-1",
+			Device:         "/dev/disk/by-id/google-attached"},
+		{Name: "detached", SizeGiB: 20, CloudID: "disks/network-detached"},
+	}, volumes)
+
+	mc, gce = getProvider()
+	mc.On("ListDisks", "zone-1", gce.network).Return(nil, errors.New("err"))
+	_, err = gce.ListVolumes()
+	assert.EqualError(t, err, "err")
+}
+
+func TestCreateAttachVolume(t *testing.T) {
+	mc, gce := getProvider()
+	mc.On("InsertDisk", "zone-1", &compute.Disk{
+		Name:        "network-data",
+		Description: gce.network,
+		SizeGb:      10,
+	}).Return(&compute.Operation{}, nil)
+	mc.On("AttachDisk", "zone-1", "name-1", &compute.AttachedDisk{
+		Source:     "disks/network-data",
+		DeviceName: "data",
+	}).Return(&compute.Operation{}, nil)
+
+	err := gce.CreateVolume(db.Volume{Name: "data", SizeGiB: 10,
+		MachineCloudID: "name-1"})
+	assert.NoError(t, err)
+
+	err = gce.AttachVolume(db.Volume{Name: "data",
+		CloudID: "disks/network-data", MachineCloudID: "name-1"})
+	assert.NoError(t, err)
+	mc.AssertExpectations(t)
+}
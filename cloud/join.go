@@ -1,7 +1,6 @@
 package cloud
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/kelda/kelda/blueprint"
@@ -23,6 +22,9 @@ type joinResult struct {
 	terminate []db.Machine
 	updateIPs []db.Machine
 
+	// The machines' status changes, which are recorded as events.
+	events []db.Event
+
 	// True if there's things going on in this join that warrant frequent polls.
 	isActive bool
 }
@@ -38,22 +40,19 @@ func joinImpl(cld *cloud) (joinResult, error) {
 	machines = getMachineRoles(machines)
 
 	var res joinResult
-	err = cld.conn.Txn(db.BlueprintTable,
-		db.MachineTable).Run(func(view db.Database) error {
-		bp, err := view.GetBlueprint()
+	err = cld.conn.Txn(db.BlueprintTable, db.MachineTable,
+		db.EventTable).Run(func(view db.Database) error {
+		bp, err := view.GetBlueprint(cld.namespace)
 		if err != nil {
 			log.WithError(err).Error("Failed to get blueprint")
 			return err
 		}
 
-		if cld.namespace != bp.Namespace {
-			err := errors.New("namespace change during a cloud run")
-			log.WithError(err).Debug("Cloud run abort")
-			return err
-		}
-
 		cld.syncDBWithCloud(view, machines)
 		res = cld.syncDBWithBlueprint(view)
+		for _, event := range res.events {
+			view.LogEvent(event)
+		}
 
 		// Regions with no machines in them should have their ACLs cleared.
 		if len(machines) > 0 {
@@ -70,9 +69,30 @@ func joinImpl(cld *cloud) (joinResult, error) {
 // syncDBWithCloud updates the machines in the database, based on the ground truth
 // from the cloud provider about which machines are running.
 func (cld *cloud) syncDBWithCloud(view db.Database, cloudMachines []db.Machine) {
-	dbms := cld.selectMachines(view)
+	// Most cloud machines are already in the database under their CloudID, so
+	// they're paired up through the index, and only the rest go through the
+	// full join.
+	var pairs []join.Pair
+	var unpairedCMs []db.Machine
+	paired := map[int]bool{}
+	for _, cm := range cloudMachines {
+		if dbm, ok := cld.machineByCloudID(view, cm); ok {
+			pairs = append(pairs, join.Pair{L: dbm, R: cm})
+			paired[dbm.ID] = true
+		} else {
+			unpairedCMs = append(unpairedCMs, cm)
+		}
+	}
+
+	var dbms []db.Machine
+	for _, dbm := range cld.selectMachines(view) {
+		if !paired[dbm.ID] {
+			dbms = append(dbms, dbm)
+		}
+	}
 
-	pairs, extraDBMs, missingCMs := join.Join(dbms, cloudMachines, machineScore)
+	joined, extraDBMs, missingCMs := join.Join(dbms, unpairedCMs, machineScore)
+	pairs = append(pairs, joined...)
 
 	for _, cm := range missingCMs {
 		pairs = append(pairs, join.Pair{L: view.InsertMachine(), R: cm})
@@ -88,9 +108,12 @@ func (cld *cloud) syncDBWithCloud(view db.Database, cloudMachines []db.Machine)
 
 		// Providers don't know about some fields, so we don't overwrite them.
 		cm.ID = dbm.ID
+		cm.Namespace = cld.namespace
 		cm.Status = dbm.Status
 		cm.SSHKeys = dbm.SSHKeys
 		cm.PublicKey = dbm.PublicKey
+		cm.Group = dbm.Group
+		cm.Drain = dbm.Drain
 		view.Commit(cm)
 	}
 }
@@ -98,25 +121,41 @@ func (cld *cloud) syncDBWithCloud(view db.Database, cloudMachines []db.Machine)
 func (cld *cloud) syncDBWithBlueprint(view db.Database) joinResult {
 	var res joinResult
 
-	bp, err := view.GetBlueprint()
+	bp, err := view.GetBlueprint(cld.namespace)
 	if err != nil {
 		// Already got the blueprint earlier in this transaction.
 		panic(fmt.Sprintf("Unreachable error: %v", err))
 	}
 
-	dbms := cld.selectMachines(view)
+	// Machines that the autoscaler drained are terminated regardless of the
+	// blueprint, which no longer counts them towards their group's size.
+	var dbms, drained []db.Machine
+	for _, dbm := range cld.selectMachines(view) {
+		if dbm.Drain {
+			drained = append(drained, dbm)
+		} else {
+			dbms = append(dbms, dbm)
+		}
+	}
 
-	bpms := cld.desiredMachines(bp.Blueprint.Machines)
-	if len(bpms) > 0 || len(dbms) > 0 {
+	bpms := append(cld.desiredMachines(bp.Blueprint.Machines),
+		cld.desiredGroupMachines(bp)...)
+	if len(bpms) > 0 || len(dbms) > 0 || len(drained) > 0 {
 		res.isActive = true
 	}
 
 	pairs, missingBPMs, extraDBMs := join.Join(bpms, dbms, machineScore)
+	for _, dbm := range drained {
+		extraDBMs = append(extraDBMs, dbm)
+	}
 
 	for _, p := range pairs {
 		bpm := p.L.(db.Machine)
 		dbm := p.R.(db.Machine)
 
+		// Machines that were booted before their group existed join it.
+		dbm.Group = bpm.Group
+
 		// Write the SSH keys into the database machine to ensure that the
 		// database contains the most up to date SSH keys. If we didn't,
 		// changes to the SSH keys in the blueprint would never get synced.
@@ -124,7 +163,8 @@ func (cld *cloud) syncDBWithBlueprint(view db.Database) joinResult {
 		// restarted when machines were already running in the cloud.
 		dbm.SSHKeys = bpm.SSHKeys
 		status := connectionStatus(dbm)
-		if status != "" {
+		if status != "" && status != dbm.Status {
+			res.events = append(res.events, cld.statusEvent(dbm, status))
 			dbm.Status = status
 		}
 		view.Commit(dbm)
@@ -143,6 +183,10 @@ func (cld *cloud) syncDBWithBlueprint(view db.Database) joinResult {
 
 	for _, extraDBM := range extraDBMs {
 		dbm := extraDBM.(db.Machine)
+		if dbm.Status != db.Stopping {
+			res.events = append(res.events,
+				cld.statusEvent(dbm, db.Stopping))
+		}
 		dbm.Status = db.Stopping
 		view.Commit(dbm)
 
@@ -154,6 +198,7 @@ func (cld *cloud) syncDBWithBlueprint(view db.Database) joinResult {
 		dbm := view.InsertMachine()
 		bpm.ID = dbm.ID
 		bpm.Status = db.Booting
+		res.events = append(res.events, cld.statusEvent(dbm, db.Booting))
 
 		res.boot = append(res.boot, bpm)
 
@@ -168,6 +213,21 @@ func (cld *cloud) syncDBWithBlueprint(view db.Database) joinResult {
 	return res
 }
 
+// statusEvent returns an event recording that `dbm` is changing to `status`.
+func (cld *cloud) statusEvent(dbm db.Machine, status string) db.Event {
+	message := status
+	if dbm.Status != "" {
+		message = fmt.Sprintf("%s -> %s", dbm.Status, status)
+	}
+	return db.Event{
+		Namespace:  cld.namespace,
+		Type:       db.MachineStatusEvent,
+		ObjectType: "Machine",
+		ObjectID:   dbm.CloudID,
+		Message:    message,
+	}
+}
+
 func machineScore(left, right interface{}) int {
 	l := left.(db.Machine)
 	r := right.(db.Machine)
@@ -184,6 +244,8 @@ func machineScore(left, right interface{}) int {
 		return -1
 	case l.Role != db.None && r.Role != db.None && l.Role != r.Role:
 		return -1
+	case l.Group != "" && r.Group != "" && l.Group != r.Group:
+		return -1
 	case l.CloudID != "" && r.CloudID != "" && l.CloudID == r.CloudID:
 		return 0
 	}
@@ -200,6 +262,11 @@ func machineScore(left, right interface{}) int {
 	if l.FloatingIP != "" && r.FloatingIP != "" && l.FloatingIP == r.FloatingIP {
 		score--
 	}
+
+	// Machines stay in the group they were booted for.
+	if l.Group != "" && l.Group == r.Group {
+		score--
+	}
 	return score
 }
 
@@ -241,9 +308,16 @@ func (cld *cloud) desiredACLs(bp db.Blueprint) map[acl.ACL]struct{} {
 	for _, conn := range bp.Connections {
 		if str.SliceContains(conn.From, blueprint.PublicInternetLabel) {
 			acl := acl.ACL{
-				CidrIP:  "0.0.0.0/0",
-				MinPort: conn.MinPort,
-				MaxPort: conn.MaxPort,
+				CidrIP:   "0.0.0.0/0",
+				MinPort:  conn.MinPort,
+				MaxPort:  conn.MaxPort,
+				Protocol: conn.Protocol,
+			}
+
+			// ICMP has no ports, so its ACLs are the same regardless of
+			// the range in the blueprint.
+			if conn.Protocol == blueprint.ICMPProtocol {
+				acl.MinPort, acl.MaxPort = 0, 0
 			}
 			aclSet[acl] = struct{}{}
 		}
@@ -252,9 +326,29 @@ func (cld *cloud) desiredACLs(bp db.Blueprint) map[acl.ACL]struct{} {
 	return aclSet
 }
 
+// machineByCloudID returns the machine in the database that `cm`, a machine
+// listed by the cloud provider, would be best joined with, namely the one with
+// its CloudID, if that machine still matches it.
+func (cld *cloud) machineByCloudID(view db.Database, cm db.Machine) (
+	db.Machine, bool) {
+
+	if cm.CloudID == "" {
+		return db.Machine{}, false
+	}
+
+	for _, dbm := range view.SelectFromMachineByCloudID(cm.CloudID) {
+		if dbm.Namespace == cld.namespace && dbm.Provider == cld.providerName &&
+			dbm.Region == cld.region && machineScore(dbm, cm) == 0 {
+			return dbm, true
+		}
+	}
+	return db.Machine{}, false
+}
+
 func (cld *cloud) selectMachines(view db.Database) []db.Machine {
 	return view.SelectFromMachine(func(dbm db.Machine) bool {
-		return dbm.Provider == cld.providerName && dbm.Region == cld.region
+		return dbm.Namespace == cld.namespace &&
+			dbm.Provider == cld.providerName && dbm.Region == cld.region
 	})
 }
 
@@ -19,17 +19,20 @@ func TestJoin(t *testing.T) {
 	cld.provider.(*fakeProvider).listError = nil
 
 	_, err = joinImpl(cld)
-	assert.EqualError(t, err, "no blueprints found")
+	assert.EqualError(t, err, `no blueprint found in namespace "ns"`)
 
+	// Blueprints in other namespaces are ignored.
 	cld.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
-		view.InsertBlueprint()
+		bp := view.InsertBlueprint()
+		bp.Namespace = "other"
+		view.Commit(bp)
 		return nil
 	})
 	_, err = joinImpl(cld)
-	assert.EqualError(t, err, "namespace change during a cloud run")
+	assert.EqualError(t, err, `no blueprint found in namespace "ns"`)
 
 	cld.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
-		bp, _ := view.GetBlueprint()
+		bp := view.InsertBlueprint()
 		bp.Blueprint.Machines = []blueprint.Machine{{
 			Provider: string(FakeAmazon),
 			Region:   testRegion,
@@ -47,12 +50,14 @@ func TestSyncDBWithCloud(t *testing.T) {
 	cld := newTestCloud(FakeAmazon, testRegion, "ns")
 	cld.conn.Txn(db.MachineTable).Run(func(view db.Database) error {
 		m := view.InsertMachine()
+		m.Namespace = "ns"
 		m.Provider = FakeAmazon
 		m.Region = testRegion
 		m.Size = "1"
 		view.Commit(m)
 
 		m = view.InsertMachine()
+		m.Namespace = "ns"
 		m.Provider = FakeAmazon
 		m.Region = testRegion
 		m.Size = "2"
@@ -75,22 +80,75 @@ func TestSyncDBWithCloud(t *testing.T) {
 
 		dbms := scrubID(db.SortMachines(view.SelectFromMachine(nil)))
 		assert.Equal(t, []db.Machine{{
-			Provider: FakeAmazon,
-			Region:   testRegion,
-			PublicIP: "1.2.3.4",
-			Status:   db.Reconnecting,
-			Size:     "2",
+			Namespace: "ns",
+			Provider:  FakeAmazon,
+			Region:    testRegion,
+			PublicIP:  "1.2.3.4",
+			Status:    db.Reconnecting,
+			Size:      "2",
 		}, {
-			Provider: FakeAmazon,
-			Region:   testRegion,
-			PublicIP: "5.6.7.8",
-			Size:     "3",
+			Namespace: "ns",
+			Provider:  FakeAmazon,
+			Region:    testRegion,
+			PublicIP:  "5.6.7.8",
+			Size:      "3",
 		}}, dbms)
 
 		return nil
 	})
 }
 
+func TestSyncDBWithCloudByCloudID(t *testing.T) {
+	cld := newTestCloud(FakeAmazon, testRegion, "ns")
+	cld.conn.Txn(db.MachineTable).Run(func(view db.Database) error {
+		insert := func(namespace, cloudID, size string) db.Machine {
+			m := view.InsertMachine()
+			m.Namespace = namespace
+			m.Provider = FakeAmazon
+			m.Region = testRegion
+			m.CloudID = cloudID
+			m.Size = size
+			m.Status = db.Connected
+			view.Commit(m)
+			return m
+		}
+		a := insert("ns", "a", "1")
+		b := insert("ns", "b", "1")
+		other := insert("other", "a", "1")
+		insert("ns", "c", "1")
+
+		cloudMachines := []db.Machine{
+			{Provider: FakeAmazon, Region: testRegion, CloudID: "b",
+				Size: "1", PublicIP: "2.2.2.2"},
+			{Provider: FakeAmazon, Region: testRegion, CloudID: "a",
+				Size: "1", PublicIP: "1.1.1.1"},
+			{Provider: FakeAmazon, Region: testRegion, CloudID: "c",
+				Size: "2"},
+		}
+		cld.syncDBWithCloud(view, cloudMachines)
+
+		// Machines keep the rows with their CloudIDs, even when the join
+		// could pair them with other rows just as well.
+		byCloudID := func(namespace, cloudID string) []db.Machine {
+			return view.SelectFromMachine(func(m db.Machine) bool {
+				return m.Namespace == namespace && m.CloudID == cloudID
+			})
+		}
+		assert.Equal(t, a.ID, byCloudID("ns", "a")[0].ID)
+		assert.Equal(t, "1.1.1.1", byCloudID("ns", "a")[0].PublicIP)
+		assert.Equal(t, db.Connected, byCloudID("ns", "a")[0].Status)
+		assert.Equal(t, b.ID, byCloudID("ns", "b")[0].ID)
+		assert.Equal(t, []db.Machine{other}, byCloudID("other", "a"))
+
+		// A row whose machine changed is replaced.
+		c := byCloudID("ns", "c")
+		assert.Len(t, c, 1)
+		assert.Equal(t, "2", c[0].Size)
+		assert.Equal(t, "", c[0].Status)
+		return nil
+	})
+}
+
 func TestSyncDBWithBlueprint(t *testing.T) {
 	cld := newTestCloud(FakeAmazon, testRegion, "ns")
 	adminKey = ""
@@ -101,6 +159,7 @@ func TestSyncDBWithBlueprint(t *testing.T) {
 		db.MachineTable).Run(func(view db.Database) error {
 
 		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
 		bp.Blueprint.Machines = []blueprint.Machine{{
 			Provider: string(FakeAmazon),
 			Region:   testRegion,
@@ -115,12 +174,22 @@ func TestSyncDBWithBlueprint(t *testing.T) {
 		view.Commit(bp)
 
 		m := view.InsertMachine()
+		m.Namespace = "ns"
 		m.Provider = FakeAmazon
 		m.Region = testRegion
 		m.Size = "2"
 		view.Commit(m)
 
+		// Machines in other namespaces are ignored.
 		m = view.InsertMachine()
+		m.Namespace = "other"
+		m.Provider = FakeAmazon
+		m.Region = testRegion
+		m.Size = "4"
+		view.Commit(m)
+
+		m = view.InsertMachine()
+		m.Namespace = "ns"
 		m.Provider = FakeAmazon
 		m.Region = testRegion
 		m.Size = "3"
@@ -130,17 +199,20 @@ func TestSyncDBWithBlueprint(t *testing.T) {
 
 		res := cld.syncDBWithBlueprint(view)
 		assert.Equal(t, []db.Machine{{
-			Provider: FakeAmazon,
-			Region:   testRegion,
-			DiskSize: 32,
-			Size:     "1",
-			Status:   db.Booting}}, scrubID(res.boot))
+			Namespace: "ns",
+			Provider:  FakeAmazon,
+			Region:    testRegion,
+			DiskSize:  32,
+			Size:      "1",
+			Status:    db.Booting}}, scrubID(res.boot))
 		assert.Equal(t, []db.Machine{{
-			Provider: FakeAmazon,
-			Region:   testRegion,
-			Size:     "2",
-			Status:   db.Stopping}}, scrubID(res.terminate))
+			Namespace: "ns",
+			Provider:  FakeAmazon,
+			Region:    testRegion,
+			Size:      "2",
+			Status:    db.Stopping}}, scrubID(res.terminate))
 		assert.Equal(t, []db.Machine{{
+			Namespace:  "ns",
 			Provider:   FakeAmazon,
 			Region:     testRegion,
 			Role:       db.Worker,
@@ -149,6 +221,86 @@ func TestSyncDBWithBlueprint(t *testing.T) {
 			FloatingIP: "5.6.7.8",
 			Status:     db.Connected}}, scrubID(res.updateIPs))
 
+		var statuses []string
+		for _, event := range res.events {
+			assert.Equal(t, "ns", event.Namespace)
+			assert.Equal(t, db.MachineStatusEvent, event.Type)
+			statuses = append(statuses, event.Message)
+		}
+		assert.Equal(t, []string{db.Connected, db.Stopping, db.Booting},
+			statuses)
+
+		// Machines whose status doesn't change have no events.
+		res = cld.syncDBWithBlueprint(view)
+		assert.Empty(t, res.events)
+
+		return nil
+	})
+}
+
+func TestSyncDBWithBlueprintGroups(t *testing.T) {
+	cld := newTestCloud(FakeAmazon, testRegion, "ns")
+	adminKey = ""
+
+	isConnected = func(s string) bool { return true }
+
+	cld.conn.Txn(db.BlueprintTable,
+		db.MachineTable).Run(func(view db.Database) error {
+
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		bp.Blueprint.MachineGroups = []blueprint.MachineGroup{{
+			Name: "group",
+			Machine: blueprint.Machine{
+				Provider: string(FakeAmazon),
+				Region:   testRegion,
+				Size:     "1",
+			},
+			Min: 1,
+			Max: 3,
+		}}
+		bp.GroupSizes = map[string]int{"group": 2}
+		view.Commit(bp)
+
+		m := view.InsertMachine()
+		m.Namespace = "ns"
+		m.Provider = FakeAmazon
+		m.Region = testRegion
+		m.Size = "1"
+		m.CloudID = "running"
+		view.Commit(m)
+
+		// Drained machines are terminated, and don't count towards the
+		// group's size.
+		m = view.InsertMachine()
+		m.Namespace = "ns"
+		m.Provider = FakeAmazon
+		m.Region = testRegion
+		m.Size = "1"
+		m.CloudID = "drained"
+		m.Group = "group"
+		m.Drain = true
+		view.Commit(m)
+
+		res := cld.syncDBWithBlueprint(view)
+		assert.Equal(t, []db.Machine{{
+			Namespace: "ns",
+			Provider:  FakeAmazon,
+			Region:    testRegion,
+			Role:      db.Worker,
+			Group:     "group",
+			DiskSize:  32,
+			Size:      "1",
+			Status:    db.Booting}}, scrubID(res.boot))
+		assert.Len(t, res.terminate, 1)
+		assert.Equal(t, "drained", res.terminate[0].CloudID)
+
+		// The machine that was running before the group existed joins it.
+		running := view.SelectFromMachine(func(m db.Machine) bool {
+			return m.CloudID == "running"
+		})
+		assert.Len(t, running, 1)
+		assert.Equal(t, "group", running[0].Group)
 		return nil
 	})
 }
@@ -163,6 +315,7 @@ func TestSyncDBWithBlueprintFloatingIP(t *testing.T) {
 		db.MachineTable).Run(func(view db.Database) error {
 
 		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
 		bp.Blueprint.Machines = []blueprint.Machine{{
 			Role:     db.Master,
 			Provider: string(FakeAmazon),
@@ -178,12 +331,14 @@ func TestSyncDBWithBlueprintFloatingIP(t *testing.T) {
 		// When the machine has not yet connected, don't attempt to update
 		// floating IPs.
 		master := view.InsertMachine()
+		master.Namespace = "ns"
 		master.Provider = FakeAmazon
 		master.Role = db.Master
 		master.Region = testRegion
 		view.Commit(master)
 
 		worker := view.InsertMachine()
+		worker.Namespace = "ns"
 		worker.Provider = FakeAmazon
 		worker.Region = testRegion
 		view.Commit(worker)
@@ -199,6 +354,7 @@ func TestSyncDBWithBlueprintFloatingIP(t *testing.T) {
 		res = cld.syncDBWithBlueprint(view)
 		assert.Subset(t, scrubID(res.updateIPs), []db.Machine{
 			{
+				Namespace:  "ns",
 				Provider:   FakeAmazon,
 				Region:     testRegion,
 				Role:       db.Worker,
@@ -267,6 +423,17 @@ func TestMachineScore(t *testing.T) {
 	m1.Preemptible = true
 	assert.Equal(t, -1, machineScore(m, m1))
 
+	// Group
+	m1 = m
+	m2 = m
+	m1.CloudID = "5"
+	m1.Group = "a"
+	assert.Equal(t, 8, machineScore(m1, m2))
+	m2.Group = "a"
+	assert.Equal(t, 7, machineScore(m1, m2))
+	m2.Group = "b"
+	assert.Equal(t, -1, machineScore(m1, m2))
+
 	// Prefer matching roles over floating IPs. The desired machine is a worker
 	// with a floating IP -- the match with a worker with the wrong IP should
 	// be better than a match with a machine with an unknown role, but the same
@@ -346,6 +513,30 @@ func TestDesiredACLs(t *testing.T) {
 	})
 	exp[acl.ACL{CidrIP: "0.0.0.0/0", MinPort: 1, MaxPort: 2}] = struct{}{}
 	assert.Equal(t, exp, acls)
+
+	// ACLs keep the protocol of their connection, and ICMP ACLs have no ports.
+	acls = cld.desiredACLs(db.Blueprint{
+		Blueprint: blueprint.Blueprint{
+			Connections: []blueprint.Connection{{
+				From:     []string{blueprint.PublicInternetLabel},
+				To:       []string{"bar"},
+				MinPort:  53,
+				MaxPort:  53,
+				Protocol: blueprint.UDPProtocol,
+			}, {
+				From:     []string{blueprint.PublicInternetLabel},
+				To:       []string{"bar"},
+				MinPort:  1,
+				MaxPort:  1,
+				Protocol: blueprint.ICMPProtocol,
+			}},
+		},
+	})
+	delete(exp, acl.ACL{CidrIP: "0.0.0.0/0", MinPort: 1, MaxPort: 2})
+	exp[acl.ACL{CidrIP: "0.0.0.0/0", MinPort: 53, MaxPort: 53,
+		Protocol: "udp"}] = struct{}{}
+	exp[acl.ACL{CidrIP: "0.0.0.0/0", Protocol: "icmp"}] = struct{}{}
+	assert.Equal(t, exp, acls)
 }
 
 // Test that syncDBWithBlueprint properly syncs the SSH key information to the database.
@@ -358,6 +549,7 @@ func TestJoinSSHKeys(t *testing.T) {
 		db.MachineTable).Run(func(view db.Database) error {
 
 		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
 		bp.Blueprint.Machines = []blueprint.Machine{{
 			Provider: string(FakeAmazon),
 			Region:   testRegion,
@@ -367,6 +559,7 @@ func TestJoinSSHKeys(t *testing.T) {
 		view.Commit(bp)
 
 		m := view.InsertMachine()
+		m.Namespace = "ns"
 		m.Provider = FakeAmazon
 		m.Region = testRegion
 		m.Size = "1"
@@ -0,0 +1,74 @@
+// +build ignore
+
+// This program generates the Go machine descriptions from the JSON
+// descriptions used by the JavaScript bindings. It is invoked by `go generate`.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+)
+
+type description struct {
+	Size string
+	CPU  float64
+	RAM  float64
+}
+
+var providers = []struct {
+	file, jsonPath, varName string
+}{
+	{"amazon.go", "../../js/bindings/amazonDescriptions.json", "amazonDescriptions"},
+	{"google.go", "../../js/bindings/googleDescriptions.json", "googleDescriptions"},
+	{"digitalocean.go", "../../js/bindings/digitalOceanDescriptions.json",
+		"digitalOceanDescriptions"},
+}
+
+func main() {
+	for _, p := range providers {
+		if err := generate(p.jsonPath, p.file, p.varName); err != nil {
+			log.Fatalf("failed to generate %s: %s", p.file, err)
+		}
+	}
+}
+
+func generate(jsonPath, goPath, varName string) error {
+	jsonBytes, err := ioutil.ReadFile(jsonPath)
+	if err != nil {
+		return err
+	}
+
+	var parsed struct{ Descriptions []description }
+	if err := json.Unmarshal(jsonBytes, &parsed); err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by gen.go from %s. DO NOT EDIT.\n\n",
+		jsonPath)
+	fmt.Fprintf(buf, "package machine\n\n")
+	fmt.Fprintf(buf, "var %s = []Description{\n", varName)
+
+	// The JSON descriptions repeat each size once per region, but the hardware
+	// of a given size is the same everywhere.
+	seen := map[string]struct{}{}
+	for _, d := range parsed.Descriptions {
+		if _, ok := seen[d.Size]; ok {
+			continue
+		}
+		seen[d.Size] = struct{}{}
+		fmt.Fprintf(buf, "\t{Size: %q, CPU: %v, RAM: %v},\n",
+			d.Size, d.CPU, d.RAM)
+	}
+	fmt.Fprintf(buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(goPath, src, 0644)
+}
@@ -0,0 +1,61 @@
+// Package machine describes the hardware of the machine sizes offered by each
+// cloud provider.
+package machine
+
+//go:generate go run gen.go
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/kelda/kelda/db"
+)
+
+// Description describes the hardware of a single machine size. CPU is measured
+// in cores, and RAM in GiB.
+type Description struct {
+	Size string
+	CPU  float64
+	RAM  float64
+}
+
+// Capacity returns the number of CPU cores and GiB of RAM available on a
+// machine of the given provider and size.  If the size is unknown, `ok` is
+// false.
+func Capacity(provider db.ProviderName, size string) (cpu, ram float64, ok bool) {
+	var descriptions []Description
+	switch provider {
+	case db.Amazon:
+		descriptions = amazonDescriptions
+	case db.Google:
+		descriptions = googleDescriptions
+	case db.DigitalOcean:
+		descriptions = digitalOceanDescriptions
+	case db.Vagrant, db.Docker, db.Static:
+		return vagrantCapacity(size)
+	}
+
+	for _, d := range descriptions {
+		if d.Size == size {
+			return d.CPU, d.RAM, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Vagrant and Docker sizes are of the form "RAM,CPU" as chosen by the
+// JavaScript bindings.  Static sizes are chosen by the inventory, and have no
+// known capacity unless they follow the same form.
+func vagrantCapacity(size string) (cpu, ram float64, ok bool) {
+	fields := strings.Split(size, ",")
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+
+	ram, ramErr := strconv.ParseFloat(fields[0], 64)
+	cpu, cpuErr := strconv.ParseFloat(fields[1], 64)
+	if ramErr != nil || cpuErr != nil {
+		return 0, 0, false
+	}
+	return cpu, ram, true
+}
@@ -0,0 +1,41 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/kelda/kelda/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacity(t *testing.T) {
+	t.Parallel()
+
+	cpu, ram, ok := Capacity(db.Amazon, "m4.large")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, cpu)
+	assert.Equal(t, 8.0, ram)
+
+	cpu, ram, ok = Capacity(db.Google, "n1-standard-4")
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, cpu)
+	assert.Equal(t, 15.0, ram)
+
+	cpu, ram, ok = Capacity(db.Vagrant, "2,1")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, cpu)
+	assert.Equal(t, 2.0, ram)
+
+	cpu, ram, ok = Capacity(db.Docker, "4,2")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, cpu)
+	assert.Equal(t, 4.0, ram)
+
+	_, _, ok = Capacity(db.Vagrant, "foo")
+	assert.False(t, ok)
+
+	_, _, ok = Capacity(db.DigitalOcean, "unknown")
+	assert.False(t, ok)
+
+	_, _, ok = Capacity("", "m4.large")
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package cloud
+
+import (
+	"sort"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/db"
+)
+
+// Plan describes the changes to the cloud that deploying a blueprint would
+// cause, without making them.
+type Plan struct {
+	Boot      []db.Machine
+	Terminate []db.Machine
+	UpdateIPs []db.Machine
+
+	AddACLs    []acl.ACL
+	RemoveACLs []acl.ACL
+}
+
+// PlanDeployment computes the Plan for deploying `bp` into its namespace, based
+// on the machines that the daemon currently knows about.  The database isn't
+// modified.
+func PlanDeployment(conn db.Conn, bp blueprint.Blueprint) Plan {
+	var curr db.Blueprint
+	var currErr error
+	var machines []db.Machine
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		curr, currErr = view.GetBlueprint(bp.Namespace)
+		machines = view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Namespace == bp.Namespace
+		})
+		return nil
+	})
+
+	// Run the same join that the clouds run, but on a scratch copy of the
+	// namespace's machines so that the real database is left untouched.
+	scratch := db.New()
+	var plan Plan
+	scratch.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		// Machine groups keep the sizes that the autoscaler chose for them.
+		dbBlueprint := view.InsertBlueprint()
+		dbBlueprint.Blueprint = bp
+		dbBlueprint.GroupSizes = curr.GroupSizes
+		view.Commit(dbBlueprint)
+
+		for _, m := range machines {
+			m.ID = view.InsertMachine().ID
+			view.Commit(m)
+		}
+
+		for _, p := range db.AllProviders {
+			for _, r := range ValidRegions(p) {
+				cld := cloud{
+					conn:         scratch,
+					namespace:    bp.Namespace,
+					region:       r,
+					providerName: p,
+				}
+				res := cld.syncDBWithBlueprint(view)
+				plan.Boot = append(plan.Boot, res.boot...)
+				plan.Terminate = append(plan.Terminate, res.terminate...)
+				plan.UpdateIPs = append(plan.UpdateIPs, res.updateIPs...)
+			}
+		}
+		return nil
+	})
+
+	// Namespaces without any machines have no ACLs.
+	currACLs := map[acl.ACL]struct{}{}
+	if currErr == nil && len(machines) > 0 {
+		currACLs = (&cloud{}).desiredACLs(curr)
+	}
+
+	newACLs := map[acl.ACL]struct{}{}
+	if len(bp.Machines) > 0 {
+		newACLs = (&cloud{}).desiredACLs(db.Blueprint{Blueprint: bp})
+	}
+
+	plan.AddACLs = aclDifference(newACLs, currACLs)
+	plan.RemoveACLs = aclDifference(currACLs, newACLs)
+	return plan
+}
+
+// aclDifference returns the ACLs in `a` that aren't in `b`, in a consistent
+// order.
+func aclDifference(a, b map[acl.ACL]struct{}) []acl.ACL {
+	var diff []acl.ACL
+	for acl := range a {
+		if _, ok := b[acl]; !ok {
+			diff = append(diff, acl)
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		switch {
+		case diff[i].CidrIP != diff[j].CidrIP:
+			return diff[i].CidrIP < diff[j].CidrIP
+		case diff[i].MinPort != diff[j].MinPort:
+			return diff[i].MinPort < diff[j].MinPort
+		case diff[i].MaxPort != diff[j].MaxPort:
+			return diff[i].MaxPort < diff[j].MaxPort
+		default:
+			return diff[i].Protocol < diff[j].Protocol
+		}
+	})
+	return diff
+}
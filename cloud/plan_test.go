@@ -0,0 +1,87 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanDeployment(t *testing.T) {
+	mock()
+
+	conn := db.New()
+	conn.Txn(db.BlueprintTable, db.MachineTable).Run(func(view db.Database) error {
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		bp.Machines = []blueprint.Machine{
+			{Provider: string(FakeAmazon), Region: testRegion, Size: "1"},
+			{Provider: string(FakeAmazon), Region: testRegion, Size: "2"},
+		}
+		bp.AdminACL = []string{"1.2.3.4/32"}
+		view.Commit(bp)
+
+		for _, size := range []string{"1", "2"} {
+			m := view.InsertMachine()
+			m.Namespace = "ns"
+			m.Provider = FakeAmazon
+			m.Region = testRegion
+			m.Size = size
+			m.CloudID = size
+			view.Commit(m)
+		}
+
+		// Machines in other namespaces aren't affected.
+		m := view.InsertMachine()
+		m.Namespace = "other"
+		m.Provider = FakeAmazon
+		m.Region = testRegion
+		m.Size = "3"
+		view.Commit(m)
+		return nil
+	})
+
+	plan := PlanDeployment(conn, blueprint.Blueprint{
+		Namespace: "ns",
+		Machines: []blueprint.Machine{
+			{Provider: string(FakeAmazon), Region: testRegion, Size: "1"},
+			{Provider: string(FakeAmazon), Region: testRegion, Size: "3"},
+		},
+		AdminACL: []string{"5.6.7.8/32"},
+	})
+
+	assert.Len(t, plan.Boot, 1)
+	assert.Equal(t, "3", plan.Boot[0].Size)
+	assert.Equal(t, "ns", plan.Boot[0].Namespace)
+
+	assert.Len(t, plan.Terminate, 1)
+	assert.Equal(t, "2", plan.Terminate[0].CloudID)
+	assert.Empty(t, plan.UpdateIPs)
+
+	assert.Equal(t, []acl.ACL{{CidrIP: "5.6.7.8/32", MinPort: 1,
+		MaxPort: 65535}}, plan.AddACLs)
+	assert.Equal(t, []acl.ACL{{CidrIP: "1.2.3.4/32", MinPort: 1,
+		MaxPort: 65535}}, plan.RemoveACLs)
+
+	// Planning shouldn't modify the database.
+	assert.Len(t, conn.SelectFromMachine(nil), 3)
+	for _, m := range conn.SelectFromMachine(nil) {
+		assert.NotEqual(t, db.Stopping, m.Status)
+	}
+
+	// Nothing is running in a new namespace, so everything must be booted.
+	plan = PlanDeployment(conn, blueprint.Blueprint{
+		Namespace: "new",
+		Machines: []blueprint.Machine{
+			{Provider: string(FakeAmazon), Region: testRegion, Size: "1"},
+		},
+	})
+	assert.Len(t, plan.Boot, 1)
+	assert.Empty(t, plan.Terminate)
+	assert.Equal(t, []acl.ACL{{CidrIP: "local", MinPort: 1,
+		MaxPort: 65535}}, plan.AddACLs)
+	assert.Empty(t, plan.RemoveACLs)
+}
@@ -0,0 +1,60 @@
+package static
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/kelda/kelda/util"
+)
+
+// runOnHostImpl runs `script` with bash as root on `h`, and returns its combined
+// output.
+func runOnHostImpl(h host, script string) (string, error) {
+	key, err := util.ReadFile(h.KeyPath)
+	if err != nil {
+		return "", fmt.Errorf("read key: %s", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("parse key: %s", err)
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:    h.User,
+		Auth:    []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		Timeout: 5 * time.Second,
+		// XXX: Like the credentials code, we ignore the host key because we
+		// don't keep track of the host keys of machines.
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:22", h.PublicIP), sshConfig)
+	if err != nil {
+		return "", fmt.Errorf("dial: %s", err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("session: %s", err)
+	}
+	defer session.Close()
+
+	session.Stdin = strings.NewReader(script)
+	out, err := session.CombinedOutput(sshCommand(h))
+	return string(out), err
+}
+
+// sshCommand returns the command that runs a script from stdin as root on `h`.
+// The login shell expands $SSH_CLIENT, whose first field is the daemon's IP as
+// the host sees it, before sudo clears the environment.
+func sshCommand(h host) string {
+	cmd := fmt.Sprintf("env %s=${SSH_CLIENT%%%% *} bash -s", daemonIPVar)
+	if h.User != "root" {
+		cmd = "sudo -n " + cmd
+	}
+	return cmd
+}
@@ -0,0 +1,445 @@
+package static
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	cliPath "github.com/kelda/kelda/cli/path"
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/cloud/cfg"
+	"github.com/kelda/kelda/counter"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util"
+)
+
+const (
+	// The file on each host that records which namespace it was booted in.
+	// Hosts without it are free to be booted.
+	namespacePath = "/etc/kelda/namespace"
+
+	// A shell expression for the interface of the host's default route.
+	defaultInterface = "$(ip route show default | awk '{print $5; exit}')"
+
+	// The environment variable in which runOnHost passes the daemon's IP, as
+	// the host sees it, to scripts.
+	daemonIPVar = "KELDA_DAEMON_IP"
+)
+
+// The Provider object represents a pool of pre-existing hosts.  Booting a
+// machine installs Kelda on a free host, and stopping it uninstalls Kelda again.
+type Provider struct {
+	namespace string
+	hosts     []host
+
+	// The ACL script that was last applied to each host, so that SetACLs only
+	// connects to hosts whose rules changed.
+	aclScripts map[string]string
+}
+
+// A host is an entry in the inventory file.
+type host struct {
+	// The address at which the daemon reaches the host.  It's also the host's
+	// CloudID.
+	PublicIP string `json:"publicIP"`
+
+	// The address at which the other hosts reach the host.  It defaults to
+	// the PublicIP.
+	PrivateIP string `json:"privateIP"`
+
+	// The network interface on which the host receives public traffic.  It
+	// defaults to the interface of the host's default route.
+	PublicInterface string `json:"publicInterface"`
+
+	// The user and private key that the daemon logs in with to install Kelda.
+	// The user defaults to root, and must otherwise have passwordless sudo.
+	User    string `json:"user"`
+	KeyPath string `json:"keyPath"`
+
+	// The size that blueprint machines must request to be booted on the host.
+	// Sizes of the form "RAM,CPU" let the scheduler account for the host's
+	// capacity.
+	Size string `json:"size"`
+}
+
+var c = counter.New("Static")
+
+// Allow mocking out for the unit tests.
+var inventoryPath = cliPath.DefaultInventoryPath
+var runOnHost = runOnHostImpl
+
+// New creates a new static provider from the hosts in the inventory file.
+func New(namespace string) (*Provider, error) {
+	hosts, err := readInventory(inventoryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		namespace:  namespace,
+		hosts:      hosts,
+		aclScripts: map[string]string{},
+	}, nil
+}
+
+func readInventory(filename string) ([]host, error) {
+	contents, err := util.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read inventory: %s", err)
+	}
+
+	var hosts []host
+	if err := json.Unmarshal([]byte(contents), &hosts); err != nil {
+		return nil, fmt.Errorf("parse inventory %s: %s", filename, err)
+	}
+
+	seen := map[string]bool{}
+	for i, h := range hosts {
+		switch {
+		case h.PublicIP == "":
+			return nil, fmt.Errorf("inventory host %d has no publicIP", i)
+		case h.KeyPath == "":
+			return nil, fmt.Errorf("inventory host %s has no keyPath",
+				h.PublicIP)
+		case seen[h.PublicIP]:
+			return nil, fmt.Errorf("inventory host %s is listed twice",
+				h.PublicIP)
+		}
+		seen[h.PublicIP] = true
+
+		if hosts[i].PrivateIP == "" {
+			hosts[i].PrivateIP = h.PublicIP
+		}
+		if hosts[i].User == "" {
+			hosts[i].User = "root"
+		}
+	}
+	return hosts, nil
+}
+
+// Boot installs Kelda on a free host of the requested size for each machine in
+// `bootSet`.  It fails without booting anything if there aren't enough free
+// hosts.
+func (prvdr *Provider) Boot(bootSet []db.Machine) ([]string, error) {
+	for _, m := range bootSet {
+		if m.Preemptible {
+			return nil, errors.New(
+				"static provider does not support preemptible instances")
+		}
+	}
+
+	// Hosts that can't be reached aren't in `namespaces`, so they aren't free.
+	namespaces := prvdr.hostNamespaces()
+
+	planned := map[string]string{}
+	for ip, ns := range namespaces {
+		planned[ip] = ns
+	}
+	for _, m := range bootSet {
+		h, ok := prvdr.freeHost(m.Size, planned)
+		if !ok {
+			return nil, noFreeHostError(m.Size)
+		}
+		planned[h.PublicIP] = prvdr.namespace
+	}
+
+	// Other namespaces may claim the free hosts between the listing and the
+	// claims, so machines are only booted on the hosts that were claimed.
+	var hosts []host
+	var claimErr error
+	for _, m := range bootSet {
+		h, err := prvdr.claimFreeHost(m.Size, namespaces)
+		if err != nil {
+			claimErr = err
+			break
+		}
+		hosts = append(hosts, h)
+	}
+
+	// If any of the bootHost() calls fail, errChan will contain exactly one
+	// error for this function to return.
+	errChan := make(chan error, 1)
+
+	var ids []string
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(h host, m db.Machine) {
+			defer wg.Done()
+			if err := prvdr.bootHost(h, m); err != nil {
+				select {
+				case errChan <- err:
+				default:
+				}
+			}
+		}(h, bootSet[i])
+		ids = append(ids, h.PublicIP)
+	}
+	wg.Wait()
+
+	err := claimErr
+	select {
+	case err = <-errChan:
+	default:
+	}
+
+	return ids, err
+}
+
+func (prvdr *Provider) freeHost(size string, namespaces map[string]string) (
+	host, bool) {
+
+	for _, h := range prvdr.hosts {
+		if ns, ok := namespaces[h.PublicIP]; ok && ns == "" && h.Size == size {
+			return h, true
+		}
+	}
+	return host{}, false
+}
+
+func noFreeHostError(size string) error {
+	return fmt.Errorf("no free host of size %q in the inventory", size)
+}
+
+// claimFreeHost claims a free host of the requested size for the namespace.
+// Hosts that another namespace claimed since `namespaces` was read are skipped.
+func (prvdr *Provider) claimFreeHost(size string, namespaces map[string]string) (
+	host, error) {
+
+	for {
+		h, ok := prvdr.freeHost(size, namespaces)
+		if !ok {
+			return host{}, noFreeHostError(size)
+		}
+
+		c.Inc("Claim")
+		out, err := runOnHost(h, claimScript(prvdr.namespace))
+		if err != nil {
+			return host{}, hostError("claim", h, err, out)
+		}
+
+		if strings.TrimSpace(out) == claimedMarker {
+			namespaces[h.PublicIP] = prvdr.namespace
+			return h, nil
+		}
+
+		log.WithField("host", h.PublicIP).Info(
+			"Host was claimed by another namespace")
+		delete(namespaces, h.PublicIP)
+	}
+}
+
+// The output of claimScript when it claims the host.
+const claimedMarker = "claimed"
+
+// claimScript records `namespace` on the host, unless the host is already
+// booted in a namespace.  The noclobber option makes the redirection fail if
+// the namespace file exists, so the check and the write are a single atomic
+// step, and concurrent claims from different namespaces can't both succeed.
+func claimScript(namespace string) string {
+	return strings.Join([]string{
+		fmt.Sprintf("mkdir -p %s", path.Dir(namespacePath)),
+		fmt.Sprintf("if (set -o noclobber; echo %s > %s) 2>/dev/null; then",
+			shellQuote(namespace), namespacePath),
+		"\techo " + claimedMarker,
+		"fi",
+	}, "\n")
+}
+
+// shellQuote quotes `s` as a single shell word.  Nothing is expanded within
+// single quotes, so each single quote in `s` ends the quoted string, adds an
+// escaped quote, and starts a new quoted string.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// bootHost runs the cloud config on `h`, which must already be claimed for the
+// namespace.  The claim comes first so that a host that fails partway through
+// is still listed, and thus eventually stopped.
+func (prvdr *Provider) bootHost(h host, m db.Machine) error {
+	c.Inc("Boot")
+	if out, err := runOnHost(h, cfg.Ubuntu(m, h.PublicInterface)); err != nil {
+		return hostError("boot", h, err, out)
+	}
+	return nil
+}
+
+// List returns the hosts that are booted in the namespace.  Hosts that can't be
+// reached are left out.
+func (prvdr *Provider) List() ([]db.Machine, error) {
+	machines := []db.Machine{}
+	for _, h := range prvdr.bootedHosts() {
+		machines = append(machines, db.Machine{
+			Provider:  db.Static,
+			CloudID:   h.PublicIP,
+			PublicIP:  h.PublicIP,
+			PrivateIP: h.PrivateIP,
+			Size:      h.Size,
+		})
+	}
+	return machines, nil
+}
+
+// bootedHosts returns the hosts that are booted in the namespace.
+func (prvdr *Provider) bootedHosts() []host {
+	namespaces := prvdr.hostNamespaces()
+
+	var booted []host
+	for _, h := range prvdr.hosts {
+		if ns := namespaces[h.PublicIP]; ns != "" && ns == prvdr.namespace {
+			booted = append(booted, h)
+		}
+	}
+	return booted
+}
+
+// hostNamespaces maps the PublicIP of each host that can be reached to the
+// namespace it's booted in, or to the empty string if it's free.
+func (prvdr *Provider) hostNamespaces() map[string]string {
+	c.Inc("List")
+	var lock sync.Mutex
+	namespaces := map[string]string{}
+
+	var wg sync.WaitGroup
+	for _, h := range prvdr.hosts {
+		wg.Add(1)
+		go func(h host) {
+			defer wg.Done()
+			out, err := runOnHost(h, fmt.Sprintf(
+				"cat %s 2>/dev/null || true", namespacePath))
+			if err != nil {
+				log.WithError(err).WithField("host", h.PublicIP).Debug(
+					"Failed to read host namespace")
+				return
+			}
+
+			lock.Lock()
+			namespaces[h.PublicIP] = strings.TrimSpace(out)
+			lock.Unlock()
+		}(h)
+	}
+	wg.Wait()
+	return namespaces
+}
+
+// Stop uninstalls Kelda from the hosts of `machines`, returning them to the
+// pool.  The containers that Kelda ran on the hosts are removed.
+func (prvdr *Provider) Stop(machines []db.Machine) error {
+	for _, m := range machines {
+		h, ok := prvdr.getHost(m.CloudID)
+		if !ok {
+			return fmt.Errorf("unknown host %s", m.CloudID)
+		}
+
+		c.Inc("Stop")
+		if out, err := runOnHost(h, uninstallScript); err != nil {
+			return hostError("stop", h, err, out)
+		}
+		delete(prvdr.aclScripts, h.PublicIP)
+	}
+	return nil
+}
+
+// uninstallScript undoes the cloud config.  Docker is left installed, but the
+// containers that Kelda ran are removed, as are the credentials and SSH keys of
+// the kelda user.  Kelda's containers are found by the labels that the minion's
+// scheduler and supervisor give them, so other containers on the host are left
+// alone.
+var uninstallScript = strings.Join([]string{
+	"systemctl disable --now minion.service ovs.service || true",
+	"docker rm -f minion 2>/dev/null || true",
+	"docker ps -aq --filter label=kelda=scheduler | xargs -r docker rm -f",
+	"docker ps -aq --filter label=containerType=keldaSystemContainer | " +
+		"xargs -r docker rm -f",
+	"rm -f /etc/systemd/system/minion.service /etc/systemd/system/ovs.service",
+	"systemctl daemon-reload",
+	"iptables -S | grep -- '-j KELDA-ACL' | sed 's/^-A/-D/' | " +
+		"xargs -r -L1 iptables",
+	"iptables -F KELDA-ACL 2>/dev/null && iptables -X KELDA-ACL || true",
+	"rm -rf /home/kelda/.kelda /home/kelda/.ssh/authorized_keys /var/lib/etcd",
+	"rm -f " + namespacePath,
+}, "\n")
+
+// SetACLs restricts the traffic that reaches the booted hosts on their public
+// interface to that allowed by `acls`.  The rules are applied with iptables.
+// Traffic between the inventory's hosts is always allowed, as is traffic from the
+// daemon.  The daemon's address is taken from the SSH connection that applies
+// the rules, because the "local" ACL is the daemon's public IP, which differs
+// from its address on the hosts' network if they share one.
+func (prvdr *Provider) SetACLs(acls []acl.ACL) error {
+	trusted := []string{"$" + daemonIPVar + "/32"}
+	for _, h := range prvdr.hosts {
+		trusted = append(trusted, h.PublicIP+"/32")
+		if h.PrivateIP != h.PublicIP {
+			trusted = append(trusted, h.PrivateIP+"/32")
+		}
+	}
+
+	for _, h := range prvdr.bootedHosts() {
+		iface := h.PublicInterface
+		if iface == "" {
+			iface = defaultInterface
+		}
+
+		script := acl.IPTablesScript(iface, trusted, acls)
+		if prvdr.aclScripts[h.PublicIP] == script {
+			continue
+		}
+
+		c.Inc("SetACLs")
+		if out, err := runOnHost(h, script); err != nil {
+			return hostError("set ACLs on", h, err, out)
+		}
+		prvdr.aclScripts[h.PublicIP] = script
+	}
+	return nil
+}
+
+// UpdateFloatingIPs is not supported.
+func (prvdr *Provider) UpdateFloatingIPs([]db.Machine) error {
+	return errors.New("static provider does not support floating IPs")
+}
+
+// ListVolumes returns no volumes, as block volumes aren't supported.
+func (prvdr *Provider) ListVolumes() ([]db.Volume, error) {
+	return nil, nil
+}
+
+// CreateVolume is not supported.
+func (prvdr *Provider) CreateVolume(db.Volume) error {
+	return errors.New("static provider does not support block volumes")
+}
+
+// AttachVolume is not supported.
+func (prvdr *Provider) AttachVolume(db.Volume) error {
+	return errors.New("static provider does not support block volumes")
+}
+
+// Cleanup is a noop, as the hosts outlive the deployment.
+func (prvdr *Provider) Cleanup() error {
+	return nil
+}
+
+func (prvdr *Provider) getHost(publicIP string) (host, bool) {
+	for _, h := range prvdr.hosts {
+		if h.PublicIP == publicIP {
+			return h, true
+		}
+	}
+	return host{}, false
+}
+
+// hostError describes the failure of a script on `h`, including the last line of
+// its output, if there is any.
+func hostError(action string, h host, err error, out string) error {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		return fmt.Errorf("%s %s: %s: %s", action, h.PublicIP, err, last)
+	}
+	return fmt.Errorf("%s %s: %s", action, h.PublicIP, err)
+}
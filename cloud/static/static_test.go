@@ -0,0 +1,291 @@
+package static
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/cloud/acl"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util"
+)
+
+const testInventory = `[
+	{"publicIP": "1.1.1.1", "privateIP": "10.0.0.1", "keyPath": "/key",
+	 "size": "8,4"},
+	{"publicIP": "2.2.2.2", "user": "admin", "keyPath": "/key",
+	 "publicInterface": "eth1", "size": "8,4"},
+	{"publicIP": "3.3.3.3", "keyPath": "/key", "size": "16,8"}
+]`
+
+func TestNew(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+	inventoryPath = "/inventory.json"
+
+	_, err := New("ns")
+	assert.Error(t, err)
+
+	util.WriteFile(inventoryPath, []byte(testInventory), 0644)
+	prvdr, err := New("ns")
+	assert.NoError(t, err)
+	assert.Equal(t, []host{
+		{PublicIP: "1.1.1.1", PrivateIP: "10.0.0.1", User: "root",
+			KeyPath: "/key", Size: "8,4"},
+		{PublicIP: "2.2.2.2", PrivateIP: "2.2.2.2", User: "admin",
+			KeyPath: "/key", PublicInterface: "eth1", Size: "8,4"},
+		{PublicIP: "3.3.3.3", PrivateIP: "3.3.3.3", User: "root",
+			KeyPath: "/key", Size: "16,8"},
+	}, prvdr.hosts)
+
+	duplicate := `[{"publicIP": "1.1.1.1", "keyPath": "/key"},
+		{"publicIP": "1.1.1.1", "keyPath": "/key"}]`
+	tests := []struct {
+		inventory, expErr string
+	}{
+		{`{`, "parse inventory /inventory.json: " +
+			"unexpected end of JSON input"},
+		{`[{"keyPath": "/key"}]`, "inventory host 0 has no publicIP"},
+		{`[{"publicIP": "1.1.1.1"}]`, "inventory host 1.1.1.1 has no keyPath"},
+		{duplicate, "inventory host 1.1.1.1 is listed twice"},
+	}
+	for _, test := range tests {
+		util.WriteFile(inventoryPath, []byte(test.inventory), 0644)
+		_, err = New("ns")
+		assert.EqualError(t, err, test.expErr)
+	}
+}
+
+func TestBootListStop(t *testing.T) {
+	fake := newFakeHosts()
+	prvdr := newTestProvider(t)
+
+	machines, err := prvdr.List()
+	assert.NoError(t, err)
+	assert.Empty(t, machines)
+
+	_, err = prvdr.Boot([]db.Machine{{Size: "8,4", Preemptible: true}})
+	assert.EqualError(t, err,
+		"static provider does not support preemptible instances")
+
+	// Hosts booted in other namespaces, or that can't be reached, aren't free.
+	fake.namespaces["1.1.1.1"] = "other"
+	fake.unreachable["3.3.3.3"] = true
+	_, err = prvdr.Boot([]db.Machine{{Size: "8,4"}, {Size: "8,4"}})
+	assert.EqualError(t, err, `no free host of size "8,4" in the inventory`)
+	_, err = prvdr.Boot([]db.Machine{{Size: "16,8"}})
+	assert.EqualError(t, err, `no free host of size "16,8" in the inventory`)
+	assert.Empty(t, fake.boots)
+
+	ids, err := prvdr.Boot([]db.Machine{{Size: "8,4", Role: db.Worker}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2.2.2.2"}, ids)
+	assert.Contains(t, fake.boots["2.2.2.2"], `--inbound-pub-intf "eth1"`)
+	assert.Contains(t, fake.boots["2.2.2.2"], `--role "Worker"`)
+
+	fake.unreachable["3.3.3.3"] = false
+	delete(fake.namespaces, "1.1.1.1")
+	ids, err = prvdr.Boot([]db.Machine{{Size: "16,8"}, {Size: "8,4"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3.3.3.3", "1.1.1.1"}, ids)
+
+	machines, err = prvdr.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []db.Machine{
+		{Provider: db.Static, CloudID: "1.1.1.1", PublicIP: "1.1.1.1",
+			PrivateIP: "10.0.0.1", Size: "8,4"},
+		{Provider: db.Static, CloudID: "2.2.2.2", PublicIP: "2.2.2.2",
+			PrivateIP: "2.2.2.2", Size: "8,4"},
+		{Provider: db.Static, CloudID: "3.3.3.3", PublicIP: "3.3.3.3",
+			PrivateIP: "3.3.3.3", Size: "16,8"},
+	}, machines)
+
+	assert.NoError(t, prvdr.Stop([]db.Machine{{CloudID: "2.2.2.2"}}))
+	assert.Equal(t, 1, fake.stops["2.2.2.2"])
+	machines, err = prvdr.List()
+	assert.NoError(t, err)
+	assert.Len(t, machines, 2)
+
+	assert.EqualError(t, prvdr.Stop([]db.Machine{{CloudID: "4.4.4.4"}}),
+		"unknown host 4.4.4.4")
+
+	fake.unreachable["1.1.1.1"] = true
+	assert.EqualError(t, prvdr.Stop([]db.Machine{{CloudID: "1.1.1.1"}}),
+		"stop 1.1.1.1: unreachable")
+}
+
+func TestSetACLs(t *testing.T) {
+	fake := newFakeHosts()
+	prvdr := newTestProvider(t)
+
+	fake.namespaces["1.1.1.1"] = "ns"
+	fake.namespaces["2.2.2.2"] = "ns"
+
+	// The daemon is trusted at the address it connects to the hosts from.
+	trusted := []string{"$KELDA_DAEMON_IP/32", "1.1.1.1/32", "10.0.0.1/32",
+		"2.2.2.2/32", "3.3.3.3/32"}
+	acls := []acl.ACL{{CidrIP: "5.5.5.5/32", MinPort: 80, MaxPort: 80}}
+	assert.NoError(t, prvdr.SetACLs(acls))
+	assert.Equal(t, map[string][]string{
+		"1.1.1.1": {acl.IPTablesScript(defaultInterface, trusted, acls)},
+		"2.2.2.2": {acl.IPTablesScript("eth1", trusted, acls)},
+	}, fake.scripts)
+
+	// The rules are only reapplied when they change.
+	assert.NoError(t, prvdr.SetACLs(acls))
+	assert.Len(t, fake.scripts["1.1.1.1"], 1)
+
+	assert.NoError(t, prvdr.SetACLs(nil))
+	assert.Len(t, fake.scripts["1.1.1.1"], 2)
+}
+
+func TestSSHCommand(t *testing.T) {
+	assert.Equal(t, "env KELDA_DAEMON_IP=${SSH_CLIENT%% *} bash -s",
+		sshCommand(host{User: "root"}))
+	assert.Equal(t, "sudo -n env KELDA_DAEMON_IP=${SSH_CLIENT%% *} bash -s",
+		sshCommand(host{User: "admin"}))
+}
+
+func TestBootClaimRace(t *testing.T) {
+	fake := newFakeHosts()
+	prvdr := newTestProvider(t)
+
+	// Another namespace claims 1.1.1.1 after it's listed as free, so the
+	// machine is booted on the next free host instead.
+	fake.stolen["1.1.1.1"] = "other"
+	ids, err := prvdr.Boot([]db.Machine{{Size: "8,4"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2.2.2.2"}, ids)
+	assert.Equal(t, "other", fake.namespaces["1.1.1.1"])
+	assert.Equal(t, "ns", fake.namespaces["2.2.2.2"])
+	assert.NotContains(t, fake.boots, "1.1.1.1")
+
+	// If every free host is taken, only the machines that claimed a host are
+	// booted.
+	delete(fake.namespaces, "2.2.2.2")
+	fake.stolen["2.2.2.2"] = "other"
+	ids, err = prvdr.Boot([]db.Machine{{Size: "16,8"}, {Size: "8,4"}})
+	assert.EqualError(t, err, `no free host of size "8,4" in the inventory`)
+	assert.Equal(t, []string{"3.3.3.3"}, ids)
+	assert.Equal(t, "other", fake.namespaces["2.2.2.2"])
+	assert.Contains(t, fake.boots, "3.3.3.3")
+}
+
+func TestClaimScript(t *testing.T) {
+	assert.Equal(t, `mkdir -p /etc/kelda
+if (set -o noclobber; echo 'ns' > /etc/kelda/namespace) 2>/dev/null; then
+	echo claimed
+fi`, claimScript("ns"))
+}
+
+func TestShellQuote(t *testing.T) {
+	// The shell must echo hostile namespaces verbatim, rather than running
+	// the commands within them.
+	for _, ns := range []string{"ns", "", "$(touch pwned)", "`touch pwned`",
+		"it's", `'; touch pwned; echo '`, `"$HOME"`} {
+		out, err := exec.Command("sh", "-c", "echo "+shellQuote(ns)).Output()
+		assert.NoError(t, err)
+		assert.Equal(t, ns+"\n", string(out))
+	}
+}
+
+func TestUninstallScript(t *testing.T) {
+	// Only the containers that Kelda ran are removed.
+	for _, line := range strings.Split(uninstallScript, "\n") {
+		if strings.HasPrefix(line, "docker ps") {
+			assert.Contains(t, line, "--filter label=")
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	prvdr := Provider{}
+	assert.Error(t, prvdr.UpdateFloatingIPs(nil))
+	assert.Error(t, prvdr.CreateVolume(db.Volume{}))
+	assert.Error(t, prvdr.AttachVolume(db.Volume{}))
+	assert.NoError(t, prvdr.Cleanup())
+
+	volumes, err := prvdr.ListVolumes()
+	assert.NoError(t, err)
+	assert.Empty(t, volumes)
+}
+
+func newTestProvider(t *testing.T) *Provider {
+	util.AppFs = afero.NewMemMapFs()
+	inventoryPath = "/inventory.json"
+	util.WriteFile(inventoryPath, []byte(testInventory), 0644)
+
+	prvdr, err := New("ns")
+	assert.NoError(t, err)
+	return prvdr
+}
+
+// fakeHosts simulates the effect of the provider's scripts on the hosts.
+type fakeHosts struct {
+	sync.Mutex
+
+	namespaces  map[string]string
+	unreachable map[string]bool
+	boots       map[string]string
+	stops       map[string]int
+	scripts     map[string][]string
+
+	// Hosts that another namespace claims after they're listed as free, but
+	// before the provider claims them.
+	stolen map[string]string
+}
+
+func newFakeHosts() *fakeHosts {
+	fake := &fakeHosts{
+		namespaces:  map[string]string{},
+		unreachable: map[string]bool{},
+		boots:       map[string]string{},
+		stops:       map[string]int{},
+		scripts:     map[string][]string{},
+		stolen:      map[string]string{},
+	}
+	runOnHost = fake.run
+	return fake
+}
+
+func (fake *fakeHosts) run(h host, script string) (string, error) {
+	fake.Lock()
+	defer fake.Unlock()
+
+	if fake.unreachable[h.PublicIP] {
+		return "", errors.New("unreachable")
+	}
+
+	switch {
+	case strings.HasPrefix(script, "cat "+namespacePath):
+		return fake.namespaces[h.PublicIP] + "\n", nil
+	case strings.HasPrefix(script, "mkdir -p /etc/kelda\nif (set -o noclobber"):
+		if ns, ok := fake.stolen[h.PublicIP]; ok {
+			fake.namespaces[h.PublicIP] = ns
+		}
+		if fake.namespaces[h.PublicIP] != "" {
+			return "", nil
+		}
+		fake.namespaces[h.PublicIP] = claimedNamespace(script)
+		return claimedMarker + "\n", nil
+	case strings.HasPrefix(script, "#!/bin/bash"):
+		fake.boots[h.PublicIP] = script
+	case script == uninstallScript:
+		delete(fake.namespaces, h.PublicIP)
+		fake.stops[h.PublicIP]++
+	default:
+		fake.scripts[h.PublicIP] = append(fake.scripts[h.PublicIP], script)
+	}
+	return "", nil
+}
+
+// claimedNamespace returns the namespace that `script`, the output of
+// claimScript, writes to the host.
+func claimedNamespace(script string) string {
+	quoted := strings.SplitN(strings.SplitN(script, "echo ", 2)[1], " > ", 2)[0]
+	return strings.Replace(strings.Trim(quoted, "'"), `'\''`, "'", -1)
+}
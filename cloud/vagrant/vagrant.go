@@ -133,6 +133,21 @@ func (prvdr *Provider) UpdateFloatingIPs([]db.Machine) error {
 	return errors.New("vagrant provider does not support floating IPs")
 }
 
+// ListVolumes returns no volumes, as block volumes aren't supported.
+func (prvdr *Provider) ListVolumes() ([]db.Volume, error) {
+	return nil, nil
+}
+
+// CreateVolume is not supported.
+func (prvdr *Provider) CreateVolume(db.Volume) error {
+	return errors.New("vagrant provider does not support block volumes")
+}
+
+// AttachVolume is not supported.
+func (prvdr *Provider) AttachVolume(db.Volume) error {
+	return errors.New("vagrant provider does not support block volumes")
+}
+
 // Cleanup removes unnecessary detritus from this provider.  It's intended to be called
 // when there are no VMs running or expected to be running soon.
 func (prvdr *Provider) Cleanup() error {
@@ -0,0 +1,186 @@
+package cloud
+
+import (
+	"sort"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/join"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// syncVolumes creates the block volumes in the blueprint that belong to this
+// cloud, attaches each of them to a worker, and records where they're attached
+// in the database so that the foreman can inform the minions.  Volumes are
+// never deleted, as doing so would destroy the data they're meant to protect.
+func (cld *cloud) syncVolumes() {
+	var bpVolumes []blueprint.Volume
+	cld.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		if bp, err := view.GetBlueprint(cld.namespace); err == nil {
+			bpVolumes = cld.desiredVolumes(bp.Blueprint.Volumes)
+		}
+		return nil
+	})
+
+	// Avoid querying the provider in the common case that the blueprint has no
+	// block volumes.
+	var cloudVolumes []db.Volume
+	if len(bpVolumes) > 0 {
+		var err error
+		cloudVolumes, err = cld.provider.ListVolumes()
+		if err != nil {
+			log.WithError(err).Warnf("Failed to list volumes in %s.", cld)
+			return
+		}
+	}
+
+	var toCreate, toAttach []db.Volume
+	cld.conn.Txn(db.MachineTable, db.VolumeTable).Run(func(view db.Database) error {
+		volumes := currentVolumes(cld.namespace, bpVolumes, cloudVolumes)
+		cld.syncDBWithVolumes(view, volumes)
+
+		workers := view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Namespace == cld.namespace &&
+				m.Provider == cld.providerName &&
+				m.Region == cld.region && m.Role == db.Worker &&
+				m.CloudID != "" && m.Status != db.Stopping
+		})
+		toCreate, toAttach = assignVolumes(volumes, workers)
+		return nil
+	})
+
+	for _, v := range toCreate {
+		c.Inc("CreateVolume")
+		if err := cld.provider.CreateVolume(v); err != nil {
+			log.WithError(err).WithField("volume", v.Name).Warnf(
+				"Failed to create volume in %s.", cld)
+		}
+	}
+
+	for _, v := range toAttach {
+		c.Inc("AttachVolume")
+		if err := cld.provider.AttachVolume(v); err != nil {
+			log.WithError(err).WithField("volume", v.Name).Warnf(
+				"Failed to attach volume in %s.", cld)
+		}
+	}
+}
+
+// desiredVolumes returns the block volumes in `volumes` that should be created
+// in this cloud.
+func (cld *cloud) desiredVolumes(volumes []blueprint.Volume) (
+	desired []blueprint.Volume) {
+
+	for _, v := range volumes {
+		if v.Type == blueprint.BlockVolume &&
+			v.Provider == string(cld.providerName) && v.Region == cld.region {
+			desired = append(desired, v)
+		}
+	}
+	return desired
+}
+
+// currentVolumes combines the blueprint's specification of each volume with its
+// state according to the cloud provider.
+func currentVolumes(namespace string, bpVolumes []blueprint.Volume,
+	cloudVolumes []db.Volume) []db.Volume {
+
+	cloudVolumeMap := map[string]db.Volume{}
+	for _, cv := range cloudVolumes {
+		cloudVolumeMap[cv.Name] = cv
+	}
+
+	var volumes []db.Volume
+	for _, bpv := range bpVolumes {
+		cv := cloudVolumeMap[bpv.Name]
+		volumes = append(volumes, db.Volume{
+			Name:           bpv.Name,
+			Type:           bpv.Type,
+			SizeGiB:        bpv.SizeGiB,
+			Provider:       db.ProviderName(bpv.Provider),
+			Region:         bpv.Region,
+			Namespace:      namespace,
+			CloudID:        cv.CloudID,
+			MachineCloudID: cv.MachineCloudID,
+			Device:         cv.Device,
+		})
+	}
+	return volumes
+}
+
+func (cld *cloud) syncDBWithVolumes(view db.Database, volumes []db.Volume) {
+	key := func(intf interface{}) interface{} {
+		return intf.(db.Volume).Name
+	}
+
+	dbVolumes := view.SelectFromVolume(func(v db.Volume) bool {
+		return v.Namespace == cld.namespace &&
+			v.Provider == cld.providerName && v.Region == cld.region
+	})
+	pairs, toAdd, toRemove := join.HashJoin(db.VolumeSlice(volumes),
+		db.VolumeSlice(dbVolumes), key, key)
+
+	for _, intf := range toRemove {
+		view.Remove(intf.(db.Volume))
+	}
+
+	for _, intf := range toAdd {
+		pairs = append(pairs, join.Pair{L: intf, R: view.InsertVolume()})
+	}
+
+	for _, pair := range pairs {
+		v := pair.L.(db.Volume)
+		v.ID = pair.R.(db.Volume).ID
+		view.Commit(v)
+	}
+}
+
+// assignVolumes decides which volumes must be created, and which unattached
+// volumes should be attached to which worker.  Volumes stay attached to their
+// machine for as long as it exists so that their containers don't have to
+// move.  Otherwise, they're attached to the worker with the fewest volumes.
+func assignVolumes(volumes []db.Volume, workers []db.Machine) (
+	toCreate, toAttach []db.Volume) {
+
+	if len(workers) == 0 {
+		return nil, nil
+	}
+
+	volumeCounts := map[string]int{}
+	for _, w := range workers {
+		volumeCounts[w.CloudID] = 0
+	}
+	for _, v := range volumes {
+		if _, ok := volumeCounts[v.MachineCloudID]; ok {
+			volumeCounts[v.MachineCloudID]++
+		}
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Name < volumes[j].Name
+	})
+
+	for _, v := range volumes {
+		if v.MachineCloudID != "" {
+			continue
+		}
+
+		var best string
+		for id, count := range volumeCounts {
+			if best == "" || count < volumeCounts[best] ||
+				(count == volumeCounts[best] && id < best) {
+				best = id
+			}
+		}
+		volumeCounts[best]++
+		v.MachineCloudID = best
+
+		if v.CloudID == "" {
+			toCreate = append(toCreate, v)
+		} else {
+			toAttach = append(toAttach, v)
+		}
+	}
+	return toCreate, toAttach
+}
@@ -0,0 +1,118 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+)
+
+func TestSyncVolumes(t *testing.T) {
+	cld := newTestCloud(FakeAmazon, testRegion, "ns")
+	provider := cld.provider.(*fakeProvider)
+
+	cld.conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		bp.Blueprint.Volumes = []blueprint.Volume{
+			{Name: "a", Type: blueprint.BlockVolume, SizeGiB: 10,
+				Provider: string(FakeAmazon), Region: testRegion},
+			{Name: "b", Type: blueprint.BlockVolume, SizeGiB: 20,
+				Provider: string(FakeAmazon), Region: testRegion},
+			{Name: "other", Type: blueprint.BlockVolume,
+				Provider: string(FakeVagrant), Region: testRegion},
+			{Name: "host", Type: blueprint.HostVolume},
+		}
+		view.Commit(bp)
+
+		for _, id := range []string{"w1", "w2"} {
+			m := view.InsertMachine()
+			m.Namespace = "ns"
+			m.Provider = FakeAmazon
+			m.Region = testRegion
+			m.Role = db.Worker
+			m.CloudID = id
+			view.Commit(m)
+		}
+		return nil
+	})
+
+	selectVolumes := func() map[string]db.Volume {
+		volumes := map[string]db.Volume{}
+		for _, v := range cld.conn.SelectFromVolume(nil) {
+			v.ID = 0
+			volumes[v.Name] = v
+		}
+		return volumes
+	}
+
+	// The volumes don't exist yet, so they should be created.
+	cld.syncVolumes()
+	assert.Equal(t, []db.Volume{
+		{Name: "a", Type: blueprint.BlockVolume, SizeGiB: 10,
+			Provider: FakeAmazon, Region: testRegion, Namespace: "ns",
+			MachineCloudID: "w1"},
+		{Name: "b", Type: blueprint.BlockVolume, SizeGiB: 20,
+			Provider: FakeAmazon, Region: testRegion, Namespace: "ns",
+			MachineCloudID: "w2"},
+	}, provider.createdVolumes)
+	assert.Empty(t, provider.attachedVolumes)
+	assert.Len(t, selectVolumes(), 2)
+	provider.clearLogs()
+
+	// Once created, they should be attached to a worker each.
+	cld.syncVolumes()
+	assert.Empty(t, provider.createdVolumes)
+	assert.Len(t, provider.attachedVolumes, 2)
+	provider.clearLogs()
+
+	// The database should reflect where the volumes are attached.
+	cld.syncVolumes()
+	assert.Empty(t, provider.createdVolumes)
+	assert.Empty(t, provider.attachedVolumes)
+
+	volumes := selectVolumes()
+	assert.Equal(t, "w1", volumes["a"].MachineCloudID)
+	assert.Equal(t, "/dev/a", volumes["a"].Device)
+	assert.Equal(t, "w2", volumes["b"].MachineCloudID)
+	assert.Equal(t, "/dev/b", volumes["b"].Device)
+
+	// Removing a volume from the blueprint removes it from the database, but
+	// not from the cloud.
+	cld.conn.Txn(db.BlueprintTable).Run(func(view db.Database) error {
+		bp, _ := view.GetBlueprint("ns")
+		bp.Blueprint.Volumes = bp.Blueprint.Volumes[1:]
+		view.Commit(bp)
+		return nil
+	})
+	cld.syncVolumes()
+	assert.Len(t, selectVolumes(), 1)
+	assert.Len(t, provider.volumes, 2)
+}
+
+func TestAssignVolumes(t *testing.T) {
+	t.Parallel()
+
+	workers := []db.Machine{{CloudID: "w1"}, {CloudID: "w2"}}
+	volumes := []db.Volume{
+		{Name: "a", CloudID: "vol-a", MachineCloudID: "w1"},
+		{Name: "b", CloudID: "vol-b", MachineCloudID: "gone"},
+		{Name: "c", CloudID: "vol-c"},
+		{Name: "d"},
+	}
+
+	// Volumes that are attached stay put, and the rest are spread across the
+	// workers.
+	toCreate, toAttach := assignVolumes(volumes, workers)
+	assert.Equal(t, []db.Volume{{Name: "d", MachineCloudID: "w1"}}, toCreate)
+	assert.Equal(t, []db.Volume{
+		{Name: "c", CloudID: "vol-c", MachineCloudID: "w2"},
+	}, toAttach)
+
+	// Without any workers, there's nowhere to put the volumes.
+	toCreate, toAttach = assignVolumes(volumes, nil)
+	assert.Empty(t, toCreate)
+	assert.Empty(t, toAttach)
+}
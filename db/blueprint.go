@@ -1,8 +1,10 @@
 package db
 
 import (
-	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"time"
 
 	"github.com/kelda/kelda/blueprint"
 )
@@ -12,6 +14,16 @@ type Blueprint struct {
 	ID int
 
 	blueprint.Blueprint `rowStringer:"omit"`
+
+	// Previous is the last blueprint deployed with different containers, which
+	// aborted rolling updates revert to.
+	Previous blueprint.Blueprint `rowStringer:"omit"`
+
+	// The number of machines that the autoscaler wants in each machine group,
+	// and when it last resized each group.  Groups that haven't been resized
+	// have their minimum size.
+	GroupSizes   map[string]int       `rowStringer:"omit"`
+	GroupsScaled map[string]time.Time `rowStringer:"omit"`
 }
 
 // InsertBlueprint creates a new Blueprint and interts it into 'db'.
@@ -42,37 +54,56 @@ func (conn Conn) SelectFromBlueprint(check func(Blueprint) bool) []Blueprint {
 	return blueprints
 }
 
-// GetBlueprint gets the blueprint from the database. There should only ever be a single
-// blueprint.
-func (db Database) GetBlueprint() (Blueprint, error) {
-	blueprints := db.SelectFromBlueprint(nil)
+// GetBlueprint gets the blueprint deployed to `namespace`.  There should only
+// ever be a single blueprint per namespace.
+func (db Database) GetBlueprint(namespace string) (Blueprint, error) {
+	blueprints := db.SelectFromBlueprint(func(bp Blueprint) bool {
+		return bp.Namespace == namespace
+	})
 	numBlueprints := len(blueprints)
 	if numBlueprints == 1 {
 		return blueprints[0], nil
 	} else if numBlueprints > 1 {
-		log.Panicf("Found %d blueprints, there should be 1", numBlueprints)
+		log.Panicf("Found %d blueprints in namespace %s, there should be 1",
+			numBlueprints, namespace)
 	}
-	return Blueprint{}, errors.New("no blueprints found")
+	return Blueprint{}, fmt.Errorf("no blueprint found in namespace %q",
+		namespace)
 }
 
-// GetBlueprintNamespace returns the namespace of the single blueprint object in the
-// blueprint table.  Otherwise it returns an error.
-func (db Database) GetBlueprintNamespace() (string, error) {
-	clst, err := db.GetBlueprint()
-	if err != nil {
-		return "", err
+// GetBlueprintNamespaces returns the namespaces of the blueprints in the
+// blueprint table, in sorted order.
+func (db Database) GetBlueprintNamespaces() []string {
+	var namespaces []string
+	for _, bp := range db.SelectFromBlueprint(nil) {
+		namespaces = append(namespaces, bp.Namespace)
 	}
-	return clst.Namespace, nil
+	sort.Strings(namespaces)
+	return namespaces
 }
 
-// GetBlueprintNamespace returns the namespace of the single blueprint object in the
-// blueprint table.  Otherwise it returns an error.
-func (conn Conn) GetBlueprintNamespace() (namespace string, err error) {
+// GetBlueprintNamespaces returns the namespaces of the blueprints in the
+// blueprint table, in sorted order.
+func (conn Conn) GetBlueprintNamespaces() (namespaces []string) {
 	conn.Txn(BlueprintTable).Run(func(db Database) error {
-		namespace, err = db.GetBlueprintNamespace()
+		namespaces = db.GetBlueprintNamespaces()
 		return nil
 	})
-	return
+	return namespaces
+}
+
+// GroupSize returns the number of machines that should be running in `group`,
+// which is the size chosen by the autoscaler, within the group's bounds.
+func (b Blueprint) GroupSize(group blueprint.MachineGroup) int {
+	size, ok := b.GroupSizes[group.Name]
+	switch {
+	case !ok || size < group.Min:
+		return group.Min
+	case size > group.Max:
+		return group.Max
+	default:
+		return size
+	}
 }
 
 func (b Blueprint) getID() int {
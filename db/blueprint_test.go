@@ -9,22 +9,29 @@ import (
 func TestBlueprint(t *testing.T) {
 	conn := New()
 
-	ns, err := conn.GetBlueprintNamespace()
-	assert.NotNil(t, err)
-	assert.Exactly(t, ns, "")
+	assert.Empty(t, conn.GetBlueprintNamespaces())
 
 	conn.Txn(AllTables...).Run(func(view Database) error {
-		bp := view.InsertBlueprint()
-		bp.Namespace = "test"
-		view.Commit(bp)
+		_, err := view.GetBlueprint("test")
+		assert.EqualError(t, err, `no blueprint found in namespace "test"`)
+
+		for _, ns := range []string{"test", "prod"} {
+			bp := view.InsertBlueprint()
+			bp.Namespace = ns
+			view.Commit(bp)
+		}
+
+		bp, err := view.GetBlueprint("test")
+		assert.NoError(t, err)
+		assert.Equal(t, "test", bp.Namespace)
 		return nil
 	})
 
-	ns, err = conn.GetBlueprintNamespace()
-	assert.NoError(t, err)
-	assert.Exactly(t, ns, "test")
+	assert.Equal(t, []string{"prod", "test"}, conn.GetBlueprintNamespaces())
 
-	bps := conn.SelectFromBlueprint(nil)
+	bps := conn.SelectFromBlueprint(func(bp Blueprint) bool {
+		return bp.Namespace == "test"
+	})
 	assert.Len(t, bps, 1)
 
 	assert.Equal(t, BlueprintTable, bps[0].tt())
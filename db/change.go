@@ -0,0 +1,165 @@
+package db
+
+import (
+	"sort"
+	"sync"
+)
+
+// A ChangeSet holds the IDs of the rows of a table that were inserted, modified,
+// or removed since its changes were last read.  A row that's inserted and then
+// modified is only reported as inserted, and one that's inserted and then
+// removed isn't reported at all.  The IDs are sorted.
+type ChangeSet struct {
+	// Reset is set when the table should be read in full, either because the
+	// trigger was just created, or because its tick fired.  The IDs are empty
+	// when it's set.
+	Reset bool
+
+	Inserted []int
+	Modified []int
+	Removed  []int
+}
+
+// Empty returns whether the table is neither reset nor changed.
+func (cs ChangeSet) Empty() bool {
+	return !cs.Reset && len(cs.Inserted) == 0 && len(cs.Modified) == 0 &&
+		len(cs.Removed) == 0
+}
+
+// IDs returns the set of IDs of every changed row.
+func (cs ChangeSet) IDs() map[int]bool {
+	ids := map[int]bool{}
+	for _, slc := range [][]int{cs.Inserted, cs.Modified, cs.Removed} {
+		for _, id := range slc {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// A ChangeTrigger is a Trigger that also records which rows of its tables
+// changed, so that consumers can process the changes instead of re-reading the
+// tables on every notification.
+type ChangeTrigger struct {
+	Trigger
+}
+
+// ChangeTrigger registers a trigger, like Trigger(), that also records the rows
+// of 'tt' that are inserted, modified, or removed.
+func (cn Conn) ChangeTrigger(tt ...TableType) ChangeTrigger {
+	changes := &changeLog{tables: map[TableType]*pendingChanges{}}
+	for _, t := range tt {
+		changes.tables[t] = &pendingChanges{reset: true}
+	}
+
+	return ChangeTrigger{cn.registerTrigger(Trigger{
+		C:       make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		changes: changes,
+	}, tt)}
+}
+
+// ChangeTriggerTick creates a change trigger that additionally ticks once every
+// N 'seconds'.  Each tick resets every table, so that consumers periodically
+// resynchronize in full.
+func (cn Conn) ChangeTriggerTick(seconds int, tt ...TableType) ChangeTrigger {
+	trigger := cn.ChangeTrigger(tt...)
+	go trigger.tick(seconds, trigger.changes.reset)
+	return trigger
+}
+
+// Changes returns, and clears, the changes to each of the trigger's tables since
+// Changes was last called.  The first call resets every table, since none of
+// their rows have been read yet.
+func (t ChangeTrigger) Changes() map[TableType]ChangeSet {
+	t.changes.Lock()
+	defer t.changes.Unlock()
+
+	res := map[TableType]ChangeSet{}
+	for tt, pending := range t.changes.tables {
+		res[tt] = pending.changeSet()
+		t.changes.tables[tt] = &pendingChanges{}
+	}
+	return res
+}
+
+type changeKind int
+
+const (
+	rowInserted changeKind = iota
+	rowModified
+	rowRemoved
+)
+
+// A changeLog accumulates the changes to a ChangeTrigger's tables until they're
+// read.  It has its own lock because it's read without locking the tables.
+type changeLog struct {
+	sync.Mutex
+	tables map[TableType]*pendingChanges
+}
+
+type pendingChanges struct {
+	reset                       bool
+	inserted, modified, removed map[int]struct{}
+}
+
+func (cl *changeLog) record(tt TableType, id int, kind changeKind) {
+	cl.Lock()
+	defer cl.Unlock()
+
+	pending := cl.tables[tt]
+	if pending == nil || pending.reset {
+		return
+	}
+
+	if pending.inserted == nil {
+		pending.inserted = map[int]struct{}{}
+		pending.modified = map[int]struct{}{}
+		pending.removed = map[int]struct{}{}
+	}
+
+	_, inserted := pending.inserted[id]
+	switch kind {
+	case rowInserted:
+		pending.inserted[id] = struct{}{}
+	case rowModified:
+		if !inserted {
+			pending.modified[id] = struct{}{}
+		}
+	case rowRemoved:
+		delete(pending.inserted, id)
+		delete(pending.modified, id)
+		if !inserted {
+			pending.removed[id] = struct{}{}
+		}
+	}
+}
+
+func (cl *changeLog) reset() {
+	cl.Lock()
+	defer cl.Unlock()
+
+	for tt := range cl.tables {
+		cl.tables[tt] = &pendingChanges{reset: true}
+	}
+}
+
+func (pending pendingChanges) changeSet() ChangeSet {
+	if pending.reset {
+		return ChangeSet{Reset: true}
+	}
+	return ChangeSet{
+		Inserted: sortedIDs(pending.inserted),
+		Modified: sortedIDs(pending.modified),
+		Removed:  sortedIDs(pending.removed),
+	}
+}
+
+func sortedIDs(set map[int]struct{}) []int {
+	var ids []int
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeTrigger(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+	trigger := conn.ChangeTrigger(MachineTable, ContainerTable)
+	defer trigger.Stop()
+
+	// The tables haven't been read yet, so the first changes are resets.
+	triggerRecv(t, trigger.Trigger)
+	assert.Equal(t, map[TableType]ChangeSet{
+		MachineTable:   {Reset: true},
+		ContainerTable: {Reset: true},
+	}, trigger.Changes())
+	assert.Equal(t, map[TableType]ChangeSet{
+		MachineTable:   {},
+		ContainerTable: {},
+	}, trigger.Changes())
+
+	var a, b, c Machine
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		a = view.InsertMachine()
+		b = view.InsertMachine()
+		c = view.InsertMachine()
+		return nil
+	})
+	triggerRecv(t, trigger.Trigger)
+	changes := trigger.Changes()
+	assert.Equal(t, ChangeSet{Inserted: []int{a.ID, b.ID, c.ID}},
+		changes[MachineTable])
+	assert.True(t, changes[ContainerTable].Empty())
+
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		// Committing an unchanged row isn't a change.
+		view.Commit(a)
+
+		b.Role = Master
+		view.Commit(b)
+		view.Remove(c)
+
+		// Rows inserted since the last read are only reported as
+		// inserted, or not at all if they were removed.
+		d := view.InsertMachine()
+		d.Role = Worker
+		view.Commit(d)
+		c = d
+
+		view.Remove(view.InsertMachine())
+		return nil
+	})
+	assert.Equal(t, ChangeSet{
+		Inserted: []int{c.ID},
+		Modified: []int{b.ID},
+		Removed:  []int{c.ID - 1},
+	}, trigger.Changes()[MachineTable])
+
+	// Removing a modified row only reports the removal.
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		b.Role = Worker
+		view.Commit(b)
+		view.Remove(b)
+		return nil
+	})
+	assert.Equal(t, ChangeSet{Removed: []int{b.ID}},
+		trigger.Changes()[MachineTable])
+
+	// Plain triggers don't record changes.
+	plain := conn.Trigger(MachineTable)
+	defer plain.Stop()
+	assert.Nil(t, plain.changes)
+}
+
+func TestChangeTriggerTick(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+	trigger := conn.ChangeTriggerTick(1, MachineTable)
+	defer trigger.Stop()
+
+	triggerRecv(t, trigger.Trigger)
+	trigger.Changes()
+
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		view.InsertMachine()
+		return nil
+	})
+	triggerRecv(t, trigger.Trigger)
+
+	// Each tick resets the tables.
+	triggerRecv(t, trigger.Trigger)
+	assert.Equal(t, map[TableType]ChangeSet{MachineTable: {Reset: true}},
+		trigger.Changes())
+}
+
+func TestChangeSet(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, ChangeSet{}.Empty())
+	assert.False(t, ChangeSet{Reset: true}.Empty())
+	assert.False(t, ChangeSet{Removed: []int{1}}.Empty())
+
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, ChangeSet{
+		Inserted: []int{1},
+		Modified: []int{2},
+		Removed:  []int{3},
+	}.IDs())
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/util/str"
 )
 
@@ -15,6 +16,14 @@ type Connection struct {
 	To      []string
 	MinPort int
 	MaxPort int
+
+	// The IP protocol allowed by the connection.  Empty allows TCP and UDP
+	// on the port range, as well as ICMP.
+	Protocol string `json:",omitempty"`
+
+	// The port that public traffic to MinPort is forwarded to, or zero if
+	// public traffic isn't remapped.
+	ContainerPort int `json:",omitempty"`
 }
 
 // InsertConnection creates a new connection row and inserts it into the database.
@@ -56,10 +65,34 @@ func (c Connection) String() string {
 	if c.MaxPort != c.MinPort {
 		port += fmt.Sprintf("-%d", c.MaxPort)
 	}
+	if c.ContainerPort != 0 && c.ContainerPort != c.MinPort {
+		port += fmt.Sprintf("->%d", c.ContainerPort)
+	}
+	if c.Protocol != "" {
+		port += "/" + c.Protocol
+	}
 
 	return fmt.Sprintf("Connection-%d{%s->%s:%s}", c.ID, c.From, c.To, port)
 }
 
+// ContainerPorts returns the range of ports on the containers in `To` that public
+// traffic to [MinPort, MaxPort] is forwarded to.
+func (c Connection) ContainerPorts() (int, int) {
+	if c.ContainerPort == 0 {
+		return c.MinPort, c.MaxPort
+	}
+	return c.ContainerPort, c.ContainerPort + c.MaxPort - c.MinPort
+}
+
+// Protocols returns the IP protocols that the connection allows.
+func (c Connection) Protocols() []string {
+	if c.Protocol == "" {
+		return []string{blueprint.TCPProtocol, blueprint.UDPProtocol,
+			blueprint.ICMPProtocol}
+	}
+	return []string{c.Protocol}
+}
+
 func (c Connection) less(r row) bool {
 	o := r.(Connection)
 
@@ -74,6 +107,8 @@ func (c Connection) less(r row) bool {
 		return c.MaxPort < o.MaxPort
 	case c.MinPort != o.MinPort:
 		return c.MinPort < o.MinPort
+	case c.Protocol != o.Protocol:
+		return c.Protocol < o.Protocol
 	default:
 		return c.ID < o.ID
 	}
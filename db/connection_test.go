@@ -32,6 +32,22 @@ func TestConnection(t *testing.T) {
 	assert.Equal(t, "Connection-1{[foo]->[]:0-3}", connection.String())
 	connection.MaxPort = 0
 
+	connection.Protocol = "udp"
+	assert.Equal(t, "Connection-1{[foo]->[]:0/udp}", connection.String())
+	connection.Protocol = ""
+
+	connection.MinPort, connection.MaxPort = 80, 81
+	min, max := connection.ContainerPorts()
+	assert.Equal(t, 80, min)
+	assert.Equal(t, 81, max)
+
+	connection.ContainerPort = 8080
+	min, max = connection.ContainerPorts()
+	assert.Equal(t, 8080, min)
+	assert.Equal(t, 8081, max)
+	assert.Equal(t, "Connection-1{[foo]->[]:80-81->8080}", connection.String())
+	connection.MinPort, connection.MaxPort, connection.ContainerPort = 0, 0, 0
+
 	assert.Equal(t, connection, connections.Get(0))
 
 	assert.True(t, connection.less(Connection{From: []string{"z"}}))
@@ -65,6 +65,12 @@ const (
 
 	// Vagrant implements local virtual machines.
 	Vagrant ProviderName = "Vagrant"
+
+	// Docker implements machines as local Docker containers.
+	Docker ProviderName = "Docker"
+
+	// Static implements pre-existing hosts listed in an inventory file.
+	Static ProviderName = "Static"
 )
 
 // AllProviders lists all of the providers that Kelda supports.
@@ -73,6 +79,8 @@ var AllProviders = []ProviderName{
 	Google,
 	DigitalOcean,
 	Vagrant,
+	Docker,
+	Static,
 }
 
 // ParseRole returns the Role represented by the string 'role', or an error.
@@ -21,26 +21,123 @@ type Container struct {
 	BlueprintID       string                              `json:",omitempty"`
 	DockerID          string                              `json:",omitempty"`
 	Status            string                              `json:",omitempty"`
-	Command           []string                            `json:",omitempty"`
+	Command           []blueprint.ContainerValue          `json:",omitempty"`
 	Env               map[string]blueprint.ContainerValue `json:",omitempty"`
 	FilepathToContent map[string]blueprint.ContainerValue `json:",omitempty"`
 	Hostname          string                              `json:",omitempty"`
 	Created           time.Time                           `json:","`
 
+	CPURequest float64 `json:",omitempty"`
+	CPULimit   float64 `json:",omitempty"`
+	RAMRequest float64 `json:",omitempty"`
+	RAMLimit   float64 `json:",omitempty"`
+
+	VolumeMounts []blueprint.VolumeMount `json:",omitempty"`
+
+	// Health is the result of the HealthCheck as last reported by the worker
+	// running the container, or empty if it hasn't been checked yet.
+	HealthCheck *blueprint.HealthCheck `json:",omitempty"`
+	Health      string                 `json:",omitempty"`
+
+	// The worker restarts the container according to its RestartPolicy, and
+	// records how many times it has done so, along with how the container
+	// last exited.
+	RestartPolicy string `json:",omitempty"`
+	RestartCount  int    `json:",omitempty"`
+	ExitCode      int    `json:",omitempty"`
+	OOMKilled     bool   `json:",omitempty"`
+
+	// Job is the BlueprintID of the job that booted the container, if any.
+	// The worker retries a job's container at most RetryLimit times.
+	Job        string `json:",omitempty"`
+	RetryLimit int    `json:",omitempty"`
+
+	// DependsOn lists the hostnames of the containers that must be ready
+	// before the leader schedules this one.
+	DependsOn []string `json:",omitempty"`
+
+	// Unschedulable is why the leader couldn't place the container, or empty
+	// if it hasn't failed to.  The daemon's autoscaler boots machines for
+	// unschedulable containers.
+	Unschedulable string `json:",omitempty"`
+
 	Image      string `json:",omitempty"`
 	ImageID    string `json:",omitempty"`
 	Dockerfile string `json:"-"`
 }
 
-// GetReferencedSecrets returns the names of all Secrets referenced in the Env
-// and FilepathToContent maps.
-func (c Container) GetReferencedSecrets() []string {
-	return append(getReferencedSecrets(c.Env),
-		getReferencedSecrets(c.FilepathToContent)...)
+const (
+	// ContainerHealthy is the Health of a container passing its health check.
+	ContainerHealthy = "healthy"
+
+	// ContainerUnhealthy is the Health of a container failing its health
+	// check.
+	ContainerUnhealthy = "unhealthy"
+)
+
+// ContainerRunning is the Status of a container that is running.
+const ContainerRunning = "running"
+
+// ContainerExited is the Status of a container that has exited, and won't be
+// restarted.
+const ContainerExited = "exited"
+
+// Healthy returns whether the container should receive traffic.  Containers
+// without a health check are always healthy, while those with one are only
+// healthy once they've passed it.
+func (c Container) Healthy() bool {
+	return c.HealthCheck == nil || c.Health == ContainerHealthy
+}
+
+// Ready returns whether the container is running and healthy.
+func (c Container) Ready() bool {
+	return c.Status == ContainerRunning && c.Healthy()
+}
+
+// ReadyHostnames returns the hostnames that dependent containers may boot
+// after.  A hostname is ready once one of its containers is ready, or, for
+// jobs, once one of its runs has succeeded.
+func ReadyHostnames(containers []Container, jobs []Job) map[string]bool {
+	ready := map[string]bool{}
+	for _, c := range containers {
+		if c.Job == "" && c.Ready() {
+			ready[c.Hostname] = true
+		}
+	}
+
+	for _, job := range jobs {
+		for _, run := range job.Runs {
+			if run.Status == JobSucceeded {
+				ready[job.Hostname] = true
+			}
+		}
+	}
+	return ready
+}
+
+// WaitingFor returns the dependencies of the container that aren't in `ready`.
+func (c Container) WaitingFor(ready map[string]bool) []string {
+	var waiting []string
+	for _, hostname := range c.DependsOn {
+		if !ready[hostname] {
+			waiting = append(waiting, hostname)
+		}
+	}
+	return waiting
 }
 
-func getReferencedSecrets(x map[string]blueprint.ContainerValue) (secrets []string) {
-	for _, maybeSecret := range x {
+// GetReferencedSecrets returns the names of all Secrets referenced in the
+// Command, and the Env and FilepathToContent maps.
+func (c Container) GetReferencedSecrets() (secrets []string) {
+	vals := append([]blueprint.ContainerValue{}, c.Command...)
+	for _, val := range c.Env {
+		vals = append(vals, val)
+	}
+	for _, val := range c.FilepathToContent {
+		vals = append(vals, val)
+	}
+
+	for _, maybeSecret := range vals {
 		if secret, ok := maybeSecret.Value.(blueprint.Secret); ok {
 			secrets = append(secrets, secret.NameOfSecret)
 		}
@@ -80,12 +177,72 @@ func (conn Conn) SelectFromContainer(check func(Container) bool) []Container {
 	return containers
 }
 
+// SelectFromContainerByMinion gets the containers placed on the minion with the
+// private IP 'minion' that satisfy the 'check'.  It uses an index, so it doesn't
+// scan every container.
+func (db Database) SelectFromContainerByMinion(minion string,
+	check func(Container) bool) []Container {
+
+	return filterContainers(db.selectIndexed(ContainerTable, minionIndex, minion),
+		check)
+}
+
+// SelectFromContainerByMinion gets the containers placed on the minion with the
+// private IP 'minion' that satisfy the 'check'.
+func (conn Conn) SelectFromContainerByMinion(minion string,
+	check func(Container) bool) []Container {
+
+	var containers []Container
+	conn.Txn(ContainerTable).Run(func(view Database) error {
+		containers = view.SelectFromContainerByMinion(minion, check)
+		return nil
+	})
+	return containers
+}
+
+// SelectFromContainerByHostname gets the containers with the given 'hostname'
+// that satisfy the 'check'.  It uses an index, so it doesn't scan every
+// container.
+func (db Database) SelectFromContainerByHostname(hostname string,
+	check func(Container) bool) []Container {
+
+	return filterContainers(
+		db.selectIndexed(ContainerTable, hostnameIndex, hostname), check)
+}
+
+// SelectFromContainerByHostname gets the containers with the given 'hostname'
+// that satisfy the 'check'.
+func (conn Conn) SelectFromContainerByHostname(hostname string,
+	check func(Container) bool) []Container {
+
+	var containers []Container
+	conn.Txn(ContainerTable).Run(func(view Database) error {
+		containers = view.SelectFromContainerByHostname(hostname, check)
+		return nil
+	})
+	return containers
+}
+
+func filterContainers(rows []row, check func(Container) bool) []Container {
+	var result []Container
+	for _, row := range rows {
+		if check == nil || check(row.(Container)) {
+			result = append(result, row.(Container))
+		}
+	}
+	return result
+}
+
 func (c Container) getID() int {
 	return c.ID
 }
 
 func (c Container) String() string {
-	cmdStr := strings.Join(append([]string{"run", c.Image}, c.Command...), " ")
+	cmd := []string{"run", c.Image}
+	for _, arg := range c.Command {
+		cmd = append(cmd, arg.String())
+	}
+	cmdStr := strings.Join(cmd, " ")
 	tags := []string{cmdStr}
 
 	if c.ImageID != "" {
@@ -118,10 +275,64 @@ func (c Container) String() string {
 		tags = append(tags, fmt.Sprintf("Env: %s", c.Env))
 	}
 
+	if c.CPURequest != 0 || c.RAMRequest != 0 {
+		tags = append(tags, fmt.Sprintf("Requests: CPU=%g RAM=%gGiB",
+			c.CPURequest, c.RAMRequest))
+	}
+
+	if c.CPULimit != 0 || c.RAMLimit != 0 {
+		tags = append(tags, fmt.Sprintf("Limits: CPU=%g RAM=%gGiB",
+			c.CPULimit, c.RAMLimit))
+	}
+
+	if len(c.VolumeMounts) > 0 {
+		var mounts []string
+		for _, vm := range c.VolumeMounts {
+			mounts = append(mounts, vm.Volume+":"+vm.MountPath)
+		}
+		tags = append(tags, fmt.Sprintf("Volumes: %s", mounts))
+	}
+
 	if len(c.Status) > 0 {
 		tags = append(tags, fmt.Sprintf("Status: %s", c.Status))
 	}
 
+	if c.RestartPolicy != "" {
+		tags = append(tags, fmt.Sprintf("RestartPolicy: %s", c.RestartPolicy))
+	}
+
+	if c.RestartCount != 0 {
+		tags = append(tags, fmt.Sprintf("RestartCount: %d", c.RestartCount))
+	}
+
+	if c.ExitCode != 0 || c.OOMKilled {
+		tags = append(tags, fmt.Sprintf("ExitCode: %d", c.ExitCode))
+	}
+
+	if c.OOMKilled {
+		tags = append(tags, "OOMKilled")
+	}
+
+	if c.Job != "" {
+		tags = append(tags, fmt.Sprintf("Job: %s", c.Job))
+	}
+
+	if len(c.DependsOn) != 0 {
+		tags = append(tags, fmt.Sprintf("DependsOn: %s", c.DependsOn))
+	}
+
+	if c.Unschedulable != "" {
+		tags = append(tags, fmt.Sprintf("Unschedulable: %s", c.Unschedulable))
+	}
+
+	if c.HealthCheck != nil {
+		health := c.Health
+		if health == "" {
+			health = "unknown"
+		}
+		tags = append(tags, fmt.Sprintf("Health: %s", health))
+	}
+
 	if !c.Created.IsZero() {
 		tags = append(tags, fmt.Sprintf("Created: %s", c.Created.String()))
 	}
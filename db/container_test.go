@@ -31,7 +31,7 @@ func TestContainerString(t *testing.T) {
 		ImageID:     "imageid",
 		Status:      "testing",
 		Hostname:    "hostname",
-		Command:     []string{"run", "/bin/sh"},
+		Command:     blueprint.NewStrings("run", "/bin/sh"),
 		Env:         fakeMap,
 		Created:     fakeTime,
 	}
@@ -67,7 +67,12 @@ func TestGetReferencedSecrets(t *testing.T) {
 	secret2 := "secret2"
 	secret3 := "secret3"
 	secret4 := "secret4"
+	secret5 := "secret5"
 	dbc := Container{
+		Command: []blueprint.ContainerValue{
+			blueprint.NewString("--password"),
+			blueprint.NewSecret(secret5),
+		},
 		Env: map[string]blueprint.ContainerValue{
 			"key1": blueprint.NewString("ignoreme"),
 			"key2": blueprint.NewSecret(secret1),
@@ -82,9 +87,39 @@ func TestGetReferencedSecrets(t *testing.T) {
 		},
 	}
 	referencedSecrets := dbc.GetReferencedSecrets()
-	assert.Len(t, referencedSecrets, 4)
+	assert.Len(t, referencedSecrets, 5)
 	assert.Contains(t, referencedSecrets, secret1)
 	assert.Contains(t, referencedSecrets, secret2)
 	assert.Contains(t, referencedSecrets, secret3)
 	assert.Contains(t, referencedSecrets, secret4)
+	assert.Contains(t, referencedSecrets, secret5)
+}
+
+func TestReadyHostnames(t *testing.T) {
+	t.Parallel()
+
+	containers := []Container{
+		{Hostname: "web", Status: ContainerRunning},
+		{Hostname: "db", Status: ContainerRunning,
+			HealthCheck: &blueprint.HealthCheck{}},
+		{Hostname: "cache", Status: ContainerRunning,
+			HealthCheck: &blueprint.HealthCheck{}, Health: ContainerHealthy},
+		{Hostname: "migrate", Status: ContainerRunning, Job: "migrate"},
+		{Hostname: "queue"},
+	}
+	jobs := []Job{
+		{Hostname: "migrate", Runs: []JobRun{{Status: JobRunning}}},
+		{Hostname: "seed", Runs: []JobRun{
+			{Status: JobSucceeded}, {Status: JobFailed}}},
+	}
+
+	ready := ReadyHostnames(containers, jobs)
+	assert.Equal(t, map[string]bool{"web": true, "cache": true, "seed": true},
+		ready)
+
+	c := Container{DependsOn: []string{"web", "db", "migrate"}}
+	assert.Equal(t, []string{"db", "migrate"}, c.WaitingFor(ready))
+
+	c.DependsOn = []string{"cache"}
+	assert.Empty(t, c.WaitingFor(ready))
 }
@@ -23,6 +23,9 @@ type Database struct {
 type Trigger struct {
 	C    chan struct{} // The channel on which notifications are delivered.
 	stop chan struct{}
+
+	// Records the rows that change, if the trigger is a ChangeTrigger.
+	changes *changeLog
 }
 
 type row interface {
@@ -57,7 +60,7 @@ var selectC = counter.New("Database Select")
 func New() Conn {
 	db := Database{make(map[TableType]*table), &idCounter{}}
 	for _, t := range AllTables {
-		db.tables[t] = newTable()
+		db.tables[t] = newTable(t)
 	}
 
 	cn := Conn{db: db}
@@ -106,7 +109,13 @@ func (tr Transaction) Run(do func(db Database) error) error {
 // cause a notification on 'Trigger.C'. So that clients properly initialize,
 // Trigger() sends an initialization tick at startup.
 func (cn Conn) Trigger(tt ...TableType) Trigger {
-	trigger := Trigger{C: make(chan struct{}, 1), stop: make(chan struct{})}
+	return cn.registerTrigger(
+		Trigger{C: make(chan struct{}, 1), stop: make(chan struct{})}, tt)
+}
+
+// registerTrigger notifies 'trigger' of changes to 'tt', and sends it an
+// initialization tick.
+func (cn Conn) registerTrigger(trigger Trigger, tt []TableType) Trigger {
 	cn.Txn(tt...).Run(func(db Database) error {
 		for _, t := range tt {
 			dbTable := db.accessTable(t)
@@ -125,27 +134,30 @@ func (cn Conn) Trigger(tt ...TableType) Trigger {
 // initialization tick at startup.
 func (cn Conn) TriggerTick(seconds int, tt ...TableType) Trigger {
 	trigger := cn.Trigger(tt...)
+	go trigger.tick(seconds, func() {})
+	return trigger
+}
 
-	go func() {
-		ticker := time.NewTicker(time.Duration(seconds) * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-			case <-trigger.stop:
-				return
-			}
-
-			select {
-			case trigger.C <- struct{}{}:
-				c.Inc("Trigger")
-			default:
-			}
+// tick notifies the trigger once every N 'seconds' until it's stopped, calling
+// 'onTick' before each notification.
+func (t Trigger) tick(seconds int, onTick func()) {
+	ticker := time.NewTicker(time.Duration(seconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.stop:
+			return
 		}
-	}()
 
-	return trigger
+		onTick()
+		select {
+		case t.C <- struct{}{}:
+			c.Inc("Trigger")
+		default:
+		}
+	}
 }
 
 // Lock all tables needed by the Transaction to perform a transact. Locking tables in
@@ -183,34 +195,54 @@ func (db Database) selectRows(tt TableType) map[int]row {
 
 func (db Database) insert(r row) {
 	insertC.Inc(reflect.TypeOf(r).String())
-	table := db.accessTable(getTableType(r))
+	tt := getTableType(r)
+	table := db.accessTable(tt)
 	table.shouldAlert = true
 	table.rows[r.getID()] = r
+	table.indexRow(r)
+	table.recordChange(tt, r.getID(), rowInserted)
 }
 
 // Commit updates the database with the data contained in row.
 func (db Database) Commit(r row) {
 	commitC.Inc(reflect.TypeOf(r).String())
 	rid := r.getID()
-	table := db.accessTable(getTableType(r))
+	tt := getTableType(r)
+	table := db.accessTable(tt)
 	old := table.rows[rid]
 
 	if reflect.TypeOf(old) != reflect.TypeOf(r) {
 		panic("Type Error")
 	}
 
-	if table.shouldAlert || !reflect.DeepEqual(r, old) {
+	// Tables that will alert anyway don't need to be compared, unless a
+	// trigger is recording which rows changed.
+	if table.shouldAlert && !table.recordsChanges() {
+		table.rows[rid] = r
+		table.reindexRow(old, r)
+		return
+	}
+
+	if !reflect.DeepEqual(r, old) {
 		table.rows[rid] = r
+		table.reindexRow(old, r)
 		table.shouldAlert = true
+		table.recordChange(tt, rid, rowModified)
 	}
 }
 
 // Remove deletes row from the database.
 func (db Database) Remove(r row) {
 	removeC.Inc(reflect.TypeOf(r).String())
-	table := db.accessTable(getTableType(r))
+	tt := getTableType(r)
+	table := db.accessTable(tt)
+	if old, ok := table.rows[r.getID()]; ok {
+		// The stored row is unindexed, in case `r` is out of date.
+		table.unindexRow(old)
+	}
 	delete(table.rows, r.getID())
 	table.shouldAlert = true
+	table.recordChange(tt, r.getID(), rowRemoved)
 }
 
 func (db Database) nextID() int {
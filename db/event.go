@@ -0,0 +1,152 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxEvents is the number of events kept for each namespace.  Once a namespace
+// has more, its oldest events are removed.
+const MaxEvents = 1000
+
+// An Event row records something that happened to an object in the
+// deployment, such as a machine changing status, or a container being placed
+// on a minion.  The daemon records the events of the machines, and the leader
+// records those of the containers and images.  The daemon then replicates the
+// leader's events so that the whole history of a namespace can be read from
+// it.
+type Event struct {
+	ID int `json:"-"`
+
+	Time time.Time `rowStringer:"omit"`
+
+	// The namespace of the event.  Only set on the daemon, which tracks the
+	// events of every namespace.
+	Namespace string `json:",omitempty"`
+
+	Type string
+
+	// The kind of object that the event is about (e.g. Machine), and the ID
+	// used to refer to it (e.g. the machine's CloudID).
+	ObjectType string
+	ObjectID   string `json:",omitempty"`
+
+	Message string `json:",omitempty"`
+}
+
+// The types of events.
+const (
+	// MachineStatusEvent is recorded when a machine's status changes.
+	MachineStatusEvent = "StatusChanged"
+
+	// BootFailedEvent is recorded when the cloud provider fails to boot
+	// machines.
+	BootFailedEvent = "BootFailed"
+
+	// StopFailedEvent is recorded when the cloud provider fails to stop
+	// machines.
+	StopFailedEvent = "StopFailed"
+
+	// PlacedEvent is recorded when the scheduler places a container on a
+	// minion.
+	PlacedEvent = "Placed"
+
+	// RescheduledEvent is recorded when the scheduler removes a container
+	// from the minion it was placed on.
+	RescheduledEvent = "Rescheduled"
+
+	// BuiltEvent is recorded when an image is built.
+	BuiltEvent = "Built"
+
+	// BuildFailedEvent is recorded when an image fails to build.
+	BuildFailedEvent = "BuildFailed"
+)
+
+// InsertEvent creates a new event row and inserts it into the database.
+func (db Database) InsertEvent() Event {
+	result := Event{ID: db.nextID()}
+	db.insert(result)
+	return result
+}
+
+// LogEvent inserts `event` into the database, stamped with the current time
+// unless it already has one.  The oldest events in the event's namespace are
+// removed once it has more than MaxEvents.
+func (db Database) LogEvent(event Event) {
+	event.ID = db.InsertEvent().ID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	db.Commit(event)
+
+	events := db.SelectFromEvent(func(e Event) bool {
+		return e.Namespace == event.Namespace
+	})
+	if len(events) <= MaxEvents {
+		return
+	}
+
+	SortEvents(events)
+	for _, e := range events[:len(events)-MaxEvents] {
+		db.Remove(e)
+	}
+}
+
+// SelectFromEvent gets all events in the database that satisfy 'check'.
+func (db Database) SelectFromEvent(check func(Event) bool) []Event {
+	var result []Event
+	for _, row := range db.selectRows(EventTable) {
+		if check == nil || check(row.(Event)) {
+			result = append(result, row.(Event))
+		}
+	}
+	return result
+}
+
+// SelectFromEvent gets all events in the database connection that satisfy
+// 'check'.
+func (cn Conn) SelectFromEvent(check func(Event) bool) []Event {
+	var events []Event
+	cn.Txn(EventTable).Run(func(view Database) error {
+		events = view.SelectFromEvent(check)
+		return nil
+	})
+	return events
+}
+
+// SortEvents sorts `events` from oldest to newest, and returns them.
+func SortEvents(events []Event) []Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].less(events[j])
+	})
+	return events
+}
+
+func (e Event) getID() int {
+	return e.ID
+}
+
+func (e Event) String() string {
+	return defaultString(e)
+}
+
+func (e Event) less(r row) bool {
+	other := r.(Event)
+	if !e.Time.Equal(other.Time) {
+		return e.Time.Before(other.Time)
+	}
+	return e.ID < other.ID
+}
+
+// EventSlice is an alias for []Event to allow for joins
+type EventSlice []Event
+
+// Get returns the value contained at the given index
+func (slc EventSlice) Get(ii int) interface{} {
+	return slc[ii]
+}
+
+// Len returns the number of items in the slice.
+func (slc EventSlice) Len() int {
+	return len(slc)
+}
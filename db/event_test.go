@@ -0,0 +1,84 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+
+	var id int
+	conn.Txn(EventTable).Run(func(view Database) error {
+		event := view.InsertEvent()
+		id = event.ID
+		event.Type = BuiltEvent
+		event.ObjectType = "Image"
+		event.ObjectID = "foo"
+		view.Commit(event)
+		return nil
+	})
+
+	events := EventSlice(conn.SelectFromEvent(nil))
+	assert.Equal(t, 1, events.Len())
+
+	event := events[0]
+	assert.Equal(t, id, event.getID())
+	assert.Equal(t, "Event-1{Type=Built, ObjectType=Image, ObjectID=foo}",
+		event.String())
+	assert.Equal(t, event, events.Get(0))
+}
+
+func TestLogEvent(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+	start := time.Unix(1500000000, 0)
+	conn.Txn(EventTable).Run(func(view Database) error {
+		// Events are kept per namespace, so the other namespace's event
+		// isn't removed.
+		view.LogEvent(Event{Namespace: "other", Time: start})
+		for i := 0; i < MaxEvents+2; i++ {
+			view.LogEvent(Event{
+				Namespace: "ns",
+				Time:      start.Add(time.Duration(i) * time.Second),
+			})
+		}
+
+		// Events without a time are stamped with the current time.
+		view.LogEvent(Event{Namespace: "ns", Message: "now"})
+		return nil
+	})
+
+	events := SortEvents(conn.SelectFromEvent(func(e Event) bool {
+		return e.Namespace == "ns"
+	}))
+	assert.Len(t, events, MaxEvents)
+	assert.Equal(t, start.Add(3*time.Second), events[0].Time)
+	assert.Equal(t, "now", events[len(events)-1].Message)
+	assert.WithinDuration(t, time.Now(), events[len(events)-1].Time, time.Minute)
+
+	assert.Len(t, conn.SelectFromEvent(func(e Event) bool {
+		return e.Namespace == "other"
+	}), 1)
+}
+
+func TestSortEvents(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	events := []Event{
+		{ID: 3, Time: now},
+		{ID: 1, Time: now.Add(time.Second)},
+		{ID: 2, Time: now},
+	}
+	assert.Equal(t, []Event{
+		{ID: 2, Time: now},
+		{ID: 3, Time: now},
+		{ID: 1, Time: now.Add(time.Second)},
+	}, SortEvents(events))
+}
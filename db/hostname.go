@@ -38,6 +38,26 @@ func (conn Conn) SelectFromHostname(check func(Hostname) bool) []Hostname {
 	return hostnames
 }
 
+// SelectFromHostnameByName gets the rows for the given 'hostname'.  It uses an
+// index, so it doesn't scan every hostname.
+func (db Database) SelectFromHostnameByName(hostname string) []Hostname {
+	var result []Hostname
+	for _, row := range db.selectIndexed(HostnameTable, hostnameIndex, hostname) {
+		result = append(result, row.(Hostname))
+	}
+	return result
+}
+
+// SelectFromHostnameByName gets the rows for the given 'hostname'.
+func (conn Conn) SelectFromHostnameByName(hostname string) []Hostname {
+	var hostnames []Hostname
+	conn.Txn(HostnameTable).Run(func(view Database) error {
+		hostnames = view.SelectFromHostnameByName(hostname)
+		return nil
+	})
+	return hostnames
+}
+
 // GetHostnameMappings returns a map of all hostnames to their IP.
 func (db Database) GetHostnameMappings() map[string]string {
 	hostnameToIP := map[string]string{}
@@ -0,0 +1,99 @@
+package db
+
+// An index maps the value of one of a table's fields to the IDs of the rows with
+// that value, so that the rows can be selected without scanning the table.
+type index struct {
+	key func(row) string
+	ids map[string]map[int]struct{}
+}
+
+// The names of the secondary indexes.  They're named after the field they index.
+const (
+	minionIndex   = "Minion"
+	hostnameIndex = "Hostname"
+	cloudIDIndex  = "CloudID"
+)
+
+// tableIndexes declares the secondary indexes of each table.  They're kept up to
+// date as rows are inserted, committed, and removed.
+var tableIndexes = map[TableType]map[string]func(row) string{
+	ContainerTable: {
+		minionIndex:   func(r row) string { return r.(Container).Minion },
+		hostnameIndex: func(r row) string { return r.(Container).Hostname },
+	},
+	HostnameTable: {
+		hostnameIndex: func(r row) string { return r.(Hostname).Hostname },
+	},
+	MachineTable: {
+		cloudIDIndex: func(r row) string { return r.(Machine).CloudID },
+	},
+}
+
+func newIndexes(tt TableType) map[string]*index {
+	indexes := map[string]*index{}
+	for name, key := range tableIndexes[tt] {
+		indexes[name] = &index{key: key, ids: map[string]map[int]struct{}{}}
+	}
+	return indexes
+}
+
+func (idx *index) add(r row) {
+	key := idx.key(r)
+	ids := idx.ids[key]
+	if ids == nil {
+		ids = map[int]struct{}{}
+		idx.ids[key] = ids
+	}
+	ids[r.getID()] = struct{}{}
+}
+
+func (idx *index) remove(r row) {
+	key := idx.key(r)
+	ids := idx.ids[key]
+	delete(ids, r.getID())
+	if len(ids) == 0 {
+		delete(idx.ids, key)
+	}
+}
+
+// update moves the row from the key of `old` to that of `new`, if they differ.
+func (idx *index) update(old, new row) {
+	if idx.key(old) != idx.key(new) {
+		idx.remove(old)
+		idx.add(new)
+	}
+}
+
+// selectIndexed returns the rows of `tt` whose `name` index has the value `key`.
+func (db Database) selectIndexed(tt TableType, name, key string) []row {
+	selectC.Inc(string(tt))
+	table := db.accessTable(tt)
+	idx, ok := table.indexes[name]
+	if !ok {
+		panic("unknown index " + name + " on " + string(tt))
+	}
+
+	var rows []row
+	for id := range idx.ids[key] {
+		rows = append(rows, table.rows[id])
+	}
+	return rows
+}
+
+func (t *table) indexRow(r row) {
+	for _, idx := range t.indexes {
+		idx.add(r)
+	}
+}
+
+func (t *table) unindexRow(r row) {
+	for _, idx := range t.indexes {
+		idx.remove(r)
+	}
+}
+
+func (t *table) reindexRow(old, new row) {
+	for _, idx := range t.indexes {
+		idx.update(old, new)
+	}
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerIndexes(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+	var a, b, c Container
+	conn.Txn(ContainerTable).Run(func(view Database) error {
+		a = view.InsertContainer()
+		a.Minion = "1.1.1.1"
+		a.Hostname = "a"
+		view.Commit(a)
+
+		b = view.InsertContainer()
+		b.Minion = "1.1.1.1"
+		b.Hostname = "b"
+		view.Commit(b)
+
+		c = view.InsertContainer()
+		return nil
+	})
+
+	checkMinion := func(minion string, exp ...int) {
+		assert.Equal(t, exp, containerIDs(
+			conn.SelectFromContainerByMinion(minion, nil)), minion)
+	}
+	checkHostname := func(hostname string, exp ...int) {
+		assert.Equal(t, exp, containerIDs(
+			conn.SelectFromContainerByHostname(hostname, nil)), hostname)
+	}
+
+	checkMinion("1.1.1.1", a.ID, b.ID)
+	checkMinion("", c.ID)
+	checkMinion("2.2.2.2")
+	checkHostname("a", a.ID)
+	checkHostname("b", b.ID)
+
+	assert.Equal(t, []int{b.ID}, containerIDs(conn.SelectFromContainerByMinion(
+		"1.1.1.1", func(dbc Container) bool { return dbc.Hostname == "b" })))
+
+	conn.Txn(ContainerTable).Run(func(view Database) error {
+		b.Minion = "2.2.2.2"
+		b.Hostname = "a"
+		view.Commit(b)
+
+		// Removing an out of date copy of the row still unindexes it.
+		stale := a
+		stale.Minion = "3.3.3.3"
+		view.Remove(stale)
+		view.Remove(stale)
+		return nil
+	})
+
+	checkMinion("1.1.1.1")
+	checkMinion("2.2.2.2", b.ID)
+	checkMinion("3.3.3.3")
+	checkHostname("a", b.ID)
+	checkHostname("b")
+}
+
+func TestHostnameIndex(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+	conn.Txn(HostnameTable).Run(func(view Database) error {
+		for _, name := range []string{"a", "b", "b"} {
+			hostname := view.InsertHostname()
+			hostname.Hostname = name
+			hostname.IP = name + "IP"
+			view.Commit(hostname)
+		}
+		return nil
+	})
+
+	hostnames := conn.SelectFromHostnameByName("a")
+	assert.Len(t, hostnames, 1)
+	assert.Equal(t, "aIP", hostnames[0].IP)
+	assert.Len(t, conn.SelectFromHostnameByName("b"), 2)
+	assert.Empty(t, conn.SelectFromHostnameByName("c"))
+}
+
+func TestMachineIndex(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+	var m Machine
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		m = view.InsertMachine()
+		m.CloudID = "id"
+		view.Commit(m)
+		return nil
+	})
+	assert.Equal(t, []Machine{m}, conn.SelectFromMachineByCloudID("id"))
+
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		m.CloudID = "new"
+		view.Commit(m)
+		return nil
+	})
+	assert.Empty(t, conn.SelectFromMachineByCloudID("id"))
+	assert.Equal(t, []Machine{m}, conn.SelectFromMachineByCloudID("new"))
+}
+
+func containerIDs(dbcs []Container) []int {
+	var ids []int
+	for _, dbc := range dbcs {
+		ids = append(ids, dbc.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// benchmarkContainers creates a database with 10,000 containers spread evenly
+// over 100 minions, each with a unique hostname.
+func benchmarkContainers() Conn {
+	conn := New()
+	conn.Txn(ContainerTable).Run(func(view Database) error {
+		for i := 0; i < 10000; i++ {
+			dbc := view.InsertContainer()
+			dbc.Minion = fmt.Sprintf("10.0.0.%d", i%100)
+			dbc.Hostname = fmt.Sprintf("container%d", i)
+			dbc.IP = "ip"
+			view.Commit(dbc)
+		}
+		return nil
+	})
+	return conn
+}
+
+func BenchmarkSelectContainersByMinionScan(b *testing.B) {
+	conn := benchmarkContainers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.SelectFromContainer(func(dbc Container) bool {
+			return dbc.IP != "" && dbc.Minion == "10.0.0.7"
+		})
+	}
+}
+
+func BenchmarkSelectContainersByMinionIndex(b *testing.B) {
+	conn := benchmarkContainers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.SelectFromContainerByMinion("10.0.0.7", func(dbc Container) bool {
+			return dbc.IP != ""
+		})
+	}
+}
+
+func BenchmarkSelectContainersByHostnameScan(b *testing.B) {
+	conn := benchmarkContainers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.SelectFromContainer(func(dbc Container) bool {
+			return dbc.Hostname == "container1234"
+		})
+	}
+}
+
+func BenchmarkSelectContainersByHostnameIndex(b *testing.B) {
+	conn := benchmarkContainers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.SelectFromContainerByHostname("container1234", nil)
+	}
+}
+
+// The indexes make commits that change an indexed field slightly more expensive.
+func BenchmarkCommitContainerMinion(b *testing.B) {
+	conn := benchmarkContainers()
+	dbc := conn.SelectFromContainerByHostname("container1234", nil)[0]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.Txn(ContainerTable).Run(func(view Database) error {
+			dbc.Minion = fmt.Sprintf("10.0.0.%d", i%100)
+			view.Commit(dbc)
+			return nil
+		})
+	}
+}
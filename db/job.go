@@ -0,0 +1,120 @@
+package db
+
+import (
+	"time"
+)
+
+// A Job row is created by the leader for each container in the blueprint that
+// runs to completion.  It tracks the job's runs, each of which boots copies of
+// the container until enough of them succeed.  Used only by the minion.
+type Job struct {
+	ID int `json:"-"`
+
+	BlueprintID string `json:",omitempty"`
+	Hostname    string `json:",omitempty"`
+	Completions int    `json:",omitempty"`
+	Parallelism int    `json:",omitempty"`
+	RetryLimit  int    `json:",omitempty"`
+	Schedule    string `json:",omitempty"`
+
+	// Created is when the leader first saw the job.  Scheduled jobs first run
+	// the first time their schedule fires after it.
+	Created time.Time `json:"," rowStringer:"omit"`
+
+	// Runs holds the most recent runs of the job, oldest first.  The last run
+	// is the current one.
+	Runs []JobRun `json:",omitempty" rowStringer:"omit"`
+}
+
+// A JobRun is a single run of a Job.
+type JobRun struct {
+	Number   int       `json:",omitempty"`
+	Status   string    `json:",omitempty"`
+	Started  time.Time `json:","`
+	Finished time.Time `json:","`
+
+	// The number of containers booted so far, and how many of those exited
+	// successfully, and how many failed even after being retried.
+	Booted    int `json:",omitempty"`
+	Succeeded int `json:",omitempty"`
+	Failed    int `json:",omitempty"`
+}
+
+// The statuses of a JobRun.
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// CurrentRun returns the job's most recent run, and false if it hasn't run yet.
+func (j Job) CurrentRun() (JobRun, bool) {
+	if len(j.Runs) == 0 {
+		return JobRun{}, false
+	}
+	return j.Runs[len(j.Runs)-1], true
+}
+
+// JobSlice is an alias for []Job to allow for joins
+type JobSlice []Job
+
+// InsertJob creates a new job row and inserts it into the database.
+func (db Database) InsertJob() Job {
+	result := Job{ID: db.nextID()}
+	db.insert(result)
+	return result
+}
+
+// SelectFromJob gets all jobs in the database that satisfy 'check'.
+func (db Database) SelectFromJob(check func(Job) bool) []Job {
+	var result []Job
+	for _, row := range db.selectRows(JobTable) {
+		if check == nil || check(row.(Job)) {
+			result = append(result, row.(Job))
+		}
+	}
+
+	return result
+}
+
+// SelectFromJob gets all jobs in the database that satisfy the 'check'.
+func (conn Conn) SelectFromJob(check func(Job) bool) []Job {
+	var jobs []Job
+	conn.Txn(JobTable).Run(func(view Database) error {
+		jobs = view.SelectFromJob(check)
+		return nil
+	})
+	return jobs
+}
+
+func (j Job) String() string {
+	return defaultString(j)
+}
+
+func (j Job) less(r row) bool {
+	return j.Hostname < r.(Job).Hostname
+}
+
+func (j Job) getID() int {
+	return j.ID
+}
+
+// Get returns the value contained at the given index
+func (js JobSlice) Get(ii int) interface{} {
+	return js[ii]
+}
+
+// Len returns the number of items in the slice
+func (js JobSlice) Len() int {
+	return len(js)
+}
+
+// Less implements less than for sort.Interface.
+func (js JobSlice) Less(i, j int) bool {
+	return js[i].less(js[j])
+}
+
+// Swap implements swapping for sort.Interface.
+func (js JobSlice) Swap(i, j int) {
+	js[i], js[j] = js[j], js[i]
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJob(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+
+	var id int
+	conn.Txn(JobTable).Run(func(view Database) error {
+		job := view.InsertJob()
+		id = job.ID
+		job.Hostname = "migrate"
+		job.Completions = 2
+		view.Commit(job)
+		return nil
+	})
+
+	jobs := JobSlice(conn.SelectFromJob(func(j Job) bool { return true }))
+	assert.Equal(t, 1, jobs.Len())
+
+	job := jobs[0]
+	assert.Equal(t, "migrate", job.Hostname)
+	assert.Equal(t, id, job.getID())
+
+	assert.Equal(t, "Job-1{Hostname=migrate, Completions=2}", job.String())
+
+	assert.Equal(t, job, jobs.Get(0))
+
+	assert.True(t, job.less(Job{Hostname: "report"}))
+
+	_, ok := job.CurrentRun()
+	assert.False(t, ok)
+
+	job.Runs = []JobRun{{Number: 1}, {Number: 2}}
+	run, ok := job.CurrentRun()
+	assert.True(t, ok)
+	assert.Equal(t, 2, run.Number)
+}
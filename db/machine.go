@@ -11,6 +11,9 @@ import (
 type Machine struct {
 	ID int //Database ID
 
+	// The namespace of the blueprint that the machine was booted for.
+	Namespace string
+
 	Role        Role
 	Provider    ProviderName
 	Region      string
@@ -20,6 +23,12 @@ type Machine struct {
 	FloatingIP  string
 	Preemptible bool
 
+	// The machine group that the machine belongs to, if any.  The autoscaler
+	// marks a group machine to Drain when it shrinks the group, and the cloud
+	// then terminates it.
+	Group string
+	Drain bool
+
 	/* Populated by the cloud provider. */
 	CloudID   string //Cloud Provider ID
 	PublicIP  string
@@ -80,6 +89,26 @@ func (cn Conn) SelectFromMachine(check func(Machine) bool) []Machine {
 	return machines
 }
 
+// SelectFromMachineByCloudID gets the machines with the given 'cloudID'.  It uses
+// an index, so it doesn't scan every machine.
+func (db Database) SelectFromMachineByCloudID(cloudID string) []Machine {
+	var result []Machine
+	for _, row := range db.selectIndexed(MachineTable, cloudIDIndex, cloudID) {
+		result = append(result, row.(Machine))
+	}
+	return result
+}
+
+// SelectFromMachineByCloudID gets the machines with the given 'cloudID'.
+func (cn Conn) SelectFromMachineByCloudID(cloudID string) []Machine {
+	var machines []Machine
+	cn.Txn(MachineTable).Run(func(view Database) error {
+		machines = view.SelectFromMachineByCloudID(cloudID)
+		return nil
+	})
+	return machines
+}
+
 func (m Machine) getID() int {
 	return m.ID
 }
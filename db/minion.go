@@ -19,6 +19,10 @@ type Minion struct {
 	Region      string
 	FloatingIP  string
 	HostSubnets []string
+
+	// A map from the name of each block volume attached to this minion to its
+	// device path.
+	Volumes map[string]string `json:",omitempty"`
 }
 
 // InsertMinion creates a new Minion and inserts it into 'db'.
@@ -28,7 +28,8 @@ func TestMinion(t *testing.T) {
 	assert.Equal(t, "foo", minion.Blueprint)
 	assert.Equal(t, id, minion.getID())
 
-	assert.Equal(t, "Minion-1{Self=true, HostSubnets=[]}", minion.String())
+	assert.Equal(t, "Minion-1{Self=true, HostSubnets=[], Volumes=map[]}",
+		minion.String())
 
 	assert.Equal(t, minion, minions.Get(0))
 
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kelda/kelda/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PersistedTables are the tables that Persist saves to disk.  The rest of the
+// database is rebuilt from the blueprints and the machines once the daemon
+// reconnects to them.
+var PersistedTables = []TableType{BlueprintTable, MachineTable}
+
+// snapshot is the contents of the persisted tables.
+type snapshot struct {
+	Blueprints []Blueprint
+	Machines   []Machine
+}
+
+// Persist saves the persisted tables to `path` whenever they change, until the
+// process exits.  Each snapshot is written to a temporary file that is synced
+// and then renamed over `path`, so a crash mid-write leaves the last complete
+// snapshot in place.  Changes committed after the last snapshot are lost if
+// the process crashes before the next one is written.
+func (cn Conn) Persist(path string) {
+	trigger := cn.Trigger(PersistedTables...)
+	defer trigger.Stop()
+
+	var last []byte
+	for range trigger.C {
+		written, err := cn.persist(path, last)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Warning(
+				"Failed to persist database")
+			continue
+		}
+		last = written
+	}
+}
+
+// persist writes a snapshot of the persisted tables to `path` unless it's the
+// same as the `last` one written, and returns the snapshot.
+func (cn Conn) persist(path string, last []byte) ([]byte, error) {
+	var snap snapshot
+	cn.Txn(PersistedTables...).Run(func(view Database) error {
+		snap.Blueprints = view.SelectFromBlueprint(nil)
+		snap.Machines = view.SelectFromMachine(nil)
+		return nil
+	})
+
+	// Sort the rows so that unchanged tables produce identical snapshots.
+	sort.Slice(snap.Blueprints, func(i, j int) bool {
+		return snap.Blueprints[i].ID < snap.Blueprints[j].ID
+	})
+	sort.Slice(snap.Machines, func(i, j int) bool {
+		return snap.Machines[i].ID < snap.Machines[j].ID
+	})
+
+	contents, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(contents, last) {
+		return last, nil
+	}
+	return contents, writeSnapshot(path, contents)
+}
+
+// Restore loads the persisted tables from the snapshot at `path`.  It should
+// be called on a new database, before anything else uses it.  A missing
+// snapshot is not an error, since nothing has been persisted yet on the
+// daemon's first boot.
+func (cn Conn) Restore(path string) error {
+	snap, err := readSnapshot(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	cn.Txn(PersistedTables...).Run(func(view Database) error {
+		for _, bp := range snap.Blueprints {
+			view.insert(bp)
+			view.reserveID(bp.ID)
+		}
+		for _, m := range snap.Machines {
+			view.insert(m)
+			view.reserveID(m.ID)
+		}
+		return nil
+	})
+	return nil
+}
+
+// reserveID ensures that newly inserted rows are never given an ID less than
+// or equal to `id`.
+func (db Database) reserveID(id int) {
+	db.idAlloc.Lock()
+	defer db.idAlloc.Unlock()
+
+	if id > db.idAlloc.curID {
+		db.idAlloc.curID = id
+	}
+}
+
+// A snapshot file is the hex encoded SHA-256 checksum of the snapshot, followed
+// by a newline and the JSON encoded snapshot.  The checksum catches snapshots
+// that were torn by a crash on filesystems where rename isn't atomic.
+func writeSnapshot(path string, contents []byte) error {
+	if err := util.AppFs.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	checksum := sha256.Sum256(contents)
+	tmpPath := path + ".tmp"
+	f, err := util.AppFs.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(f, "%s\n%s", hex.EncodeToString(checksum[:]), contents)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return util.AppFs.Rename(tmpPath, path)
+}
+
+func readSnapshot(path string) (snapshot, error) {
+	file, err := util.ReadFile(path)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	parts := bytes.SplitN([]byte(file), []byte("\n"), 2)
+	if len(parts) != 2 {
+		return snapshot{}, errors.New("malformed snapshot")
+	}
+
+	checksum := sha256.Sum256(parts[1])
+	if string(parts[0]) != hex.EncodeToString(checksum[:]) {
+		return snapshot{}, errors.New("snapshot checksum mismatch")
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(parts[1], &snap); err != nil {
+		return snapshot{}, fmt.Errorf("parse snapshot: %s", err)
+	}
+	return snap, nil
+}
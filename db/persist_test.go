@@ -0,0 +1,148 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/util"
+)
+
+const testSnapshotPath = "/kelda/daemon.db"
+
+// crashFs simulates the daemon crashing after it writes the temporary snapshot,
+// but before it's renamed into place.
+type crashFs struct {
+	afero.Fs
+}
+
+func (fs crashFs) Rename(oldname, newname string) error {
+	return errors.New("crashed")
+}
+
+func TestPersistRestore(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+
+	conn := New()
+	conn.Txn(AllTables...).Run(func(view Database) error {
+		bp := view.InsertBlueprint()
+		bp.Namespace = "ns"
+		bp.Containers = []blueprint.Container{{ID: "1", Hostname: "web"}}
+		bp.GroupSizes = map[string]int{"group": 2}
+		bp.GroupsScaled = map[string]time.Time{
+			"group": time.Unix(1500000000, 0).UTC()}
+		view.Commit(bp)
+
+		m := view.InsertMachine()
+		m.Namespace = "ns"
+		m.Provider = Amazon
+		m.CloudID = "i-1"
+		m.Status = Connected
+		m.PublicKey = "key"
+		view.Commit(m)
+
+		// Only the persisted tables are restored.
+		view.InsertContainer()
+		return nil
+	})
+
+	written, err := conn.persist(testSnapshotPath, nil)
+	assert.NoError(t, err)
+
+	restored := New()
+	assert.NoError(t, restored.Restore(testSnapshotPath))
+	assert.Equal(t, conn.SelectFromBlueprint(nil), restored.SelectFromBlueprint(nil))
+	assert.Equal(t, conn.SelectFromMachine(nil), restored.SelectFromMachine(nil))
+	assert.Empty(t, restored.SelectFromContainer(nil))
+
+	// New rows don't reuse the restored rows' IDs.
+	restored.Txn(MachineTable).Run(func(view Database) error {
+		assert.Equal(t, 3, view.InsertMachine().ID)
+		return nil
+	})
+
+	// Unchanged tables aren't rewritten.
+	util.AppFs.Remove(testSnapshotPath)
+	_, err = conn.persist(testSnapshotPath, written)
+	assert.NoError(t, err)
+	exists, _ := util.FileExists(testSnapshotPath)
+	assert.False(t, exists)
+}
+
+func TestRestoreMissing(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+
+	conn := New()
+	assert.NoError(t, conn.Restore(testSnapshotPath))
+	assert.Empty(t, conn.SelectFromBlueprint(nil))
+	assert.Empty(t, conn.SelectFromMachine(nil))
+}
+
+func TestPersistCrash(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	util.AppFs = fs
+
+	conn := New()
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		m := view.InsertMachine()
+		m.CloudID = "old"
+		view.Commit(m)
+		return nil
+	})
+	written, err := conn.persist(testSnapshotPath, nil)
+	assert.NoError(t, err)
+
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		m := view.SelectFromMachine(nil)[0]
+		m.CloudID = "new"
+		view.Commit(m)
+		return nil
+	})
+
+	// A crash before the new snapshot is renamed into place leaves the old
+	// snapshot, and a partially written temporary file.
+	util.AppFs = crashFs{fs}
+	_, err = conn.persist(testSnapshotPath, written)
+	assert.EqualError(t, err, "crashed")
+	util.WriteFile(testSnapshotPath+".tmp", []byte("{\"Machi"), 0600)
+
+	util.AppFs = fs
+	restored := New()
+	assert.NoError(t, restored.Restore(testSnapshotPath))
+	machines := restored.SelectFromMachine(nil)
+	assert.Len(t, machines, 1)
+	assert.Equal(t, "old", machines[0].CloudID)
+
+	// The next snapshot replaces the partial temporary file.
+	_, err = conn.persist(testSnapshotPath, written)
+	assert.NoError(t, err)
+	restored = New()
+	assert.NoError(t, restored.Restore(testSnapshotPath))
+	assert.Equal(t, "new", restored.SelectFromMachine(nil)[0].CloudID)
+}
+
+func TestRestoreTorn(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+
+	conn := New()
+	conn.Txn(MachineTable).Run(func(view Database) error {
+		view.InsertMachine()
+		return nil
+	})
+	_, err := conn.persist(testSnapshotPath, nil)
+	assert.NoError(t, err)
+
+	// A snapshot that was cut short by a crash is rejected rather than
+	// partially restored.
+	contents, _ := util.ReadFile(testSnapshotPath)
+	util.WriteFile(testSnapshotPath, []byte(contents[:len(contents)-5]), 0600)
+	assert.EqualError(t, New().Restore(testSnapshotPath),
+		"snapshot checksum mismatch")
+
+	util.WriteFile(testSnapshotPath, []byte("garbage"), 0600)
+	assert.EqualError(t, New().Restore(testSnapshotPath), "malformed snapshot")
+}
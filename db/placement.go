@@ -13,7 +13,8 @@ type Placement struct {
 
 	// Constraint based on co-location with another container. If Exclusive is
 	// true, then the TargetContainer cannot be placed on the same machine as
-	// OtherContainer. OtherContainer must be a hostname.
+	// OtherContainer. Otherwise, it must be placed on the same machine.
+	// OtherContainer must be a hostname.
 	OtherContainer string
 
 	// Machine Constraints
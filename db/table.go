@@ -38,24 +38,35 @@ var ImageTable = TableType(reflect.TypeOf(Image{}).String())
 // HostnameTable is the type of the Hostname table.
 var HostnameTable = TableType(reflect.TypeOf(Hostname{}).String())
 
+// VolumeTable is the type of the Volume table.
+var VolumeTable = TableType(reflect.TypeOf(Volume{}).String())
+
+// JobTable is the type of the Job table.
+var JobTable = TableType(reflect.TypeOf(Job{}).String())
+
+// EventTable is the type of the Event table.
+var EventTable = TableType(reflect.TypeOf(Event{}).String())
+
 // AllTables is a slice of all the db TableTypes. It is used primarily for tests,
 // where there is no reason to put lots of thought into which tables a Transaction
 // should use.
 var AllTables = []TableType{BlueprintTable, MachineTable, ContainerTable, MinionTable,
 	ConnectionTable, LoadBalancerTable, EtcdTable, PlacementTable, ImageTable,
-	HostnameTable}
+	HostnameTable, VolumeTable, JobTable, EventTable}
 
 type table struct {
-	rows map[int]row
+	rows    map[int]row
+	indexes map[string]*index
 
 	triggers    map[Trigger]struct{}
 	shouldAlert bool
 	sync.Mutex
 }
 
-func newTable() *table {
+func newTable(tt TableType) *table {
 	return &table{
 		rows:        make(map[int]row),
+		indexes:     newIndexes(tt),
 		triggers:    make(map[Trigger]struct{}),
 		shouldAlert: false,
 	}
@@ -77,3 +88,24 @@ func (t *table) alert() {
 		}
 	}
 }
+
+// recordsChanges returns whether any of the table's triggers records which rows
+// change.
+func (t *table) recordsChanges() bool {
+	for trigger := range t.triggers {
+		if trigger.changes != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// recordChange records that the row with ID 'id' changed, for each trigger that
+// records changes.
+func (t *table) recordChange(tt TableType, id int, kind changeKind) {
+	for trigger := range t.triggers {
+		if trigger.changes != nil {
+			trigger.changes.record(tt, id, kind)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package db
+
+// A Volume row is created for each volume in the blueprint.  On the daemon, it
+// tracks the block storage backing the volume, and on the minions it tracks
+// which minion the volume is bound to.
+type Volume struct {
+	ID int `json:"-"`
+
+	Name     string
+	Type     string
+	SizeGiB  int
+	Provider ProviderName
+	Region   string
+
+	// The namespace of the blueprint that the volume belongs to.  Only used on
+	// the daemon, which may be running several namespaces at once.
+	Namespace string
+
+	// Populated by the cloud provider for block volumes.
+	CloudID        string
+	MachineCloudID string
+	Device         string
+
+	// The PrivateIP of the minion that containers mounting this volume must be
+	// placed on.  The leader replicates it to the other minions through etcd,
+	// so host volumes stay bound to their minion after a change of leader.
+	Minion string
+}
+
+// VolumeSlice is an alias for []Volume to allow for joins
+type VolumeSlice []Volume
+
+// InsertVolume creates a new volume row and inserts it into the database.
+func (db Database) InsertVolume() Volume {
+	result := Volume{ID: db.nextID()}
+	db.insert(result)
+	return result
+}
+
+// SelectFromVolume gets all volumes in the database that satisfy 'check'.
+func (db Database) SelectFromVolume(check func(Volume) bool) []Volume {
+	var result []Volume
+	for _, row := range db.selectRows(VolumeTable) {
+		if check == nil || check(row.(Volume)) {
+			result = append(result, row.(Volume))
+		}
+	}
+
+	return result
+}
+
+// SelectFromVolume gets all volumes in the database that satisfy the 'check'.
+func (conn Conn) SelectFromVolume(check func(Volume) bool) []Volume {
+	var volumes []Volume
+	conn.Txn(VolumeTable).Run(func(view Database) error {
+		volumes = view.SelectFromVolume(check)
+		return nil
+	})
+	return volumes
+}
+
+func (v Volume) String() string {
+	return defaultString(v)
+}
+
+func (v Volume) less(r row) bool {
+	return v.Name < r.(Volume).Name
+}
+
+func (v Volume) getID() int {
+	return v.ID
+}
+
+// Get returns the value contained at the given index
+func (vs VolumeSlice) Get(ii int) interface{} {
+	return vs[ii]
+}
+
+// Len returns the number of items in the slice
+func (vs VolumeSlice) Len() int {
+	return len(vs)
+}
+
+// Less implements less than for sort.Interface.
+func (vs VolumeSlice) Less(i, j int) bool {
+	return vs[i].less(vs[j])
+}
+
+// Swap implements swapping for sort.Interface.
+func (vs VolumeSlice) Swap(i, j int) {
+	vs[i], vs[j] = vs[j], vs[i]
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolume(t *testing.T) {
+	t.Parallel()
+
+	conn := New()
+
+	var id int
+	conn.Txn(VolumeTable).Run(func(view Database) error {
+		volume := view.InsertVolume()
+		id = volume.ID
+		volume.Name = "data"
+		volume.Type = "block"
+		volume.SizeGiB = 10
+		view.Commit(volume)
+		return nil
+	})
+
+	volumes := VolumeSlice(conn.SelectFromVolume(
+		func(v Volume) bool { return true }))
+	assert.Equal(t, 1, volumes.Len())
+
+	volume := volumes[0]
+	assert.Equal(t, "data", volume.Name)
+	assert.Equal(t, id, volume.getID())
+
+	assert.Equal(t, "Volume-1{Name=data, Type=block, SizeGiB=10}",
+		volume.String())
+
+	assert.Equal(t, volume, volumes.Get(0))
+
+	assert.True(t, volume.less(Volume{Name: "logs"}))
+}
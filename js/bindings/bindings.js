@@ -18,6 +18,8 @@ const providerDefaultRegions = {
   Google: 'us-east1-b',
   DigitalOcean: 'sfo2',
   Vagrant: '',
+  Docker: '',
+  Static: '',
 };
 
 const githubCache = {};
@@ -49,10 +51,15 @@ class Infrastructure {
    *   add its IP address here.  These IP addresses must be in CIDR notation; e.g.,
    *   to allow access from 1.2.3.4, set adminACL to ["1.2.3.4/32"]. To allow access
    *   from all IP addresses, set adminACL to ["0.0.0.0/0"].
+   * @param {MachineGroup|MachineGroup[]} [opts.machineGroups] - Groups of
+   *   worker machines that Kelda resizes to fit the containers.  If any
+   *   groups are given, `workers` may be empty.
    */
   constructor(masters, workers, opts = {}) {
     this.namespace = opts.namespace || 'kelda';
     this.adminACL = getStringArray('adminACL', opts.adminACL);
+    this.machineGroups = (opts.machineGroups === undefined) ? [] :
+      boxObjects(opts.machineGroups, MachineGroup);
 
     checkExtraKeys(opts, this);
 
@@ -65,9 +72,9 @@ class Infrastructure {
     if (boxedMasters.length < 1) {
       throw new Error('masters must include 1 or more Machines to use as ' +
         'Kelda masters.');
-    } else if (boxedWorkers.length < 1) {
+    } else if (boxedWorkers.length < 1 && this.machineGroups.length < 1) {
       throw new Error('workers must include 1 or more Machines to use as ' +
-        'Kelda workers.');
+        'Kelda workers, unless machineGroups are given.');
     }
 
     const machineWithRole = (machine, role) => {
@@ -115,14 +122,34 @@ class Infrastructure {
       containers.push(c.toKeldaRepresentation());
     });
 
+    // Volumes are deployed implicitly by the containers that mount them.
+    const volumes = [];
+    const volumeNames = {};
+    this.containers.forEach((c) => {
+      Object.keys(c.volumeMounts).sort().forEach((mountPath) => {
+        const volume = c.volumeMounts[mountPath].toKeldaRepresentation();
+        const existing = volumeNames[volume.name];
+        if (existing === undefined) {
+          volumeNames[volume.name] = volume;
+          volumes.push(volume);
+        } else if (stringify(existing) !== stringify(volume)) {
+          throw new Error(`volume "${volume.name}" has differing ` +
+            'definitions');
+        }
+      });
+    });
+
     const machines = this.machines.map(m => m.toKeldaRepresentation());
+    const machineGroups = this.machineGroups.map(g => g.toKeldaRepresentation());
 
     const keldaInfrastructure = {
       machines,
+      machineGroups,
       loadBalancers,
       containers,
       connections: _connections,
       placements,
+      volumes,
 
       namespace: this.namespace,
       adminACL: this.adminACL,
@@ -204,6 +231,13 @@ function baseInfrastructure() {
 // we need a special label for it).
 const publicInternetLabel = 'public';
 
+// The IP protocols that connections may be restricted to.
+const protocols = ['tcp', 'udp', 'icmp'];
+
+// The largest port range that may be forwarded to different container ports.
+// The minions forward each port of such a range with its own iptables rule.
+const maxRemappedPorts = 100;
+
 // Global unique ID counter.
 let uniqueIDCounter = 0;
 
@@ -263,7 +297,8 @@ function hash(str) {
 }
 
 /**
- * Checks if the namespace is lower case, and if all referenced
+ * Checks if the namespace is lower case and only contains lowercase
+ * characters, numbers and hyphens, and if all referenced
  * containers in connections and load balancers are really deployed.
  * @private
  *
@@ -275,6 +310,11 @@ function vet(infrastructure) {
     throw new Error(`namespace "${infrastructure.namespace}" contains ` +
                   'uppercase letters. Namespaces must be lowercase.');
   }
+  if (!/^[a-z0-9-]*$/.test(infrastructure.namespace)) {
+    throw new Error(`namespace "${infrastructure.namespace}" is not a valid ` +
+                  'namespace. Namespaces must only contain lowercase ' +
+                  'characters, numbers and hyphens.');
+  }
   const lbHostnames = infrastructure.loadBalancers.map(l => l.name);
   const containerHostnames = infrastructure.containers.map(c => c.hostname);
   const hostnames = lbHostnames.concat(containerHostnames);
@@ -296,6 +336,41 @@ function vet(infrastructure) {
     });
   });
 
+  infrastructure.placements.forEach((p) => {
+    if (p.otherContainer !== undefined &&
+      !containerHostnames.includes(p.otherContainer)) {
+      throw new Error(`placement ${stringify(p)} references an undefined ` +
+        `container: ${p.otherContainer}`);
+    }
+  });
+
+  const dependsOn = {};
+  infrastructure.containers.forEach((c) => {
+    c.dependsOn.forEach((hostname) => {
+      if (!containerHostnames.includes(hostname)) {
+        throw new Error(`container "${c.hostname}" depends on an ` +
+          `undeployed container: ${hostname}`);
+      }
+    });
+    dependsOn[c.hostname] = c.dependsOn;
+  });
+
+  // Walk the dependencies depth first, and fail if a container is reached
+  // again while its own dependencies are still being walked.
+  const visited = {};
+  const visit = (hostname, path) => {
+    if (path.includes(hostname)) {
+      const cycle = path.slice(path.indexOf(hostname)).concat(hostname);
+      throw new Error(`dependency cycle: ${cycle.join(' -> ')}`);
+    }
+    if (visited[hostname]) {
+      return;
+    }
+    dependsOn[hostname].forEach(dep => visit(dep, path.concat(hostname)));
+    visited[hostname] = true;
+  };
+  containerHostnames.forEach(hostname => visit(hostname, []));
+
   const dockerfiles = {};
   infrastructure.containers.forEach((c) => {
     const name = c.image.name;
@@ -306,6 +381,43 @@ function vet(infrastructure) {
     dockerfiles[name] = c.image.dockerfile;
   });
 
+  infrastructure.containers.forEach((c) => {
+    if (c.cpuLimit !== 0 && c.cpuLimit < c.cpuRequest) {
+      throw new Error(`container "${c.hostname}" has a cpuLimit lower ` +
+        'than its cpuRequest');
+    }
+    if (c.ramLimit !== 0 && c.ramLimit < c.ramRequest) {
+      throw new Error(`container "${c.hostname}" has a ramLimit lower ` +
+        'than its ramRequest');
+    }
+  });
+
+  const rollingUpdates = {};
+  infrastructure.containers.forEach((c) => {
+    if (c.rollingUpdate === undefined) {
+      return;
+    }
+    const group = c.rollingUpdate.group;
+    if (rollingUpdates[group] !== undefined &&
+      stringify(rollingUpdates[group]) !== stringify(c.rollingUpdate)) {
+      throw new Error(`rolling update group "${group}" has differing ` +
+        'settings');
+    }
+    rollingUpdates[group] = c.rollingUpdate;
+  });
+
+  infrastructure.volumes.forEach((v) => {
+    if (v.type !== 'block') {
+      return;
+    }
+    const inCloud = infrastructure.machines.some(m => m.role === 'Worker' &&
+      m.provider === v.provider && m.region === v.region);
+    if (!inCloud) {
+      throw new Error(`block volume "${v.name}" is in ${v.provider} ` +
+        `region '${v.region}', which has no worker machines`);
+    }
+  });
+
   // Check to make sure all machines have the same region and provider.
   let lastMachine;
   infrastructure.machines.forEach((m) => {
@@ -542,6 +654,32 @@ function getNumber(argName, arg) {
   throw new Error(`${argName} must be a number (was: ${stringify(arg)})`);
 }
 
+/**
+ * Forces `arg` to be a map from paths to Volumes, even if it's undefined.
+ * @private
+ *
+ * @param {string} argName - The name of the map (for logging).
+ * @param {Object.<string, Volume>} arg - The map that might be undefined.
+ * @returns {Object.<string, Volume>} An empty map if `arg` is not defined,
+ *   and otherwise ensures that `arg` maps strings to Volumes and returns it.
+ */
+function getVolumeMap(argName, arg) {
+  if (arg === undefined) {
+    return {};
+  }
+  if (typeof arg !== 'object' || Array.isArray(arg)) {
+    throw new Error(`${argName} must be a map from paths to Volumes ` +
+      `(was: ${stringify(arg)})`);
+  }
+  Object.keys(arg).forEach((key) => {
+    if (!(arg[key] instanceof Volume)) {
+      throw new Error(`${argName} must be a map from paths to Volumes ` +
+        `(was: ${stringify(arg)})`);
+    }
+  });
+  return arg;
+}
+
 /**
  * Forces `arg` to be a string, even if it's undefined.
  * @private
@@ -594,6 +732,34 @@ function getSecretOrStringMap(argName, arg) {
   return arg;
 }
 
+/**
+ * @private
+ * @param {string} argName - The name of `arg` (for logging).
+ * @param {(string|Secret|RuntimeValue)[]} arg - The array of strings,
+ *   Secrets, or RuntimeValues.
+ * @returns {(string|Secret|RuntimeValue)[]} An empty array if `arg` is not
+ *   defined, and otherwise ensures that `arg` is an array of strings,
+ *   RuntimeValues, or Secrets and then returns it.
+ */
+function getSecretOrStringArray(argName, arg) {
+  if (arg === undefined) {
+    return [];
+  }
+  if (!Array.isArray(arg)) {
+    throw new Error(`${argName} must be an array of strings, RuntimeValues, ` +
+      `or Secrets (was: ${stringify(arg)})`);
+  }
+  for (let i = 0; i < arg.length; i += 1) {
+    if (typeof arg[i] !== 'string' && !(arg[i] instanceof Secret) &&
+      !(arg[i] instanceof RuntimeValue)) {
+      throw new Error(`${argName} must be an array of strings, ` +
+        `RuntimeValues, or Secrets. Item at index ${i} ` +
+        `(${stringify(arg[i])}) is not a string, RuntimeValue or Secret.`);
+    }
+  }
+  return arg;
+}
+
 /**
  * Verifies `arg` is an array of strings or undefined.
  * @private
@@ -669,11 +835,12 @@ class Machine {
    * @param {Object.<string, string>} opts - Arguments that modify the machine.
    *   Only 'provider' is required; the remaining options are optional.
    * @param {string} opts.provider - The cloud provider that the machine
-   *   should be launched in. Accepted values are Amazon, DigitalOcean, Google,
-   *   and Vagrant.
+   *   should be launched in. Accepted values are Amazon, DigitalOcean, Docker,
+   *   Google, Static, and Vagrant.
    * @param {string} [opts.region] - The region the machine will run-in
    *   (provider-specific; e.g., for Amazon, this could be 'us-west-2').
-   * @param {string} [opts.size] - The instance type (provider-specific).
+   * @param {string} [opts.size] - The instance type (provider-specific). For
+   *   the Static provider, it must match the size of a host in the inventory.
    * @param {Range|int} [opts.cpu] - The desired number of CPUs.
    * @param {Range|int} [opts.ram] - The desired amount of RAM in GiB.
    * @param {int} [opts.diskSize] - The desired amount of disk space in GB.
@@ -690,7 +857,7 @@ class Machine {
     this.provider = getString('provider', opts.provider);
     if (this.provider === '') {
       throw new Error('Machine must specify a provider (accepted values are Amazon, ' +
-        'DigitalOcean, Google, and Vagrant');
+        'DigitalOcean, Docker, Google, Static, and Vagrant');
     }
     this.role = getString('role', opts.role);
     this.region = getString('region', opts.region);
@@ -720,10 +887,17 @@ class Machine {
    * @returns {void}
    */
   chooseSize(cpu, ram) {
-    if (this.provider === 'Vagrant') {
+    if (this.provider === 'Vagrant' || this.provider === 'Docker') {
       this.vagrantSize(cpu, ram);
       return;
     }
+    if (this.provider === 'Static') {
+      // Static sizes are chosen by the inventory, so they're used as is.
+      if (this.size === '') {
+        this.vagrantSize(cpu, ram);
+      }
+      return;
+    }
     let providerDescriptions;
     switch (this.provider) {
       case 'Amazon':
@@ -838,11 +1012,12 @@ class Machine {
   }
 
   /**
-   * Rounds up RAM and CPU requirements to be at least one for Vagrant.
+   * Rounds up RAM and CPU requirements to be at least one for Vagrant, Docker,
+   * and Static.
    * @private
    * @param {Range} cpuRange - The desired number of CPUs.
    * @param {Range} ramRange - The desired amount of RAM in GiB.
-   * @returns {string} The rounded up Vagrant size.
+   * @returns {string} The rounded up size.
    */
   vagrantSize(cpuRange, ramRange) {
     let ram = ramRange.min;
@@ -914,6 +1089,80 @@ class Machine {
   }
 }
 
+class MachineGroup {
+  /**
+   * Creates a new MachineGroup, which represents a set of identical worker
+   * machines whose size Kelda adjusts to fit the application.  When
+   * containers can't be scheduled for lack of room, the group grows by one
+   * machine, up to `max`.  When a machine's containers would fit on the other
+   * workers, and `cooldown` seconds have passed since the group last changed
+   * size, the machine is drained and the group shrinks, down to `min`.
+   * Machines that run containers with volumes are never drained.
+   * @constructor
+   *
+   * @example <caption>Run between one and five workers on Amazon.</caption>
+   * const group = new MachineGroup('web', new Machine({
+   *   provider: 'Amazon',
+   *   size: 'm4.large',
+   * }), { min: 1, max: 5 });
+   * const infra = new Infrastructure(master, [], { machineGroups: group });
+   *
+   * @param {string} name - The name of the group, which must be unique within
+   *   the infrastructure.
+   * @param {Machine} machine - The machine that each of the group's workers
+   *   is a copy of.  It must have the same provider and region as the
+   *   infrastructure's other machines.
+   * @param {Object} opts - The bounds of the group.
+   * @param {number} [opts.min=0] - The fewest machines that the group runs.
+   * @param {number} opts.max - The most machines that the group runs.
+   * @param {number} [opts.cooldown=300] - The number of seconds after the
+   *   group last changed size before it may shrink.
+   */
+  constructor(name, machine, opts = {}) {
+    if (typeof name !== 'string' || name === '') {
+      throw new Error(`name must be a non-empty string (was: ${stringify(name)})`);
+    }
+    if (!(machine instanceof Machine)) {
+      throw new Error(`machine must be a Machine (was: ${stringify(machine)})`);
+    }
+
+    this.name = name;
+    this.machine = machine.clone();
+    this.machine.role = 'Worker';
+    this.min = getNumber('min', opts.min);
+    this.max = getNumber('max', opts.max);
+    this.cooldown = getNumber('cooldown', opts.cooldown);
+
+    checkExtraKeys(opts, this);
+
+    if (this.min < 0 || this.max < 1 || this.min > this.max) {
+      throw new Error(`machine group "${name}" must have 0 <= min <= max ` +
+        `and max >= 1 (was min ${this.min} and max ${this.max})`);
+    }
+    if (this.cooldown < 0) {
+      throw new Error(`cooldown of machine group "${name}" must not be ` +
+        'negative');
+    }
+  }
+
+  /**
+   * Converts the MachineGroup to the JSON format expected by the Kelda go
+   * code.
+   * @private
+   * @returns {Object} A map that can be converted to JSON and interpreted by the Kelda
+   *   Go code.
+   */
+  toKeldaRepresentation() {
+    return {
+      name: this.name,
+      machine: this.machine.toKeldaRepresentation(),
+      min: this.min,
+      max: this.max,
+      cooldown: this.cooldown,
+    };
+  }
+}
+
 class Image {
   /**
    * Creates a Docker Image.
@@ -1007,8 +1256,9 @@ class Container {
    *   boot, or a string with the name of a Docker image (that exists in
    *   Docker Hub) that the container should boot.
    * @param {Object} [opts] - Additional, named, optional arguments.
-   * @param {string[]} [opts.command] - The command to use when starting
-   *   the container.
+   * @param {(string|Secret|RuntimeValue)[]} [opts.command] - The command to
+   *   use when starting the container. If the command references a
+   *   {@link Secret}, the container won't boot until the secret has been set.
    * @param {Object.<string, string|Secret|RuntimeValue>} [opts.env] -
    *   Environment variables to set in the booted container. The key is the name
    *   of the environment variable.
@@ -1019,6 +1269,38 @@ class Container {
    *   by this argument changes and the blueprint is re-run, Kelda will re-start
    *   the container using the new files.  Files are installed with permissions
    *   0644 and parent directories are automatically created.
+   * @param {number} [opts.cpuRequest] - The number of CPU cores reserved for
+   *   the container. Kelda only places the container on a machine with
+   *   enough unreserved cores.
+   * @param {number} [opts.ramRequest] - The amount of RAM, in GiB, reserved
+   *   for the container.
+   * @param {number} [opts.cpuLimit] - The maximum number of CPU cores the
+   *   container may use.
+   * @param {number} [opts.ramLimit] - The maximum amount of RAM, in GiB, the
+   *   container may use.
+   * @param {Object.<string, Volume>} [opts.volumeMounts] - Volumes to mount
+   *   in the container.  The key is the path in the container at which the
+   *   volume is mounted.  The container is always placed on the machine that
+   *   holds its volumes.
+   * @param {HealthCheck} [opts.healthCheck] - A check that is periodically run
+   *   against the container.  Containers that fail their health check are
+   *   removed from DNS and load balancers until they pass it again.
+   * @param {string} [opts.restartPolicy=always] - Whether the container is
+   *   restarted when it exits.  Either 'always', 'on-failure' (only when it
+   *   exits with a non-zero status), or 'never'.
+   * @param {RollingUpdate} [opts.rollingUpdate] - How the container is
+   *   replaced when its spec changes.  By default, all containers whose spec
+   *   changed are replaced at once.
+   * @param {Job} [opts.job] - If set, the container runs to completion as a
+   *   {@link Job}, rather than as a long-running service.  Jobs can't have a
+   *   `restartPolicy` or `rollingUpdate`.
+   * @param {Container[]} [opts.dependsOn] - Containers that must be running
+   *   (and healthy, if they have a health check) before this container boots.
+   *   Jobs must have succeeded instead.  Cyclic dependencies are not allowed.
+   * @param {number} [opts.replicas=1] - The number of copies of the container
+   *   to run.  Replicas after the first have the container's hostname with
+   *   `-2`, `-3`, and so on appended.  Jobs can't have replicas.  The number
+   *   of replicas can be changed without redeploying with `kelda scale`.
    */
   constructor(hostnamePrefix, image, opts = {}) {
     // refID is used to distinguish infrastructures with multiple references to the
@@ -1039,13 +1321,62 @@ class Container {
     this.hostname = uniqueHostname(this.hostnamePrefix);
     validateHostname(this.hostname);
 
-    this.command = getStringArray('command', opts.command);
+    this.command = getSecretOrStringArray('command', opts.command);
     this.env = getSecretOrStringMap('env', opts.env);
     this.filepathToContent = getSecretOrStringMap('filepathToContent',
       opts.filepathToContent);
+    this.cpuRequest = getNumber('cpuRequest', opts.cpuRequest);
+    this.ramRequest = getNumber('ramRequest', opts.ramRequest);
+    this.cpuLimit = getNumber('cpuLimit', opts.cpuLimit);
+    this.ramLimit = getNumber('ramLimit', opts.ramLimit);
+    this.volumeMounts = getVolumeMap('volumeMounts', opts.volumeMounts);
+    this.healthCheck = opts.healthCheck;
+    if (this.healthCheck !== undefined &&
+      !(this.healthCheck instanceof HealthCheck)) {
+      throw new Error('healthCheck must be a HealthCheck (was: ' +
+        `${stringify(this.healthCheck)})`);
+    }
+    this.restartPolicy = getString('restartPolicy', opts.restartPolicy);
+    if (!['', 'always', 'on-failure', 'never'].includes(this.restartPolicy)) {
+      throw new Error(`unknown restart policy '${this.restartPolicy}' ` +
+        '(must be \'always\', \'on-failure\', or \'never\')');
+    }
+    this.rollingUpdate = opts.rollingUpdate;
+    if (this.rollingUpdate !== undefined &&
+      !(this.rollingUpdate instanceof RollingUpdate)) {
+      throw new Error('rollingUpdate must be a RollingUpdate (was: ' +
+        `${stringify(this.rollingUpdate)})`);
+    }
+    this.job = opts.job;
+    if (this.job !== undefined) {
+      if (!(this.job instanceof Job)) {
+        throw new Error(`job must be a Job (was: ${stringify(this.job)})`);
+      }
+      if (this.restartPolicy !== '' || this.rollingUpdate !== undefined) {
+        throw new Error('jobs can\'t have a restartPolicy or rollingUpdate');
+      }
+    }
+
+    this.replicas = opts.replicas === undefined ? 1 : opts.replicas;
+    if (!Number.isInteger(this.replicas) || this.replicas < 1) {
+      throw new Error('replicas must be a positive integer (was: ' +
+        `${stringify(this.replicas)})`);
+    }
+    if (this.job !== undefined && this.replicas > 1) {
+      throw new Error('jobs can\'t have replicas');
+    }
+
+    this.dependsOn = opts.dependsOn || [];
+    if (!Array.isArray(this.dependsOn) ||
+      !this.dependsOn.every(c => c instanceof Container)) {
+      throw new Error('dependsOn must be an array of Containers (was: ' +
+        `${stringify(this.dependsOn)})`);
+    }
 
     // Don't allow callers to modify the arguments by reference.
+    this.dependsOn = _.clone(this.dependsOn);
     this.command = _.clone(this.command);
+    this.volumeMounts = _.clone(this.volumeMounts);
     this.env = _.clone(this.env);
     this.filepathToContent = _.clone(this.filepathToContent);
     this.image = this.image.clone();
@@ -1062,6 +1393,19 @@ class Container {
     return new Container(this.hostnamePrefix, this.image, this);
   }
 
+  /**
+   * Mounts the given volume in the container.
+   *
+   * @param {Volume} volume - The volume to mount.
+   * @param {string} mountPath - The path in the container at which to mount
+   *   the volume.
+   * @returns {void}
+   */
+  mount(volume, mountPath) {
+    this.volumeMounts = Object.assign({}, this.volumeMounts,
+      getVolumeMap('volumeMounts', { [mountPath]: volume }));
+  }
+
   /**
    * Sets the given environment variable to the given value.
    *
@@ -1135,6 +1479,25 @@ class Container {
     });
   }
 
+  /**
+   * Requires this Container to be placed on the same Machine as another
+   * Container, e.g. so that a cache can sit next to the application using it.
+   *
+   * @param {Container} other - The Container to place this Container with.
+   * @returns {void}
+   */
+  placeWith(other) {
+    if (!(other instanceof Container)) {
+      throw new Error('Containers can only be placed with other ' +
+        `Containers, not ${stringify(other)}`);
+    }
+    this.placements.push({
+      targetContainer: this.hostname,
+      exclusive: false,
+      otherContainer: other.hostname,
+    });
+  }
+
   /**
    * Allows connections to this Container from the given Container(s) on the given
    * port or port range.  Containers have a default-deny firewall, meaning that
@@ -1221,6 +1584,309 @@ class Container {
       env: this.env,
       filepathToContent: this.filepathToContent,
       hostname: this.hostname,
+      cpuRequest: this.cpuRequest,
+      ramRequest: this.ramRequest,
+      cpuLimit: this.cpuLimit,
+      ramLimit: this.ramLimit,
+      volumeMounts: Object.keys(this.volumeMounts).sort().map(mountPath => ({
+        volume: this.volumeMounts[mountPath].name,
+        mountPath,
+      })),
+      healthCheck: this.healthCheck === undefined ? undefined :
+        this.healthCheck.toKeldaRepresentation(),
+      restartPolicy: this.restartPolicy,
+      rollingUpdate: this.rollingUpdate === undefined ? undefined :
+        this.rollingUpdate.toKeldaRepresentation(this.hostnamePrefix),
+      job: this.job === undefined ? undefined :
+        this.job.toKeldaRepresentation(),
+      dependsOn: this.dependsOn.map(c => c.hostname),
+      replicas: this.replicas,
+    };
+  }
+}
+
+class Job {
+  /**
+   * Creates a new Job, which runs a container to completion rather than as a
+   * long-running service.  Each run of the job boots copies of the container,
+   * at most `parallelism` at a time, until `completions` of them have exited
+   * successfully.  A copy that exits with a non-zero status is retried up to
+   * `retryLimit` times, after which the run fails.  The history of each job's
+   * runs is shown by `kelda show`.
+   *
+   * @constructor
+   *
+   * @example <caption>Run a database migration once.</caption>
+   * new Container('migrate', 'app', {
+   *   command: ['migrate'],
+   *   job: new Job({ retryLimit: 3 }),
+   * });
+   *
+   * @example <caption>Generate a report every night at 3am.</caption>
+   * new Container('report', 'app', {
+   *   command: ['report'],
+   *   job: new Job({ schedule: '0 3 * * *' }),
+   * });
+   *
+   * @param {Object} [opts] - The options of the job.
+   * @param {number} [opts.completions=1] - The number of copies of the
+   *   container that must exit successfully for a run to succeed.
+   * @param {number} [opts.parallelism=1] - The most copies of the container
+   *   that may run at once.
+   * @param {number} [opts.retryLimit=0] - The number of times a copy of the
+   *   container that fails is retried.
+   * @param {string} [opts.schedule] - A cron expression with five fields
+   *   (minute, hour, day of month, month, and day of week), such as
+   *   `'0 3 * * *'`.  If set, the job runs each time the schedule fires, unless
+   *   its previous run is still going.  Otherwise, the job runs once.
+   */
+  constructor(opts = {}) {
+    this.completions = getNumber('completions', opts.completions);
+    this.parallelism = getNumber('parallelism', opts.parallelism);
+    this.retryLimit = getNumber('retryLimit', opts.retryLimit);
+    this.schedule = getString('schedule', opts.schedule);
+
+    checkExtraKeys(opts, this);
+
+    if (this.completions < 0 || this.parallelism < 0 || this.retryLimit < 0) {
+      throw new Error('completions, parallelism, and retryLimit must not be ' +
+        'negative');
+    }
+    if (this.schedule !== '' && this.schedule.trim().split(/\s+/).length !== 5) {
+      throw new Error(`schedule '${this.schedule}' must have five fields ` +
+        '(minute, hour, day of month, month, and day of week)');
+    }
+  }
+
+  /**
+   * Converts the Job to the JSON format expected by the Kelda go code.
+   * @private
+   * @returns {Object} A map that can be converted to JSON and interpreted by the Kelda
+   *   Go code.
+   */
+  toKeldaRepresentation() {
+    return {
+      completions: this.completions,
+      parallelism: this.parallelism,
+      retryLimit: this.retryLimit,
+      schedule: this.schedule,
+    };
+  }
+}
+
+class RollingUpdate {
+  /**
+   * Creates a new RollingUpdate, which replaces the containers in a group a
+   * few at a time when their spec changes, rather than all at once.  The next
+   * batch of containers is only replaced once the replacements in the previous
+   * one are running, and healthy if they have a health check.  Rolling updates
+   * can be paused, resumed, and aborted with `kelda rollout`.
+   *
+   * @constructor
+   *
+   * @example <caption>Update three web servers one at a time.</caption>
+   * const update = new RollingUpdate({ maxUnavailable: 1 });
+   * for (let i = 0; i < 3; i += 1) {
+   *   new Container('web', 'nginx', { rollingUpdate: update });
+   * }
+   *
+   * @param {Object} [opts] - The options of the rolling update.
+   * @param {string} [opts.group] - The name of the group of containers that
+   *   are updated together.  Defaults to the hostname prefix of each container.
+   * @param {number} [opts.maxUnavailable] - The number of containers in the
+   *   group that may be stopped before their replacements are ready.  Defaults
+   *   to 1 unless `maxSurge` is set.
+   * @param {number} [opts.maxSurge=0] - The number of replacements that may be
+   *   booted alongside the containers they replace.
+   */
+  constructor(opts = {}) {
+    this.group = getString('group', opts.group);
+    this.maxUnavailable = getNumber('maxUnavailable', opts.maxUnavailable);
+    this.maxSurge = getNumber('maxSurge', opts.maxSurge);
+
+    checkExtraKeys(opts, this);
+
+    if (this.maxUnavailable < 0 || this.maxSurge < 0) {
+      throw new Error('maxUnavailable and maxSurge must not be negative');
+    }
+  }
+
+  /**
+   * Converts the RollingUpdate to the JSON format expected by the Kelda go
+   * code.
+   * @private
+   * @param {string} hostnamePrefix - The hostname prefix of the container, which
+   *   is the group if none was given.
+   * @returns {Object} A map that can be converted to JSON and interpreted by the Kelda
+   *   Go code.
+   */
+  toKeldaRepresentation(hostnamePrefix) {
+    return {
+      group: this.group || hostnamePrefix,
+      maxUnavailable: this.maxUnavailable,
+      maxSurge: this.maxSurge,
+    };
+  }
+}
+
+class HealthCheck {
+  /**
+   * Creates a new HealthCheck, which determines whether a container should
+   * receive traffic.  A container is unhealthy once `failureThreshold` checks
+   * in a row fail, and healthy again as soon as a check passes.  Until it first
+   * passes its health check, a container is considered unhealthy.
+   *
+   * @constructor
+   *
+   * @example <caption>Only send traffic to a web server once GET requests to
+   * /healthz succeed.</caption>
+   * const web = new Container('web', 'nginx', {
+   *   healthCheck: new HealthCheck({ type: 'http', port: 80, path: '/healthz' }),
+   * });
+   *
+   * @param {Object} opts - The options of the health check.
+   * @param {string} opts.type - Either 'http', which passes if a GET request
+   *   returns a 2xx or 3xx status code, 'tcp', which passes if a connection can
+   *   be opened, or 'exec', which passes if a command exits with status zero.
+   * @param {number} [opts.port] - The port to check for 'http' and 'tcp' checks.
+   * @param {string} [opts.path=/] - The path requested by 'http' checks.
+   * @param {string[]} [opts.command] - The command run in the container by
+   *   'exec' checks.
+   * @param {number} [opts.interval=10] - The number of seconds between checks.
+   * @param {number} [opts.timeout=5] - The number of seconds after which a
+   *   check fails.
+   * @param {number} [opts.failureThreshold=3] - The number of consecutive
+   *   failed checks after which the container is unhealthy.
+   */
+  constructor(opts = {}) {
+    this.type = getString('type', opts.type);
+    this.port = getNumber('port', opts.port);
+    this.path = getString('path', opts.path);
+    this.command = _.clone(getStringArray('command', opts.command));
+    this.interval = getNumber('interval', opts.interval);
+    this.timeout = getNumber('timeout', opts.timeout);
+    this.failureThreshold = getNumber('failureThreshold',
+      opts.failureThreshold);
+
+    checkExtraKeys(opts, this);
+
+    if (this.type === 'http' || this.type === 'tcp') {
+      if (this.port <= 0) {
+        throw new Error(`${this.type} health checks require a port`);
+      }
+    } else if (this.type === 'exec') {
+      if (this.command.length === 0) {
+        throw new Error('exec health checks require a command');
+      }
+    } else {
+      throw new Error(`unknown health check type '${this.type}' (must be ` +
+        '\'http\', \'tcp\', or \'exec\')');
+    }
+  }
+
+  /**
+   * Converts the HealthCheck to the JSON format expected by the Kelda go code.
+   * @private
+   * @returns {Object} A map that can be converted to JSON and interpreted by the Kelda
+   *   Go code.
+   */
+  toKeldaRepresentation() {
+    return {
+      type: this.type,
+      port: this.port,
+      path: this.path,
+      command: this.command,
+      intervalSeconds: this.interval,
+      timeoutSeconds: this.timeout,
+      failureThreshold: this.failureThreshold,
+    };
+  }
+}
+
+class Volume {
+  /**
+   * Creates a new Volume, which is storage whose contents outlive the
+   * containers that mount it.  Volumes are deployed along with the containers
+   * that mount them.
+   *
+   * Host volumes are stored on the disk of the first machine that a container
+   * mounting them is placed on, and containers mounting them are always placed
+   * on that machine.  Block volumes are created in the cloud provider's block
+   * storage (e.g. EBS on Amazon), and if their machine goes away, they're
+   * attached to another worker along with the containers that mount them.
+   *
+   * Volumes are never deleted by Kelda.
+   *
+   * @constructor
+   *
+   * @example <caption>Create a 10GiB block volume on Amazon, and mount it at
+   * /data in a container.</caption>
+   * const data = new Volume('data', {
+   *   type: 'block', size: 10, provider: 'Amazon', region: 'us-west-1',
+   * });
+   * const redis = new Container('redis', 'redis', {
+   *   volumeMounts: { '/data': data },
+   * });
+   *
+   * @param {string} name - The name of the volume.  It must be unique within
+   *   the blueprint.
+   * @param {Object} [opts] - Additional, named, optional arguments.
+   * @param {string} [opts.type=host] - Either 'host' or 'block'.
+   * @param {number} [opts.size] - The size of a block volume, in GiB.
+   * @param {string} [opts.provider] - The provider in which to create a block
+   *   volume.
+   * @param {string} [opts.region] - The region in which to create a block
+   *   volume.  Defaults to the provider's default region.
+   */
+  constructor(name, opts = {}) {
+    this.name = getString('name', name);
+    validateHostname(this.name);
+
+    this.type = getString('type', opts.type) || 'host';
+    this.size = getNumber('size', opts.size);
+    this.provider = getString('provider', opts.provider);
+    this.region = getString('region', opts.region);
+
+    checkExtraKeys(opts, this);
+
+    if (this.type === 'host') {
+      if (this.size !== 0 || this.provider !== '' || this.region !== '') {
+        throw new Error(`host volume "${this.name}" cannot have a size, ` +
+          'provider, or region');
+      }
+    } else if (this.type === 'block') {
+      if (this.size <= 0) {
+        throw new Error(`block volume "${this.name}" must have a ` +
+          'positive size');
+      }
+      if (!objectHasKey.call(providerDefaultRegions, this.provider) ||
+        this.provider === 'Vagrant' || this.provider === 'Docker' ||
+        this.provider === 'Static') {
+        throw new Error(`block volume "${this.name}" has unsupported ` +
+          `provider '${this.provider}'`);
+      }
+      if (this.region === '') {
+        this.region = providerDefaultRegions[this.provider];
+      }
+    } else {
+      throw new Error(`volume "${this.name}" has unknown type ` +
+        `'${this.type}' (must be 'host' or 'block')`);
+    }
+  }
+
+  /**
+   * Converts the Volume to the JSON format expected by the Kelda go code.
+   * @private
+   * @returns {Object} A map that can be converted to JSON and interpreted by the Kelda
+   *   Go code.
+   */
+  toKeldaRepresentation() {
+    return {
+      name: this.name,
+      type: this.type,
+      sizeGiB: this.size,
+      provider: this.provider,
+      region: this.region,
     };
   }
 }
@@ -1271,6 +1937,45 @@ class RuntimeValue {
  */
 const hostIP = new RuntimeValue('host.ip');
 
+/**
+ * hostPrivateIP is the {@link RuntimeValue} for the private IP of the machine
+ * the container is running on.
+ */
+const hostPrivateIP = new RuntimeValue('host.privateIP');
+
+/**
+ * hostFloatingIP is the {@link RuntimeValue} for the floating IP of the machine
+ * the container is running on. It's empty if the machine has no floating IP.
+ */
+const hostFloatingIP = new RuntimeValue('host.floatingIP');
+
+/**
+ * hostProvider is the {@link RuntimeValue} for the cloud provider of the
+ * machine the container is running on, e.g. "Amazon".
+ */
+const hostProvider = new RuntimeValue('host.provider');
+
+/**
+ * hostRegion is the {@link RuntimeValue} for the region of the machine the
+ * container is running on.
+ */
+const hostRegion = new RuntimeValue('host.region');
+
+/**
+ * hostSize is the {@link RuntimeValue} for the instance size of the machine the
+ * container is running on.
+ */
+const hostSize = new RuntimeValue('host.size');
+
+/**
+ * containerIP is the {@link RuntimeValue} for the container's own IP address
+ * on the Kelda network.
+ *
+ * @example <caption>Advertise the container's IP to its peers.</caption>
+ * myContainer.setEnv('ADVERTISE_ADDR', containerIP);
+ */
+const containerIP = new RuntimeValue('container.ip');
+
 /**
  * Attempts to convert `objects` into an array of objects that
  * define getConnectableName.
@@ -1373,17 +2078,41 @@ function allow(src, dst, port) {
  * @param {Connectable|Connectable[]} dst - the Connectables that can accept inbound
  *  traffic from those listed in `src`.
  * @param {int|Port|PortRange} portRange - The ports on which Connectables can
- *   send traffic.
+ *   send traffic.  Must be null for ICMP.
+ * @param {string|Object} [options] - Either the IP protocol of the traffic,
+ *   or an object with the following optional fields.
+ * @param {string} [options.protocol] - The IP protocol of the traffic: 'tcp',
+ *   'udp', or 'icmp'.  If omitted, both TCP and UDP traffic are allowed on the
+ *   ports, as is ICMP.
+ * @param {int} [options.containerPort] - For traffic from publicInternet, the
+ *   container port that the first port in `portRange` is forwarded to.  The
+ *   rest of the range is shifted by the same amount, and may have at most 100
+ *   ports.  Defaults to the public port.
  * @returns {void}
  */
-function allowTraffic(src, dst, portRange) {
-  if (portRange === undefined || portRange === null) {
+function allowTraffic(src, dst, portRange, options) {
+  const opts = typeof options === 'string' ? { protocol: options } :
+    (options || {});
+  const { protocol, containerPort } = opts;
+  if (protocol !== undefined && !protocols.includes(protocol)) {
+    throw new Error(`protocol must be one of ${protocols.join(', ')}, ` +
+      `not ${stringify(protocol)}`);
+  }
+
+  const noPorts = portRange === undefined || portRange === null;
+  let ports = new PortRange(0, 0);
+  if (protocol === 'icmp') {
+    if (!noPorts) {
+      throw new Error('ICMP traffic does not have ports');
+    }
+  } else if (noPorts) {
     throw new Error('a port or port range is required');
+  } else {
+    ports = boxRange(portRange);
   }
 
   const srcArr = boxConnectable(src);
   const dstArr = boxConnectable(dst);
-  const ports = boxRange(portRange);
 
   for (let i = 0; i < srcArr.length; i += 1) {
     if (srcArr[i] instanceof LoadBalancer) {
@@ -1392,10 +2121,24 @@ function allowTraffic(src, dst, portRange) {
     }
   }
 
-  if ((srcArr.includes(publicInternet) || dstArr.includes(publicInternet)) &&
-    (ports.min !== ports.max)) {
-    throw new Error('public internet can only connect to single ports ' +
-      'and not to port ranges');
+  if (containerPort !== undefined) {
+    if (!srcArr.includes(publicInternet)) {
+      throw new Error('a container port can only be set for traffic from ' +
+        'publicInternet');
+    }
+    if (protocol === 'icmp') {
+      throw new Error('ICMP traffic does not have ports');
+    }
+    if (!Number.isInteger(containerPort) || containerPort < 1 ||
+      containerPort + (ports.max - ports.min) > 65535) {
+      throw new Error(`container port ${stringify(containerPort)} is not ` +
+        `valid for port range ${ports}`);
+    }
+    if (containerPort !== ports.min &&
+      ports.max - ports.min >= maxRemappedPorts) {
+      throw new Error(`port range ${ports} is too large to remap. Remapped ` +
+        `ranges may have at most ${maxRemappedPorts} ports`);
+    }
   }
 
   _connections.push({
@@ -1403,6 +2146,8 @@ function allowTraffic(src, dst, portRange) {
     to: dstArr.map(c => c.getConnectableName()),
     minPort: ports.min,
     maxPort: ports.max,
+    protocol,
+    containerPort,
   });
 }
 
@@ -1504,19 +2249,30 @@ function resetGlobals() {
 
 module.exports = {
   Container,
+  HealthCheck,
   Infrastructure,
   Image,
   Machine,
+  MachineGroup,
   Port,
   PortRange,
   Range,
+  Job,
+  RollingUpdate,
   Secret,
   LoadBalancer,
+  Volume,
   allow,
   allowTraffic,
+  containerIP,
   getInfrastructure,
   githubKeys,
+  hostFloatingIP,
   hostIP,
+  hostPrivateIP,
+  hostProvider,
+  hostRegion,
+  hostSize,
   publicInternet,
   resetGlobals,
   baseInfraLocation,
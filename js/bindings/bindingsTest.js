@@ -112,7 +112,8 @@ describe('Bindings', () => {
     });
     it('throws error when no Provider specified', () => {
       expect(() => new b.Machine({})).to.throw('Machine must specify a provider ' +
-        '(accepted values are Amazon, DigitalOcean, Google, and Vagrant');
+        '(accepted values are Amazon, DigitalOcean, Docker, Google, Static, ' +
+        'and Vagrant');
     });
     it('chooses size when provided ram and cpu', () => {
       const machine = new b.Machine({
@@ -304,6 +305,30 @@ describe('Bindings', () => {
         region: '',
       }]);
     });
+    it('uses empty string as region for Docker', () => {
+      const machine = new b.Machine({
+        provider: 'Docker',
+        sshKeys: ['key1', 'key2'],
+      });
+      infra = new b.Infrastructure(machine, machine);
+      checkMachines([{
+        provider: 'Docker',
+        region: '',
+      }]);
+    });
+    it('uses the provided size for Static', () => {
+      const machine = new b.Machine({
+        provider: 'Static',
+        size: 'rack1',
+        sshKeys: ['key1', 'key2'],
+      });
+      infra = new b.Infrastructure(machine, machine);
+      checkMachines([{
+        provider: 'Static',
+        region: '',
+        size: 'rack1',
+      }]);
+    });
     it('uses provided region when region is provided', () => {
       const machine = new b.Machine({
         provider: 'Amazon',
@@ -487,6 +512,22 @@ describe('Bindings', () => {
         filepathToContent: {},
       }]);
     });
+    it('command with secrets and runtime values', () => {
+      const container = new b.Container('host', 'image', {
+        command: ['--password', new b.Secret('pw'), '--ip', b.containerIP],
+      });
+      container.deploy(infra);
+      checkContainers([{
+        command: ['--password', { nameOfSecret: 'pw' },
+          '--ip', { resourceKey: 'container.ip' }],
+      }]);
+    });
+    it('bad command', () => {
+      expect(() => new b.Container('host', 'image', { command: ['a', 1] }))
+        .to.throw('command must be an array of strings, RuntimeValues, or ' +
+          'Secrets. Item at index 1 (1) is not a string, RuntimeValue or ' +
+          'Secret.');
+    });
     it('env', () => {
       const c = new b.Container('host', 'image');
       c.env.foo = 'bar';
@@ -506,6 +547,26 @@ describe('Bindings', () => {
         filepathToContent: {},
       }]);
     });
+    it('runtime values', () => {
+      const c = new b.Container('host', 'image');
+      c.env.privateIP = b.hostPrivateIP;
+      c.env.floatingIP = b.hostFloatingIP;
+      c.env.provider = b.hostProvider;
+      c.env.region = b.hostRegion;
+      c.env.size = b.hostSize;
+      c.filepathToContent['/ip'] = b.containerIP;
+      c.deploy(infra);
+      checkContainers([{
+        env: {
+          privateIP: { resourceKey: 'host.privateIP' },
+          floatingIP: { resourceKey: 'host.floatingIP' },
+          provider: { resourceKey: 'host.provider' },
+          region: { resourceKey: 'host.region' },
+          size: { resourceKey: 'host.size' },
+        },
+        filepathToContent: { '/ip': { resourceKey: 'container.ip' } },
+      }]);
+    });
     it('hostname', () => {
       const c = new b.Container('host', new b.Image('image'));
       c.deploy(infra);
@@ -573,6 +634,287 @@ describe('Bindings', () => {
       expect(() => infra.toKeldaRepresentation()).to
         .throw('hostname "host" used multiple times');
     });
+    it('resources', () => {
+      const c = new b.Container('host', 'image', {
+        cpuRequest: 1,
+        ramRequest: 2,
+        cpuLimit: 2,
+        ramLimit: 4,
+      });
+      c.deploy(infra);
+      checkContainers([{
+        id: '293fc7ad8a799d3cf2619a3db7124b0459f395cb',
+        hostname: 'host',
+        cpuRequest: 1,
+        ramRequest: 2,
+        cpuLimit: 2,
+        ramLimit: 4,
+      }]);
+    });
+    it('limit lower than request causes error', () => {
+      const c = new b.Container('host', 'image', {
+        ramRequest: 2,
+        ramLimit: 1,
+      });
+      c.deploy(infra);
+      expect(() => infra.toKeldaRepresentation()).to
+        .throw('container "host" has a ramLimit lower than its ramRequest');
+    });
+    it('volume mounts', () => {
+      const data = new b.Volume('data');
+      const logs = new b.Volume('logs', {
+        type: 'block', size: 10, provider: 'Amazon',
+      });
+      const c = new b.Container('host', 'image', {
+        volumeMounts: { '/var/log': logs },
+      });
+      c.mount(data, '/data');
+      c.deploy(infra);
+      checkContainers([{
+        hostname: 'host',
+        volumeMounts: [
+          { volume: 'data', mountPath: '/data' },
+          { volume: 'logs', mountPath: '/var/log' },
+        ],
+      }]);
+      expect(infra.toKeldaRepresentation().volumes).to.eql([
+        {
+          name: 'data', type: 'host', sizeGiB: 0, provider: '', region: '',
+        },
+        {
+          name: 'logs',
+          type: 'block',
+          sizeGiB: 10,
+          provider: 'Amazon',
+          region: 'us-west-1',
+        },
+      ]);
+    });
+    it('volume mounts must be Volumes', () => {
+      expect(() => new b.Container('host', 'image', {
+        volumeMounts: { '/data': 'data' },
+      })).to.throw('volumeMounts must be a map from paths to Volumes ' +
+        '(was: {"/data":"data"})');
+    });
+    it('volumes with differing definitions cause error', () => {
+      new b.Container('a', 'image', {
+        volumeMounts: { '/data': new b.Volume('data') },
+      }).deploy(infra);
+      new b.Container('b', 'image', {
+        volumeMounts: {
+          '/data': new b.Volume('data', { type: 'block', size: 1, provider: 'Amazon' }),
+        },
+      }).deploy(infra);
+      expect(() => infra.toKeldaRepresentation()).to
+        .throw('volume "data" has differing definitions');
+    });
+    it('block volume without workers in its cloud causes error', () => {
+      const v = new b.Volume('data', {
+        type: 'block', size: 1, provider: 'Google',
+      });
+      new b.Container('host', 'image', { volumeMounts: { '/data': v } })
+        .deploy(infra);
+      expect(() => infra.toKeldaRepresentation()).to
+        .throw('block volume "data" is in Google region \'us-east1-b\', ' +
+          'which has no worker machines');
+    });
+    it('invalid volumes', () => {
+      expect(() => new b.Volume('data', { type: 'nfs' })).to
+        .throw('volume "data" has unknown type \'nfs\' ' +
+          '(must be \'host\' or \'block\')');
+      expect(() => new b.Volume('data', { size: 1 })).to
+        .throw('host volume "data" cannot have a size, provider, or region');
+      expect(() => new b.Volume('data', { type: 'block', provider: 'Amazon' }))
+        .to.throw('block volume "data" must have a positive size');
+      expect(() => new b.Volume('data', { type: 'block', size: 1 })).to
+        .throw('block volume "data" has unsupported provider \'\'');
+      expect(() => new b.Volume('data', {
+        type: 'block', size: 1, provider: 'Docker',
+      })).to.throw('block volume "data" has unsupported provider \'Docker\'');
+    });
+    it('health check', () => {
+      const c = new b.Container('host', 'image', {
+        healthCheck: new b.HealthCheck({
+          type: 'http', port: 80, path: '/healthz', interval: 5,
+        }),
+      });
+      c.deploy(infra);
+      checkContainers([{
+        hostname: 'host',
+        healthCheck: {
+          type: 'http',
+          port: 80,
+          path: '/healthz',
+          command: [],
+          intervalSeconds: 5,
+          timeoutSeconds: 0,
+          failureThreshold: 0,
+        },
+      }]);
+    });
+    it('invalid health checks', () => {
+      expect(() => new b.Container('host', 'image', {
+        healthCheck: { type: 'tcp', port: 80 },
+      })).to.throw('healthCheck must be a HealthCheck ' +
+        '(was: {"port":80,"type":"tcp"})');
+      expect(() => new b.HealthCheck({ type: 'tcp' })).to
+        .throw('tcp health checks require a port');
+      expect(() => new b.HealthCheck({ type: 'exec' })).to
+        .throw('exec health checks require a command');
+      expect(() => new b.HealthCheck({ type: 'ping' })).to
+        .throw('unknown health check type \'ping\' ' +
+          '(must be \'http\', \'tcp\', or \'exec\')');
+    });
+    it('restart policy', () => {
+      const c = new b.Container('host', 'image', {
+        restartPolicy: 'on-failure',
+      });
+      c.deploy(infra);
+      checkContainers([{
+        hostname: 'host',
+        restartPolicy: 'on-failure',
+      }]);
+    });
+    it('invalid restart policy', () => {
+      expect(() => new b.Container('host', 'image', {
+        restartPolicy: 'sometimes',
+      })).to.throw('unknown restart policy \'sometimes\' ' +
+        '(must be \'always\', \'on-failure\', or \'never\')');
+    });
+    it('rolling update', () => {
+      const update = new b.RollingUpdate({ maxSurge: 1 });
+      new b.Container('web', 'image', { rollingUpdate: update }).deploy(infra);
+      new b.Container('web', 'image', { rollingUpdate: update }).deploy(infra);
+      new b.Container('db', 'image', {
+        rollingUpdate: new b.RollingUpdate({ group: 'db-group' }),
+      }).deploy(infra);
+      checkContainers([{
+        hostname: 'web',
+        rollingUpdate: { group: 'web', maxUnavailable: 0, maxSurge: 1 },
+      }, {
+        hostname: 'web2',
+        rollingUpdate: { group: 'web', maxUnavailable: 0, maxSurge: 1 },
+      }, {
+        hostname: 'db',
+        rollingUpdate: { group: 'db-group', maxUnavailable: 0, maxSurge: 0 },
+      }]);
+    });
+    it('invalid rolling updates', () => {
+      expect(() => new b.Container('host', 'image', {
+        rollingUpdate: { maxSurge: 1 },
+      })).to.throw('rollingUpdate must be a RollingUpdate ' +
+        '(was: {"maxSurge":1})');
+      expect(() => new b.RollingUpdate({ maxSurge: -1 })).to
+        .throw('maxUnavailable and maxSurge must not be negative');
+      expect(() => new b.RollingUpdate({ batchSize: 1 })).to
+        .throw('Unrecognized keys passed to RollingUpdate constructor: ' +
+          'batchSize');
+    });
+    it('rolling update group with differing settings', () => {
+      new b.Container('web', 'image', {
+        rollingUpdate: new b.RollingUpdate({ maxSurge: 1 }),
+      }).deploy(infra);
+      new b.Container('web', 'image', {
+        rollingUpdate: new b.RollingUpdate({ maxSurge: 2 }),
+      }).deploy(infra);
+      expect(() => infra.toKeldaRepresentation()).to
+        .throw('rolling update group "web" has differing settings');
+    });
+    it('job', () => {
+      new b.Container('migrate', 'image', {
+        job: new b.Job({ completions: 2, retryLimit: 3 }),
+      }).deploy(infra);
+      new b.Container('report', 'image', {
+        job: new b.Job({ schedule: '0 3 * * *' }),
+      }).deploy(infra);
+      checkContainers([{
+        hostname: 'migrate',
+        job: { completions: 2, parallelism: 0, retryLimit: 3, schedule: '' },
+      }, {
+        hostname: 'report',
+        job: {
+          completions: 0, parallelism: 0, retryLimit: 0, schedule: '0 3 * * *',
+        },
+      }]);
+    });
+    it('invalid jobs', () => {
+      expect(() => new b.Container('host', 'image', {
+        job: { completions: 1 },
+      })).to.throw('job must be a Job (was: {"completions":1})');
+      expect(() => new b.Container('host', 'image', {
+        job: new b.Job(), restartPolicy: 'never',
+      })).to.throw('jobs can\'t have a restartPolicy or rollingUpdate');
+      expect(() => new b.Job({ parallelism: -1 })).to
+        .throw('completions, parallelism, and retryLimit must not be negative');
+      expect(() => new b.Job({ schedule: '0 3 * *' })).to
+        .throw('schedule \'0 3 * *\' must have five fields (minute, hour, ' +
+          'day of month, month, and day of week)');
+    });
+    it('depends on', () => {
+      const db = new b.Container('db', 'image');
+      const migrate = new b.Container('migrate', 'image', {
+        job: new b.Job(), dependsOn: [db],
+      });
+      const web = new b.Container('web', 'image', {
+        dependsOn: [db, migrate],
+      });
+      db.deploy(infra);
+      migrate.deploy(infra);
+      web.deploy(infra);
+      web.clone().deploy(infra);
+      checkContainers([{
+        hostname: 'db',
+        dependsOn: [],
+      }, {
+        hostname: 'migrate',
+        dependsOn: ['db'],
+      }, {
+        hostname: 'web',
+        dependsOn: ['db', 'migrate'],
+      }, {
+        hostname: 'web2',
+        dependsOn: ['db', 'migrate'],
+      }]);
+    });
+    it('invalid dependencies', () => {
+      expect(() => new b.Container('host', 'image', {
+        dependsOn: ['db'],
+      })).to.throw('dependsOn must be an array of Containers (was: ["db"])');
+
+      const db = new b.Container('db', 'image');
+      new b.Container('web', 'image', { dependsOn: [db] }).deploy(infra);
+      expect(() => infra.toKeldaRepresentation()).to
+        .throw('container "web" depends on an undeployed container: db');
+    });
+    it('dependency cycle', () => {
+      const db = new b.Container('db', 'image');
+      const web = new b.Container('web', 'image', { dependsOn: [db] });
+      db.dependsOn.push(web);
+      db.deploy(infra);
+      web.deploy(infra);
+      expect(() => infra.toKeldaRepresentation()).to
+        .throw('dependency cycle: db -> web -> db');
+    });
+    it('replicas', () => {
+      new b.Container('web', 'image', { replicas: 3 }).deploy(infra);
+      new b.Container('db', 'image').deploy(infra);
+      checkContainers([{
+        hostname: 'web',
+        replicas: 3,
+      }, {
+        hostname: 'db',
+        replicas: 1,
+      }]);
+    });
+    it('invalid replicas', () => {
+      expect(() => new b.Container('host', 'image', { replicas: 0 })).to
+        .throw('replicas must be a positive integer (was: 0)');
+      expect(() => new b.Container('host', 'image', { replicas: 1.5 })).to
+        .throw('replicas must be a positive integer (was: 1.5)');
+      expect(() => new b.Container('host', 'image', {
+        job: new b.Job(), replicas: 2,
+      })).to.throw('jobs can\'t have replicas');
+    });
     it('image dockerfile', () => {
       const z = new b.Container('host', new b.Image('name', 'dockerfile'));
       z.deploy(infra);
@@ -682,6 +1024,20 @@ describe('Bindings', () => {
         floatingIp: 'xxx.xxx.xxx.xxx',
       }]);
     });
+    it('ContainerRule', () => {
+      const cache = new b.Container('cache', 'image');
+      cache.deploy(infra);
+      cache.placeWith(target);
+      checkPlacements([{
+        targetContainer: 'cache',
+        exclusive: false,
+        otherContainer: 'host',
+      }]);
+    });
+    it('ContainerRule with a non-Container', () => {
+      expect(() => target.placeWith('cache')).to.throw(
+        'Containers can only be placed with other Containers, not "cache"');
+    });
   });
   describe('LoadBalancer', () => {
     beforeEach(createBasicInfra);
@@ -818,16 +1174,22 @@ describe('Bindings', () => {
       }]);
     });
     it('connect to publicInternet port range', () => {
-      expect(() =>
-        b.publicInternet.allowFrom(foo, new b.PortRange(80, 81))).to
-        .throw('public internet can only connect to single ports ' +
-                        'and not to port ranges');
+      b.publicInternet.allowFrom(foo, new b.PortRange(80, 81));
+      checkConnections([{
+        from: ['foo'],
+        to: ['public'],
+        minPort: 80,
+        maxPort: 81,
+      }]);
     });
     it('connect from publicInternet port range', () => {
-      expect(() =>
-        foo.allowFrom(b.publicInternet, new b.PortRange(80, 81))).to
-        .throw('public internet can only connect to single ports ' +
-                        'and not to port ranges');
+      foo.allowFrom(b.publicInternet, new b.PortRange(80, 81));
+      checkConnections([{
+        from: ['public'],
+        to: ['foo'],
+        minPort: 80,
+        maxPort: 81,
+      }]);
     });
     it('allowFrom non-container', () => {
       expect(() => foo.allowFrom(10, 10)).to
@@ -878,6 +1240,34 @@ describe('Bindings', () => {
       expect(() => b.allowTraffic(foo, bar)).to
         .throw('a port or port range is required');
     });
+    it('protocol', () => {
+      b.allowTraffic(foo, bar, 53, 'udp');
+      checkConnections([{
+        from: ['foo'],
+        to: ['bar'],
+        minPort: 53,
+        maxPort: 53,
+        protocol: 'udp',
+      }]);
+    });
+    it('icmp', () => {
+      b.allowTraffic(b.publicInternet, foo, null, 'icmp');
+      checkConnections([{
+        from: ['public'],
+        to: ['foo'],
+        minPort: 0,
+        maxPort: 0,
+        protocol: 'icmp',
+      }]);
+    });
+    it('icmp with ports', () => {
+      expect(() => b.allowTraffic(foo, bar, 80, 'icmp')).to
+        .throw('ICMP traffic does not have ports');
+    });
+    it('invalid protocol', () => {
+      expect(() => b.allowTraffic(foo, bar, 80, 'sctp')).to
+        .throw('protocol must be one of tcp, udp, icmp, not "sctp"');
+    });
     it('connect to invalid port range', () => {
       expect(() => b.allowTraffic(foo, bar, true)).to
         .throw('Input argument must be a number or a Range');
@@ -916,22 +1306,59 @@ describe('Bindings', () => {
           'item at index 0 is not valid');
     });
     it('connect to publicInternet port range', () => {
-      expect(() =>
-        b.allowTraffic(foo, b.publicInternet, new b.PortRange(80, 81))).to
-        .throw('public internet can only connect to single ports ' +
-          'and not to port ranges');
+      b.allowTraffic(foo, b.publicInternet, new b.PortRange(80, 81));
+      checkConnections([{
+        from: ['foo'],
+        to: ['public'],
+        minPort: 80,
+        maxPort: 81,
+      }]);
     });
     it('connect from publicInternet port range', () => {
+      b.allowTraffic(b.publicInternet, foo, new b.PortRange(80, 81));
+      checkConnections([{
+        from: ['public'],
+        to: ['foo'],
+        minPort: 80,
+        maxPort: 81,
+      }]);
+    });
+    it('connect from publicInternet to a container port', () => {
+      b.allowTraffic(b.publicInternet, foo, new b.PortRange(80, 81),
+        { protocol: 'tcp', containerPort: 8080 });
+      checkConnections([{
+        from: ['public'],
+        to: ['foo'],
+        minPort: 80,
+        maxPort: 81,
+        protocol: 'tcp',
+        containerPort: 8080,
+      }]);
+    });
+    it('container port requires publicInternet source', () => {
       expect(() =>
-        b.allowTraffic(b.publicInternet, foo, new b.PortRange(80, 81))).to
-        .throw('public internet can only connect to single ports ' +
-          'and not to port ranges');
+        b.allowTraffic(bar, foo, 80, { containerPort: 8080 })).to
+        .throw('a container port can only be set for traffic from ' +
+          'publicInternet');
     });
-    it('connect from publicInternet port range with others', () => {
+    it('container port must fit the port range', () => {
       expect(() =>
-        b.allowTraffic([b.publicInternet, bar], foo, new b.PortRange(80, 81))).to
-        .throw('public internet can only connect to single ports ' +
-          'and not to port ranges');
+        b.allowTraffic(b.publicInternet, foo, new b.PortRange(80, 90),
+          { containerPort: 65530 })).to
+        .throw('container port 65530 is not valid for port range [80, 90]');
+    });
+    it('remapped port ranges are limited in size', () => {
+      expect(() =>
+        b.allowTraffic(b.publicInternet, foo, new b.PortRange(80, 1000),
+          { containerPort: 8080 })).to
+        .throw('port range [80, 1000] is too large to remap. Remapped ' +
+          'ranges may have at most 100 ports');
+    });
+    it('container port with ICMP', () => {
+      expect(() =>
+        b.allowTraffic(b.publicInternet, foo, null,
+          { protocol: 'icmp', containerPort: 8080 })).to
+        .throw('ICMP traffic does not have ports');
     });
     it('does not allow connections between non-Connectables', () => {
       expect(() => b.allowTraffic(10, 10, 10)).to
@@ -1037,6 +1464,14 @@ describe('Bindings', () => {
       expect(deploy).to.throw('namespace "BadNamespace" contains ' +
                   'uppercase letters. Namespaces must be lowercase.');
     });
+    it('should error when given namespace contains shell characters', () => {
+      const machine = new b.Machine({ provider: 'Amazon' });
+      infra = new b.Infrastructure(
+        machine, machine, { namespace: '$(reboot)' });
+      expect(deploy).to.throw('namespace "$(reboot)" is not a valid ' +
+                  'namespace. Namespaces must only contain lowercase ' +
+                  'characters, numbers and hyphens.');
+    });
     it('connect from undeployed container', () => {
       createBasicInfra();
       const foo = new b.LoadBalancer('foo', []);
@@ -1047,6 +1482,16 @@ describe('Bindings', () => {
         'connection {"from":["baz"],"maxPort":80,"minPort":80,' +
                 '"to":["foo"]} references an undefined hostname: baz');
     });
+    it('place with undeployed container', () => {
+      createBasicInfra();
+      const foo = new b.Container('foo', 'image');
+      foo.deploy(infra);
+
+      foo.placeWith(new b.Container('baz', 'image'));
+      expect(deploy).to.throw(
+        'placement {"exclusive":false,"otherContainer":"baz",' +
+                '"targetContainer":"foo"} references an undefined container: baz');
+    });
     it('duplicate image', () => {
       createBasicInfra();
       (new b.Container('host', new b.Image('img', 'dk'))).deploy(infra);
@@ -1213,6 +1658,56 @@ describe('Bindings', () => {
       const machine = new b.Machine({ provider: 'Amazon' });
       expect(() => new b.Infrastructure(machine, machine, {})).to.not.throw();
     });
+    it('allows no workers when machine groups are given', () => {
+      const machine = new b.Machine({ provider: 'Amazon', size: 'm4.large' });
+      const group = new b.MachineGroup('group', machine, { max: 2 });
+      infra = new b.Infrastructure(machine, [], { machineGroups: group });
+      expect(infra.toKeldaRepresentation().machineGroups).to.containSubset([{
+        name: 'group',
+        machine: {
+          role: 'Worker',
+          provider: 'Amazon',
+          size: 'm4.large',
+        },
+        min: 0,
+        max: 2,
+        cooldown: 0,
+      }]);
+    });
+  });
+  describe('MachineGroup', () => {
+    const machine = new b.Machine({ provider: 'Amazon' });
+    it('copies the machine as a worker', () => {
+      const group = new b.MachineGroup('group', machine,
+        { min: 1, max: 3, cooldown: 60 });
+      expect(group.machine.role).to.equal('Worker');
+      expect(machine.role).to.equal('');
+      expect(group.toKeldaRepresentation()).to.containSubset({
+        name: 'group', min: 1, max: 3, cooldown: 60,
+      });
+    });
+    it('errors when given a bad name or machine', () => {
+      expect(() => new b.MachineGroup('', machine, { max: 1 }))
+        .to.throw('name must be a non-empty string (was: "")');
+      expect(() => new b.MachineGroup('group', 'machine', { max: 1 }))
+        .to.throw('machine must be a Machine (was: "machine")');
+    });
+    it('errors when given bad bounds', () => {
+      expect(() => new b.MachineGroup('group', machine))
+        .to.throw('machine group "group" must have 0 <= min <= max and ' +
+          'max >= 1 (was min 0 and max 0)');
+      expect(() => new b.MachineGroup('group', machine, { min: 2, max: 1 }))
+        .to.throw('machine group "group" must have 0 <= min <= max and ' +
+          'max >= 1 (was min 2 and max 1)');
+      expect(() => new b.MachineGroup('group', machine,
+        { max: 1, cooldown: -1 }))
+        .to.throw('cooldown of machine group "group" must not be negative');
+    });
+    it('should error when given invalid arguments', () => {
+      expect(() => new b.MachineGroup('group', machine, { max: 1, badArg: 1 }))
+        .to.throw('Unrecognized keys passed to MachineGroup constructor: ' +
+          'badArg');
+    });
   });
   describe('Query', () => {
     const machine = new b.Machine({ provider: 'Amazon' });
@@ -30,6 +30,8 @@ let providerConfig = { // eslint-disable-line prefer-const
     },
   },
   Vagrant: {},
+  Docker: {},
+  Static: {},
 };
 
 /**
@@ -42,6 +42,18 @@ type Container struct {
 	Env      map[string]string
 	Labels   map[string]string
 	Created  time.Time
+
+	// The state of the container's process.  The exit code, OOM status, and
+	// finish time describe the most recent time the process exited.
+	Running    bool
+	ExitCode   int
+	OOMKilled  bool
+	FinishedAt time.Time
+
+	CPUShares         int64
+	CPUQuota          int64
+	Memory            int64
+	MemoryReservation int64
 }
 
 // ContainerSlice is an alias for []Container to allow for joins
@@ -79,6 +91,13 @@ type RunOptions struct {
 	VolumesFrom []string
 	CapAdd      []string
 	Mounts      []dkc.HostMount
+
+	// Resource controls.  CPUQuota is relative to the default CFS period of
+	// 100ms, and the memory options are in bytes.  Zero values are unlimited.
+	CPUShares         int64
+	CPUQuota          int64
+	Memory            int64
+	MemoryReservation int64
 }
 
 type client interface {
@@ -94,6 +113,9 @@ type client interface {
 	CreateContainer(dkc.CreateContainerOptions) (*dkc.Container, error)
 	CreateNetwork(dkc.CreateNetworkOptions) (*dkc.Network, error)
 	ListNetworks() ([]dkc.Network, error)
+	CreateExec(dkc.CreateExecOptions) (*dkc.Exec, error)
+	StartExec(id string, opts dkc.StartExecOptions) error
+	InspectExec(id string) (*dkc.ExecInspect, error)
 }
 
 var c = counter.New("Docker")
@@ -133,6 +155,11 @@ func (dk Client) Run(opts RunOptions) (string, error) {
 		DNSSearch:   opts.DNSSearch,
 		CapAdd:      opts.CapAdd,
 		Mounts:      opts.Mounts,
+
+		CPUShares:         opts.CPUShares,
+		CPUQuota:          opts.CPUQuota,
+		Memory:            opts.Memory,
+		MemoryReservation: opts.MemoryReservation,
 	}
 
 	var nc *dkc.NetworkingConfig
@@ -210,6 +237,26 @@ func (dk Client) RemoveID(id string) error {
 	return nil
 }
 
+// Exec runs `cmd` in the container with the given ID, and returns its exit
+// code once it completes.
+func (dk Client) Exec(id string, cmd []string) (int, error) {
+	c.Inc("Exec")
+	exec, err := dk.CreateExec(dkc.CreateExecOptions{Container: id, Cmd: cmd})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := dk.StartExec(exec.ID, dkc.StartExecOptions{}); err != nil {
+		return 0, err
+	}
+
+	inspect, err := dk.InspectExec(exec.ID)
+	if err != nil {
+		return 0, err
+	}
+	return inspect.ExitCode, nil
+}
+
 // Build builds an image with the given name and Dockerfile, and returns the
 // ID of the resulting image.
 func (dk Client) Build(name, dockerfile string, useCache bool) (id string, err error) {
@@ -302,6 +349,12 @@ func (dk Client) List(filters map[string][]string) ([]Container, error) {
 	return dk.list(filters, false)
 }
 
+// ListAll returns a slice of all containers, including those that have exited.
+func (dk Client) ListAll(filters map[string][]string) ([]Container, error) {
+	c.Inc("List All")
+	return dk.list(filters, true)
+}
+
 func (dk Client) list(filters map[string][]string, all bool) ([]Container, error) {
 	opts := dkc.ListContainersOptions{All: all, Filters: filters}
 	apics, err := dk.ListContainers(opts)
@@ -356,6 +409,18 @@ func (dk Client) Get(id string) (Container, error) {
 		Labels:   dkc.Config.Labels,
 		Status:   dkc.State.Status,
 		Created:  dkc.Created,
+
+		Running:    dkc.State.Running,
+		ExitCode:   dkc.State.ExitCode,
+		OOMKilled:  dkc.State.OOMKilled,
+		FinishedAt: dkc.State.FinishedAt,
+	}
+
+	if dkc.HostConfig != nil {
+		c.CPUShares = dkc.HostConfig.CPUShares
+		c.CPUQuota = dkc.HostConfig.CPUQuota
+		c.Memory = dkc.HostConfig.Memory
+		c.MemoryReservation = dkc.HostConfig.MemoryReservation
 	}
 
 	networks := keys(dkc.NetworkSettings.Networks)
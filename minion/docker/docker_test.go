@@ -322,6 +322,31 @@ func TestRemove(t *testing.T) {
 	assert.Zero(t, len(containers))
 }
 
+func TestExec(t *testing.T) {
+	t.Parallel()
+	md, dk := NewMock()
+
+	id, err := dk.Run(RunOptions{Name: "name"})
+	assert.Nil(t, err)
+
+	md.ExecExitCodes["false"] = 1
+	code, err := dk.Exec(id, []string{"true"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, code)
+
+	code, err = dk.Exec(id, []string{"false"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, []string{"true", "false"}, md.Executions[id])
+
+	_, err = dk.Exec("unknown", []string{"true"})
+	assert.NotNil(t, err)
+
+	md.InspectExecError = true
+	_, err = dk.Exec(id, []string{"true"})
+	assert.NotNil(t, err)
+}
+
 func TestBuild(t *testing.T) {
 	t.Parallel()
 	md, dk := NewMock()
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"sync"
+	"time"
 
 	dkc "github.com/fsouza/go-dockerclient"
 	"github.com/kelda/kelda/minion/network/plugin"
@@ -17,6 +18,10 @@ import (
 type mockContainer struct {
 	*dkc.Container
 	Running bool
+
+	exitCode   int
+	oomKilled  bool
+	finishedAt time.Time
 }
 
 // BuildImageOptions represents the parameters in a call to BuildImage.
@@ -45,11 +50,16 @@ type MockClient struct {
 	createdExecs map[string]dkc.CreateExecOptions
 	Executions   map[string][]string
 
+	// The exit code reported for each command run with Exec, keyed by the
+	// command joined with spaces.
+	ExecExitCodes map[string]int
+
 	CreateError           bool
 	CreateNetworkError    bool
 	ListNetworksError     bool
 	CreateExecError       bool
 	InspectContainerError bool
+	InspectExecError      bool
 	InspectImageError     bool
 	ListError             bool
 	BuildError            bool
@@ -65,16 +75,17 @@ type MockClient struct {
 // that allows testers to manipulate it's behavior.
 func NewMock() (*MockClient, Client) {
 	md := &MockClient{
-		Mutex:        &sync.Mutex{},
-		Built:        map[BuildImageOptions]struct{}{},
-		Pulled:       map[string]struct{}{},
-		Pushed:       map[dkc.PushImageOptions]struct{}{},
-		Containers:   map[string]mockContainer{},
-		Networks:     map[string]*dkc.Network{},
-		Uploads:      map[UploadToContainerOptions]struct{}{},
-		Images:       map[string]*dkc.Image{},
-		createdExecs: map[string]dkc.CreateExecOptions{},
-		Executions:   map[string][]string{},
+		Mutex:         &sync.Mutex{},
+		Built:         map[BuildImageOptions]struct{}{},
+		Pulled:        map[string]struct{}{},
+		Pushed:        map[dkc.PushImageOptions]struct{}{},
+		Containers:    map[string]mockContainer{},
+		Networks:      map[string]*dkc.Network{},
+		Uploads:       map[UploadToContainerOptions]struct{}{},
+		Images:        map[string]*dkc.Image{},
+		createdExecs:  map[string]dkc.CreateExecOptions{},
+		Executions:    map[string][]string{},
+		ExecExitCodes: map[string]int{},
 	}
 	return md, Client{md, &sync.Mutex{}, map[string]*cacheEntry{}}
 }
@@ -97,10 +108,20 @@ func (dk MockClient) StartContainer(id string, hostConfig *dkc.HostConfig) error
 
 // StopContainer stops the given docker container.
 func (dk MockClient) StopContainer(id string) {
+	dk.ExitContainer(id, 0, false, time.Time{})
+}
+
+// ExitContainer stops the given docker container as if its process exited with
+// the given status at `finishedAt`.
+func (dk MockClient) ExitContainer(id string, exitCode int, oomKilled bool,
+	finishedAt time.Time) {
 	dk.Lock()
 	defer dk.Unlock()
 	container := dk.Containers[id]
 	container.Running = false
+	container.exitCode = exitCode
+	container.oomKilled = oomKilled
+	container.finishedAt = finishedAt
 	dk.Containers[id] = container
 }
 
@@ -291,12 +312,18 @@ func (dk MockClient) InspectContainer(id string) (*dkc.Container, error) {
 		return nil, ErrNoSuchContainer
 	}
 
-	toReturn := container.Container
+	toReturn := *container.Container
+	toReturn.State = dkc.State{
+		ExitCode:   container.exitCode,
+		OOMKilled:  container.oomKilled,
+		FinishedAt: container.finishedAt,
+	}
 	if container.Running {
-		toReturn.State = dkc.State{Status: "Running"}
+		toReturn.State.Status = "Running"
+		toReturn.State.Running = true
 	}
 
-	return toReturn, nil
+	return &toReturn, nil
 }
 
 // CreateContainer creates a container in accordance with the supplied options.
@@ -338,7 +365,7 @@ func (dk *MockClient) CreateContainer(opts dkc.CreateContainerOptions) (*dkc.Con
 	if img, ok := dk.Images[image]; ok {
 		container.Image = img.ID
 	}
-	dk.Containers[id] = mockContainer{container, false}
+	dk.Containers[id] = mockContainer{Container: container}
 	return container, nil
 }
 
@@ -375,6 +402,24 @@ func (dk MockClient) StartExec(id string, opts dkc.StartExecOptions) error {
 	return nil
 }
 
+// InspectExec returns the exit code of the supplied execution object.
+func (dk MockClient) InspectExec(id string) (*dkc.ExecInspect, error) {
+	dk.Lock()
+	defer dk.Unlock()
+
+	if dk.InspectExecError {
+		return nil, errors.New("inspect exec error")
+	}
+
+	exec, ok := dk.createdExecs[id]
+	if !ok {
+		return nil, errors.New("unknown exec")
+	}
+
+	cmd := strings.Join(exec.Cmd, " ")
+	return &dkc.ExecInspect{ID: id, ExitCode: dk.ExecExitCodes[cmd]}, nil
+}
+
 // ResetExec clears the list of created and started executions, for use by the unit
 // tests.
 func (dk *MockClient) ResetExec() {
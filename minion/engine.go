@@ -2,6 +2,8 @@ package minion
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/db"
@@ -14,11 +16,15 @@ import (
 
 func syncPolicy(conn db.Conn) {
 	loopLog := util.NewEventTimer("Minion-Update")
-	for range conn.Trigger(db.MinionTable, db.EtcdTable).C {
+	// Rolling updates and jobs progress as the status of containers changes,
+	// so the policy is re-evaluated whenever the container table changes.  It's
+	// also re-evaluated periodically so that scheduled jobs start on time.
+	for range conn.TriggerTick(30, db.MinionTable, db.EtcdTable,
+		db.ContainerTable).C {
 		loopLog.LogStart()
 		txn := conn.Txn(db.ConnectionTable, db.ContainerTable, db.MinionTable,
 			db.EtcdTable, db.PlacementTable, db.ImageTable,
-			db.LoadBalancerTable)
+			db.LoadBalancerTable, db.VolumeTable, db.JobTable)
 		txn.Run(func(view db.Database) error {
 			minion := view.MinionSelf()
 			if view.EtcdLeader() {
@@ -36,48 +42,66 @@ func updatePolicy(view db.Database, bp string) {
 		log.WithError(err).Warn("Invalid blueprint.")
 		return
 	}
+	compiled = compiled.ExpandReplicas()
 
 	c.Inc("Update Policy")
 	updateImages(view, compiled)
 	updateContainers(view, compiled)
+	updateJobs(view, compiled, time.Now())
+	updateVolumes(view, compiled)
 	updateLoadBalancers(view, compiled)
 	updateConnections(view, compiled)
 	updatePlacements(view, compiled)
 }
 
 // `portPlacements` creates exclusive placement rules such that no two containers
-// listening on the same public port get placed on the same machine.
+// listening on overlapping public ports get placed on the same machine.
 func portPlacements(connections []db.Connection) (placements []db.Placement) {
-	ports := make(map[int][]string)
+	type listener struct {
+		hostname string
+		conn     db.Connection
+	}
+
+	var listeners []listener
 	for _, conn := range connections {
-		if !str.SliceContains(conn.From, blueprint.PublicInternetLabel) {
+		// ICMP has no ports, so its connections can't conflict.
+		if !str.SliceContains(conn.From, blueprint.PublicInternetLabel) ||
+			conn.Protocol == blueprint.ICMPProtocol {
 			continue
 		}
 
-		// XXX: Public connections do not currently support ranges, so we
-		// can safely consider just the MinPort.
-		ports[conn.MinPort] = append(ports[conn.MinPort],
-			str.SliceFilterOut(conn.To, blueprint.PublicInternetLabel)...)
-	}
-
-	// Create placement rules for all combinations of containers that listen on
-	// the same port. We do not need to create a rule for every permutation
-	// because order does not matter for the `TargetContainer` and
-	// `OtherContainer` fields -- the placement is equivalent if the two fields
-	// are swapped.  We do so by creating a placement rule between each
-	// container, and the containers after it. There is no need to create rules
-	// for the preceding containers because the previous rules will have
-	// covered it.
-	for _, cids := range ports {
-		for i, tgt := range cids {
-			for _, other := range cids[i+1:] {
-				placements = append(placements,
-					db.Placement{
-						Exclusive:       true,
-						TargetContainer: tgt,
-						OtherContainer:  other,
-					},
-				)
+		for _, hostname := range conn.To {
+			if hostname != blueprint.PublicInternetLabel {
+				listeners = append(listeners, listener{hostname, conn})
+			}
+		}
+	}
+
+	// Create placement rules for all pairs of containers whose public ports
+	// overlap. We do not need to create a rule for every permutation because
+	// order does not matter for the `TargetContainer` and `OtherContainer`
+	// fields -- the placement is equivalent if the two fields are swapped.  We
+	// do so by creating a placement rule between each container, and the
+	// containers after it. There is no need to create rules for the preceding
+	// containers because the previous rules will have covered it.
+	seen := map[db.Placement]struct{}{}
+	for i, tgt := range listeners {
+		for _, other := range listeners[i+1:] {
+			if tgt.hostname == other.hostname ||
+				!portsOverlap(tgt.conn, other.conn) {
+				continue
+			}
+
+			hostnames := []string{tgt.hostname, other.hostname}
+			sort.Strings(hostnames)
+			placement := db.Placement{
+				Exclusive:       true,
+				TargetContainer: hostnames[0],
+				OtherContainer:  hostnames[1],
+			}
+			if _, ok := seen[placement]; !ok {
+				seen[placement] = struct{}{}
+				placements = append(placements, placement)
 			}
 		}
 	}
@@ -85,6 +109,15 @@ func portPlacements(connections []db.Connection) (placements []db.Placement) {
 	return placements
 }
 
+// portsOverlap returns whether `a` and `b` both allow public traffic on the
+// same port and protocol.
+func portsOverlap(a, b db.Connection) bool {
+	if a.Protocol != "" && b.Protocol != "" && a.Protocol != b.Protocol {
+		return false
+	}
+	return a.MinPort <= b.MaxPort && b.MinPort <= a.MaxPort
+}
+
 func updatePlacements(view db.Database, bp blueprint.Blueprint) {
 	connections := view.SelectFromConnection(nil)
 	placements := db.PlacementSlice(portPlacements(connections))
@@ -92,6 +125,7 @@ func updatePlacements(view db.Database, bp blueprint.Blueprint) {
 		placements = append(placements, db.Placement{
 			TargetContainer: sp.TargetContainer,
 			Exclusive:       sp.Exclusive,
+			OtherContainer:  sp.OtherContainer,
 			Provider:        sp.Provider,
 			Size:            sp.Size,
 			Region:          sp.Region,
@@ -175,10 +209,12 @@ func updateConnections(view db.Database, bp blueprint.Blueprint) {
 		for _, to := range c.To {
 			if lb, ok := loadBalancers[to]; ok {
 				scs = append(scs, blueprint.Connection{
-					From:    c.From,
-					To:      lb.Hostnames,
-					MinPort: c.MinPort,
-					MaxPort: c.MaxPort,
+					From:          c.From,
+					To:            lb.Hostnames,
+					MinPort:       c.MinPort,
+					MaxPort:       c.MaxPort,
+					Protocol:      c.Protocol,
+					ContainerPort: c.ContainerPort,
 				})
 			}
 		}
@@ -186,12 +222,14 @@ func updateConnections(view db.Database, bp blueprint.Blueprint) {
 
 	dbcKey := func(val interface{}) interface{} {
 		c := val.(db.Connection)
-		return fmt.Sprintf("%s %s %d %d", c.From, c.To, c.MinPort, c.MaxPort)
+		return fmt.Sprintf("%s %s %d %d %s %d", c.From, c.To,
+			c.MinPort, c.MaxPort, c.Protocol, c.ContainerPort)
 	}
 
 	bpKey := func(val interface{}) interface{} {
 		c := val.(blueprint.Connection)
-		return fmt.Sprintf("%s %s %d %d", c.From, c.To, c.MinPort, c.MaxPort)
+		return fmt.Sprintf("%s %s %d %d %s %d", c.From, c.To,
+			c.MinPort, c.MaxPort, c.Protocol, c.ContainerPort)
 	}
 
 	vcs := view.SelectFromConnection(nil)
@@ -209,6 +247,8 @@ func updateConnections(view db.Database, bp blueprint.Blueprint) {
 		dbc.To = bpc.To
 		dbc.MinPort = bpc.MinPort
 		dbc.MaxPort = bpc.MaxPort
+		dbc.Protocol = bpc.Protocol
+		dbc.ContainerPort = bpc.ContainerPort
 		view.Commit(dbc)
 	}
 }
@@ -216,15 +256,13 @@ func updateConnections(view db.Database, bp blueprint.Blueprint) {
 func queryContainers(bp blueprint.Blueprint) []db.Container {
 	containers := map[string]*db.Container{}
 	for _, c := range bp.Containers {
-		containers[c.Hostname] = &db.Container{
-			BlueprintID:       c.ID,
-			Command:           c.Command,
-			Env:               c.Env,
-			FilepathToContent: c.FilepathToContent,
-			Image:             c.Image.Name,
-			Dockerfile:        c.Image.Dockerfile,
-			Hostname:          c.Hostname,
+		// The containers of jobs are booted by updateJobs.
+		if c.Job != nil {
+			continue
 		}
+
+		dbc := newContainer(c)
+		containers[c.Hostname] = &dbc
 	}
 
 	var ret []db.Container
@@ -235,13 +273,38 @@ func queryContainers(bp blueprint.Blueprint) []db.Container {
 	return ret
 }
 
+// newContainer converts the blueprint container `c` into a database row.
+func newContainer(c blueprint.Container) db.Container {
+	return db.Container{
+		BlueprintID:       c.ID,
+		Command:           c.Command,
+		Env:               c.Env,
+		FilepathToContent: c.FilepathToContent,
+		Image:             c.Image.Name,
+		Dockerfile:        c.Image.Dockerfile,
+		Hostname:          c.Hostname,
+		CPURequest:        c.CPURequest,
+		CPULimit:          c.CPULimit,
+		RAMRequest:        c.RAMRequest,
+		RAMLimit:          c.RAMLimit,
+		VolumeMounts:      c.VolumeMounts,
+		HealthCheck:       c.HealthCheck,
+		RestartPolicy:     c.RestartPolicy,
+		DependsOn:         c.DependsOn,
+	}
+}
+
 func updateContainers(view db.Database, bp blueprint.Blueprint) {
 	key := func(val interface{}) interface{} {
 		return val.(db.Container).BlueprintID
 	}
 
+	services := view.SelectFromContainer(func(dbc db.Container) bool {
+		return dbc.Job == ""
+	})
 	pairs, news, dbcs := join.HashJoin(db.ContainerSlice(queryContainers(bp)),
-		db.ContainerSlice(view.SelectFromContainer(nil)), key, key)
+		db.ContainerSlice(services), key, key)
+	news, dbcs = rollingUpdate(view, bp, news, dbcs)
 
 	for _, dbc := range dbcs {
 		view.Remove(dbc.(db.Container))
@@ -262,10 +325,59 @@ func updateContainers(view db.Database, bp blueprint.Blueprint) {
 		dbc.FilepathToContent = newc.FilepathToContent
 		dbc.BlueprintID = newc.BlueprintID
 		dbc.Hostname = newc.Hostname
+		dbc.CPURequest = newc.CPURequest
+		dbc.CPULimit = newc.CPULimit
+		dbc.RAMRequest = newc.RAMRequest
+		dbc.RAMLimit = newc.RAMLimit
+		dbc.VolumeMounts = newc.VolumeMounts
+		dbc.HealthCheck = newc.HealthCheck
+		dbc.RestartPolicy = newc.RestartPolicy
+		dbc.DependsOn = newc.DependsOn
 		view.Commit(dbc)
 	}
 }
 
+func updateVolumes(view db.Database, bp blueprint.Blueprint) {
+	key := func(intf interface{}) interface{} {
+		switch v := intf.(type) {
+		case blueprint.Volume:
+			return v.Name
+		case db.Volume:
+			return v.Name
+		}
+		panic("unreachable")
+	}
+
+	pairs, toAdd, toRemove := join.HashJoin(blueprintVolumeSlice(bp.Volumes),
+		db.VolumeSlice(view.SelectFromVolume(nil)), key, key)
+
+	for _, intf := range toRemove {
+		view.Remove(intf.(db.Volume))
+	}
+
+	for _, intf := range toAdd {
+		pairs = append(pairs, join.Pair{L: intf, R: view.InsertVolume()})
+	}
+
+	for _, pair := range pairs {
+		bpVolume := pair.L.(blueprint.Volume)
+		dbVolume := pair.R.(db.Volume)
+
+		// A volume that changes type no longer holds the same data, so it
+		// shouldn't keep the minion it was bound to.
+		if dbVolume.Type != bpVolume.Type {
+			dbVolume.Minion = ""
+		}
+
+		dbVolume.Name = bpVolume.Name
+		dbVolume.Type = bpVolume.Type
+		dbVolume.SizeGiB = bpVolume.SizeGiB
+		dbVolume.Provider = db.ProviderName(bpVolume.Provider)
+		dbVolume.Region = bpVolume.Region
+		view.Commit(dbVolume)
+	}
+}
+
 func updateImages(view db.Database, bp blueprint.Blueprint) {
 	dbImageKey := func(intf interface{}) interface{} {
 		return blueprint.Image{
@@ -313,3 +425,13 @@ func (slc blueprintImageSlice) Get(ii int) interface{} {
 func (slc blueprintImageSlice) Len() int {
 	return len(slc)
 }
+
+type blueprintVolumeSlice []blueprint.Volume
+
+func (slc blueprintVolumeSlice) Get(ii int) interface{} {
+	return slc[ii]
+}
+
+func (slc blueprintVolumeSlice) Len() int {
+	return len(slc)
+}
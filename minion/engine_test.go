@@ -30,7 +30,7 @@ func TestContainerTxn(t *testing.T) {
 				Hostname: "foo",
 				ID:       "f133411ac23f45342a7b8b89bbe5e9efd0e711e5",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"tail"},
+				Command:  blueprint.NewStrings("tail"),
 			},
 		},
 		LoadBalancers: []blueprint.LoadBalancer{
@@ -54,13 +54,13 @@ func TestContainerTxn(t *testing.T) {
 				Hostname: "foo",
 				ID:       "f133411ac23f45342a7b8b89bbe5e9efd0e711e5",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"tail"},
+				Command:  blueprint.NewStrings("tail"),
 			},
 			{
 				Hostname: "bar",
 				ID:       "6e24c8cbeb63dbffcc82730d01b439e2f5085f59",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"tail"},
+				Command:  blueprint.NewStrings("tail"),
 			},
 		},
 		LoadBalancers: []blueprint.LoadBalancer{
@@ -87,13 +87,13 @@ func TestContainerTxn(t *testing.T) {
 				Hostname: "foo",
 				ID:       "0b8a2ed7d14d78a388375025223b05d072bbaec3",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"cat"},
+				Command:  blueprint.NewStrings("cat"),
 			},
 			{
 				Hostname: "bar",
 				ID:       "f133411ac23f45342a7b8b89bbe5e9efd0e711e5",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"tail"},
+				Command:  blueprint.NewStrings("tail"),
 			},
 		},
 		LoadBalancers: []blueprint.LoadBalancer{
@@ -120,13 +120,13 @@ func TestContainerTxn(t *testing.T) {
 				Hostname: "foo",
 				ID:       "7a6244b8d2bfa10ee2fcbe6836a0519e116aee31",
 				Image:    blueprint.Image{Name: "ubuntu"},
-				Command:  []string{"cat"},
+				Command:  blueprint.NewStrings("cat"),
 			},
 			{
 				Hostname: "bar",
 				ID:       "f133411ac23f45342a7b8b89bbe5e9efd0e711e5",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"tail"},
+				Command:  blueprint.NewStrings("tail"),
 			},
 		},
 		LoadBalancers: []blueprint.LoadBalancer{
@@ -153,13 +153,13 @@ func TestContainerTxn(t *testing.T) {
 				Hostname: "foo",
 				ID:       "0b8a2ed7d14d78a388375025223b05d072bbaec3",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"cat"},
+				Command:  blueprint.NewStrings("cat"),
 			},
 			{
 				Hostname: "bar",
 				ID:       "d1c9f501efd7a348e54388358c5fe29690fb147d",
 				Image:    blueprint.Image{Name: "alpine"},
-				Command:  []string{"cat"},
+				Command:  blueprint.NewStrings("cat"),
 			},
 		},
 		LoadBalancers: []blueprint.LoadBalancer{
@@ -410,6 +410,17 @@ func TestPlacementTxn(t *testing.T) {
 		},
 	)
 
+	// Container placement
+	bp.Placements = []blueprint.Placement{
+		{TargetContainer: "foo", OtherContainer: "bar"},
+	}
+	checkPlacement(bp,
+		db.Placement{
+			TargetContainer: "foo",
+			OtherContainer:  "bar",
+		},
+	)
+
 	// Port placement
 	bp.Placements = nil
 	bp.Connections = []blueprint.Connection{
@@ -442,6 +453,42 @@ func TestPlacementTxn(t *testing.T) {
 			Exclusive:       true,
 		},
 	)
+
+	// Containers conflict if their public port ranges overlap on a common
+	// protocol, regardless of which container ports they're forwarded to.
+	bp.Connections = []blueprint.Connection{
+		{From: []string{blueprint.PublicInternetLabel},
+			To: []string{fooHostname}, MinPort: 80, MaxPort: 90},
+		{From: []string{blueprint.PublicInternetLabel},
+			To: []string{barHostname}, MinPort: 90, MaxPort: 100,
+			ContainerPort: 8080},
+		{From: []string{blueprint.PublicInternetLabel},
+			To: []string{bazHostname}, MinPort: 85, MaxPort: 85,
+			Protocol: blueprint.UDPProtocol},
+		{From: []string{blueprint.PublicInternetLabel},
+			To: []string{bazHostname}, MinPort: 95, MaxPort: 95,
+			Protocol: blueprint.UDPProtocol},
+		{From: []string{blueprint.PublicInternetLabel},
+			To: []string{barHostname}, MinPort: 95, MaxPort: 95,
+			Protocol: blueprint.TCPProtocol},
+	}
+	checkPlacement(bp,
+		db.Placement{
+			TargetContainer: barHostname,
+			OtherContainer:  fooHostname,
+			Exclusive:       true,
+		},
+		db.Placement{
+			TargetContainer: bazHostname,
+			OtherContainer:  fooHostname,
+			Exclusive:       true,
+		},
+		db.Placement{
+			TargetContainer: barHostname,
+			OtherContainer:  bazHostname,
+			Exclusive:       true,
+		},
+	)
 }
 
 func checkImage(t *testing.T, conn db.Conn, bp blueprint.Blueprint, exp ...db.Image) {
@@ -624,4 +671,93 @@ func TestLoadBalancerTxn(t *testing.T) {
 		Name:      loadBalancerB,
 		Hostnames: hostnamesB,
 	})
+
+	// Load balancers include every replica of their containers.
+	checkLoadBalancer(t, conn, blueprint.Blueprint{
+		Containers: []blueprint.Container{
+			{ID: "1", Hostname: "b", Replicas: 3},
+		},
+		LoadBalancers: []blueprint.LoadBalancer{
+			{
+				Name:      loadBalancerB,
+				Hostnames: hostnamesB,
+			},
+		},
+	}, db.LoadBalancer{
+		Name:      loadBalancerB,
+		Hostnames: []string{"b", "b-2", "b-3", "bb"},
+	})
+}
+
+func TestReplicasTxn(t *testing.T) {
+	t.Parallel()
+	conn := db.New()
+
+	update := func(replicas int) (hostnames []string) {
+		bp := blueprint.Blueprint{Containers: []blueprint.Container{
+			{ID: "1", Hostname: "web", Replicas: replicas},
+		}}
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			updatePolicy(view, bp.String())
+			for _, dbc := range view.SelectFromContainer(nil) {
+				hostnames = append(hostnames, dbc.Hostname)
+			}
+			return nil
+		})
+		sort.Strings(hostnames)
+		return hostnames
+	}
+
+	assert.Equal(t, []string{"web"}, update(0))
+	assert.Equal(t, []string{"web", "web-2", "web-3"}, update(3))
+	assert.Equal(t, []string{"web", "web-2"}, update(2))
+}
+
+func TestVolumeTxn(t *testing.T) {
+	t.Parallel()
+	conn := db.New()
+
+	update := func(volumes ...blueprint.Volume) []db.Volume {
+		var dbVolumes []db.Volume
+		bp := blueprint.Blueprint{Volumes: volumes}
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			updatePolicy(view, bp.String())
+			dbVolumes = view.SelectFromVolume(nil)
+			return nil
+		})
+		for i := range dbVolumes {
+			dbVolumes[i].ID = 0
+		}
+		sort.Slice(dbVolumes, func(i, j int) bool {
+			return dbVolumes[i].Name < dbVolumes[j].Name
+		})
+		return dbVolumes
+	}
+
+	data := blueprint.Volume{Name: "data", Type: blueprint.HostVolume}
+	assert.Equal(t, []db.Volume{{Name: "data", Type: blueprint.HostVolume}},
+		update(data))
+
+	// The minion a volume is bound to should survive blueprint updates.
+	conn.Txn(db.VolumeTable).Run(func(view db.Database) error {
+		dbv := view.SelectFromVolume(nil)[0]
+		dbv.Minion = "1.2.3.4"
+		view.Commit(dbv)
+		return nil
+	})
+
+	logs := blueprint.Volume{Name: "logs", Type: blueprint.BlockVolume,
+		SizeGiB: 10, Provider: "Amazon", Region: "us-west-1"}
+	assert.Equal(t, []db.Volume{
+		{Name: "data", Type: blueprint.HostVolume, Minion: "1.2.3.4"},
+		{Name: "logs", Type: blueprint.BlockVolume, SizeGiB: 10,
+			Provider: db.Amazon, Region: "us-west-1"},
+	}, update(data, logs))
+
+	// Unless the type of the volume changes.
+	data.Type = blueprint.BlockVolume
+	assert.Equal(t, []db.Volume{{Name: "data", Type: blueprint.BlockVolume}},
+		update(data))
+
+	assert.Empty(t, update())
 }
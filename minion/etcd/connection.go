@@ -33,7 +33,7 @@ func runConnectionOnce(conn db.Conn, store Store) error {
 	if conn.EtcdLeader() {
 		c.Inc("Run Connection Leader")
 		slice := db.ConnectionSlice(conn.SelectFromConnection(nil))
-		err = writeEtcdSlice(store, connectionPath, etcdStr, slice)
+		_, err = writeEtcdSlice(store, connectionPath, etcdStr, slice)
 		if err != nil {
 			return fmt.Errorf("etcd write error: %s", err)
 		}
@@ -53,8 +53,8 @@ func runConnectionOnce(conn db.Conn, store Store) error {
 func joinConnections(view db.Database, etcdConns []db.Connection) {
 	key := func(iface interface{}) interface{} {
 		conn := iface.(db.Connection)
-		return fmt.Sprintf("%s %s %d %d",
-			conn.From, conn.To, conn.MinPort, conn.MaxPort)
+		return fmt.Sprintf("%s %s %d %d %s %d", conn.From, conn.To,
+			conn.MinPort, conn.MaxPort, conn.Protocol, conn.ContainerPort)
 	}
 
 	_, connIfaces, etcdConnIfaces := join.HashJoin(
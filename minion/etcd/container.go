@@ -17,50 +17,101 @@ import (
 const containerPath = "/containers"
 
 func runContainer(conn db.Conn, store Store) {
+	var state containerSync
 	etcdWatch := store.Watch(containerPath, 1*time.Second)
-	trigg := conn.TriggerTick(60, db.ContainerTable)
+	trigg := conn.ChangeTriggerTick(60, db.ContainerTable)
 	for range util.JoinNotifiers(trigg.C, etcdWatch) {
-		if err := runContainerOnce(conn, store); err != nil {
+		changes := trigg.Changes()[db.ContainerTable]
+		if err := runContainerOnce(conn, store, &state, changes); err != nil {
 			log.WithError(err).Warn("Failed to sync containers with Etcd.")
 		}
 	}
 }
 
-func runContainerOnce(conn db.Conn, store Store) error {
+// containerSync is the state that runContainerOnce keeps between runs, so that it
+// only processes the containers that changed.
+type containerSync struct {
+	synced bool
+	leader bool
+	self   db.Minion
+
+	// The containers as of the last sync with etcd.
+	etcdStr string
+
+	// The containers the leader writes to etcd, by ID.
+	containers map[int]db.Container
+}
+
+func runContainerOnce(conn db.Conn, store Store, state *containerSync,
+	changes db.ChangeSet) error {
+
 	etcdStr, err := readEtcdNode(store, containerPath)
 	if err != nil {
 		return fmt.Errorf("etcd read error: %s", err)
 	}
 
-	if conn.EtcdLeader() {
+	leader := conn.EtcdLeader()
+	self := conn.MinionSelf()
+	full := !state.synced || changes.Reset || state.leader != leader ||
+		state.self.Role != self.Role || state.self.PrivateIP != self.PrivateIP
+	if full {
+		*state = containerSync{leader: leader, self: self}
+	} else if etcdStr == state.etcdStr && (!leader || changes.Empty()) {
+		// Workers only need to sync when etcd changes, and the leader when
+		// either changes.
+		return nil
+	}
+
+	if leader {
 		c.Inc("Run Container Leader")
-		return updateLeader(conn, store, etcdStr)
+		err = updateLeader(conn, store, state, etcdStr, changes, full)
+	} else {
+		c.Inc("Run Container Worker")
+		updateNonLeader(conn, etcdStr)
+		state.etcdStr = etcdStr
 	}
 
-	c.Inc("Run Container Worker")
-	updateNonLeader(conn, etcdStr)
-	return nil
+	if err == nil {
+		state.synced = true
+	}
+	return err
 }
 
-func updateLeader(conn db.Conn, store Store, etcdStr string) error {
-	self := conn.MinionSelf()
-	myIP := self.PrivateIP
+// updateLeader writes the containers to etcd.  Unless `full` is set, only the
+// containers in `changes` are re-read from the database.
+func updateLeader(conn db.Conn, store Store, state *containerSync, etcdStr string,
+	changes db.ChangeSet, full bool) error {
 
-	dbcs := conn.SelectFromContainer(func(dbc db.Container) bool {
-		return dbc.Minion != "" && dbc.IP != ""
-	})
-	for i := range dbcs {
-		if dbcs[i].Dockerfile == "" {
-			continue
+	ids := changes.IDs()
+	if full {
+		state.containers = map[int]db.Container{}
+	}
+	for id := range ids {
+		delete(state.containers, id)
+	}
+
+	myIP := state.self.PrivateIP
+	for _, dbc := range conn.SelectFromContainer(func(dbc db.Container) bool {
+		return (full || ids[dbc.ID]) && dbc.Minion != "" && dbc.IP != ""
+	}) {
+		if dbc.Dockerfile != "" {
+			dbc.Image = myIP + ":5000/" + dbc.Image
 		}
-		dbcs[i].Image = myIP + ":5000/" + dbcs[i].Image
+		state.containers[dbc.ID] = dbc
 	}
 
-	err := writeEtcdSlice(store, containerPath, etcdStr, db.ContainerSlice(dbcs))
+	var dbcs []db.Container
+	for _, dbc := range state.containers {
+		dbcs = append(dbcs, dbc)
+	}
+
+	newStr, err := writeEtcdSlice(store, containerPath, etcdStr,
+		db.ContainerSlice(dbcs))
 	if err != nil {
 		return fmt.Errorf("etcd write error: %s", err)
 	}
 
+	state.etcdStr = newStr
 	return nil
 }
 
@@ -95,6 +146,12 @@ func joinContainers(view db.Database, etcdDBCs []db.Container) {
 			Command           string
 			Env               string
 			FilepathToContent string
+			CPURequest        float64
+			CPULimit          float64
+			RAMRequest        float64
+			RAMLimit          float64
+			VolumeMounts      string
+			HealthCheck       string
 		}{
 			Hostname:          dbc.Hostname,
 			IP:                dbc.IP,
@@ -104,6 +161,12 @@ func joinContainers(view db.Database, etcdDBCs []db.Container) {
 			Command:           fmt.Sprintf("%v", dbc.Command),
 			Env:               containerValueMapKey(dbc.Env),
 			FilepathToContent: containerValueMapKey(dbc.FilepathToContent),
+			CPURequest:        dbc.CPURequest,
+			CPULimit:          dbc.CPULimit,
+			RAMRequest:        dbc.RAMRequest,
+			RAMLimit:          dbc.RAMLimit,
+			VolumeMounts:      fmt.Sprintf("%v", dbc.VolumeMounts),
+			HealthCheck:       healthCheckKey(dbc.HealthCheck),
 		}
 	}
 
@@ -133,6 +196,15 @@ func joinContainers(view db.Database, etcdDBCs []db.Container) {
 		dbc.Env = edbc.Env
 		dbc.FilepathToContent = edbc.FilepathToContent
 		dbc.Hostname = edbc.Hostname
+		dbc.CPURequest = edbc.CPURequest
+		dbc.CPULimit = edbc.CPULimit
+		dbc.RAMRequest = edbc.RAMRequest
+		dbc.RAMLimit = edbc.RAMLimit
+		dbc.VolumeMounts = edbc.VolumeMounts
+		dbc.HealthCheck = edbc.HealthCheck
+		dbc.RestartPolicy = edbc.RestartPolicy
+		dbc.Job = edbc.Job
+		dbc.RetryLimit = edbc.RetryLimit
 		view.Commit(dbc)
 	}
 }
@@ -146,3 +218,11 @@ func containerValueMapKey(x map[string]blueprint.ContainerValue) string {
 	}
 	return str.MapAsString(m)
 }
+
+// healthCheckKey converts the given health check into a consistent string.
+func healthCheckKey(hc *blueprint.HealthCheck) string {
+	if hc == nil {
+		return ""
+	}
+	return fmt.Sprintf("%+v", *hc)
+}
@@ -1,6 +1,7 @@
 package etcd
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,8 +15,17 @@ func TestRunContainerOnce(t *testing.T) {
 
 	store := newTestMock()
 	conn := db.New()
+	trigger := conn.ChangeTrigger(db.ContainerTable)
+	defer trigger.Stop()
+
+	// Share the state between runs, so that they're incremental.
+	var state containerSync
+	runOnce := func() error {
+		changes := trigger.Changes()[db.ContainerTable]
+		return runContainerOnce(conn, store, &state, changes)
+	}
 
-	err := runContainerOnce(conn, store)
+	err := runOnce()
 	assert.Error(t, err)
 
 	err = store.Set(containerPath, "", 0)
@@ -39,7 +49,7 @@ func TestRunContainerOnce(t *testing.T) {
 		dbc.Minion = "1.2.3.4"
 		dbc.BlueprintID = "12"
 		dbc.Image = "ubuntu"
-		dbc.Command = []string{"1", "2", "3"}
+		dbc.Command = blueprint.NewStrings("1", "2", "3")
 		dbc.Env = map[string]blueprint.ContainerValue{
 			"red":   blueprint.NewSecret("pill"),
 			"blue":  blueprint.NewString("pill"),
@@ -52,7 +62,7 @@ func TestRunContainerOnce(t *testing.T) {
 		return nil
 	})
 
-	err = runContainerOnce(conn, store)
+	err = runOnce()
 	assert.NoError(t, err)
 
 	// Check that the container in the database was properly written into etcd.
@@ -111,7 +121,7 @@ func TestRunContainerOnce(t *testing.T) {
 		return nil
 	})
 
-	err = runContainerOnce(conn, store)
+	err = runOnce()
 	assert.NoError(t, err)
 
 	// Ensure that the minion properly synced the container from etcd.
@@ -120,7 +130,7 @@ func TestRunContainerOnce(t *testing.T) {
 		BlueprintID: "12",
 		Minion:      "1.2.3.4",
 		Image:       "ubuntu",
-		Command:     []string{"1", "2", "3"},
+		Command:     blueprint.NewStrings("1", "2", "3"),
 		Env: map[string]blueprint.ContainerValue{
 			"red":   blueprint.NewSecret("pill"),
 			"blue":  blueprint.NewString("pill"),
@@ -138,7 +148,7 @@ func TestRunContainerOnce(t *testing.T) {
 
 	// Run the same non-leader sync test again to make sure the result is
 	// consistent.
-	err = runContainerOnce(conn, store)
+	err = runOnce()
 	assert.NoError(t, err)
 
 	dbcs = conn.SelectFromContainer(nil)
@@ -156,7 +166,7 @@ func TestRunContainerOnce(t *testing.T) {
 		return nil
 	})
 
-	err = runContainerOnce(conn, store)
+	err = runOnce()
 	assert.NoError(t, err)
 
 	dbcs = conn.SelectFromContainer(nil)
@@ -174,7 +184,7 @@ func TestRunContainerOnce(t *testing.T) {
 		return nil
 	})
 
-	err = runContainerOnce(conn, store)
+	err = runOnce()
 	assert.NoError(t, err)
 
 	dbcs = conn.SelectFromContainer(nil)
@@ -212,7 +222,7 @@ func TestRunContainerOnceWithDockerfile(t *testing.T) {
 		return nil
 	})
 
-	err = runContainerOnce(conn, store)
+	err = runContainerOnce(conn, store, &containerSync{}, db.ChangeSet{})
 	assert.NoError(t, err)
 
 	str, err := store.Get(containerPath)
@@ -229,3 +239,90 @@ func TestRunContainerOnceWithDockerfile(t *testing.T) {
 ]`
 	assert.Equal(t, expStr, str)
 }
+
+func TestRunContainerOnceIncremental(t *testing.T) {
+	t.Parallel()
+
+	store := newTestMock()
+	conn := db.New()
+	trigger := conn.ChangeTrigger(db.ContainerTable)
+	defer trigger.Stop()
+
+	var state containerSync
+	runOnce := func() {
+		changes := trigger.Changes()[db.ContainerTable]
+		assert.NoError(t, runContainerOnce(conn, store, &state, changes))
+	}
+	etcdImages := func() (images []string) {
+		str, err := store.Get(containerPath)
+		assert.NoError(t, err)
+
+		var dbcs []db.Container
+		assert.NoError(t, json.Unmarshal([]byte(str), &dbcs))
+		for _, dbc := range dbcs {
+			images = append(images, dbc.Image)
+		}
+		return images
+	}
+
+	assert.NoError(t, store.Set(containerPath, "", 0))
+
+	var a, b db.Container
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		self := view.InsertMinion()
+		self.Self = true
+		self.Role = db.Master
+		view.Commit(self)
+
+		etcd := view.InsertEtcd()
+		etcd.Leader = true
+		view.Commit(etcd)
+
+		a = view.InsertContainer()
+		a.IP = "10.0.0.2"
+		a.BlueprintID = "a"
+		a.Minion = "1.2.3.4"
+		a.Image = "a"
+		view.Commit(a)
+
+		b = view.InsertContainer()
+		b.IP = "10.0.0.3"
+		b.BlueprintID = "b"
+		b.Minion = "1.2.3.4"
+		b.Image = "b"
+		view.Commit(b)
+		return nil
+	})
+	runOnce()
+	assert.Equal(t, []string{"a", "b"}, etcdImages())
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		a.Image = "a2"
+		view.Commit(a)
+		view.Remove(b)
+		return nil
+	})
+	runOnce()
+	assert.Equal(t, []string{"a2"}, etcdImages())
+
+	// Containers that haven't been placed aren't written.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		a.Minion = ""
+		view.Commit(a)
+		return nil
+	})
+	runOnce()
+	assert.Empty(t, etcdImages())
+
+	// If etcd changes out from under the leader, it's rewritten even though
+	// the database didn't change.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		a.Minion = "1.2.3.4"
+		view.Commit(a)
+		return nil
+	})
+	runOnce()
+	assert.NoError(t, store.Set(containerPath, "[]", 0))
+	runOnce()
+	assert.Equal(t, []string{"a2"}, etcdImages())
+}
@@ -33,7 +33,7 @@ func runHostnameOnce(conn db.Conn, store Store) error {
 	if conn.EtcdLeader() {
 		c.Inc("Run Hostname Leader")
 		hostnames := db.HostnameSlice(conn.SelectFromHostname(nil))
-		err := writeEtcdSlice(store, hostnamePath, etcdStr, hostnames)
+		_, err := writeEtcdSlice(store, hostnamePath, etcdStr, hostnames)
 		if err != nil {
 			return fmt.Errorf("etcd write error: %s", err)
 		}
@@ -0,0 +1,77 @@
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/join"
+	"github.com/kelda/kelda/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const jobPath = "/jobs"
+
+// runJob replicates the leader's jobs, and thus the history of their runs, to
+// the other minions.  That way, a minion that becomes the leader knows which
+// jobs already ran, and doesn't run them again.
+func runJob(conn db.Conn, store Store) {
+	etcdWatch := store.Watch(jobPath, 1*time.Second)
+	trigg := conn.TriggerTick(60, db.JobTable)
+	for range util.JoinNotifiers(trigg.C, etcdWatch) {
+		if err := runJobOnce(conn, store); err != nil {
+			log.WithError(err).Warn("Failed to sync jobs with Etcd")
+		}
+	}
+}
+
+func runJobOnce(conn db.Conn, store Store) error {
+	etcdStr, err := readEtcdNode(store, jobPath)
+	if err != nil {
+		return fmt.Errorf("etcd read error: %s", err)
+	}
+
+	if conn.EtcdLeader() {
+		c.Inc("Run Job Leader")
+		jobs := db.JobSlice(conn.SelectFromJob(nil))
+		if _, err := writeEtcdSlice(store, jobPath, etcdStr, jobs); err != nil {
+			return fmt.Errorf("etcd write error: %s", err)
+		}
+	} else {
+		c.Inc("Run Job Worker")
+		var etcdJobs []db.Job
+		json.Unmarshal([]byte(etcdStr), &etcdJobs)
+		conn.Txn(db.JobTable).Run(func(view db.Database) error {
+			joinJobs(view, etcdJobs)
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// joinJobs makes the job table match the jobs written by the leader, which are
+// keyed by the BlueprintID of their container.
+func joinJobs(view db.Database, etcdJobs []db.Job) {
+	key := func(iface interface{}) interface{} {
+		return iface.(db.Job).BlueprintID
+	}
+	pairs, dbIfaces, etcdIfaces := join.HashJoin(
+		db.JobSlice(view.SelectFromJob(nil)), db.JobSlice(etcdJobs), key, key)
+
+	for _, iface := range dbIfaces {
+		view.Remove(iface.(db.Job))
+	}
+
+	for _, iface := range etcdIfaces {
+		pairs = append(pairs, join.Pair{L: view.InsertJob(), R: iface})
+	}
+
+	for _, pair := range pairs {
+		job := pair.R.(db.Job)
+		job.ID = pair.L.(db.Job).ID
+		view.Commit(job)
+	}
+}
@@ -0,0 +1,78 @@
+package etcd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelda/kelda/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunJobOnce(t *testing.T) {
+	t.Parallel()
+
+	store := newTestMock()
+	conn := db.New()
+
+	err := runJobOnce(conn, store)
+	assert.Error(t, err)
+
+	err = store.Set(jobPath, "", 0)
+	assert.NoError(t, err)
+
+	created := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	expJob := db.Job{
+		BlueprintID: "migrate-id",
+		Hostname:    "migrate",
+		Created:     created,
+		Runs: []db.JobRun{{Number: 1, Status: db.JobSucceeded,
+			Started: created, Finished: created.Add(time.Minute),
+			Booted: 1, Succeeded: 1}},
+	}
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		etcd := view.InsertEtcd()
+		etcd.Leader = true
+		view.Commit(etcd)
+
+		job := view.InsertJob()
+		expJob.ID = job.ID
+		view.Commit(expJob)
+		return nil
+	})
+
+	err = runJobOnce(conn, store)
+	assert.NoError(t, err)
+
+	str, err := store.Get(jobPath)
+	assert.NoError(t, err)
+	assert.Contains(t, str, `"BlueprintID": "migrate-id"`)
+	assert.Contains(t, str, `"Status": "succeeded"`)
+
+	// Once the minion isn't the leader, its jobs are replaced by those that
+	// the leader wrote.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		etcd := view.SelectFromEtcd(nil)[0]
+		etcd.Leader = false
+		view.Commit(etcd)
+
+		job := view.SelectFromJob(nil)[0]
+		job.Runs = nil
+		view.Commit(job)
+
+		other := view.InsertJob()
+		other.BlueprintID = "other-id"
+		view.Commit(other)
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		err = runJobOnce(conn, store)
+		assert.NoError(t, err)
+
+		jobs := conn.SelectFromJob(nil)
+		assert.Len(t, jobs, 1)
+		jobs[0].ID = 0
+		expJob.ID = 0
+		assert.Equal(t, expJob, jobs[0])
+	}
+}
@@ -2,6 +2,7 @@ package etcd
 
 import (
 	"encoding/json"
+	"fmt"
 	"path"
 	"strings"
 	"time"
@@ -91,9 +92,11 @@ func diffMinion(dbMinions, storeMinions []db.Minion) (del, add []db.Minion) {
 		return struct {
 			Role, PrivateIP, HostSubnets       string
 			Provider, Size, Region, FloatingIP string
+			Volumes                            string
 		}{
 			string(m.Role), m.PrivateIP, strings.Join(m.HostSubnets, " "),
 			m.Provider, m.Size, m.Region, m.FloatingIP,
+			fmt.Sprint(m.Volumes),
 		}
 	}
 
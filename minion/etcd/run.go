@@ -13,11 +13,15 @@ import (
 func Run(conn db.Conn) {
 	store := NewStore()
 	makeEtcdDir(minionPath, store, 0)
+	makeEtcdDir(statusPath, store, 0)
 
 	go runElection(conn, store)
 	go runConnection(conn, store)
 	go runContainer(conn, store)
 	go runHostname(conn, store)
+	go runJob(conn, store)
+	go runVolume(conn, store)
+	go runStatus(conn, store)
 	runMinionSync(conn, store)
 }
 
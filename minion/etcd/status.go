@@ -0,0 +1,119 @@
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	statusPath    = "/status"
+	statusTimeout = 60
+)
+
+// containerStatus is the state of a container that is only known to the worker
+// running it.
+type containerStatus struct {
+	Status   string `json:",omitempty"`
+	Health   string `json:",omitempty"`
+	ExitCode int    `json:",omitempty"`
+}
+
+// runStatus forwards the status, health, and exit code of each container to the
+// leader.
+// Each worker writes the status of its containers to a key of its own, which
+// expires if the worker stops refreshing it.
+func runStatus(conn db.Conn, store Store) {
+	etcdWatch := store.Watch(statusPath, 1*time.Second)
+	trigg := conn.TriggerTick(statusTimeout/2, db.ContainerTable)
+	for range util.JoinNotifiers(trigg.C, etcdWatch) {
+		if err := runStatusOnce(conn, store); err != nil {
+			log.WithError(err).Warn("Failed to sync status with Etcd.")
+		}
+	}
+}
+
+func runStatusOnce(conn db.Conn, store Store) error {
+	self := conn.MinionSelf()
+	if self.Role == db.Worker && self.PrivateIP != "" {
+		c.Inc("Write Status")
+		if err := writeStatus(conn, store, self.PrivateIP); err != nil {
+			return fmt.Errorf("etcd write error: %s", err)
+		}
+	}
+
+	if conn.EtcdLeader() {
+		c.Inc("Read Status")
+		tree, err := store.GetTree(statusPath)
+		if err != nil {
+			return fmt.Errorf("etcd read error: %s", err)
+		}
+
+		conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+			joinStatus(view, parseStatus(tree))
+			return nil
+		})
+	}
+	return nil
+}
+
+// writeStatus publishes a map from BlueprintID to status for the containers
+// running on this worker.
+func writeStatus(conn db.Conn, store Store, myIP string) error {
+	status := map[string]containerStatus{}
+	for _, dbc := range conn.SelectFromContainerByMinion(myIP,
+		func(dbc db.Container) bool {
+			return dbc.Status != "" || dbc.Health != ""
+		}) {
+		status[dbc.BlueprintID] = containerStatus{
+			dbc.Status, dbc.Health, dbc.ExitCode}
+	}
+
+	js, err := jsonMarshal(status)
+	if err != nil {
+		return err
+	}
+
+	key := path.Join(statusPath, myIP)
+	return store.Set(key, string(js), statusTimeout*time.Second)
+}
+
+// parseStatus converts the tree written by the workers into a map from minion IP
+// to the status of each of its containers.
+func parseStatus(tree Tree) map[string]map[string]containerStatus {
+	status := map[string]map[string]containerStatus{}
+	for minion, t := range tree.Children {
+		var minionStatus map[string]containerStatus
+		if err := json.Unmarshal([]byte(t.Value), &minionStatus); err != nil {
+			log.WithField("json", t.Value).Warning("Failed to parse status.")
+			continue
+		}
+		status[minion] = minionStatus
+	}
+	return status
+}
+
+// joinStatus records the status reported by the worker each container is
+// scheduled on.  Containers that haven't been reported on have unknown status.
+func joinStatus(view db.Database, status map[string]map[string]containerStatus) {
+	for _, dbc := range view.SelectFromContainer(nil) {
+		cs := status[dbc.Minion][dbc.BlueprintID]
+		if dbc.HealthCheck == nil {
+			cs.Health = ""
+		}
+
+		if dbc.Status != cs.Status || dbc.Health != cs.Health ||
+			dbc.ExitCode != cs.ExitCode {
+			dbc.Status = cs.Status
+			dbc.Health = cs.Health
+			dbc.ExitCode = cs.ExitCode
+			view.Commit(dbc)
+		}
+	}
+}
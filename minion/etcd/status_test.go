@@ -0,0 +1,111 @@
+package etcd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+)
+
+func TestRunStatusOnce(t *testing.T) {
+	t.Parallel()
+
+	store := newTestMock()
+	assert.NoError(t, store.Mkdir(statusPath, 0))
+
+	worker := db.New()
+	worker.Txn(db.AllTables...).Run(func(view db.Database) error {
+		self := view.InsertMinion()
+		self.Self = true
+		self.Role = db.Worker
+		self.PrivateIP = "1.2.3.4"
+		view.Commit(self)
+
+		dbc := view.InsertContainer()
+		dbc.BlueprintID = "healthy"
+		dbc.Minion = "1.2.3.4"
+		dbc.Status = db.ContainerRunning
+		dbc.Health = db.ContainerHealthy
+		view.Commit(dbc)
+
+		dbc = view.InsertContainer()
+		dbc.BlueprintID = "failed"
+		dbc.Minion = "1.2.3.4"
+		dbc.Status = db.ContainerExited
+		dbc.ExitCode = 2
+		view.Commit(dbc)
+		return nil
+	})
+
+	err := runStatusOnce(worker, store)
+	assert.NoError(t, err)
+
+	str, err := store.Get(statusPath + "/1.2.3.4")
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+    "failed": {
+        "Status": "exited",
+        "ExitCode": 2
+    },
+    "healthy": {
+        "Status": "running",
+        "Health": "healthy"
+    }
+}`, str)
+
+	leader := db.New()
+	leader.Txn(db.AllTables...).Run(func(view db.Database) error {
+		self := view.InsertMinion()
+		self.Self = true
+		self.Role = db.Master
+		view.Commit(self)
+
+		etcd := view.InsertEtcd()
+		etcd.Leader = true
+		view.Commit(etcd)
+
+		for _, id := range []string{"healthy", "unknown", "unchecked",
+			"failed"} {
+			dbc := view.InsertContainer()
+			dbc.BlueprintID = id
+			dbc.Minion = "1.2.3.4"
+			if id != "unchecked" && id != "failed" {
+				dbc.HealthCheck = &blueprint.HealthCheck{Type: "tcp"}
+			}
+			view.Commit(dbc)
+		}
+		return nil
+	})
+
+	err = runStatusOnce(leader, store)
+	assert.NoError(t, err)
+
+	status := map[string]string{}
+	health := map[string]string{}
+	exitCodes := map[string]int{}
+	for _, dbc := range leader.SelectFromContainer(nil) {
+		status[dbc.BlueprintID] = dbc.Status
+		health[dbc.BlueprintID] = dbc.Health
+		exitCodes[dbc.BlueprintID] = dbc.ExitCode
+	}
+	assert.Equal(t, map[string]string{
+		"healthy":   db.ContainerRunning,
+		"unknown":   "",
+		"unchecked": "",
+		"failed":    db.ContainerExited,
+	}, status)
+	assert.Equal(t, map[string]string{
+		"healthy":   db.ContainerHealthy,
+		"unknown":   "",
+		"unchecked": "",
+		"failed":    "",
+	}, health)
+	assert.Equal(t, map[string]int{
+		"healthy":   0,
+		"unknown":   0,
+		"unchecked": 0,
+		"failed":    2,
+	}, exitCodes)
+}
@@ -7,13 +7,17 @@ import (
 	"github.com/coreos/etcd/client"
 )
 
-func writeEtcdSlice(store Store, path, old string, new sort.Interface) error {
+// writeEtcdSlice writes `new` to `path`, unless it's unchanged from `old`, and
+// returns what was written.
+func writeEtcdSlice(store Store, path, old string, new sort.Interface) (
+	string, error) {
+
 	sort.Sort(new)
 	newStr, err := jsonMarshal(new)
 	if err == nil && string(newStr) != old {
 		err = store.Set(path, string(newStr), 0)
 	}
-	return err
+	return string(newStr), err
 }
 
 func readEtcdNode(store Store, path string) (string, error) {
@@ -0,0 +1,80 @@
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/join"
+	"github.com/kelda/kelda/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const volumePath = "/volumes"
+
+// runVolume replicates the leader's volumes, and thus the minions that host
+// volumes are bound to, to the other minions.  That way, a minion that becomes
+// the leader places the containers mounting a host volume on the minion that
+// holds its data.
+func runVolume(conn db.Conn, store Store) {
+	etcdWatch := store.Watch(volumePath, 1*time.Second)
+	trigg := conn.TriggerTick(60, db.VolumeTable)
+	for range util.JoinNotifiers(trigg.C, etcdWatch) {
+		if err := runVolumeOnce(conn, store); err != nil {
+			log.WithError(err).Warn("Failed to sync volumes with Etcd")
+		}
+	}
+}
+
+func runVolumeOnce(conn db.Conn, store Store) error {
+	etcdStr, err := readEtcdNode(store, volumePath)
+	if err != nil {
+		return fmt.Errorf("etcd read error: %s", err)
+	}
+
+	if conn.EtcdLeader() {
+		c.Inc("Run Volume Leader")
+		volumes := db.VolumeSlice(conn.SelectFromVolume(nil))
+		_, err := writeEtcdSlice(store, volumePath, etcdStr, volumes)
+		if err != nil {
+			return fmt.Errorf("etcd write error: %s", err)
+		}
+	} else {
+		c.Inc("Run Volume Worker")
+		var etcdVolumes []db.Volume
+		json.Unmarshal([]byte(etcdStr), &etcdVolumes)
+		conn.Txn(db.VolumeTable).Run(func(view db.Database) error {
+			joinVolumes(view, etcdVolumes)
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// joinVolumes makes the volume table match the volumes written by the leader,
+// which are keyed by name.
+func joinVolumes(view db.Database, etcdVolumes []db.Volume) {
+	key := func(iface interface{}) interface{} {
+		return iface.(db.Volume).Name
+	}
+	pairs, dbIfaces, etcdIfaces := join.HashJoin(
+		db.VolumeSlice(view.SelectFromVolume(nil)),
+		db.VolumeSlice(etcdVolumes), key, key)
+
+	for _, iface := range dbIfaces {
+		view.Remove(iface.(db.Volume))
+	}
+
+	for _, iface := range etcdIfaces {
+		pairs = append(pairs, join.Pair{L: view.InsertVolume(), R: iface})
+	}
+
+	for _, pair := range pairs {
+		vol := pair.R.(db.Volume)
+		vol.ID = pair.L.(db.Volume).ID
+		view.Commit(vol)
+	}
+}
@@ -0,0 +1,72 @@
+package etcd
+
+import (
+	"testing"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunVolumeOnce(t *testing.T) {
+	t.Parallel()
+
+	store := newTestMock()
+	conn := db.New()
+
+	err := runVolumeOnce(conn, store)
+	assert.Error(t, err)
+
+	err = store.Set(volumePath, "", 0)
+	assert.NoError(t, err)
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		etcd := view.InsertEtcd()
+		etcd.Leader = true
+		view.Commit(etcd)
+
+		vol := view.InsertVolume()
+		vol.Name = "data"
+		vol.Type = blueprint.HostVolume
+		vol.Minion = "10.0.0.2"
+		view.Commit(vol)
+		return nil
+	})
+
+	err = runVolumeOnce(conn, store)
+	assert.NoError(t, err)
+
+	str, err := store.Get(volumePath)
+	assert.NoError(t, err)
+	assert.Contains(t, str, `"Name": "data"`)
+	assert.Contains(t, str, `"Minion": "10.0.0.2"`)
+
+	// Once the minion isn't the leader, its volumes are replaced by those
+	// that the leader wrote.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		etcd := view.SelectFromEtcd(nil)[0]
+		etcd.Leader = false
+		view.Commit(etcd)
+
+		vol := view.SelectFromVolume(nil)[0]
+		vol.Minion = ""
+		view.Commit(vol)
+
+		other := view.InsertVolume()
+		other.Name = "other"
+		view.Commit(other)
+		return nil
+	})
+
+	expVolume := db.Volume{Name: "data", Type: blueprint.HostVolume,
+		Minion: "10.0.0.2"}
+	for i := 0; i < 2; i++ {
+		err = runVolumeOnce(conn, store)
+		assert.NoError(t, err)
+
+		volumes := conn.SelectFromVolume(nil)
+		assert.Len(t, volumes, 1)
+		volumes[0].ID = 0
+		assert.Equal(t, expVolume, volumes[0])
+	}
+}
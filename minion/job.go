@@ -0,0 +1,198 @@
+package minion
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/join"
+
+	"github.com/robfig/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxJobRuns is the number of runs of each job whose history is kept.
+const maxJobRuns = 10
+
+// updateJobs runs the jobs in the blueprint.  Each run of a job boots copies of
+// the job's container, at most Parallelism at a time, and removes them as they
+// exit, until either Completions of them have succeeded, or one has failed even
+// after being retried.  Jobs without a schedule run once, while those with one
+// start a new run each time their schedule fires, unless the previous run is
+// still going.  The etcd package replicates the jobs to the other minions, so a
+// new leader picks up where the old one left off.
+func updateJobs(view db.Database, bp blueprint.Blueprint, now time.Time) {
+	var bpJobs blueprintJobSlice
+	for _, c := range bp.Containers {
+		if c.Job != nil {
+			bpJobs = append(bpJobs, c)
+		}
+	}
+
+	key := func(intf interface{}) interface{} {
+		switch v := intf.(type) {
+		case blueprint.Container:
+			return v.ID
+		case db.Job:
+			return v.BlueprintID
+		}
+		panic("unreachable")
+	}
+
+	pairs, toAdd, toRemove := join.HashJoin(bpJobs,
+		db.JobSlice(view.SelectFromJob(nil)), key, key)
+
+	for _, intf := range toRemove {
+		view.Remove(intf.(db.Job))
+	}
+
+	for _, intf := range toAdd {
+		job := view.InsertJob()
+		job.Created = now
+		pairs = append(pairs, join.Pair{L: intf, R: job})
+	}
+
+	jobDBCs := map[string][]db.Container{}
+	for _, dbc := range view.SelectFromContainer(func(dbc db.Container) bool {
+		return dbc.Job != ""
+	}) {
+		jobDBCs[dbc.Job] = append(jobDBCs[dbc.Job], dbc)
+	}
+
+	for _, pair := range pairs {
+		c := pair.L.(blueprint.Container)
+		job := pair.R.(db.Job)
+
+		job.BlueprintID = c.ID
+		job.Hostname = c.Hostname
+		job.Completions = c.Job.Completions
+		job.Parallelism = c.Job.Parallelism
+		job.RetryLimit = c.Job.RetryLimit
+		job.Schedule = c.Job.Schedule
+		job = runJob(view, job, c, jobDBCs[c.ID], now)
+		view.Commit(job)
+
+		delete(jobDBCs, c.ID)
+	}
+
+	// The remaining containers belong to jobs that are no longer in the
+	// blueprint.
+	for _, dbcs := range jobDBCs {
+		for _, dbc := range dbcs {
+			view.Remove(dbc)
+		}
+	}
+}
+
+// runJob advances the current run of `job`, starting a new one if it's due.
+// `dbcs` are the containers that were booted for the job's runs.
+func runJob(view db.Database, job db.Job, c blueprint.Container,
+	dbcs []db.Container, now time.Time) db.Job {
+
+	run, ok := job.CurrentRun()
+	if (!ok || run.Status != db.JobRunning) && jobDue(job, now) {
+		run = db.JobRun{Number: run.Number + 1, Status: db.JobRunning,
+			Started: now}
+		job.Runs = append(job.Runs, run)
+		if len(job.Runs) > maxJobRuns {
+			job.Runs = job.Runs[len(job.Runs)-maxJobRuns:]
+		}
+	}
+
+	if run.Status != db.JobRunning {
+		for _, dbc := range dbcs {
+			view.Remove(dbc)
+		}
+		return job
+	}
+
+	var active []db.Container
+	for _, dbc := range dbcs {
+		if dbc.Status != db.ContainerExited {
+			active = append(active, dbc)
+			continue
+		}
+
+		if dbc.ExitCode == 0 {
+			run.Succeeded++
+		} else {
+			run.Failed++
+		}
+		view.Remove(dbc)
+	}
+
+	completions := job.Completions
+	if completions == 0 {
+		completions = 1
+	}
+
+	parallelism := job.Parallelism
+	if parallelism == 0 {
+		parallelism = 1
+	}
+
+	switch {
+	case run.Failed > 0:
+		run.Status = db.JobFailed
+	case run.Succeeded >= completions:
+		run.Status = db.JobSucceeded
+	}
+
+	if run.Status != db.JobRunning {
+		run.Finished = now
+		for _, dbc := range active {
+			view.Remove(dbc)
+		}
+	} else {
+		for booted := len(active); booted < parallelism &&
+			run.Succeeded+booted < completions; booted++ {
+			dbc := view.InsertContainer()
+			id := dbc.ID
+			dbc = newContainer(c)
+			dbc.ID = id
+			dbc.BlueprintID = fmt.Sprintf("%s-%d-%d", c.ID, run.Number,
+				run.Booted)
+			dbc.Job = c.ID
+			dbc.RetryLimit = job.RetryLimit
+			dbc.RestartPolicy = blueprint.RestartOnFailure
+			view.Commit(dbc)
+			run.Booted++
+		}
+	}
+
+	job.Runs[len(job.Runs)-1] = run
+	return job
+}
+
+// jobDue returns whether a new run of `job` should start at `now`, assuming
+// that the previous run, if any, has finished.
+func jobDue(job db.Job, now time.Time) bool {
+	run, ran := job.CurrentRun()
+	if job.Schedule == "" {
+		return !ran
+	}
+
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		log.WithError(err).WithField("job", job.Hostname).Warning(
+			"Invalid job schedule")
+		return false
+	}
+
+	last := job.Created
+	if ran {
+		last = run.Started
+	}
+	return !schedule.Next(last).After(now)
+}
+
+type blueprintJobSlice []blueprint.Container
+
+func (slc blueprintJobSlice) Get(ii int) interface{} {
+	return slc[ii]
+}
+
+func (slc blueprintJobSlice) Len() int {
+	return len(slc)
+}
@@ -0,0 +1,142 @@
+package minion
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+)
+
+func TestUpdateJobs(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	bp := blueprint.Blueprint{Containers: []blueprint.Container{
+		{ID: "service", Hostname: "service"},
+		{ID: "migrate", Hostname: "migrate", Job: &blueprint.Job{
+			Completions: 3, Parallelism: 2, RetryLimit: 1}},
+	}}
+
+	update := func(now time.Time) {
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			updateContainers(view, bp)
+			updateJobs(view, bp, now)
+			return nil
+		})
+	}
+
+	// exit marks the job container with the given BlueprintID as exited,
+	// and returns the BlueprintIDs of the job's containers.
+	exit := func(id string, exitCode int) []string {
+		var ids []string
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			for _, dbc := range view.SelectFromContainer(nil) {
+				if dbc.BlueprintID == id {
+					dbc.Status = db.ContainerExited
+					dbc.ExitCode = exitCode
+					view.Commit(dbc)
+				}
+				if dbc.Job != "" {
+					ids = append(ids, dbc.BlueprintID)
+				}
+			}
+			return nil
+		})
+		sort.Strings(ids)
+		return ids
+	}
+
+	currentRun := func() db.JobRun {
+		jobs := conn.SelectFromJob(nil)
+		assert.Len(t, jobs, 1)
+		run, _ := jobs[0].CurrentRun()
+		return run
+	}
+
+	update(start)
+	assert.Equal(t, []string{"migrate-1-0", "migrate-1-1"}, exit("", 0))
+
+	dbcs := conn.SelectFromContainer(func(dbc db.Container) bool {
+		return dbc.BlueprintID == "migrate-1-0"
+	})
+	assert.Len(t, dbcs, 1)
+	assert.Equal(t, "migrate", dbcs[0].Job)
+	assert.Equal(t, "migrate", dbcs[0].Hostname)
+	assert.Equal(t, 1, dbcs[0].RetryLimit)
+	assert.Equal(t, blueprint.RestartOnFailure, dbcs[0].RestartPolicy)
+
+	// Only the third completion is booted once a container succeeds.
+	exit("migrate-1-0", 0)
+	update(start)
+	assert.Equal(t, []string{"migrate-1-1", "migrate-1-2"}, exit("", 0))
+
+	exit("migrate-1-1", 0)
+	exit("migrate-1-2", 0)
+	update(start.Add(time.Minute))
+	assert.Empty(t, exit("", 0))
+	assert.Equal(t, db.JobRun{Number: 1, Status: db.JobSucceeded,
+		Started: start, Finished: start.Add(time.Minute),
+		Booted: 3, Succeeded: 3}, currentRun())
+
+	// Jobs without a schedule don't run again.
+	update(start.Add(time.Hour))
+	assert.Empty(t, exit("", 0))
+	assert.Equal(t, db.JobSucceeded, currentRun().Status)
+
+	// The service is unaffected by the job.
+	assert.Len(t, conn.SelectFromContainer(nil), 1)
+
+	// A scheduled job starts a new run each time its schedule fires.
+	bp.Containers[1].Job = &blueprint.Job{Schedule: "0 * * * *"}
+	update(start.Add(30 * time.Minute))
+	assert.Empty(t, exit("", 0))
+
+	update(start.Add(time.Hour))
+	assert.Equal(t, []string{"migrate-2-0"}, exit("", 0))
+
+	// A container that fails even after being retried fails the run.
+	exit("migrate-2-0", 1)
+	update(start.Add(time.Hour + time.Minute))
+	assert.Empty(t, exit("", 0))
+	assert.Equal(t, db.JobFailed, currentRun().Status)
+	assert.Equal(t, 1, currentRun().Failed)
+
+	update(start.Add(2 * time.Hour))
+	assert.Equal(t, []string{"migrate-3-0"}, exit("", 0))
+	assert.Equal(t, 3, currentRun().Number)
+	assert.Len(t, conn.SelectFromJob(nil)[0].Runs, 3)
+
+	// Removing the job from the blueprint removes its containers.
+	bp.Containers = bp.Containers[:1]
+	update(start.Add(2 * time.Hour))
+	assert.Empty(t, exit("", 0))
+	assert.Empty(t, conn.SelectFromJob(nil))
+	assert.Len(t, conn.SelectFromContainer(nil), 1)
+}
+
+func TestJobDue(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2017, 1, 1, 0, 30, 0, 0, time.UTC)
+	job := db.Job{Created: created}
+	assert.True(t, jobDue(job, created))
+
+	job.Runs = []db.JobRun{{Started: created}}
+	assert.False(t, jobDue(job, created.Add(24*time.Hour)))
+
+	job = db.Job{Created: created, Schedule: "0 3 * * *"}
+	assert.False(t, jobDue(job, created))
+	assert.True(t, jobDue(job, created.Add(150*time.Minute)))
+
+	job.Runs = []db.JobRun{{Started: created.Add(150 * time.Minute)}}
+	assert.False(t, jobDue(job, created.Add(24*time.Hour)))
+	assert.True(t, jobDue(job, created.Add(26*time.Hour+30*time.Minute)))
+
+	job.Schedule = "bad"
+	assert.False(t, jobDue(job, created.Add(48*time.Hour)))
+}
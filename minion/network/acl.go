@@ -25,44 +25,121 @@ type connection struct {
 	from string
 	to   string
 
-	minPort int
-	maxPort int
+	minPort  int
+	maxPort  int
+	protocol string
 }
 
-func updateACLs(client ovsdb.Client, dbConns []db.Connection,
-	hostnameToIP map[string]string) {
+// A resolvedConnection is a db.Connection whose hostnames have been replaced by
+// IP addresses.
+type resolvedConnection struct {
+	ok          bool // False if either end resolved to no addresses.
+	conn        connection
+	addressSets map[string][]string
+}
+
+// aclSync is the state that updateACLs keeps between runs, so that it only
+// resolves the connections that changed, and only syncs OVSDB when they do.
+type aclSync struct {
+	synced bool
 
-	connections, addressSets := resolveConnections(dbConns, hostnameToIP)
-	syncAddressSets(client, addressSets)
-	syncACLs(client, connections)
+	// The hostname mappings that the connections were last resolved with.
+	hostnameToIPs map[string][]string
+
+	// The resolved connections, by db.Connection ID.
+	resolved map[int]resolvedConnection
 }
 
-func resolveConnections(dbConns []db.Connection, hostnameToIP map[string]string) (
-	[]connection, []ovsdb.AddressSet) {
+// updateACLs syncs the ACLs and address sets in OVSDB with `dbConns`.  Unless
+// `changes` is reset, only the connections that changed, or that refer to
+// hostnames whose IPs changed, are resolved again.  If none were, and the last
+// sync succeeded, OVSDB isn't touched.
+func updateACLs(client ovsdb.Client, state *aclSync, dbConns []db.Connection,
+	changes db.ChangeSet, hostnameToIPs map[string][]string) {
 
-	var conns []connection
-	addressSets := map[string][]string{}
+	full := !state.synced || changes.Reset
+	if full {
+		state.resolved = map[int]resolvedConnection{}
+	}
+
+	ids := changes.IDs()
+	dirty := full || len(changes.Removed) > 0
+	for _, id := range changes.Removed {
+		delete(state.resolved, id)
+	}
+
+	changedHostnames := diffHostnames(state.hostnameToIPs, hostnameToIPs)
+	state.hostnameToIPs = hostnameToIPs
+	for _, dbConn := range dbConns {
+		if full || ids[dbConn.ID] ||
+			refersTo(dbConn.From, changedHostnames) ||
+			refersTo(dbConn.To, changedHostnames) {
+			state.resolved[dbConn.ID] = resolveConnection(dbConn,
+				hostnameToIPs)
+			dirty = true
+		}
+	}
+
+	if !dirty {
+		return
+	}
 
-	// Given a slice of db.Connection, create a slice of create a slice of connection
-	// structs where the from and to are replaced either by individual IP addresses,
-	// or the name of an address set that contains a list of IP addresses.
+	var resolved []resolvedConnection
 	for _, dbConn := range dbConns {
-		from := str.SliceFilterOut(dbConn.From, blueprint.PublicInternetLabel)
-		from = resolveHostnames(from, hostnameToIP)
+		resolved = append(resolved, state.resolved[dbConn.ID])
+	}
+	connections, addressSets := mergeConnections(resolved)
+	setsSynced := syncAddressSets(client, addressSets)
+	aclsSynced := syncACLs(client, connections)
+	state.synced = setsSynced && aclsSynced
+}
+
+// resolveConnection replaces the from and to of `dbConn` with either an
+// individual IP address, or the name of an address set that contains a list of
+// IP addresses.
+func resolveConnection(dbConn db.Connection,
+	hostnameToIPs map[string][]string) resolvedConnection {
 
-		to := str.SliceFilterOut(dbConn.To, blueprint.PublicInternetLabel)
-		to = resolveHostnames(to, hostnameToIP)
+	from := str.SliceFilterOut(dbConn.From, blueprint.PublicInternetLabel)
+	from = resolveHostnames(from, hostnameToIPs)
 
-		if len(from) == 0 || len(to) == 0 {
-			continue // Either from or to contained only `public`.
+	to := str.SliceFilterOut(dbConn.To, blueprint.PublicInternetLabel)
+	to = resolveHostnames(to, hostnameToIPs)
+
+	if len(from) == 0 || len(to) == 0 {
+		// Either from or to contained only `public`.
+		return resolvedConnection{}
+	}
+
+	addressSets := map[string][]string{}
+	return resolvedConnection{
+		ok: true,
+		conn: connection{
+			minPort:  dbConn.MinPort,
+			maxPort:  dbConn.MaxPort,
+			protocol: dbConn.Protocol,
+			from:     endpointName(from, addressSets),
+			to:       endpointName(to, addressSets),
+		},
+		addressSets: addressSets,
+	}
+}
+
+// mergeConnections collects the connections, and the address sets they use.
+func mergeConnections(resolved []resolvedConnection) (
+	[]connection, []ovsdb.AddressSet) {
+
+	var conns []connection
+	addressSets := map[string][]string{}
+	for _, rc := range resolved {
+		if !rc.ok {
+			continue
 		}
 
-		conns = append(conns, connection{
-			minPort: dbConn.MinPort,
-			maxPort: dbConn.MaxPort,
-			from:    endpointName(from, addressSets),
-			to:      endpointName(to, addressSets),
-		})
+		conns = append(conns, rc.conn)
+		for name, addresses := range rc.addressSets {
+			addressSets[name] = addresses
+		}
 	}
 
 	var result []ovsdb.AddressSet
@@ -76,24 +153,57 @@ func resolveConnections(dbConns []db.Connection, hostnameToIP map[string]string)
 	return conns, result
 }
 
-func resolveHostnames(hostnames []string, hostnameToIP map[string]string) []string {
+// diffHostnames returns the hostnames whose IPs differ between `old` and `new`.
+func diffHostnames(old, new map[string][]string) map[string]bool {
+	changed := map[string]bool{}
+	for hostname, ips := range new {
+		if !str.SliceEq(sortedCopy(ips), sortedCopy(old[hostname])) {
+			changed[hostname] = true
+		}
+	}
+	for hostname := range old {
+		if _, ok := new[hostname]; !ok {
+			changed[hostname] = true
+		}
+	}
+	return changed
+}
+
+func sortedCopy(slc []string) []string {
+	res := append([]string{}, slc...)
+	sort.Strings(res)
+	return res
+}
+
+func refersTo(hostnames []string, changed map[string]bool) bool {
+	for _, hostname := range hostnames {
+		if changed[hostname] {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveHostnames(hostnames []string, hostnameToIPs map[string][]string) []string {
 	var res []string
 	for _, m := range hostnames {
-		ip, ok := hostnameToIP[m]
+		ips, ok := hostnameToIPs[m]
 		if !ok {
 			log.WithField("hostname", m).Debug("Unknown hostname in ACL")
 			continue
 		}
-		res = append(res, ip)
+		res = append(res, ips...)
 	}
 	return res
 }
 
-func syncAddressSets(ovsdbClient ovsdb.Client, expSets []ovsdb.AddressSet) {
+// syncAddressSets makes the address sets in OVSDB match `expSets`, and returns
+// whether it succeeded.
+func syncAddressSets(ovsdbClient ovsdb.Client, expSets []ovsdb.AddressSet) bool {
 	sets, err := ovsdbClient.ListAddressSets()
 	if err != nil {
 		log.WithError(err).Error("Failed to list address sets")
-		return
+		return false
 	}
 
 	ovsdbKey := func(intf interface{}) interface{} {
@@ -117,17 +227,21 @@ func syncAddressSets(ovsdbClient ovsdb.Client, expSets []ovsdb.AddressSet) {
 		toCreate = append(toCreate, set.(ovsdb.AddressSet))
 	}
 
+	ok := true
 	if len(toDelete) > 0 {
 		if err := ovsdbClient.DeleteAddressSets(toDelete); err != nil {
 			log.WithError(err).Warn("Error deleting address set")
+			ok = false
 		}
 	}
 
 	if len(toCreate) > 0 {
 		if err := ovsdbClient.CreateAddressSets(toCreate); err != nil {
 			log.WithError(err).Warn("Error adding address set")
+			ok = false
 		}
 	}
+	return ok
 }
 
 func directedACLs(acl ovsdb.ACL) (res []ovsdb.ACL) {
@@ -144,11 +258,13 @@ func directedACLs(acl ovsdb.ACL) (res []ovsdb.ACL) {
 	return res
 }
 
-func syncACLs(ovsdbClient ovsdb.Client, connections []connection) {
+// syncACLs makes the ACLs in OVSDB allow `connections`, and returns whether it
+// succeeded.
+func syncACLs(ovsdbClient ovsdb.Client, connections []connection) bool {
 	ovsdbACLs, err := ovsdbClient.ListACLs()
 	if err != nil {
 		log.WithError(err).Error("Failed to list ACLs")
-		return
+		return false
 	}
 
 	expACLs := directedACLs(ovsdb.ACL{
@@ -161,15 +277,21 @@ func syncACLs(ovsdbClient ovsdb.Client, connections []connection) {
 
 	icmpMatches := map[string]struct{}{}
 	for _, conn := range connections {
-		matchStr := getMatchString(conn)
-		expACLs = append(expACLs, directedACLs(
-			ovsdb.ACL{
-				Core: ovsdb.ACLCore{
-					Action:   "allow",
-					Match:    matchStr,
-					Priority: 1,
-				},
-			})...)
+		if conn.protocol != blueprint.ICMPProtocol {
+			expACLs = append(expACLs, directedACLs(
+				ovsdb.ACL{
+					Core: ovsdb.ACLCore{
+						Action:   "allow",
+						Match:    getMatchString(conn),
+						Priority: 1,
+					},
+				})...)
+		}
+
+		// Connections restricted to TCP or UDP don't allow ICMP.
+		if conn.protocol != "" && conn.protocol != blueprint.ICMPProtocol {
+			continue
+		}
 
 		icmpMatch := and(from(conn.from), to(conn.to), "icmp")
 		if _, ok := icmpMatches[icmpMatch]; !ok {
@@ -206,17 +328,21 @@ func syncACLs(ovsdbClient ovsdb.Client, connections []connection) {
 		toCreate = append(toCreate, acl.(ovsdb.ACL).Core)
 	}
 
+	ok := true
 	if len(toDelete) > 0 {
 		if err := ovsdbClient.DeleteACLs(lSwitch, toDelete); err != nil {
 			log.WithError(err).Warn("Error deleting ACL")
+			ok = false
 		}
 	}
 
 	if len(toCreate) > 0 {
 		if err := ovsdbClient.CreateACLs(lSwitch, toCreate); err != nil {
 			log.WithError(err).Warn("Error adding ACLs")
+			ok = false
 		}
 	}
+	return ok
 }
 
 func getMatchString(conn connection) string {
@@ -226,15 +352,19 @@ func getMatchString(conn connection) string {
 	return or(
 		and(
 			from(conn.from), to(conn.to),
-			portConstraint(conn.minPort, conn.maxPort, "dst")),
+			portConstraint(conn, "dst")),
 		and(
 			from(conn.to), to(conn.from),
-			portConstraint(conn.minPort, conn.maxPort, "src")))
+			portConstraint(conn, "src")))
 }
 
-func portConstraint(minPort, maxPort int, direction string) string {
+func portConstraint(conn connection, direction string) string {
+	if conn.protocol != "" {
+		return fmt.Sprintf("%[1]d <= %[2]s.%[3]s <= %[4]d",
+			conn.minPort, conn.protocol, direction, conn.maxPort)
+	}
 	return fmt.Sprintf("(%[1]d <= udp.%[2]s <= %[3]d || "+
-		"%[1]d <= tcp.%[2]s <= %[3]d)", minPort, direction, maxPort)
+		"%[1]d <= tcp.%[2]s <= %[3]d)", conn.minPort, direction, conn.maxPort)
 }
 
 func from(ip string) string {
@@ -2,6 +2,7 @@ package network
 
 import (
 	"errors"
+	"sort"
 	"testing"
 
 	"github.com/kelda/kelda/db"
@@ -14,7 +15,7 @@ import (
 func TestResolveConenctions(t *testing.T) {
 	t.Parallel()
 
-	connections, addressSets := resolveConnections([]db.Connection{{
+	dbConns := []db.Connection{{
 		From: []string{"public"},
 		To:   []string{"a"},
 	}, {
@@ -40,11 +41,18 @@ func TestResolveConenctions(t *testing.T) {
 		To:      []string{"a", "b", "c"},
 		MinPort: 7,
 		MaxPort: 8,
-	}}, map[string]string{
-		"a": "1.1.1.1",
-		"b": "2.2.2.2",
-		"c": "3.3.3.3",
-	})
+	}}
+	hostnameToIPs := map[string][]string{
+		"a": {"1.1.1.1"},
+		"b": {"2.2.2.2"},
+		"c": {"3.3.3.3"},
+	}
+
+	var resolved []resolvedConnection
+	for _, dbConn := range dbConns {
+		resolved = append(resolved, resolveConnection(dbConn, hostnameToIPs))
+	}
+	connections, addressSets := mergeConnections(resolved)
 
 	assert.Equal(t, []connection{{
 		from: "$sha886f76b8da8aa4cb490c3c9e7c8e6" +
@@ -95,12 +103,83 @@ func TestResolveConenctions(t *testing.T) {
 
 }
 
+func TestUpdateACLs(t *testing.T) {
+	t.Parallel()
+
+	client := new(mocks.Client)
+	client.On("ListAddressSets").Return(nil, nil)
+	client.On("CreateAddressSets", mock.Anything).Return(nil)
+	client.On("ListACLs").Return(nil, nil)
+	client.On("CreateACLs", "kelda", mock.Anything).Return(nil)
+
+	var state aclSync
+	conns := []db.Connection{{
+		ID:      1,
+		From:    []string{"a"},
+		To:      []string{"b"},
+		MinPort: 80,
+		MaxPort: 80,
+	}}
+	hostnames := func(b ...string) map[string][]string {
+		return map[string][]string{"a": {"1.1.1.1"}, "b": b}
+	}
+
+	updateACLs(client, &state, conns, db.ChangeSet{Reset: true},
+		hostnames("2.2.2.2"))
+	client.AssertNumberOfCalls(t, "ListACLs", 1)
+	assert.True(t, state.synced)
+
+	// Nothing changed, so OVSDB isn't synced again.
+	updateACLs(client, &state, conns, db.ChangeSet{}, hostnames("2.2.2.2"))
+	client.AssertNumberOfCalls(t, "ListACLs", 1)
+
+	// Nor is it if only unreferenced hostnames change.
+	updateACLs(client, &state, conns, db.ChangeSet{},
+		map[string][]string{"a": {"1.1.1.1"}, "b": {"2.2.2.2"},
+			"unused": {"9.9.9.9"}})
+	client.AssertNumberOfCalls(t, "ListACLs", 1)
+
+	// Connections that refer to hostnames whose IPs changed are resolved again.
+	updateACLs(client, &state, conns, db.ChangeSet{},
+		hostnames("3.3.3.3", "2.2.2.2"))
+	client.AssertNumberOfCalls(t, "ListACLs", 2)
+	assert.Equal(t, "$sha"+
+		"c20bf4e13f9d88b278468732b1137200065f504b6a6b0a76c5f63e5594f9920a",
+		state.resolved[1].conn.to)
+
+	// The order of the IPs doesn't matter.
+	updateACLs(client, &state, conns, db.ChangeSet{},
+		hostnames("2.2.2.2", "3.3.3.3"))
+	client.AssertNumberOfCalls(t, "ListACLs", 2)
+
+	// Failed syncs are retried even if nothing changed.
+	failing := new(mocks.Client)
+	failing.On("ListAddressSets").Return(nil, assert.AnError)
+	failing.On("ListACLs").Return(nil, nil)
+	failing.On("CreateACLs", "kelda", mock.Anything).Return(nil)
+	conns[0].MinPort = 81
+	updateACLs(failing, &state, conns, db.ChangeSet{Modified: []int{1}},
+		hostnames("2.2.2.2", "3.3.3.3"))
+	assert.False(t, state.synced)
+	assert.Equal(t, 81, state.resolved[1].conn.minPort)
+
+	updateACLs(client, &state, conns, db.ChangeSet{},
+		hostnames("2.2.2.2", "3.3.3.3"))
+	client.AssertNumberOfCalls(t, "ListACLs", 3)
+	assert.True(t, state.synced)
+
+	updateACLs(client, &state, nil, db.ChangeSet{Removed: []int{1}},
+		hostnames("2.2.2.2", "3.3.3.3"))
+	client.AssertNumberOfCalls(t, "ListACLs", 4)
+	assert.Empty(t, state.resolved)
+}
+
 func TestSyncAddressSets(t *testing.T) {
 	t.Parallel()
 
 	client := new(mocks.Client)
 	client.On("ListAddressSets").Return(nil, assert.AnError).Once()
-	syncAddressSets(client, nil)
+	assert.False(t, syncAddressSets(client, nil))
 	client.AssertCalled(t, "ListAddressSets")
 
 	client.On("ListAddressSets").Return([]ovsdb.AddressSet{{Name: "old"}}, nil)
@@ -109,12 +188,12 @@ func TestSyncAddressSets(t *testing.T) {
 		assert.AnError).Once()
 	client.On("CreateAddressSets", []ovsdb.AddressSet{{Name: "new"}}).Return(
 		assert.AnError).Once()
-	syncAddressSets(client, []ovsdb.AddressSet{{Name: "new"}})
+	assert.False(t, syncAddressSets(client, []ovsdb.AddressSet{{Name: "new"}}))
 	client.AssertExpectations(t)
 
 	client.On("DeleteAddressSets", []ovsdb.AddressSet{{Name: "old"}}).Return(nil)
 	client.On("CreateAddressSets", []ovsdb.AddressSet{{Name: "new"}}).Return(nil)
-	syncAddressSets(client, []ovsdb.AddressSet{{Name: "new"}})
+	assert.True(t, syncAddressSets(client, []ovsdb.AddressSet{{Name: "new"}}))
 	client.AssertExpectations(t)
 }
 
@@ -124,7 +203,7 @@ func TestSyncACLs(t *testing.T) {
 
 	anErr := errors.New("err")
 	client.On("ListACLs").Return(nil, anErr).Once()
-	syncACLs(client, nil)
+	assert.False(t, syncACLs(client, nil))
 	client.AssertCalled(t, "ListACLs")
 
 	conns := []connection{
@@ -145,7 +224,7 @@ func TestSyncACLs(t *testing.T) {
 
 	client.On("CreateACLs", "kelda", mock.Anything).Return(nil).Once()
 	client.On("DeleteACLs", mock.Anything, mock.Anything).Return(anErr).Once()
-	syncACLs(client, conns)
+	assert.False(t, syncACLs(client, conns))
 
 	// The order to CreateACLs is not deterministic, so we have to check that it
 	// was called properly after the fact.
@@ -180,22 +259,22 @@ func TestSyncACLs(t *testing.T) {
 	}, {
 		Priority:  1,
 		Direction: "from-lport",
-		Match:     getMatchString(connection{"8.8.8.8", "9.9.9.9", 80, 80}),
+		Match:     getMatchString(conns[0]),
 		Action:    "allow",
 	}, {
 		Priority:  1,
 		Direction: "to-lport",
-		Match:     getMatchString(connection{"8.8.8.8", "9.9.9.9", 80, 80}),
+		Match:     getMatchString(conns[0]),
 		Action:    "allow",
 	}, {
 		Priority:  1,
 		Direction: "from-lport",
-		Match:     getMatchString(connection{"8.8.8.8", "9.9.9.9", 8080, 8080}),
+		Match:     getMatchString(conns[1]),
 		Action:    "allow",
 	}, {
 		Priority:  1,
 		Direction: "to-lport",
-		Match:     getMatchString(connection{"8.8.8.8", "9.9.9.9", 8080, 8080}),
+		Match:     getMatchString(conns[1]),
 		Action:    "allow",
 	}}
 
@@ -208,6 +287,39 @@ func TestSyncACLs(t *testing.T) {
 	client.On("CreateACLs", mock.Anything, mock.Anything, mock.Anything,
 		mock.Anything, mock.Anything).Return(anErr)
 	client.On("DeleteACLs", mock.Anything, mock.Anything).Return(anErr).Once()
-	syncACLs(client, conns)
+	assert.False(t, syncACLs(client, conns))
 	client.AssertCalled(t, "ListACLs")
 }
+
+func TestSyncACLsProtocol(t *testing.T) {
+	t.Parallel()
+
+	client := new(mocks.Client)
+	client.On("ListACLs").Return(nil, nil)
+	client.On("CreateACLs", "kelda", mock.Anything).Return(nil)
+
+	syncACLs(client, []connection{
+		{"8.8.8.8", "9.9.9.9", 53, 53, "udp"},
+		{"8.8.8.8", "7.7.7.7", 0, 0, "icmp"},
+	})
+
+	var matches []string
+	actualACLs := client.Calls[1].Arguments.Get(1).([]ovsdb.ACLCore)
+	for _, acl := range actualACLs {
+		if acl.Direction == "to-lport" {
+			matches = append(matches, acl.Match)
+		}
+	}
+
+	// The UDP connection doesn't allow TCP or ICMP, and the ICMP connection
+	// allows nothing else.
+	sort.Strings(matches)
+	assert.Equal(t, []string{
+		"((ip4.src == 8.8.8.8 && ip4.dst == 9.9.9.9 && " +
+			"53 <= udp.dst <= 53) || " +
+			"(ip4.src == 9.9.9.9 && ip4.dst == 8.8.8.8 && " +
+			"53 <= udp.src <= 53))",
+		and(from("8.8.8.8"), to("7.7.7.7"), "icmp"),
+		"ip",
+	}, matches)
+}
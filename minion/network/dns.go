@@ -34,9 +34,11 @@ func runDNS(conn db.Conn) {
 }
 
 func syncHostnames(conn db.Conn) {
-	for range conn.Trigger(db.LoadBalancerTable, db.ContainerTable,
-		db.MinionTable).C {
-		syncHostnamesOnce(conn)
+	var state hostnameSync
+	trigger := conn.ChangeTrigger(db.LoadBalancerTable, db.ContainerTable,
+		db.MinionTable)
+	for range trigger.C {
+		syncHostnamesOnce(conn, &state, trigger.Changes())
 	}
 }
 
@@ -46,18 +48,114 @@ func serveDNS(conn db.Conn) {
 	}
 }
 
-func syncHostnamesOnce(conn db.Conn) {
+// hostnameSync is the state that syncHostnamesOnce keeps between runs, so that
+// it only recomputes the hostnames of the rows that changed.
+type hostnameSync struct {
+	synced bool
+
+	// The hostname of each load balancer and container, by ID, as of the
+	// last run.
+	loadBalancers map[int]string
+	containers    map[int]string
+}
+
+func syncHostnamesOnce(conn db.Conn, state *hostnameSync,
+	changes map[db.TableType]db.ChangeSet) {
+
 	if !conn.EtcdLeader() {
+		*state = hostnameSync{}
 		return
 	}
 
-	conn.Txn(db.LoadBalancerTable, db.ContainerTable, db.HostnameTable).
-		Run(joinHostnames)
+	lbChanges := changes[db.LoadBalancerTable]
+	dbcChanges := changes[db.ContainerTable]
+	if state.synced && lbChanges.Empty() && dbcChanges.Empty() {
+		return
+	}
+
+	conn.Txn(db.LoadBalancerTable, db.ContainerTable, db.HostnameTable).Run(
+		func(view db.Database) error {
+			full := !state.synced || lbChanges.Reset || dbcChanges.Reset
+			affected := state.update(view, lbChanges, dbcChanges, full)
+			joinHostnames(view, affected)
+			return nil
+		})
+}
+
+// update records the hostnames of the load balancers and containers that
+// changed, and returns the hostnames that they had or now have.  If `full` is
+// set, every row is recorded and the returned set is nil, meaning that every
+// hostname is affected.
+func (state *hostnameSync) update(view db.Database, lbChanges,
+	dbcChanges db.ChangeSet, full bool) map[string]bool {
+
+	var affected map[string]bool
+	if full {
+		*state = hostnameSync{
+			synced:        true,
+			loadBalancers: map[int]string{},
+			containers:    map[int]string{},
+		}
+	} else {
+		affected = map[string]bool{}
+	}
+
+	lbIDs, dbcIDs := lbChanges.IDs(), dbcChanges.IDs()
+	forget := func(hostnames map[int]string, ids map[int]bool) {
+		for id := range ids {
+			if hostname, ok := hostnames[id]; ok {
+				affected[hostname] = true
+				delete(hostnames, id)
+			}
+		}
+	}
+	forget(state.loadBalancers, lbIDs)
+	forget(state.containers, dbcIDs)
+
+	for _, lb := range view.SelectFromLoadBalancer(func(lb db.LoadBalancer) bool {
+		return full || lbIDs[lb.ID]
+	}) {
+		state.loadBalancers[lb.ID] = lb.Name
+		if !full {
+			affected[lb.Name] = true
+		}
+	}
+
+	for _, dbc := range view.SelectFromContainer(func(dbc db.Container) bool {
+		return full || dbcIDs[dbc.ID]
+	}) {
+		state.containers[dbc.ID] = dbc.Hostname
+		if !full {
+			affected[dbc.Hostname] = true
+		}
+	}
+	return affected
 }
 
-func joinHostnames(view db.Database) error {
+// joinHostnames updates the hostname table to match the load balancers and
+// containers.  If `affected` is non-nil, only the hostnames in it are updated.
+func joinHostnames(view db.Database, affected map[string]bool) {
+	var lbs []db.LoadBalancer
+	var dbcs []db.Container
+	var current []db.Hostname
+	if affected == nil {
+		lbs = view.SelectFromLoadBalancer(nil)
+		dbcs = view.SelectFromContainer(nil)
+		current = view.SelectFromHostname(nil)
+	} else {
+		lbs = view.SelectFromLoadBalancer(func(lb db.LoadBalancer) bool {
+			return affected[lb.Name]
+		})
+		for hostname := range affected {
+			dbcs = append(dbcs,
+				view.SelectFromContainerByHostname(hostname, nil)...)
+			current = append(current,
+				view.SelectFromHostnameByName(hostname)...)
+		}
+	}
+
 	var target []db.Hostname
-	for _, lb := range view.SelectFromLoadBalancer(nil) {
+	for _, lb := range lbs {
 		if lb.IP != "" {
 			target = append(target, db.Hostname{
 				Hostname: lb.Name,
@@ -65,8 +163,10 @@ func joinHostnames(view db.Database) error {
 			})
 		}
 	}
-	for _, c := range view.SelectFromContainer(nil) {
-		if c.Hostname != "" && c.IP != "" {
+	// Unhealthy containers are left out so that they stop receiving traffic
+	// from both DNS and the load balancers.
+	for _, c := range hostnameContainers(dbcs) {
+		if c.Healthy() {
 			target = append(target, db.Hostname{
 				Hostname: c.Hostname,
 				IP:       c.IP,
@@ -80,7 +180,7 @@ func joinHostnames(view db.Database) error {
 		return h
 	}
 	_, toAdd, toDel := join.HashJoin(db.HostnameSlice(target),
-		db.HostnameSlice(view.SelectFromHostname(nil)), key, key)
+		db.HostnameSlice(current), key, key)
 
 	for _, intf := range toDel {
 		view.Remove(intf.(db.Hostname))
@@ -92,8 +192,31 @@ func joinHostnames(view db.Database) error {
 		tgt.ID = dbHostname.ID
 		view.Commit(tgt)
 	}
+}
+
+// hostnameContainers picks the container that each hostname refers to.  During a
+// rolling update, a container and its replacement briefly share a hostname, in
+// which case the hostname refers to whichever is ready, preferring the older
+// container if both are.
+func hostnameContainers(dbcs []db.Container) []db.Container {
+	byHostname := map[string]db.Container{}
+	for _, dbc := range dbcs {
+		if dbc.Hostname == "" || dbc.IP == "" {
+			continue
+		}
+
+		curr, ok := byHostname[dbc.Hostname]
+		if !ok || (dbc.Ready() && !curr.Ready()) ||
+			(dbc.Ready() == curr.Ready() && dbc.ID < curr.ID) {
+			byHostname[dbc.Hostname] = dbc
+		}
+	}
 
-	return nil
+	var res []db.Container
+	for _, dbc := range byHostname {
+		res = append(res, dbc)
+	}
+	return res
 }
 
 func serveDNSOnce(conn db.Conn) {
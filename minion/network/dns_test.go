@@ -4,6 +4,7 @@ import (
 	"net"
 	"testing"
 
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/db"
 	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
@@ -156,7 +157,7 @@ func TestSyncHostnamesWorker(t *testing.T) {
 		view.Commit(dbl)
 		return nil
 	})
-	syncHostnamesOnce(conn)
+	syncHostnamesOnce(conn, &hostnameSync{}, nil)
 	assert.Empty(t, conn.SelectFromHostname(nil))
 
 	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
@@ -165,11 +166,89 @@ func TestSyncHostnamesWorker(t *testing.T) {
 		view.Commit(etcd)
 		return nil
 	})
-	syncHostnamesOnce(conn)
+	syncHostnamesOnce(conn, &hostnameSync{}, nil)
 	assert.Equal(t, []db.Hostname{{ID: 3, Hostname: "lb", IP: "IP"}},
 		conn.SelectFromHostname(nil))
 }
 
+func TestSyncHostnamesIncremental(t *testing.T) {
+	conn := db.New()
+	trigger := conn.ChangeTrigger(db.LoadBalancerTable, db.ContainerTable)
+	defer trigger.Stop()
+
+	var state hostnameSync
+	sync := func() {
+		syncHostnamesOnce(conn, &state, trigger.Changes())
+	}
+
+	var a, b db.Container
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		etcd := view.InsertEtcd()
+		etcd.Leader = true
+		view.Commit(etcd)
+
+		a = view.InsertContainer()
+		a.Hostname = "a"
+		a.IP = "aIP"
+		view.Commit(a)
+
+		b = view.InsertContainer()
+		b.Hostname = "b"
+		b.IP = "bIP"
+		view.Commit(b)
+		return nil
+	})
+	sync()
+	assertHostnamesEqual(t, []db.Hostname{
+		{Hostname: "a", IP: "aIP"},
+		{Hostname: "b", IP: "bIP"},
+	}, conn.SelectFromHostname(nil))
+
+	// Hostnames of unchanged rows are left alone, even if they're out of sync.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		h := view.SelectFromHostname(func(h db.Hostname) bool {
+			return h.Hostname == "b"
+		})[0]
+		h.IP = "stale"
+		view.Commit(h)
+
+		a.Hostname = "renamed"
+		view.Commit(a)
+
+		lb := view.InsertLoadBalancer()
+		lb.Name = "lb"
+		lb.IP = "lbIP"
+		view.Commit(lb)
+		return nil
+	})
+	sync()
+	assertHostnamesEqual(t, []db.Hostname{
+		{Hostname: "renamed", IP: "aIP"},
+		{Hostname: "b", IP: "stale"},
+		{Hostname: "lb", IP: "lbIP"},
+	}, conn.SelectFromHostname(nil))
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		view.Remove(b)
+		return nil
+	})
+	sync()
+	assertHostnamesEqual(t, []db.Hostname{
+		{Hostname: "renamed", IP: "aIP"},
+		{Hostname: "lb", IP: "lbIP"},
+	}, conn.SelectFromHostname(nil))
+
+	// Losing the leadership forgets the state, so that the next sync is full.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		etcd := view.SelectFromEtcd(nil)[0]
+		etcd.Leader = false
+		view.Commit(etcd)
+		return nil
+	})
+	sync()
+	assert.False(t, state.synced)
+}
+
 type syncHostnameTest struct {
 	loadBalancers              []db.LoadBalancer
 	containers                 []db.Container
@@ -244,6 +323,54 @@ func TestSyncHostnames(t *testing.T) {
 				{Hostname: "container", IP: "containerIP"},
 			},
 		},
+		{
+			containers: []db.Container{
+				{
+					Hostname:    "healthy",
+					IP:          "healthyIP",
+					HealthCheck: &blueprint.HealthCheck{},
+					Health:      db.ContainerHealthy,
+				},
+				{
+					Hostname:    "unhealthy",
+					IP:          "unhealthyIP",
+					HealthCheck: &blueprint.HealthCheck{},
+					Health:      db.ContainerUnhealthy,
+				},
+				{
+					Hostname:    "unknown",
+					IP:          "unknownIP",
+					HealthCheck: &blueprint.HealthCheck{},
+				},
+			},
+			oldHostnames: []db.Hostname{
+				{Hostname: "unhealthy", IP: "unhealthyIP"},
+			},
+			expHostnames: []db.Hostname{
+				{Hostname: "healthy", IP: "healthyIP"},
+			},
+		},
+		{
+			// Containers being replaced by a rolling update share their
+			// hostname with their replacement.
+			containers: []db.Container{
+				{Hostname: "booting", IP: "oldBootingIP",
+					Status: db.ContainerRunning},
+				{Hostname: "booting", IP: "newBootingIP"},
+				{Hostname: "ready", IP: "oldReadyIP"},
+				{Hostname: "ready", IP: "newReadyIP",
+					Status: db.ContainerRunning},
+				{Hostname: "both", IP: "oldBothIP",
+					Status: db.ContainerRunning},
+				{Hostname: "both", IP: "newBothIP",
+					Status: db.ContainerRunning},
+			},
+			expHostnames: []db.Hostname{
+				{Hostname: "booting", IP: "oldBootingIP"},
+				{Hostname: "ready", IP: "newReadyIP"},
+				{Hostname: "both", IP: "oldBothIP"},
+			},
+		},
 	}
 	for _, test := range tests {
 		conn := db.New()
@@ -269,7 +396,7 @@ func TestSyncHostnames(t *testing.T) {
 			}
 			return nil
 		})
-		syncHostnamesOnce(conn)
+		syncHostnamesOnce(conn, &hostnameSync{}, nil)
 		assertHostnamesEqual(t, test.expHostnames, conn.SelectFromHostname(nil))
 	}
 }
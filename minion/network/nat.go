@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/kelda/kelda/blueprint"
@@ -197,7 +198,7 @@ func preroutingRules(publicInterface string, containers []db.Container,
 
 	// Map each hostname to all ports on which it can receive packets
 	// from the public internet.
-	portsFromWeb := make(map[string]map[int]struct{})
+	portsFromWeb := make(map[string]map[publicPort]struct{})
 	for _, conn := range connections {
 		if !str.SliceContains(conn.From, blueprint.PublicInternetLabel) {
 			continue
@@ -205,35 +206,65 @@ func preroutingRules(publicInterface string, containers []db.Container,
 
 		for _, to := range conn.To {
 			if _, ok := portsFromWeb[to]; !ok {
-				portsFromWeb[to] = make(map[int]struct{})
+				portsFromWeb[to] = make(map[publicPort]struct{})
 			}
 
-			portsFromWeb[to][conn.MinPort] = struct{}{}
+			for _, port := range publicPorts(conn) {
+				// ICMP has no ports, so it can't be forwarded to a
+				// container.
+				if port.protocol == blueprint.ICMPProtocol {
+					continue
+				}
+				portsFromWeb[to][port] = struct{}{}
+			}
 		}
 	}
 
-	// Map the container's port to the same port of the host.
+	// Map the host's ports to the container's ports.
 	for _, dbc := range containers {
 		for port := range portsFromWeb[dbc.Hostname] {
-			for _, protocol := range []string{"tcp", "udp"} {
-				rules = append(rules, fmt.Sprintf(
-					"-i %[1]s -p %[2]s -m %[2]s "+
-						"--dport %[3]d -j DNAT "+
-						"--to-destination %[4]s:%[3]d",
-					publicInterface, protocol, port, dbc.IP))
-			}
+			rules = append(rules, dnatRules(publicInterface, dbc.IP, port)...)
 		}
 	}
 
 	return rules
 }
 
+// dnatRules returns the rules that forward public traffic on `port` to the
+// container at `ip`.
+func dnatRules(publicInterface, ip string, port publicPort) (rules []string) {
+	rule := "-i %[1]s -p %[2]s -m %[2]s --dport %[3]s -j DNAT " +
+		"--to-destination %[4]s"
+
+	// DNAT can't shift a range of ports, so remapped ranges are forwarded one
+	// port at a time.  Validation limits remapped ranges to a few ports.
+	// Unshifted ranges, however large, need a single rule that forwards
+	// traffic to the port it arrived on.
+	switch {
+	case port.minPort == port.maxPort:
+		dst := fmt.Sprintf("%s:%d", ip, port.containerPort)
+		rules = append(rules, fmt.Sprintf(rule, publicInterface,
+			port.protocol, portStr(port), dst))
+	case port.containerPort == port.minPort:
+		rules = append(rules, fmt.Sprintf(rule, publicInterface,
+			port.protocol, portStr(port), ip))
+	default:
+		offset := port.containerPort - port.minPort
+		for p := port.minPort; p <= port.maxPort; p++ {
+			dst := fmt.Sprintf("%s:%d", ip, p+offset)
+			rules = append(rules, fmt.Sprintf(rule, publicInterface,
+				port.protocol, strconv.Itoa(p), dst))
+		}
+	}
+	return rules
+}
+
 func postroutingRules(publicInterface string, containers []db.Container,
 	connections []db.Connection) (rules []string) {
 
 	// Map each hostname to all ports on which it can send packets
 	// to the public internet.
-	portsToWeb := make(map[string]map[int]struct{})
+	portsToWeb := make(map[string]map[publicPort]struct{})
 	for _, conn := range connections {
 		for _, to := range conn.To {
 			if to != blueprint.PublicInternetLabel {
@@ -243,29 +274,72 @@ func postroutingRules(publicInterface string, containers []db.Container,
 
 		for _, from := range conn.From {
 			if _, ok := portsToWeb[from]; !ok {
-				portsToWeb[from] = make(map[int]struct{})
+				portsToWeb[from] = make(map[publicPort]struct{})
 			}
 
-			portsToWeb[from][conn.MinPort] = struct{}{}
+			for _, port := range publicPorts(conn) {
+				portsToWeb[from][port] = struct{}{}
+			}
 		}
 	}
 
 	for _, dbc := range containers {
 		for port := range portsToWeb[dbc.Hostname] {
-			for _, protocol := range []string{"tcp", "udp"} {
+			if port.protocol == blueprint.ICMPProtocol {
 				rules = append(rules, fmt.Sprintf(
-					"-s %[1]s/32 -p %[2]s -m %[2]s "+
-						"--dport %[3]d -o %[4]s "+
-						"-j MASQUERADE",
-					dbc.IP, protocol, port, publicInterface,
-				))
+					"-s %s/32 -p icmp -o %s -j MASQUERADE",
+					dbc.IP, publicInterface))
+				continue
 			}
+
+			rules = append(rules, fmt.Sprintf(
+				"-s %[1]s/32 -p %[2]s -m %[2]s "+
+					"--dport %[3]s -o %[4]s "+
+					"-j MASQUERADE",
+				dbc.IP, port.protocol, portStr(port), publicInterface,
+			))
 		}
 	}
 
 	return rules
 }
 
+type publicPort struct {
+	minPort  int
+	maxPort  int
+	protocol string
+
+	// The container port that traffic to minPort is forwarded to.
+	containerPort int
+}
+
+// publicPorts returns the ports and protocols that `conn` allows traffic to or
+// from the public internet on.
+func publicPorts(conn db.Connection) []publicPort {
+	containerPort, _ := conn.ContainerPorts()
+	port := func(protocol string) publicPort {
+		return publicPort{conn.MinPort, conn.MaxPort, protocol, containerPort}
+	}
+
+	var ports []publicPort
+	for _, protocol := range conn.Protocols() {
+		if protocol == blueprint.ICMPProtocol {
+			ports = append(ports, publicPort{protocol: protocol})
+		} else {
+			ports = append(ports, port(protocol))
+		}
+	}
+	return ports
+}
+
+// portStr formats the public ports of `port` for iptables.
+func portStr(port publicPort) string {
+	if port.minPort == port.maxPort {
+		return strconv.Itoa(port.minPort)
+	}
+	return fmt.Sprintf("%d:%d", port.minPort, port.maxPort)
+}
+
 type rule struct {
 	table  string
 	chain  string
@@ -69,24 +69,72 @@ func TestPreroutingRules(t *testing.T) {
 			From:    []string{blueprint.PublicInternetLabel},
 			To:      []string{"red"},
 			MinPort: 80,
+			MaxPort: 80,
 		},
 		{
 			From:    []string{blueprint.PublicInternetLabel},
 			To:      []string{"purple"},
 			MinPort: 81,
+			MaxPort: 81,
 		},
 		{
 			From:    []string{"yellow"},
 			To:      []string{blueprint.PublicInternetLabel},
 			MinPort: 80,
+			MaxPort: 80,
+		},
+		{
+			From:     []string{blueprint.PublicInternetLabel},
+			To:       []string{"red"},
+			MinPort:  53,
+			MaxPort:  53,
+			Protocol: blueprint.UDPProtocol,
+		},
+		{
+			From:     []string{blueprint.PublicInternetLabel},
+			To:       []string{"red"},
+			Protocol: blueprint.ICMPProtocol,
+		},
+		{
+			From:     []string{blueprint.PublicInternetLabel},
+			To:       []string{"purple"},
+			MinPort:  1000,
+			MaxPort:  1010,
+			Protocol: blueprint.TCPProtocol,
+		},
+		{
+			From:          []string{blueprint.PublicInternetLabel},
+			To:            []string{"purple"},
+			MinPort:       443,
+			MaxPort:       443,
+			ContainerPort: 8443,
+			Protocol:      blueprint.TCPProtocol,
+		},
+		{
+			From:          []string{blueprint.PublicInternetLabel},
+			To:            []string{"purple"},
+			MinPort:       2000,
+			MaxPort:       2001,
+			ContainerPort: 3000,
+			Protocol:      blueprint.UDPProtocol,
 		},
 	}
 
 	actual := preroutingRules("eth0", containers, connections)
+	sort.Strings(actual)
 	exp := []string{
+		"-i eth0 -p tcp -m tcp --dport 1000:1010 -j DNAT " +
+			"--to-destination 9.9.9.9",
+		"-i eth0 -p tcp -m tcp --dport 443 -j DNAT " +
+			"--to-destination 9.9.9.9:8443",
 		"-i eth0 -p tcp -m tcp --dport 80 -j DNAT --to-destination 8.8.8.8:80",
-		"-i eth0 -p udp -m udp --dport 80 -j DNAT --to-destination 8.8.8.8:80",
 		"-i eth0 -p tcp -m tcp --dport 81 -j DNAT --to-destination 9.9.9.9:81",
+		"-i eth0 -p udp -m udp --dport 2000 -j DNAT " +
+			"--to-destination 9.9.9.9:3000",
+		"-i eth0 -p udp -m udp --dport 2001 -j DNAT " +
+			"--to-destination 9.9.9.9:3001",
+		"-i eth0 -p udp -m udp --dport 53 -j DNAT --to-destination 8.8.8.8:53",
+		"-i eth0 -p udp -m udp --dport 80 -j DNAT --to-destination 8.8.8.8:80",
 		"-i eth0 -p udp -m udp --dport 81 -j DNAT --to-destination 9.9.9.9:81",
 	}
 	assert.Equal(t, exp, actual)
@@ -111,18 +159,43 @@ func TestPostroutingRules(t *testing.T) {
 			From:    []string{"red"},
 			To:      []string{blueprint.PublicInternetLabel},
 			MinPort: 80,
+			MaxPort: 80,
 		},
 		{
 			From:    []string{"purple"},
 			To:      []string{blueprint.PublicInternetLabel},
 			MinPort: 81,
+			MaxPort: 81,
+		},
+		{
+			From:     []string{"red"},
+			To:       []string{blueprint.PublicInternetLabel},
+			MinPort:  443,
+			MaxPort:  443,
+			Protocol: blueprint.TCPProtocol,
+		},
+		{
+			From:     []string{"red"},
+			To:       []string{blueprint.PublicInternetLabel},
+			Protocol: blueprint.ICMPProtocol,
+		},
+		{
+			From:     []string{"purple"},
+			To:       []string{blueprint.PublicInternetLabel},
+			MinPort:  6000,
+			MaxPort:  6010,
+			Protocol: blueprint.UDPProtocol,
 		},
 	}
 
 	exp := []string{
+		"-s 8.8.8.8/32 -p icmp -o eth0 -j MASQUERADE",
+		"-s 8.8.8.8/32 -p tcp -m tcp --dport 443 -o eth0 -j MASQUERADE",
 		"-s 8.8.8.8/32 -p tcp -m tcp --dport 80 -o eth0 -j MASQUERADE",
 		"-s 8.8.8.8/32 -p udp -m udp --dport 80 -o eth0 -j MASQUERADE",
+		"-s 9.9.9.9/32 -p icmp -o eth0 -j MASQUERADE",
 		"-s 9.9.9.9/32 -p tcp -m tcp --dport 81 -o eth0 -j MASQUERADE",
+		"-s 9.9.9.9/32 -p udp -m udp --dport 6000:6010 -o eth0 -j MASQUERADE",
 		"-s 9.9.9.9/32 -p udp -m udp --dport 81 -o eth0 -j MASQUERADE",
 	}
 	actual := postroutingRules("eth0", containers, connections)
@@ -11,6 +11,7 @@ import (
 	"github.com/kelda/kelda/join"
 	"github.com/kelda/kelda/minion/ipdef"
 	"github.com/kelda/kelda/minion/ovsdb"
+	"github.com/kelda/kelda/util/str"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -30,10 +31,15 @@ func Run(conn db.Conn, inboundPubIntf, outboundPubIntf string) {
 	go runDNS(conn)
 	go runUpdateIPs(conn)
 
-	for range conn.TriggerTick(30, db.ContainerTable, db.HostnameTable,
-		db.ConnectionTable, db.LoadBalancerTable, db.EtcdTable).C {
+	var acls aclSync
+	trigger := conn.ChangeTriggerTick(30, db.ContainerTable, db.HostnameTable,
+		db.ConnectionTable, db.LoadBalancerTable, db.EtcdTable)
+	for range trigger.C {
+		changes := trigger.Changes()
 		if conn.EtcdLeader() {
-			runMaster(conn)
+			runMaster(conn, &acls, changes[db.ConnectionTable])
+		} else {
+			acls = aclSync{}
 		}
 	}
 }
@@ -43,7 +49,7 @@ func Run(conn db.Conn, inboundPubIntf, outboundPubIntf string) {
 // port for each container, creating ACLs, creating the load balancer router,
 // and creating load balancers.  The specialized OpenFlow rules Kelda requires
 // are managed by the workers individuallly.
-func runMaster(conn db.Conn) {
+func runMaster(conn db.Conn, acls *aclSync, connChanges db.ChangeSet) {
 	c.Inc("Run Master")
 
 	var loadBalancers []db.LoadBalancer
@@ -70,6 +76,9 @@ func runMaster(conn db.Conn) {
 	ovsdbClient, err := ovsdb.Open()
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to OVSDB.")
+
+		// The connection changes weren't applied, so resync them in full.
+		*acls = aclSync{}
 		return
 	}
 	defer ovsdbClient.Disconnect()
@@ -77,7 +86,33 @@ func runMaster(conn db.Conn) {
 	updateLogicalSwitch(ovsdbClient, containers)
 	updateLoadBalancerRouter(ovsdbClient)
 	updateLoadBalancers(ovsdbClient, loadBalancers, hostnameToIP)
-	updateACLs(ovsdbClient, connections, hostnameToIP)
+	updateACLs(ovsdbClient, acls, connections, connChanges,
+		aclHostnames(hostnameToIP, containers))
+}
+
+// aclHostnames maps each hostname to the IPs of all the containers that use it.
+// Unlike the hostname table, this includes unhealthy containers, which
+// shouldn't receive load balanced traffic but must keep their connections in
+// order to recover, as well as both a container and its replacement during a
+// rolling update.
+func aclHostnames(hostnameToIP map[string]string,
+	containers []db.Container) map[string][]string {
+
+	res := map[string][]string{}
+	for hostname, ip := range hostnameToIP {
+		res[hostname] = []string{ip}
+	}
+
+	for _, dbc := range containers {
+		if dbc.Hostname == "" {
+			continue
+		}
+
+		if !str.SliceContains(res[dbc.Hostname], dbc.IP) {
+			res[dbc.Hostname] = append(res[dbc.Hostname], dbc.IP)
+		}
+	}
+	return res
 }
 
 func updateLogicalSwitch(ovsdbClient ovsdb.Client, containers []db.Container) {
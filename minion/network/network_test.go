@@ -125,3 +125,21 @@ func TestUpdateLoadBalancerRouter(t *testing.T) {
 	updateLoadBalancerRouter(client)
 	client.AssertExpectations(t)
 }
+
+func TestACLHostnames(t *testing.T) {
+	hostnameToIP := map[string]string{"lb": "lbIP", "healthy": "healthyIP"}
+	containers := []db.Container{
+		{Hostname: "healthy", IP: "healthyIP"},
+		{Hostname: "unhealthy", IP: "unhealthyIP"},
+		{Hostname: "replaced", IP: "oldIP"},
+		{Hostname: "replaced", IP: "newIP"},
+	}
+
+	assert.Equal(t, map[string][]string{
+		"lb":        {"lbIP"},
+		"healthy":   {"healthyIP"},
+		"unhealthy": {"unhealthyIP"},
+		"replaced":  {"oldIP", "newIP"},
+	}, aclHostnames(hostnameToIP, containers))
+	assert.Len(t, hostnameToIP, 2)
+}
@@ -3,8 +3,11 @@ package openflow
 import (
 	"fmt"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/counter"
 	"github.com/kelda/kelda/minion/ipdef"
 	"github.com/kelda/kelda/minion/ovsdb"
@@ -97,15 +100,21 @@ Table_2 {
 
 		for each toPub {
 			// Response packets have toPub as the source port.
-			[tcp|udp],dl_dst=dbc.mac,ip_dst=dbc.ip,tp_src=toPub,
+			toPub.protocol,dl_dst=dbc.mac,ip_dst=dbc.ip,tp_src=toPub,
 				actions=output:veth
 		}
 
 		for each fromPub {
 			// Inbound packets have toPub as the destination port.
-			[tcp|udp],dl_dst=dbc.mac,ip_dst=dbc.ip,tp_dst=fromPub,
+			fromPub.protocol,dl_dst=dbc.mac,ip_dst=dbc.ip,tp_dst=fromPub,
 				actions=output:veth
 		}
+
+		// ICMP has no ports, so it's allowed in both directions if
+		// either toPub or fromPub allows it.
+		if icmp allowed {
+			icmp,dl_dst=dbc.mac,ip_dst=dbc.ip,actions=output:veth
+		}
         }
 }
 
@@ -126,15 +135,19 @@ Table_3 {
 	for each db.Container {
 		for each toPub {
 			// Outbound packets have fromPub as the destination port.
-			[tcp|udp],dl_src=dbc.mac,ip_src=dbc.ip,tp_dst=toPub,
+			toPub.protocol,dl_src=dbc.mac,ip_src=dbc.ip,tp_dst=toPub,
 				actions=output:LOCAL
 		}
 
 		for each fromPub {
 			// Response packets have fromPub as the source port.
-			[tcp|udp],dl_src=dbc.mac,ip_src=dbc.ip,tp_src=fromPub,
+			fromPub.protocol,dl_src=dbc.mac,ip_src=dbc.ip,tp_src=fromPub,
 				actions=output:LOCAL
 		}
+
+		if icmp allowed {
+			icmp,dl_src=dbc.mac,ip_src=dbc.ip,actions=output:LOCAL
+		}
 	}
 }
 
@@ -147,9 +160,16 @@ type Container struct {
 	Mac   string
 	IP    string
 
-	// Set of ports going to and from the public internet.
-	ToPub   map[int]struct{}
-	FromPub map[int]struct{}
+	// Set of port ranges going to and from the public internet.
+	ToPub   map[PortRange]struct{}
+	FromPub map[PortRange]struct{}
+}
+
+// A PortRange is an inclusive range of ports of a single IP protocol.  ICMP has
+// no ports, so its ranges leave Min and Max unset.
+type PortRange struct {
+	Min, Max int
+	Protocol string
 }
 
 type container struct {
@@ -244,35 +264,101 @@ func containerFlows(c container) []string {
 			"action=output:%d", c.Mac, ipdef.GatewayIP, c.vethPort),
 	}
 
-	table2 := "table=2,priority=500,%s,dl_dst=%s,ip_dst=%s,tp_src=%d," +
+	var icmp bool
+	table2 := "table=2,priority=500,%s,dl_dst=%s,ip_dst=%s,tp_src=%s," +
 		"actions=output:%d"
-	table3 := "table=3,priority=500,%s,dl_src=%s,ip_src=%s,tp_dst=%d," +
+	table3 := "table=3,priority=500,%s,dl_src=%s,ip_src=%s,tp_dst=%s," +
 		"actions=output:LOCAL"
-	for to := range c.Container.ToPub {
-		flows = append(flows,
-			fmt.Sprintf(table2, "tcp", c.Mac, c.IP, to, c.vethPort),
-			fmt.Sprintf(table2, "udp", c.Mac, c.IP, to, c.vethPort),
+	for _, toRange := range sortedRanges(c.Container.ToPub) {
+		if toRange.Protocol == blueprint.ICMPProtocol {
+			icmp = true
+			continue
+		}
 
-			fmt.Sprintf(table3, "tcp", c.Mac, c.IP, to),
-			fmt.Sprintf(table3, "udp", c.Mac, c.IP, to))
+		proto := toRange.Protocol
+		for _, to := range portMatches(toRange) {
+			flows = append(flows,
+				fmt.Sprintf(table2, proto, c.Mac, c.IP, to, c.vethPort),
+				fmt.Sprintf(table3, proto, c.Mac, c.IP, to))
+		}
 	}
 
-	table2 = "table=2,priority=500,%s,dl_dst=%s,ip_dst=%s,tp_dst=%d," +
+	table2 = "table=2,priority=500,%s,dl_dst=%s,ip_dst=%s,tp_dst=%s," +
 		"actions=output:%d"
-	table3 = "table=3,priority=500,%s,dl_src=%s,ip_src=%s,tp_src=%d," +
+	table3 = "table=3,priority=500,%s,dl_src=%s,ip_src=%s,tp_src=%s," +
 		"actions=output:LOCAL"
-	for from := range c.Container.FromPub {
-		flows = append(flows,
-			fmt.Sprintf(table2, "tcp", c.Mac, c.IP, from, c.vethPort),
-			fmt.Sprintf(table2, "udp", c.Mac, c.IP, from, c.vethPort),
+	for _, fromRange := range sortedRanges(c.Container.FromPub) {
+		if fromRange.Protocol == blueprint.ICMPProtocol {
+			icmp = true
+			continue
+		}
 
-			fmt.Sprintf(table3, "tcp", c.Mac, c.IP, from),
-			fmt.Sprintf(table3, "udp", c.Mac, c.IP, from))
+		proto := fromRange.Protocol
+		for _, from := range portMatches(fromRange) {
+			flows = append(flows,
+				fmt.Sprintf(table2, proto, c.Mac, c.IP, from, c.vethPort),
+				fmt.Sprintf(table3, proto, c.Mac, c.IP, from))
+		}
+	}
+
+	// ICMP messages have no ports, and their replies look like requests, so
+	// ICMP is allowed in both directions whenever either direction allows it.
+	if icmp {
+		flows = append(flows,
+			fmt.Sprintf("table=2,priority=500,icmp,dl_dst=%s,ip_dst=%s,"+
+				"actions=output:%d", c.Mac, c.IP, c.vethPort),
+			fmt.Sprintf("table=3,priority=500,icmp,dl_src=%s,ip_src=%s,"+
+				"actions=output:LOCAL", c.Mac, c.IP))
 	}
 
 	return flows
 }
 
+// sortedRanges returns the ranges in `set` in a deterministic order, so that
+// the flows don't change each time they're generated.
+func sortedRanges(set map[PortRange]struct{}) []PortRange {
+	var ranges []PortRange
+	for r := range set {
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].Protocol != ranges[j].Protocol {
+			return ranges[i].Protocol < ranges[j].Protocol
+		}
+		if ranges[i].Min != ranges[j].Min {
+			return ranges[i].Min < ranges[j].Min
+		}
+		return ranges[i].Max < ranges[j].Max
+	})
+	return ranges
+}
+
+// portMatches returns the tp_src or tp_dst values that together match the ports
+// in `r`.  Each is either a single port, or a port and mask that match an
+// aligned block of a power of two ports, so a range needs at most two matches
+// per bit of the port number rather than one per port.
+func portMatches(r PortRange) []string {
+	var matches []string
+	for port := r.Min; port <= r.Max; {
+		// The largest aligned block that starts at `port` and ends within
+		// the range.
+		size := 1
+		for port%(2*size) == 0 && port+2*size-1 <= r.Max {
+			size *= 2
+		}
+
+		if size == 1 {
+			matches = append(matches, strconv.Itoa(port))
+		} else {
+			matches = append(matches, fmt.Sprintf("0x%x/0x%x", port,
+				0xffff&^(size-1)))
+		}
+		port += size
+	}
+	return matches
+}
+
 func allFlows(containers []container) []string {
 	var gatewayBroadcastActions []string
 	for _, c := range containers {
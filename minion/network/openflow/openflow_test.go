@@ -73,16 +73,18 @@ func TestAllFlows(t *testing.T) {
 		patchPort: 4,
 		vethPort:  5,
 		Container: Container{
-			IP:    "6.7.8.9",
-			Mac:   "66:66:66:66:66:66",
-			ToPub: map[int]struct{}{5: {}}},
+			IP:  "6.7.8.9",
+			Mac: "66:66:66:66:66:66",
+			ToPub: map[PortRange]struct{}{
+				{Min: 5, Max: 5, Protocol: "tcp"}: {}}},
 	}, {
 		patchPort: 9,
 		vethPort:  8,
 		Container: Container{
-			IP:      "9.8.7.6",
-			Mac:     "99:99:99:99:99:99",
-			FromPub: map[int]struct{}{8: {}}}}})
+			IP:  "9.8.7.6",
+			Mac: "99:99:99:99:99:99",
+			FromPub: map[PortRange]struct{}{
+				{Min: 8, Max: 8, Protocol: "udp"}: {}}}}})
 	exp := append(staticFlows,
 		"table=0,in_port=5,dl_src=66:66:66:66:66:66,"+
 			"actions=load:0x4->NXM_NX_REG0[],resubmit(,1)",
@@ -92,29 +94,93 @@ func TestAllFlows(t *testing.T) {
 			"action=output:5",
 		"table=2,priority=500,tcp,dl_dst=66:66:66:66:66:66,ip_dst=6.7.8.9,"+
 			"tp_src=5,actions=output:5",
-		"table=2,priority=500,udp,dl_dst=66:66:66:66:66:66,ip_dst=6.7.8.9,"+
-			"tp_src=5,actions=output:5",
 		"table=3,priority=500,tcp,dl_src=66:66:66:66:66:66,ip_src=6.7.8.9,"+
 			"tp_dst=5,actions=output:LOCAL",
-		"table=3,priority=500,udp,dl_src=66:66:66:66:66:66,ip_src=6.7.8.9,"+
-			"tp_dst=5,actions=output:LOCAL",
 		"table=0,in_port=8,dl_src=99:99:99:99:99:99,"+
 			"actions=load:0x9->NXM_NX_REG0[],resubmit(,1)",
 		"table=0,in_port=9,actions=output:8",
 		"table=2,priority=900,arp,dl_dst=99:99:99:99:99:99,action=output:8",
 		"table=2,priority=800,ip,dl_dst=99:99:99:99:99:99,nw_src=10.0.0.1,"+
 			"action=output:8",
-		"table=2,priority=500,tcp,dl_dst=99:99:99:99:99:99,ip_dst=9.8.7.6,"+
-			"tp_dst=8,actions=output:8",
 		"table=2,priority=500,udp,dl_dst=99:99:99:99:99:99,ip_dst=9.8.7.6,"+
 			"tp_dst=8,actions=output:8",
-		"table=3,priority=500,tcp,dl_src=99:99:99:99:99:99,ip_src=9.8.7.6,"+
-			"tp_src=8,actions=output:LOCAL",
 		"table=3,priority=500,udp,dl_src=99:99:99:99:99:99,ip_src=9.8.7.6,"+
 			"tp_src=8,actions=output:LOCAL",
 		"table=2,priority=1000,dl_dst=ff:ff:ff:ff:ff:ff,"+
 			"actions=output:5,output:8")
 	assert.Equal(t, exp, flows)
+
+	// ICMP has no ports, so it's allowed in both directions.
+	flows = allFlows([]container{{
+		patchPort: 4,
+		vethPort:  5,
+		Container: Container{
+			IP:  "6.7.8.9",
+			Mac: "66:66:66:66:66:66",
+			ToPub: map[PortRange]struct{}{
+				{Protocol: "icmp"}: {}}}}})
+	exp = append(staticFlows,
+		"table=0,in_port=5,dl_src=66:66:66:66:66:66,"+
+			"actions=load:0x4->NXM_NX_REG0[],resubmit(,1)",
+		"table=0,in_port=4,actions=output:5",
+		"table=2,priority=900,arp,dl_dst=66:66:66:66:66:66,action=output:5",
+		"table=2,priority=800,ip,dl_dst=66:66:66:66:66:66,nw_src=10.0.0.1,"+
+			"action=output:5",
+		"table=2,priority=500,icmp,dl_dst=66:66:66:66:66:66,ip_dst=6.7.8.9,"+
+			"actions=output:5",
+		"table=3,priority=500,icmp,dl_src=66:66:66:66:66:66,ip_src=6.7.8.9,"+
+			"actions=output:LOCAL",
+		"table=2,priority=1000,dl_dst=ff:ff:ff:ff:ff:ff,actions=output:5")
+	assert.Equal(t, exp, flows)
+
+	// A connection without a protocol allows TCP, UDP, and ICMP.
+	flows = allFlows([]container{{
+		patchPort: 9,
+		vethPort:  8,
+		Container: Container{
+			IP:  "9.8.7.6",
+			Mac: "99:99:99:99:99:99",
+			FromPub: map[PortRange]struct{}{
+				{Min: 8, Max: 8, Protocol: "tcp"}: {},
+				{Min: 8, Max: 8, Protocol: "udp"}: {},
+				{Protocol: "icmp"}:                {}}}}})
+	exp = append(staticFlows,
+		"table=0,in_port=8,dl_src=99:99:99:99:99:99,"+
+			"actions=load:0x9->NXM_NX_REG0[],resubmit(,1)",
+		"table=0,in_port=9,actions=output:8",
+		"table=2,priority=900,arp,dl_dst=99:99:99:99:99:99,action=output:8",
+		"table=2,priority=800,ip,dl_dst=99:99:99:99:99:99,nw_src=10.0.0.1,"+
+			"action=output:8",
+		"table=2,priority=500,tcp,dl_dst=99:99:99:99:99:99,ip_dst=9.8.7.6,"+
+			"tp_dst=8,actions=output:8",
+		"table=3,priority=500,tcp,dl_src=99:99:99:99:99:99,ip_src=9.8.7.6,"+
+			"tp_src=8,actions=output:LOCAL",
+		"table=2,priority=500,udp,dl_dst=99:99:99:99:99:99,ip_dst=9.8.7.6,"+
+			"tp_dst=8,actions=output:8",
+		"table=3,priority=500,udp,dl_src=99:99:99:99:99:99,ip_src=9.8.7.6,"+
+			"tp_src=8,actions=output:LOCAL",
+		"table=2,priority=500,icmp,dl_dst=99:99:99:99:99:99,ip_dst=9.8.7.6,"+
+			"actions=output:8",
+		"table=3,priority=500,icmp,dl_src=99:99:99:99:99:99,ip_src=9.8.7.6,"+
+			"actions=output:LOCAL",
+		"table=2,priority=1000,dl_dst=ff:ff:ff:ff:ff:ff,actions=output:8")
+	assert.Equal(t, exp, flows)
+}
+
+func TestPortMatches(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"80"}, portMatches(PortRange{Min: 80, Max: 80}))
+	assert.Equal(t, []string{"0x50/0xfffe"},
+		portMatches(PortRange{Min: 80, Max: 81}))
+
+	// 1000-2000 is split into aligned blocks.
+	assert.Equal(t, []string{"0x3e8/0xfff8", "0x3f0/0xfff0", "0x400/0xfe00",
+		"0x600/0xff00", "0x700/0xff80", "0x780/0xffc0", "0x7c0/0xfff0",
+		"2000"}, portMatches(PortRange{Min: 1000, Max: 2000}))
+
+	assert.Equal(t, []string{"0x0/0x0"},
+		portMatches(PortRange{Min: 0, Max: 65535}))
 }
 
 func TestResolveContainers(t *testing.T) {
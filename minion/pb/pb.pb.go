@@ -71,6 +71,7 @@ type MinionConfig struct {
 	EtcdMembers         []string          `protobuf:"bytes,10,rep,name=EtcdMembers" json:"EtcdMembers,omitempty"`
 	AuthorizedKeys      []string          `protobuf:"bytes,11,rep,name=AuthorizedKeys" json:"AuthorizedKeys,omitempty"`
 	MinionIPToPublicKey map[string]string `protobuf:"bytes,12,rep,name=MinionIPToPublicKey" json:"MinionIPToPublicKey,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Volumes             map[string]string `protobuf:"bytes,13,rep,name=Volumes" json:"Volumes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *MinionConfig) Reset()                    { *m = MinionConfig{} }
@@ -162,6 +163,13 @@ func (m *MinionConfig) GetMinionIPToPublicKey() map[string]string {
 	return nil
 }
 
+func (m *MinionConfig) GetVolumes() map[string]string {
+	if m != nil {
+		return m.Volumes
+	}
+	return nil
+}
+
 type Reply struct {
 }
 
@@ -293,31 +301,33 @@ var _Minion_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("minion/pb/pb.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 410 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0x51, 0x6b, 0xdb, 0x30,
-	0x10, 0x80, 0x63, 0xc7, 0x71, 0xe2, 0x4b, 0x97, 0x86, 0xdb, 0x18, 0x22, 0x8c, 0x61, 0xfc, 0x10,
-	0xcc, 0x18, 0x2e, 0x64, 0x2f, 0x63, 0x6f, 0xdd, 0xea, 0x0e, 0x13, 0xd2, 0x1a, 0xa5, 0xb0, 0xbd,
-	0xc6, 0xcd, 0x2d, 0x13, 0x73, 0x2d, 0x4f, 0x91, 0x03, 0xee, 0x6f, 0xd9, 0x8f, 0x1d, 0x91, 0x4d,
-	0x97, 0x94, 0xec, 0xed, 0xee, 0xbb, 0xfb, 0x84, 0xa4, 0x3b, 0xc0, 0x07, 0x51, 0x08, 0x59, 0x5c,
-	0x94, 0xd9, 0x45, 0x99, 0x45, 0xa5, 0x92, 0x5a, 0x06, 0x7f, 0x1c, 0x38, 0x5b, 0x18, 0xfc, 0x45,
-	0x16, 0x3f, 0xc4, 0x06, 0x47, 0x60, 0x27, 0x57, 0xcc, 0xf2, 0xad, 0xd0, 0xe3, 0x76, 0x72, 0x85,
-	0x53, 0x70, 0x94, 0xcc, 0x89, 0xd9, 0xbe, 0x15, 0x8e, 0x66, 0x18, 0x1d, 0x36, 0x47, 0x5c, 0xe6,
-	0xc4, 0x4d, 0x1d, 0xdf, 0x80, 0x97, 0x2a, 0xb1, 0x5b, 0x69, 0x4a, 0x52, 0xd6, 0x35, 0xfa, 0x3f,
-	0x80, 0x13, 0x18, 0xa4, 0x55, 0x96, 0x8b, 0xfb, 0x24, 0x65, 0x8e, 0x29, 0x3e, 0xe5, 0x7b, 0xf3,
-	0x73, 0x5e, 0x51, 0xa9, 0x44, 0xa1, 0x59, 0xaf, 0x31, 0x9f, 0x80, 0x31, 0x95, 0xdc, 0x89, 0x35,
-	0x29, 0xe6, 0xb6, 0x66, 0x9b, 0x23, 0x82, 0xb3, 0x14, 0x8f, 0xc4, 0xfa, 0x86, 0x9b, 0x18, 0x5f,
-	0x83, 0xcb, 0x69, 0x23, 0x64, 0xc1, 0x06, 0x86, 0xb6, 0x19, 0xbe, 0x05, 0xb8, 0xce, 0xe5, 0x4a,
-	0x8b, 0x62, 0x93, 0xa4, 0xcc, 0x33, 0xb5, 0x03, 0x82, 0x3e, 0x0c, 0x63, 0x7d, 0xbf, 0x5e, 0xd0,
-	0x43, 0x46, 0x6a, 0xcb, 0xc0, 0xef, 0x86, 0x1e, 0x3f, 0x44, 0x38, 0x85, 0xd1, 0x65, 0xa5, 0x7f,
-	0x4a, 0x25, 0x1e, 0x69, 0x3d, 0xa7, 0x7a, 0xcb, 0x86, 0xa6, 0xe9, 0x19, 0xc5, 0xef, 0xf0, 0xb2,
-	0xf9, 0xa4, 0x24, 0xbd, 0x93, 0xcd, 0x2b, 0xe7, 0x54, 0xb3, 0x33, 0xbf, 0x1b, 0x0e, 0x67, 0xd3,
-	0xe3, 0x0f, 0x3c, 0xd1, 0x18, 0x17, 0x5a, 0xd5, 0xfc, 0xd4, 0x11, 0x93, 0x6b, 0x60, 0xff, 0x13,
-	0x70, 0x0c, 0xdd, 0x5f, 0x54, 0xb7, 0x83, 0xdb, 0x87, 0xf8, 0x0a, 0x7a, 0xbb, 0x55, 0x5e, 0x35,
-	0xa3, 0xf3, 0x78, 0x93, 0x7c, 0xb2, 0x3f, 0x5a, 0x41, 0x08, 0xce, 0x7e, 0x72, 0x38, 0x00, 0xe7,
-	0xe6, 0xf6, 0x26, 0x1e, 0x77, 0x10, 0xc0, 0xfd, 0x76, 0xcb, 0xe7, 0x31, 0x1f, 0x5b, 0xfb, 0x78,
-	0x71, 0xb9, 0xbc, 0x8b, 0xf9, 0xd8, 0x0e, 0xfa, 0xd0, 0xe3, 0x54, 0xe6, 0x75, 0xe0, 0x41, 0x9f,
-	0xd3, 0xef, 0x8a, 0xb6, 0x7a, 0x96, 0x81, 0xdb, 0xdc, 0x02, 0xdf, 0xc1, 0xf9, 0x92, 0xf4, 0xd1,
-	0xfa, 0xbc, 0x38, 0x7a, 0xdf, 0xc4, 0x8d, 0x1a, 0xbd, 0x83, 0xef, 0xe1, 0xfc, 0xeb, 0xb3, 0xde,
-	0x41, 0xd4, 0x1e, 0x39, 0x39, 0xb6, 0x82, 0x4e, 0xe6, 0x9a, 0xed, 0xfc, 0xf0, 0x37, 0x00, 0x00,
-	0xff, 0xff, 0xdc, 0x84, 0xc2, 0xfb, 0xb3, 0x02, 0x00, 0x00,
+	// 437 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0x5f, 0x8b, 0xd3, 0x40,
+	0x10, 0x6f, 0xd2, 0x34, 0x6d, 0xa6, 0xbd, 0x5e, 0x19, 0x45, 0x96, 0x20, 0x12, 0xf2, 0x50, 0x82,
+	0x48, 0x0e, 0xaa, 0x0f, 0x72, 0x6f, 0xa7, 0x97, 0x93, 0x50, 0x7a, 0x17, 0xb6, 0x87, 0xfa, 0xda,
+	0x5c, 0xc7, 0xba, 0x98, 0x66, 0x63, 0x9a, 0x14, 0x72, 0x1f, 0xc2, 0xcf, 0x2c, 0xdd, 0xc4, 0xb3,
+	0x39, 0xce, 0x07, 0xdf, 0x66, 0x7e, 0xff, 0x96, 0x9d, 0x9d, 0x05, 0xdc, 0x8a, 0x54, 0xc8, 0xf4,
+	0x2c, 0x8b, 0xcf, 0xb2, 0xd8, 0xcf, 0x72, 0x59, 0x48, 0xf7, 0x57, 0x0f, 0x46, 0x0b, 0x05, 0x7f,
+	0x94, 0xe9, 0x37, 0xb1, 0xc1, 0x31, 0xe8, 0xe1, 0x25, 0xd3, 0x1c, 0xcd, 0xb3, 0xb8, 0x1e, 0x5e,
+	0xe2, 0x14, 0x8c, 0x5c, 0x26, 0xc4, 0x74, 0x47, 0xf3, 0xc6, 0x33, 0xf4, 0x8f, 0xc5, 0x3e, 0x97,
+	0x09, 0x71, 0xc5, 0xe3, 0x4b, 0xb0, 0xa2, 0x5c, 0xec, 0x57, 0x05, 0x85, 0x11, 0xeb, 0x2a, 0xfb,
+	0x5f, 0x00, 0x6d, 0x18, 0x44, 0x65, 0x9c, 0x88, 0xbb, 0x30, 0x62, 0x86, 0x22, 0x1f, 0xfa, 0x83,
+	0xf3, 0x43, 0x52, 0x52, 0x96, 0x8b, 0xb4, 0x60, 0xbd, 0xda, 0xf9, 0x00, 0x28, 0x67, 0x2e, 0xf7,
+	0x62, 0x4d, 0x39, 0x33, 0x1b, 0x67, 0xd3, 0x23, 0x82, 0xb1, 0x14, 0xf7, 0xc4, 0xfa, 0x0a, 0x57,
+	0x35, 0xbe, 0x00, 0x93, 0xd3, 0x46, 0xc8, 0x94, 0x0d, 0x14, 0xda, 0x74, 0xf8, 0x0a, 0xe0, 0x2a,
+	0x91, 0xab, 0x42, 0xa4, 0x9b, 0x30, 0x62, 0x96, 0xe2, 0x8e, 0x10, 0x74, 0x60, 0x18, 0x14, 0x77,
+	0xeb, 0x05, 0x6d, 0x63, 0xca, 0x77, 0x0c, 0x9c, 0xae, 0x67, 0xf1, 0x63, 0x08, 0xa7, 0x30, 0xbe,
+	0x28, 0x8b, 0xef, 0x32, 0x17, 0xf7, 0xb4, 0x9e, 0x53, 0xb5, 0x63, 0x43, 0x25, 0x7a, 0x84, 0xe2,
+	0x57, 0x78, 0x56, 0x0f, 0x29, 0x8c, 0x6e, 0x65, 0x7d, 0xcb, 0x39, 0x55, 0x6c, 0xe4, 0x74, 0xbd,
+	0xe1, 0x6c, 0xda, 0x1e, 0xe0, 0x13, 0xc2, 0x20, 0x2d, 0xf2, 0x8a, 0x3f, 0x15, 0x81, 0xef, 0xa0,
+	0xff, 0x59, 0x26, 0xe5, 0x96, 0x76, 0xec, 0x44, 0xa5, 0xd9, 0xed, 0xb4, 0x86, 0xac, 0x13, 0xfe,
+	0x48, 0xed, 0x2b, 0x60, 0xff, 0x3a, 0x06, 0x27, 0xd0, 0xfd, 0x41, 0x55, 0xf3, 0xdc, 0x87, 0x12,
+	0x9f, 0x43, 0x6f, 0xbf, 0x4a, 0xca, 0xfa, 0xc1, 0x2d, 0x5e, 0x37, 0xe7, 0xfa, 0x7b, 0xcd, 0x3e,
+	0x87, 0xd1, 0xf1, 0x01, 0xff, 0xe3, 0x75, 0x3d, 0x30, 0x0e, 0xbb, 0x82, 0x03, 0x30, 0xae, 0x6f,
+	0xae, 0x83, 0x49, 0x07, 0x01, 0xcc, 0x2f, 0x37, 0x7c, 0x1e, 0xf0, 0x89, 0x76, 0xa8, 0x17, 0x17,
+	0xcb, 0xdb, 0x80, 0x4f, 0x74, 0xb7, 0x0f, 0x3d, 0x4e, 0x59, 0x52, 0xb9, 0x16, 0xf4, 0x39, 0xfd,
+	0x2c, 0x69, 0x57, 0xcc, 0x62, 0x30, 0xeb, 0x1b, 0xe0, 0x6b, 0x38, 0x5d, 0x52, 0xd1, 0x5a, 0xd8,
+	0x93, 0xd6, 0x0c, 0x6c, 0xd3, 0xaf, 0xed, 0x1d, 0x7c, 0x03, 0xa7, 0x9f, 0x1e, 0x69, 0x07, 0x7e,
+	0x13, 0x69, 0xb7, 0x5d, 0x6e, 0x27, 0x36, 0xd5, 0x7f, 0x78, 0xfb, 0x7b, 0x00, 0xfc, 0x0d, 0xad,
+	0x85, 0x25, 0x03, 0x00, 0x00,
 }
@@ -74,6 +74,7 @@ func syncImages(conn db.Conn, dk docker.Client) {
 
 			log.WithError(err).WithField("image", img.Name).
 				Error("Failed to update registry")
+			logEvent(conn, db.BuildFailedEvent, img, err.Error())
 			return
 		}
 
@@ -81,6 +82,7 @@ func syncImages(conn db.Conn, dk docker.Client) {
 		img.Status = db.Built
 
 		log.WithField("image", img.Name).Info("Built image.")
+		logEvent(conn, db.BuiltEvent, img, "built "+id)
 	}
 
 	for _, img := range toBuild {
@@ -116,6 +118,18 @@ func writeImage(conn db.Conn, img db.Image) {
 	}
 }
 
+func logEvent(conn db.Conn, eventType string, img db.Image, message string) {
+	conn.Txn(db.EventTable).Run(func(view db.Database) error {
+		view.LogEvent(db.Event{
+			Type:       eventType,
+			ObjectType: "Image",
+			ObjectID:   img.Name,
+			Message:    message,
+		})
+		return nil
+	})
+}
+
 func getImageHandle(view db.Database, ref db.Image) (db.Image, error) {
 	matchingImages := view.SelectFromImage(func(img db.Image) bool {
 		return img.Dockerfile == ref.Dockerfile && img.Name == ref.Name
@@ -29,6 +29,11 @@ func TestSyncImages(t *testing.T) {
 	assert.Empty(t, images[0].DockerID)
 	assert.Empty(t, images[0].Status)
 
+	events := conn.SelectFromEvent(nil)
+	assert.Len(t, events, 1)
+	assert.Equal(t, db.BuildFailedEvent, events[0].Type)
+	assert.Equal(t, "image", events[0].ObjectID)
+
 	// Test successfully building an image.
 	md.BuildError = false
 	syncImages(conn, dk)
@@ -38,6 +43,11 @@ func TestSyncImages(t *testing.T) {
 	assert.NotEmpty(t, builtID, "should save ID of built image")
 	assert.Equal(t, db.Built, images[0].Status)
 
+	events = db.SortEvents(conn.SelectFromEvent(nil))
+	assert.Len(t, events, 2)
+	assert.Equal(t, db.BuiltEvent, events[1].Type)
+	assert.Equal(t, "built "+builtID, events[1].Message)
+
 	// Test ignoring already-built image.
 	md.ResetBuilt()
 	syncImages(conn, dk)
@@ -47,6 +57,7 @@ func TestSyncImages(t *testing.T) {
 	assert.Equal(t, builtID, images[0].DockerID, "should not change image ID")
 	assert.Equal(t, md.Built, map[docker.BuildImageOptions]struct{}{},
 		"should not attempt to rebuild")
+	assert.Len(t, conn.SelectFromEvent(nil), 2)
 }
 
 func TestUpdateRegistry(t *testing.T) {
@@ -0,0 +1,158 @@
+package minion
+
+import (
+	"sort"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+)
+
+// rollingUpdate decides which of the containers that updateContainers would
+// insert and remove should actually change right now.  A container in `news` and
+// one in `olds` with the same hostname are a replacement, i.e. a change to the
+// container's spec.  Replacements of containers in a RollingUpdate group happen
+// in batches limited by the group's MaxSurge and MaxUnavailable, and the next
+// batch only starts once the replacements in the previous one are ready.  All
+// other changes happen immediately.
+func rollingUpdate(view db.Database, bp blueprint.Blueprint,
+	news, olds []interface{}) (insert, remove []interface{}) {
+
+	updates := map[string]blueprint.RollingUpdate{}
+	groups := map[string][]string{}
+	for _, c := range bp.Containers {
+		if c.RollingUpdate != nil {
+			updates[c.Hostname] = *c.RollingUpdate
+			group := c.RollingUpdate.Group
+			groups[group] = append(groups[group], c.Hostname)
+		}
+	}
+
+	newByHostname := map[string]db.Container{}
+	for _, iface := range news {
+		dbc := iface.(db.Container)
+		newByHostname[dbc.Hostname] = dbc
+	}
+
+	oldByHostname := map[string][]db.Container{}
+	oldIDs := map[int]struct{}{}
+	for _, iface := range olds {
+		dbc := iface.(db.Container)
+		oldByHostname[dbc.Hostname] = append(oldByHostname[dbc.Hostname], dbc)
+		oldIDs[dbc.ID] = struct{}{}
+	}
+
+	// The replacements for containers that were previously surged have already
+	// been inserted, so they're the current containers that aren't being removed.
+	replacements := map[string][]db.Container{}
+	for _, dbc := range view.SelectFromContainer(nil) {
+		if _, ok := oldIDs[dbc.ID]; !ok {
+			replacements[dbc.Hostname] = append(
+				replacements[dbc.Hostname], dbc)
+		}
+	}
+
+	for _, iface := range news {
+		dbc := iface.(db.Container)
+		_, rolling := updates[dbc.Hostname]
+		if !rolling || len(oldByHostname[dbc.Hostname]) == 0 {
+			insert = append(insert, iface)
+		}
+	}
+
+	for _, iface := range olds {
+		dbc := iface.(db.Container)
+		if _, rolling := updates[dbc.Hostname]; !rolling {
+			remove = append(remove, iface)
+		}
+	}
+
+	var groupNames []string
+	for group := range groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+
+	for _, group := range groupNames {
+		hostnames := groups[group]
+		sort.Strings(hostnames)
+		ru := updates[hostnames[0]]
+		if ru.MaxSurge == 0 && ru.MaxUnavailable == 0 {
+			ru.MaxUnavailable = 1
+		}
+
+		var unavailable, surging int
+		var pending []string
+		for _, hostname := range hostnames {
+			oldDBCs := oldByHostname[hostname]
+			newDBCs := replacements[hostname]
+			if !anyReady(oldDBCs) && !anyReady(newDBCs) {
+				unavailable++
+			}
+
+			if len(oldDBCs) == 0 {
+				continue
+			}
+
+			if _, ok := newByHostname[hostname]; ok {
+				pending = append(pending, hostname)
+				continue
+			}
+
+			// The replacement is already running alongside the old
+			// containers, which are stopped once it's ready.
+			if anyReady(newDBCs) {
+				remove = append(remove, containerIfaces(oldDBCs)...)
+			} else {
+				surging++
+			}
+		}
+
+		// Containers that aren't ready anyway are replaced first, as doing
+		// so doesn't make the group any less available.
+		sort.SliceStable(pending, func(i, j int) bool {
+			return !anyReady(oldByHostname[pending[i]]) &&
+				anyReady(oldByHostname[pending[j]])
+		})
+
+		for _, hostname := range pending {
+			if ru.Paused {
+				break
+			}
+
+			newDBC := newByHostname[hostname]
+			oldDBCs := oldByHostname[hostname]
+			switch {
+			case !anyReady(oldDBCs):
+			case surging < ru.MaxSurge:
+				surging++
+				insert = append(insert, newDBC)
+				continue
+			case unavailable < ru.MaxUnavailable:
+				unavailable++
+			default:
+				continue
+			}
+
+			insert = append(insert, newDBC)
+			remove = append(remove, containerIfaces(oldDBCs)...)
+		}
+	}
+	return insert, remove
+}
+
+func anyReady(dbcs []db.Container) bool {
+	for _, dbc := range dbcs {
+		if dbc.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+func containerIfaces(dbcs []db.Container) []interface{} {
+	var ifaces []interface{}
+	for _, dbc := range dbcs {
+		ifaces = append(ifaces, dbc)
+	}
+	return ifaces
+}
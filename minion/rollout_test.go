@@ -0,0 +1,147 @@
+package minion
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+)
+
+func TestRollingUpdate(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	update := func(version string, ru *blueprint.RollingUpdate) {
+		var bp blueprint.Blueprint
+		for _, hostname := range []string{"a", "b", "c"} {
+			bp.Containers = append(bp.Containers, blueprint.Container{
+				ID:            hostname + version,
+				Hostname:      hostname,
+				Image:         blueprint.Image{Name: version},
+				RollingUpdate: ru,
+			})
+		}
+
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			updateContainers(view, bp)
+			return nil
+		})
+	}
+
+	// Mark every container as running, and return the BlueprintIDs of the
+	// containers that existed beforehand.
+	runAll := func() []string {
+		var ids []string
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			for _, dbc := range view.SelectFromContainer(nil) {
+				ids = append(ids, dbc.BlueprintID)
+				dbc.Status = db.ContainerRunning
+				view.Commit(dbc)
+			}
+			return nil
+		})
+		sort.Strings(ids)
+		return ids
+	}
+
+	ru := &blueprint.RollingUpdate{Group: "group"}
+	update("1", ru)
+	assert.Equal(t, []string{"a1", "b1", "c1"}, runAll())
+
+	// One container at a time is replaced, and only once its predecessor's
+	// replacement is running.
+	update("2", ru)
+	update("2", ru)
+	assert.Equal(t, []string{"a2", "b1", "c1"}, runAll())
+	update("2", ru)
+	assert.Equal(t, []string{"a2", "b2", "c1"}, runAll())
+
+	// Paused updates don't replace any more containers.
+	update("2", &blueprint.RollingUpdate{Group: "group", Paused: true})
+	assert.Equal(t, []string{"a2", "b2", "c1"}, runAll())
+
+	update("2", ru)
+	assert.Equal(t, []string{"a2", "b2", "c2"}, runAll())
+
+	// With a surge, the replacement boots alongside the old container, which is
+	// removed once the replacement is running.
+	ru = &blueprint.RollingUpdate{Group: "group", MaxSurge: 2}
+	update("3", ru)
+	assert.Equal(t, []string{"a2", "a3", "b2", "b3", "c2"}, runAll())
+	update("3", ru)
+	assert.Equal(t, []string{"a3", "b3", "c2", "c3"}, runAll())
+	update("3", ru)
+	assert.Equal(t, []string{"a3", "b3", "c3"}, runAll())
+
+	// Containers that aren't running are replaced immediately.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainer(nil) {
+			dbc.Status = ""
+			view.Commit(dbc)
+		}
+		return nil
+	})
+	update("4", ru)
+	assert.Equal(t, []string{"a4", "b4", "c4"}, runAll())
+
+	// Containers without a rolling update are all replaced at once.
+	update("5", nil)
+	assert.Equal(t, []string{"a5", "b5", "c5"}, runAll())
+}
+
+func TestRollingUpdateHealth(t *testing.T) {
+	t.Parallel()
+
+	hc := &blueprint.HealthCheck{Type: blueprint.TCPHealthCheck, Port: 80}
+	ru := blueprint.RollingUpdate{Group: "group"}
+	bp := blueprint.Blueprint{Containers: []blueprint.Container{
+		{ID: "a2", Hostname: "a", HealthCheck: hc, RollingUpdate: &ru},
+		{ID: "b2", Hostname: "b", HealthCheck: hc, RollingUpdate: &ru},
+	}}
+
+	conn := db.New()
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		for _, id := range []string{"a2", "b1"} {
+			dbc := view.InsertContainer()
+			dbc.BlueprintID = id
+			dbc.Hostname = id[:1]
+			dbc.HealthCheck = hc
+			dbc.Status = db.ContainerRunning
+			dbc.Health = db.ContainerHealthy
+			view.Commit(dbc)
+		}
+		return nil
+	})
+
+	ids := func() []string {
+		var ids []string
+		for _, dbc := range conn.SelectFromContainer(nil) {
+			ids = append(ids, dbc.BlueprintID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	setHealth := func(health string) {
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			dbc := view.SelectFromContainer(func(dbc db.Container) bool {
+				return dbc.BlueprintID == "a2"
+			})[0]
+			dbc.Health = health
+			view.Commit(dbc)
+
+			updateContainers(view, bp)
+			return nil
+		})
+	}
+
+	// The running replacement of `a` is unhealthy, so `b` isn't replaced.
+	setHealth(db.ContainerUnhealthy)
+	assert.Equal(t, []string{"a2", "b1"}, ids())
+
+	setHealth(db.ContainerHealthy)
+	assert.Equal(t, []string{"a2", "b2"}, ids())
+}
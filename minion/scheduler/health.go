@@ -0,0 +1,300 @@
+package scheduler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/minion/docker"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netns"
+)
+
+// The values used for the fields of a health check that the blueprint leaves
+// unspecified.
+const (
+	defaultHealthInterval  = 10 * time.Second
+	defaultHealthTimeout   = 5 * time.Second
+	defaultHealthThreshold = 3
+)
+
+// healthState tracks the recent results of a container's health check.
+type healthState struct {
+	lastCheck time.Time
+	failures  int
+	health    string
+
+	// running is closed once the last health check that was started returns,
+	// which may be well after it timed out.  A new check isn't started until
+	// then, so that a check that hangs doesn't leak a goroutine, and possibly
+	// a docker exec, every interval.
+	running chan struct{}
+}
+
+// busy returns whether the last health check of the container is still running.
+func (state *healthState) busy() bool {
+	if state.running == nil {
+		return false
+	}
+
+	select {
+	case <-state.running:
+		return false
+	default:
+		return true
+	}
+}
+
+// runHealthChecks periodically runs the health checks of the containers on this
+// worker, and records whether each container is healthy in its database row.
+func runHealthChecks(conn db.Conn, dk docker.Client) {
+	states := map[string]*healthState{}
+	for range time.Tick(time.Second) {
+		self := conn.MinionSelf()
+		if self.Role == db.Worker && self.PrivateIP != "" {
+			checkHealthOnce(conn, dk, self.PrivateIP, states, time.Now())
+		}
+	}
+}
+
+// checkHealthOnce runs the health checks that are due, and commits the resulting
+// health of each container.  `states` is keyed by DockerID so that a
+// restarted container starts with a clean slate.
+func checkHealthOnce(conn db.Conn, dk docker.Client, myIP string,
+	states map[string]*healthState, now time.Time) {
+
+	dbcs := conn.SelectFromContainerByMinion(myIP, func(dbc db.Container) bool {
+		return dbc.DockerID != "" && dbc.HealthCheck != nil && !exited(dbc)
+	})
+
+	var due []db.Container
+	current := map[string]struct{}{}
+	for _, dbc := range dbcs {
+		current[dbc.DockerID] = struct{}{}
+		state, ok := states[dbc.DockerID]
+		if !ok {
+			state = &healthState{}
+			states[dbc.DockerID] = state
+		}
+
+		interval := secondsOr(dbc.HealthCheck.IntervalSeconds,
+			defaultHealthInterval)
+		if now.Sub(state.lastCheck) >= interval {
+			state.lastCheck = now
+			due = append(due, dbc)
+		}
+	}
+
+	for id := range states {
+		if _, ok := current[id]; !ok {
+			delete(states, id)
+		}
+	}
+
+	errs := make([]error, len(due))
+	var wg sync.WaitGroup
+	for i, dbc := range due {
+		// A check that's still running has already timed out, so the
+		// container fails this check as well.
+		state := states[dbc.DockerID]
+		if state.busy() {
+			errs[i] = errors.New("previous check is still running")
+			continue
+		}
+
+		state.running = make(chan struct{})
+		wg.Add(1)
+		go func(i int, dbc db.Container, running chan struct{}) {
+			errs[i] = checkHealth(dk, dbc, running)
+			wg.Done()
+		}(i, dbc, state.running)
+	}
+	wg.Wait()
+
+	for i, dbc := range due {
+		state := states[dbc.DockerID]
+		prev := state.health
+		updateHealth(state, errs[i], dbc.HealthCheck.FailureThreshold)
+		if prev != state.health && state.health == db.ContainerUnhealthy {
+			log.WithError(errs[i]).WithField("container", dbc.Hostname).
+				Warn("Container failed its health check")
+		}
+	}
+
+	conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainerByMinion(myIP, nil) {
+			var health string
+			state, ok := states[dbc.DockerID]
+			if ok && dbc.HealthCheck != nil {
+				health = state.health
+			}
+
+			if dbc.Health != health {
+				dbc.Health = health
+				view.Commit(dbc)
+			}
+		}
+		return nil
+	})
+}
+
+// updateHealth records the result of a health check in `state`.  A container is
+// healthy as soon as it passes a check, but is only unhealthy once it has failed
+// `threshold` checks in a row.
+func updateHealth(state *healthState, err error, threshold int) {
+	if threshold <= 0 {
+		threshold = defaultHealthThreshold
+	}
+
+	if err == nil {
+		state.failures = 0
+		state.health = db.ContainerHealthy
+		return
+	}
+
+	state.failures++
+	if state.failures >= threshold {
+		state.health = db.ContainerUnhealthy
+	}
+}
+
+// checkHealth runs the health check of `dbc`, and returns an error if it fails
+// or doesn't complete within its timeout.  `running` is closed once the check
+// returns, even if it timed out.
+func checkHealth(dk docker.Client, dbc db.Container,
+	running chan struct{}) error {
+
+	hc := *dbc.HealthCheck
+	timeout := secondsOr(hc.TimeoutSeconds, defaultHealthTimeout)
+
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(running)
+		errChan <- runHealthCheck(dk, dbc, timeout)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-time.After(timeout):
+		return errors.New("timed out")
+	}
+}
+
+var runHealthCheck = func(dk docker.Client, dbc db.Container,
+	timeout time.Duration) error {
+
+	hc := *dbc.HealthCheck
+	switch hc.Type {
+	case blueprint.ExecHealthCheck:
+		code, err := dk.Exec(dbc.DockerID, hc.Command)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("exited with status %d", code)
+		}
+		return nil
+	case blueprint.TCPHealthCheck, blueprint.HTTPHealthCheck:
+		dkc, err := dk.Get(dbc.DockerID)
+		if err != nil {
+			return err
+		}
+
+		addr := net.JoinHostPort(dbc.IP, strconv.Itoa(hc.Port))
+		conn, err := dialInNetns(dkc.Pid, addr, timeout)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if hc.Type == blueprint.TCPHealthCheck {
+			return nil
+		}
+		conn.SetDeadline(time.Now().Add(timeout))
+		return httpCheck(conn, addr, hc.Path)
+	default:
+		return fmt.Errorf("unknown health check type: %s", hc.Type)
+	}
+}
+
+// httpCheck sends a GET request for `path` over `conn`, and returns an error
+// unless the response has a 2xx or 3xx status code.
+func httpCheck(conn net.Conn, host, path string) error {
+	if path == "" {
+		path = "/"
+	}
+
+	req, err := http.NewRequest("GET", "http://"+host+path, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// dialInNetns connects to `addr` from within the network namespace of the
+// process with `pid`, as the container's IP isn't routable from the host.  The
+// dial happens in a goroutine that never unlocks its OS thread so that the
+// runtime discards the thread, rather than reusing it in the wrong namespace,
+// once the goroutine exits.  The connection remains usable from any thread.
+var dialInNetns = func(pid int, addr string, timeout time.Duration) (
+	net.Conn, error) {
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	resultChan := make(chan result, 1)
+	go func() {
+		runtime.LockOSThread()
+
+		ns, err := netns.GetFromPid(pid)
+		if err != nil {
+			resultChan <- result{err: err}
+			return
+		}
+		defer ns.Close()
+
+		if err := netns.Set(ns); err != nil {
+			resultChan <- result{err: err}
+			return
+		}
+
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		resultChan <- result{conn, err}
+	}()
+
+	res := <-resultChan
+	return res.conn, res.err
+}
+
+func secondsOr(seconds int, def time.Duration) time.Duration {
+	if seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/minion/docker"
+)
+
+func TestCheckHealthOnce(t *testing.T) {
+	conn := db.New()
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		dbc := view.InsertContainer()
+		dbc.Hostname = "checked"
+		dbc.Minion = "1.2.3.4"
+		dbc.DockerID = "checked"
+		dbc.HealthCheck = &blueprint.HealthCheck{
+			Type:             blueprint.TCPHealthCheck,
+			FailureThreshold: 2,
+		}
+		view.Commit(dbc)
+
+		dbc = view.InsertContainer()
+		dbc.Hostname = "unchecked"
+		dbc.Minion = "1.2.3.4"
+		dbc.DockerID = "unchecked"
+		view.Commit(dbc)
+		return nil
+	})
+
+	var checkErr error
+	var checked []string
+	origRunHealthCheck := runHealthCheck
+	defer func() { runHealthCheck = origRunHealthCheck }()
+	runHealthCheck = func(_ docker.Client, dbc db.Container,
+		_ time.Duration) error {
+		checked = append(checked, dbc.DockerID)
+		return checkErr
+	}
+
+	healthOf := func(hostname string) string {
+		dbcs := conn.SelectFromContainer(func(dbc db.Container) bool {
+			return dbc.Hostname == hostname
+		})
+		return dbcs[0].Health
+	}
+
+	_, dk := docker.NewMock()
+	states := map[string]*healthState{}
+	now := time.Now()
+
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now)
+	assert.Equal(t, []string{"checked"}, checked)
+	assert.Equal(t, db.ContainerHealthy, healthOf("checked"))
+	assert.Equal(t, "", healthOf("unchecked"))
+
+	// The check isn't due again until the interval has passed.
+	checkErr = errors.New("err")
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now.Add(time.Second))
+	assert.Len(t, checked, 1)
+
+	// The first failure is below the threshold.
+	now = now.Add(defaultHealthInterval)
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now)
+	assert.Equal(t, db.ContainerHealthy, healthOf("checked"))
+
+	now = now.Add(defaultHealthInterval)
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now)
+	assert.Equal(t, db.ContainerUnhealthy, healthOf("checked"))
+
+	// A restarted container starts with a clean slate.
+	conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+		dbc := view.SelectFromContainer(func(dbc db.Container) bool {
+			return dbc.Hostname == "checked"
+		})[0]
+		dbc.DockerID = "restarted"
+		view.Commit(dbc)
+		return nil
+	})
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now.Add(time.Second))
+	assert.Equal(t, "", healthOf("checked"))
+	assert.Len(t, states, 1)
+	assert.Contains(t, states, "restarted")
+}
+
+func TestCheckHealthHung(t *testing.T) {
+	conn := db.New()
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		dbc := view.InsertContainer()
+		dbc.Hostname = "hung"
+		dbc.Minion = "1.2.3.4"
+		dbc.DockerID = "hung"
+		dbc.HealthCheck = &blueprint.HealthCheck{
+			Type:             blueprint.ExecHealthCheck,
+			TimeoutSeconds:   1,
+			FailureThreshold: 2,
+		}
+		view.Commit(dbc)
+		return nil
+	})
+
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	origRunHealthCheck := runHealthCheck
+	defer func() { runHealthCheck = origRunHealthCheck }()
+	runHealthCheck = func(docker.Client, db.Container, time.Duration) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+
+	_, dk := docker.NewMock()
+	states := map[string]*healthState{}
+	now := time.Now()
+
+	// The check times out, but keeps running.
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now)
+	assert.Len(t, started, 1)
+	assert.Equal(t, 1, states["hung"].failures)
+
+	// No new check is started while the previous one is still running, but
+	// the container still fails the check.
+	now = now.Add(defaultHealthInterval)
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now)
+	assert.Len(t, started, 1)
+	assert.Equal(t, db.ContainerUnhealthy, states["hung"].health)
+
+	// Once the hung check returns, the next check runs as usual.
+	close(release)
+	<-states["hung"].running
+	now = now.Add(defaultHealthInterval)
+	checkHealthOnce(conn, dk, "1.2.3.4", states, now)
+	assert.Len(t, started, 2)
+	assert.Equal(t, db.ContainerHealthy, states["hung"].health)
+}
+
+func TestUpdateHealth(t *testing.T) {
+	state := &healthState{}
+	updateHealth(state, errors.New("err"), 0)
+	updateHealth(state, errors.New("err"), 0)
+	assert.Equal(t, "", state.health)
+
+	updateHealth(state, errors.New("err"), 0)
+	assert.Equal(t, db.ContainerUnhealthy, state.health)
+
+	updateHealth(state, nil, 0)
+	assert.Equal(t, db.ContainerHealthy, state.health)
+	assert.Equal(t, 0, state.failures)
+}
+
+func TestRunHealthCheck(t *testing.T) {
+	md, dk := docker.NewMock()
+	id, err := dk.Run(docker.RunOptions{Name: "name"})
+	assert.NoError(t, err)
+
+	check := func(hc blueprint.HealthCheck) error {
+		return runHealthCheck(dk, db.Container{
+			DockerID:    id,
+			IP:          "127.0.0.1",
+			HealthCheck: &hc,
+		}, time.Second)
+	}
+
+	md.ExecExitCodes["false"] = 1
+	assert.NoError(t, check(blueprint.HealthCheck{
+		Type: blueprint.ExecHealthCheck, Command: []string{"true"}}))
+	assert.EqualError(t, check(blueprint.HealthCheck{
+		Type: blueprint.ExecHealthCheck, Command: []string{"false"}}),
+		"exited with status 1")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/healthz" {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+	defer server.Close()
+
+	var dialedAddr string
+	origDialInNetns := dialInNetns
+	defer func() { dialInNetns = origDialInNetns }()
+	dialInNetns = func(_ int, addr string, timeout time.Duration) (
+		net.Conn, error) {
+		dialedAddr = addr
+		return net.DialTimeout("tcp", server.Listener.Addr().String(),
+			timeout)
+	}
+
+	assert.NoError(t, check(blueprint.HealthCheck{
+		Type: blueprint.TCPHealthCheck, Port: 80}))
+	assert.Equal(t, "127.0.0.1:80", dialedAddr)
+
+	assert.NoError(t, check(blueprint.HealthCheck{
+		Type: blueprint.HTTPHealthCheck, Port: 80, Path: "/healthz"}))
+	assert.EqualError(t, check(blueprint.HealthCheck{
+		Type: blueprint.HTTPHealthCheck, Port: 80}),
+		"status 500 Internal Server Error")
+
+	assert.EqualError(t, check(blueprint.HealthCheck{Type: "ping"}),
+		"unknown health check type: ping")
+}
@@ -2,11 +2,13 @@ package scheduler
 
 import (
 	"container/heap"
+	"errors"
 	"fmt"
 	"sort"
 
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/cloud/machine"
 	"github.com/kelda/kelda/db"
-	"github.com/kelda/kelda/util/str"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,8 +20,15 @@ type minion struct {
 type context struct {
 	minions     []*minion
 	constraints []db.Placement
+	volumes     map[string]*db.Volume
 	unassigned  []*db.Container
 	changed     []*db.Container
+
+	changedVolumes []*db.Volume
+
+	// groups maps each container to the group of containers that inclusive
+	// placement constraints require to share its minion, including itself.
+	groups map[*db.Container][]*db.Container
 }
 
 // isMasterReady waits for there to be at least one worker in the database so
@@ -36,8 +45,9 @@ func runMaster(conn db.Conn) {
 		return
 	}
 
-	conn.Txn(db.ContainerTable, db.MinionTable, db.ImageTable,
-		db.PlacementTable).Run(func(view db.Database) error {
+	conn.Txn(db.ContainerTable, db.EventTable, db.ImageTable, db.JobTable,
+		db.MinionTable, db.PlacementTable,
+		db.VolumeTable).Run(func(view db.Database) error {
 		placeContainers(view)
 		return nil
 	})
@@ -45,17 +55,70 @@ func runMaster(conn db.Conn) {
 
 func placeContainers(view db.Database) {
 	constraints := view.SelectFromPlacement(nil)
-	containers := view.SelectFromContainer(nil)
 	minions := view.SelectFromMinion(nil)
 	images := view.SelectFromImage(nil)
+	volumes := view.SelectFromVolume(nil)
+
+	// Containers aren't scheduled until their dependencies are ready.  Those
+	// that have already been scheduled stay put even if a dependency stops
+	// being ready.
+	all := view.SelectFromContainer(nil)
+	ready := db.ReadyHostnames(all, view.SelectFromJob(nil))
+	var containers []db.Container
+	for _, dbc := range all {
+		if dbc.Minion == "" && len(dbc.WaitingFor(ready)) != 0 {
+			continue
+		}
+		containers = append(containers, dbc)
+	}
 
-	ctx := makeContext(minions, constraints, containers, images)
+	// The context modifies `containers` in place, so the minions that they
+	// were placed on are saved to record how they moved.
+	prevMinion := map[int]string{}
+	for _, dbc := range containers {
+		prevMinion[dbc.ID] = dbc.Minion
+	}
+
+	ctx := makeContext(minions, constraints, containers, images, volumes)
 	cleanupPlacements(ctx)
 	placeUnassigned(ctx)
 
+	logged := map[int]bool{}
 	for _, change := range ctx.changed {
 		view.Commit(*change)
+
+		if !logged[change.ID] && change.Minion != prevMinion[change.ID] {
+			logged[change.ID] = true
+			view.LogEvent(placementEvent(prevMinion[change.ID], *change))
+		}
+	}
+
+	for _, change := range ctx.changedVolumes {
+		view.Commit(*change)
+	}
+}
+
+// placementEvent returns the event recording that `dbc` moved from the minion
+// `prev` to its current one.
+func placementEvent(prev string, dbc db.Container) db.Event {
+	event := db.Event{
+		Type:       db.RescheduledEvent,
+		ObjectType: "Container",
+		ObjectID:   dbc.BlueprintID,
 	}
+
+	switch {
+	case prev == "":
+		event.Type = db.PlacedEvent
+		event.Message = fmt.Sprintf("%s placed on %s", dbc.Hostname,
+			dbc.Minion)
+	case dbc.Minion == "":
+		event.Message = fmt.Sprintf("%s removed from %s", dbc.Hostname, prev)
+	default:
+		event.Message = fmt.Sprintf("%s moved from %s to %s", dbc.Hostname,
+			prev, dbc.Minion)
+	}
+	return event
 }
 
 // Unassign all containers that are placed incorrectly.
@@ -63,7 +126,9 @@ func cleanupPlacements(ctx *context) {
 	for _, m := range ctx.minions {
 		var valid []*db.Container
 		for _, dbc := range m.containers {
-			if validPlacement(ctx.constraints, *m, valid, dbc) {
+			if validPlacement(ctx.constraints, *m, valid, dbc) &&
+				ctx.followsVolumes(*m, dbc) {
+				ctx.bindVolumes(*m, dbc)
 				valid = append(valid, dbc)
 				continue
 			}
@@ -74,39 +139,234 @@ func cleanupPlacements(ctx *context) {
 		}
 		m.containers = valid
 	}
+
+	// Containers that must be co-located, but were placed apart, are
+	// unassigned unless they're on the minion hosting most of their group.
+	for _, group := range ctx.uniqueGroups() {
+		counts := map[string]int{}
+		for _, dbc := range group {
+			if dbc.Minion != "" {
+				counts[dbc.Minion]++
+			}
+		}
+		if len(counts) < 2 {
+			continue
+		}
+
+		var best string
+		for ip, count := range counts {
+			if best == "" || count > counts[best] ||
+				(count == counts[best] && ip < best) {
+				best = ip
+			}
+		}
+
+		for _, dbc := range group {
+			if dbc.Minion != "" && dbc.Minion != best {
+				c.Inc("Reschedule Container")
+				ctx.unassign(dbc)
+				ctx.unassigned = append(ctx.unassigned, dbc)
+			}
+		}
+	}
 }
 
 func placeUnassigned(ctx *context) {
 	minions := minionHeap(ctx.minions)
 	heap.Init(&minions)
 
-Outer:
+	attempted := map[*db.Container]bool{}
 	for _, dbc := range ctx.unassigned {
-		for i, m := range minions {
-			if validPlacement(ctx.constraints, *m, m.containers, dbc) {
-				c.Inc("Place Container")
-				dbc.Minion = m.PrivateIP
-				ctx.changed = append(ctx.changed, dbc)
-				m.containers = append(m.containers, dbc)
-				heap.Fix(&minions, i)
-				log.WithField("container", dbc).Info("Placed container.")
-				continue Outer
+		if dbc.Minion != "" || attempted[dbc] {
+			continue
+		}
+
+		group := ctx.group(dbc)
+		for _, member := range group {
+			attempted[member] = true
+		}
+
+		if err := ctx.placeGroup(minions, group); err != nil {
+			log.WithError(err).WithField("containers", group).Warning(
+				"Failed to place containers.")
+			ctx.markUnschedulable(group, err.Error())
+		}
+		heap.Init(&minions)
+	}
+}
+
+// placeGroup places `group` on the first minion that can hold all of its
+// containers.  If some of the containers are already placed, their minion is
+// tried first, and if it can't hold the rest, they're moved along with them.
+func (ctx *context) placeGroup(minions []*minion, group []*db.Container) error {
+	if a, b, ok := conflictingConstraints(ctx.constraints, group); ok {
+		return fmt.Errorf("placement constraints require %s and %s to "+
+			"be both co-located and kept apart", a, b)
+	}
+
+	var candidates []*minion
+	for _, m := range minions {
+		for _, dbc := range group {
+			if dbc.Minion == m.PrivateIP {
+				candidates = append(candidates, m)
+				break
+			}
+		}
+	}
+	candidates = append(candidates, minions...)
+
+	for _, m := range candidates {
+		if ctx.canHold(*m, group) {
+			ctx.assign(m, group)
+			return nil
+		}
+	}
+
+	if len(group) == 1 {
+		return errors.New("no minion satisfies the container's placement " +
+			"constraints and resource requests")
+	}
+	return fmt.Errorf("no single minion satisfies the placement constraints "+
+		"and resource requests of all %d co-located containers", len(group))
+}
+
+// canHold returns whether `m` can hold every container in `group` in addition
+// to the containers already placed on it.
+func (ctx *context) canHold(m minion, group []*db.Container) bool {
+	var peers []*db.Container
+	for _, p := range m.containers {
+		if !containsContainer(group, p) {
+			peers = append(peers, p)
+		}
+	}
+
+	for _, dbc := range group {
+		if !validPlacement(ctx.constraints, m, peers, dbc) ||
+			!ctx.followsVolumes(m, dbc) {
+			return false
+		}
+		peers = append(peers, dbc)
+	}
+	return true
+}
+
+// assign places every container in `group` on `m`, moving those that are
+// currently placed elsewhere.
+func (ctx *context) assign(m *minion, group []*db.Container) {
+	for _, dbc := range group {
+		if dbc.Minion == m.PrivateIP {
+			continue
+		}
+
+		if dbc.Minion != "" {
+			c.Inc("Reschedule Container")
+			ctx.unassign(dbc)
+		}
+
+		c.Inc("Place Container")
+		ctx.bindVolumes(*m, dbc)
+		dbc.Minion = m.PrivateIP
+		dbc.Unschedulable = ""
+		ctx.changed = append(ctx.changed, dbc)
+		m.containers = append(m.containers, dbc)
+		log.WithField("container", dbc).Info("Placed container.")
+	}
+}
+
+// markUnschedulable records why the containers in `group` couldn't be placed,
+// so that the daemon's autoscaler can boot a machine for them.
+func (ctx *context) markUnschedulable(group []*db.Container, reason string) {
+	for _, dbc := range group {
+		if dbc.Minion == "" && dbc.Unschedulable != reason {
+			dbc.Unschedulable = reason
+			ctx.changed = append(ctx.changed, dbc)
+		}
+	}
+}
+
+// unassign removes `dbc` from the minion it's placed on.
+func (ctx *context) unassign(dbc *db.Container) {
+	for _, m := range ctx.minions {
+		if m.PrivateIP != dbc.Minion {
+			continue
+		}
+
+		var remaining []*db.Container
+		for _, p := range m.containers {
+			if p != dbc {
+				remaining = append(remaining, p)
 			}
 		}
+		m.containers = remaining
+	}
+	dbc.Minion = ""
+	ctx.changed = append(ctx.changed, dbc)
+}
+
+// group returns the containers that must be placed on the same minion as `dbc`,
+// including `dbc` itself.
+func (ctx *context) group(dbc *db.Container) []*db.Container {
+	if group, ok := ctx.groups[dbc]; ok {
+		return group
+	}
+	return []*db.Container{dbc}
+}
+
+// uniqueGroups returns each group of co-located containers once.
+func (ctx *context) uniqueGroups() [][]*db.Container {
+	var groups [][]*db.Container
+	seen := map[*db.Container]bool{}
+	for _, dbc := range ctx.schedulable() {
+		group := ctx.group(dbc)
+		if !seen[group[0]] {
+			seen[group[0]] = true
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// conflictingConstraints returns the hostnames of two containers in `group`
+// that an exclusive constraint forbids from sharing a minion.
+func conflictingConstraints(constraints []db.Placement, group []*db.Container) (
+	string, string, bool) {
+
+	for _, constraint := range constraints {
+		if !constraint.Exclusive || constraint.OtherContainer == "" {
+			continue
+		}
 
-		log.WithField("container", dbc).Warning("Failed to place container.")
+		var hasTarget, hasOther bool
+		for _, dbc := range group {
+			if dbc.Hostname == constraint.TargetContainer {
+				hasTarget = true
+			}
+			if dbc.Hostname == constraint.OtherContainer {
+				hasOther = true
+			}
+		}
+		if hasTarget && hasOther {
+			return constraint.TargetContainer, constraint.OtherContainer, true
+		}
 	}
+	return "", "", false
 }
 
+func containsContainer(dbcs []*db.Container, dbc *db.Container) bool {
+	for _, d := range dbcs {
+		if d == dbc {
+			return true
+		}
+	}
+	return false
+}
+
+// canBeColocated returns false if `constraint` forbids `toPlace` from sharing a
+// minion with any of `peers`.  Inclusive constraints are satisfied by placing
+// each group of co-located containers together, so they never forbid anything.
 func canBeColocated(constraint db.Placement, toPlace db.Container,
 	peers []*db.Container) bool {
 	if !constraint.Exclusive {
-		// XXX: Inclusive OtherContainer is hard because we can't
-		// make placement decisions without considering all the
-		// containers on all of the minions.
-		log.WithField("constraint", constraint).Warning(
-			"Kelda currently does not support inclusive" +
-				" container placement constraints")
 		return true
 	}
 
@@ -122,9 +382,63 @@ func canBeColocated(constraint db.Placement, toPlace db.Container,
 	return true
 }
 
+// hasCapacity returns true if the resources requested by `dbc` and `peers` fit
+// within the capacity of `m`. Minions whose capacity is unknown are assumed to
+// have room for any container.
+func hasCapacity(m minion, peers []*db.Container, dbc *db.Container) bool {
+	cpuCap, ramCap, ok := machine.Capacity(db.ProviderName(m.Provider), m.Size)
+	if !ok {
+		return true
+	}
+
+	cpu, ram := dbc.CPURequest, dbc.RAMRequest
+	for _, p := range peers {
+		cpu += p.CPURequest
+		ram += p.RAMRequest
+	}
+	return cpu <= cpuCap && ram <= ramCap
+}
+
+// followsVolumes returns true if every volume mounted by `dbc` is either
+// bound to `m`, or is a host volume that isn't bound to any minion yet.  Block
+// volumes that aren't attached to a minion can't be mounted anywhere.
+func (ctx *context) followsVolumes(m minion, dbc *db.Container) bool {
+	for _, vm := range dbc.VolumeMounts {
+		vol := ctx.volumes[vm.Volume]
+		if vol == nil {
+			return false
+		}
+
+		if vol.Minion == "" && vol.Type == blueprint.HostVolume {
+			continue
+		}
+
+		if vol.Minion != m.PrivateIP {
+			return false
+		}
+	}
+	return true
+}
+
+// bindVolumes binds the unbound host volumes mounted by `dbc` to `m`, so that
+// the containers using them follow it there.
+func (ctx *context) bindVolumes(m minion, dbc *db.Container) {
+	for _, vm := range dbc.VolumeMounts {
+		vol := ctx.volumes[vm.Volume]
+		if vol != nil && vol.Minion == "" {
+			vol.Minion = m.PrivateIP
+			ctx.changedVolumes = append(ctx.changedVolumes, vol)
+		}
+	}
+}
+
 func validPlacement(constraints []db.Placement, m minion, peers []*db.Container,
 	dbc *db.Container) bool {
 
+	if !hasCapacity(m, peers, dbc) {
+		return false
+	}
+
 	for _, constraint := range constraints {
 		if constraint.OtherContainer != "" {
 			if !canBeColocated(constraint, *dbc, peers) {
@@ -169,12 +483,14 @@ func validPlacement(constraints []db.Placement, m minion, peers []*db.Container,
 }
 
 func makeContext(minions []db.Minion, constraints []db.Placement,
-	containers []db.Container, images []db.Image) *context {
+	containers []db.Container, images []db.Image,
+	volumes []db.Volume) *context {
 
 	ctx := context{}
 	ctx.constraints = constraints
 
 	ipMinion := map[string]*minion{}
+	volumeMinion := map[string]string{}
 	for _, dbm := range minions {
 		if dbm.Role != db.Worker || dbm.PrivateIP == "" {
 			continue
@@ -183,6 +499,31 @@ func makeContext(minions []db.Minion, constraints []db.Placement,
 		m := minion{dbm, nil}
 		ctx.minions = append(ctx.minions, &m)
 		ipMinion[m.PrivateIP] = &m
+
+		for name := range dbm.Volumes {
+			volumeMinion[name] = dbm.PrivateIP
+		}
+	}
+
+	// Block volumes are bound to whichever minion the cloud provider attached
+	// them to, while host volumes stay bound to their minion for as long as it
+	// exists.
+	ctx.volumes = map[string]*db.Volume{}
+	for i := range volumes {
+		vol := &volumes[i]
+		ctx.volumes[vol.Name] = vol
+
+		bound := vol.Minion
+		if vol.Type == blueprint.BlockVolume {
+			bound = volumeMinion[vol.Name]
+		} else if ipMinion[bound] == nil {
+			bound = ""
+		}
+
+		if bound != vol.Minion {
+			vol.Minion = bound
+			ctx.changedVolumes = append(ctx.changedVolumes, vol)
+		}
 	}
 
 	builtImages := map[db.Image]db.Image{}
@@ -227,14 +568,80 @@ func makeContext(minions []db.Minion, constraints []db.Placement,
 		minion.containers = append(minion.containers, dbc)
 	}
 
+	ctx.groups = colocationGroups(constraints, ctx.schedulable())
+
 	// XXX: We sort containers based on their image and command in an effort to
 	// encourage the scheduler to spread them out.  This is somewhat of a hack -- we
-	// need a more clever scheduler at some point.
+	// need a more clever scheduler at some point.  Containers with larger
+	// resource requests are placed first so that they can be packed onto the
+	// minions before the space is fragmented by smaller containers.
 	sort.Sort(dbcSlice(ctx.unassigned))
 
 	return &ctx
 }
 
+// schedulable returns the containers that are either placed, or waiting to be.
+func (ctx *context) schedulable() []*db.Container {
+	dbcs := append([]*db.Container{}, ctx.unassigned...)
+	for _, m := range ctx.minions {
+		dbcs = append(dbcs, m.containers...)
+	}
+	return dbcs
+}
+
+// colocationGroups partitions `dbcs` into the groups of containers that inclusive
+// OtherContainer constraints require to share a minion, and maps each container
+// to its group.  Containers that aren't in any such constraint are omitted.
+// Containers in a group are sorted in the order they're placed.
+func colocationGroups(constraints []db.Placement,
+	dbcs []*db.Container) map[*db.Container][]*db.Container {
+
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(hostname string) string {
+		if parent[hostname] == hostname {
+			return hostname
+		}
+		root := find(parent[hostname])
+		parent[hostname] = root
+		return root
+	}
+
+	for _, constraint := range constraints {
+		if !constraint.Exclusive && constraint.OtherContainer != "" {
+			for _, hostname := range []string{constraint.TargetContainer,
+				constraint.OtherContainer} {
+				if _, ok := parent[hostname]; !ok {
+					parent[hostname] = hostname
+				}
+			}
+
+			a := find(constraint.TargetContainer)
+			b := find(constraint.OtherContainer)
+			if a != b {
+				parent[a] = b
+			}
+		}
+	}
+
+	byRoot := map[string][]*db.Container{}
+	for _, dbc := range dbcs {
+		if _, ok := parent[dbc.Hostname]; ok {
+			root := find(dbc.Hostname)
+			byRoot[root] = append(byRoot[root], dbc)
+		}
+	}
+
+	groups := map[*db.Container][]*db.Container{}
+	for _, group := range byRoot {
+		sort.Sort(dbcSlice(group))
+		for _, dbc := range group {
+			groups[dbc] = group
+		}
+	}
+	return groups
+}
+
 // Minion Heap.  Minions are sorted based on the number of containers scheduled on them
 // with fewer containers being higher priority.
 type minionHeap []*minion
@@ -254,10 +661,14 @@ type dbcSlice []*db.Container
 
 func (s dbcSlice) Less(i, j int) bool {
 	switch {
+	case s[i].CPURequest != s[j].CPURequest:
+		return s[i].CPURequest > s[j].CPURequest
+	case s[i].RAMRequest != s[j].RAMRequest:
+		return s[i].RAMRequest > s[j].RAMRequest
 	case s[i].Image != s[j].Image:
 		return s[i].Image < s[j].Image
-	case !str.SliceEq(s[i].Command, s[j].Command):
-		return fmt.Sprintf("%s", s[i].Command) < fmt.Sprintf("%s", s[j].Command)
+	case fmt.Sprint(s[i].Command) != fmt.Sprint(s[j].Command):
+		return fmt.Sprint(s[i].Command) < fmt.Sprint(s[j].Command)
 	default:
 		return s[i].BlueprintID < s[j].BlueprintID
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/kelda/kelda/blueprint"
 	"github.com/kelda/kelda/db"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,6 +42,140 @@ func TestPlaceContainers(t *testing.T) {
 	})
 }
 
+func TestPlacementEvents(t *testing.T) {
+	t.Parallel()
+	conn := db.New()
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		m := view.InsertMinion()
+		m.PrivateIP = "1"
+		m.Role = db.Worker
+		view.Commit(m)
+
+		dbc := view.InsertContainer()
+		dbc.BlueprintID = "a"
+		dbc.Hostname = "web"
+		view.Commit(dbc)
+
+		// The container's minion no longer exists.
+		dbc = view.InsertContainer()
+		dbc.BlueprintID = "b"
+		dbc.Hostname = "db"
+		dbc.Minion = "2"
+		view.Commit(dbc)
+		return nil
+	})
+
+	place := func() (messages []string) {
+		start := len(conn.SelectFromEvent(nil))
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			placeContainers(view)
+			return nil
+		})
+
+		events := db.SortEvents(conn.SelectFromEvent(nil))[start:]
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].ObjectID < events[j].ObjectID
+		})
+		for _, event := range events {
+			assert.Equal(t, "Container", event.ObjectType)
+			messages = append(messages, event.Type+": "+event.Message)
+		}
+		return messages
+	}
+
+	assert.Equal(t, []string{
+		"Placed: web placed on 1",
+		"Rescheduled: db moved from 2 to 1",
+	}, place())
+
+	// Containers that stay put have no events.
+	assert.Empty(t, place())
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		view.Remove(view.SelectFromMinion(nil)[0])
+		return nil
+	})
+	assert.Equal(t, []string{
+		"Rescheduled: web removed from 1",
+		"Rescheduled: db removed from 1",
+	}, place())
+}
+
+func TestPlaceDependencies(t *testing.T) {
+	t.Parallel()
+	conn := db.New()
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		m := view.InsertMinion()
+		m.PrivateIP = "1"
+		m.Role = db.Worker
+		view.Commit(m)
+
+		dbc := view.InsertContainer()
+		dbc.BlueprintID = "web"
+		dbc.Hostname = "web"
+		dbc.DependsOn = []string{"db", "migrate"}
+		view.Commit(dbc)
+
+		dbc = view.InsertContainer()
+		dbc.BlueprintID = "db"
+		dbc.Hostname = "db"
+		view.Commit(dbc)
+
+		job := view.InsertJob()
+		job.Hostname = "migrate"
+		job.Runs = []db.JobRun{{Status: db.JobRunning}}
+		view.Commit(job)
+		return nil
+	})
+
+	place := func() map[string]string {
+		conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+			placeContainers(view)
+			return nil
+		})
+
+		minions := map[string]string{}
+		for _, dbc := range conn.SelectFromContainer(nil) {
+			minions[dbc.BlueprintID] = dbc.Minion
+		}
+		return minions
+	}
+
+	assert.Equal(t, map[string]string{"web": "", "db": "1"}, place())
+
+	// The web container still waits for the job after the database starts.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		dbc := view.SelectFromContainer(func(dbc db.Container) bool {
+			return dbc.Hostname == "db"
+		})[0]
+		dbc.Status = db.ContainerRunning
+		view.Commit(dbc)
+		return nil
+	})
+	assert.Equal(t, map[string]string{"web": "", "db": "1"}, place())
+
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		job := view.SelectFromJob(nil)[0]
+		job.Runs[0].Status = db.JobSucceeded
+		view.Commit(job)
+		return nil
+	})
+	assert.Equal(t, map[string]string{"web": "1", "db": "1"}, place())
+
+	// Scheduled containers aren't evicted when their dependencies stop.
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		dbc := view.SelectFromContainer(func(dbc db.Container) bool {
+			return dbc.Hostname == "db"
+		})[0]
+		dbc.Status = ""
+		view.Commit(dbc)
+		return nil
+	})
+	assert.Equal(t, map[string]string{"web": "1", "db": "1"}, place())
+}
+
 func TestCleanup(t *testing.T) {
 	t.Parallel()
 
@@ -73,7 +208,7 @@ func TestCleanup(t *testing.T) {
 		},
 	}
 
-	ctx := makeContext(minions, placements, containers, nil)
+	ctx := makeContext(minions, placements, containers, nil, nil)
 	cleanupPlacements(ctx)
 
 	expMinions := []*minion{
@@ -143,7 +278,7 @@ func TestCleanupContainerRule(t *testing.T) {
 		},
 	}
 
-	ctx := makeContext(minions, placements, containers, nil)
+	ctx := makeContext(minions, placements, containers, nil, nil)
 	cleanupPlacements(ctx)
 
 	expMinions := []*minion{
@@ -177,7 +312,7 @@ func TestPlaceUnassigned(t *testing.T) {
 	t.Parallel()
 
 	var exp []*db.Container
-	ctx := makeContext(nil, nil, nil, nil)
+	ctx := makeContext(nil, nil, nil, nil, nil)
 	placeUnassigned(ctx)
 	assert.Equal(t, exp, ctx.changed)
 
@@ -221,7 +356,7 @@ func TestPlaceUnassigned(t *testing.T) {
 		},
 	}
 
-	ctx = makeContext(minions, placements, containers, nil)
+	ctx = makeContext(minions, placements, containers, nil, nil)
 	placeUnassigned(ctx)
 
 	exp = nil
@@ -236,16 +371,33 @@ func TestPlaceUnassigned(t *testing.T) {
 
 	assert.Equal(t, exp, ctx.changed)
 
-	ctx = makeContext(minions, placements, containers, nil)
+	ctx = makeContext(minions, placements, containers, nil, nil)
 	placeUnassigned(ctx)
 	assert.Nil(t, ctx.changed)
 
 	placements[0].Exclusive = false
 	placements[0].Region = "Nowhere"
 	containers[0].Minion = ""
-	ctx = makeContext(minions, placements, containers, nil)
+	ctx = makeContext(minions, placements, containers, nil, nil)
+	placeUnassigned(ctx)
+	assert.Len(t, ctx.changed, 1)
+	assert.Equal(t, "1", ctx.changed[0].Hostname)
+	assert.Equal(t, "no minion satisfies the container's placement "+
+		"constraints and resource requests", ctx.changed[0].Unschedulable)
+
+	// Containers are only changed the first time they fail to be placed, and
+	// the reason is cleared once they are.
+	containers[0].Unschedulable = ctx.changed[0].Unschedulable
+	ctx = makeContext(minions, placements, containers, nil, nil)
 	placeUnassigned(ctx)
 	assert.Nil(t, ctx.changed)
+
+	placements[0].Region = "Region1"
+	ctx = makeContext(minions, placements, containers, nil, nil)
+	placeUnassigned(ctx)
+	assert.Len(t, ctx.changed, 1)
+	assert.Equal(t, "1", ctx.changed[0].Minion)
+	assert.Empty(t, ctx.changed[0].Unschedulable)
 }
 
 func TestMakeContext(t *testing.T) {
@@ -322,7 +474,7 @@ func TestMakeContext(t *testing.T) {
 		},
 	}
 
-	ctx := makeContext(minions, placements, containers, images)
+	ctx := makeContext(minions, placements, containers, images, nil)
 	assert.Equal(t, placements, ctx.constraints)
 
 	expMinions := []*minion{
@@ -631,14 +783,225 @@ func TestValidPlacementMachine(t *testing.T) {
 func TestSort(t *testing.T) {
 	a := &db.Container{Image: "1", BlueprintID: "1"}
 	b := &db.Container{Image: "1", BlueprintID: "2"}
-	c := &db.Container{Image: "2", Command: []string{"1", "2"}}
-	d := &db.Container{Image: "2", Command: []string{"3", "4"}}
+	c := &db.Container{Image: "2", Command: blueprint.NewStrings("1", "2")}
+	d := &db.Container{Image: "2", Command: blueprint.NewStrings("3", "4")}
 
 	slice := []*db.Container{d, c, b, a}
 	sort.Sort(dbcSlice(slice))
 	assert.Equal(t, slice, []*db.Container{a, b, c, d})
+
+	big := &db.Container{Image: "2", CPURequest: 2}
+	small := &db.Container{Image: "1", CPURequest: 1, RAMRequest: 2}
+	smaller := &db.Container{Image: "1", CPURequest: 1, RAMRequest: 1}
+	slice = []*db.Container{a, smaller, small, big}
+	sort.Sort(dbcSlice(slice))
+	assert.Equal(t, slice, []*db.Container{big, small, smaller, a})
 }
 
 func (m minion) String() string {
 	return spew.Sprintf("(%s Containers: %s)", m.Minion, m.containers)
 }
+
+func TestPlaceUnassignedResources(t *testing.T) {
+	t.Parallel()
+
+	// A t2.medium has 2 cores and 4GiB of RAM.
+	minions := []db.Minion{
+		{
+			PrivateIP: "1",
+			Provider:  string(db.Amazon),
+			Size:      "t2.medium",
+			Role:      db.Worker,
+		},
+		{
+			PrivateIP: "2",
+			Provider:  string(db.Amazon),
+			Size:      "t2.medium",
+			Role:      db.Worker,
+		},
+	}
+	containers := []db.Container{
+		{ID: 1, BlueprintID: "1", CPURequest: 1, RAMRequest: 3},
+		{ID: 2, BlueprintID: "2", CPURequest: 1, RAMRequest: 3},
+		{ID: 3, BlueprintID: "3", CPURequest: 1, RAMRequest: 1},
+		{ID: 4, BlueprintID: "4", CPURequest: 1, RAMRequest: 1},
+		{ID: 5, BlueprintID: "5", CPURequest: 1},
+	}
+
+	ctx := makeContext(minions, nil, containers, nil, nil)
+	placeUnassigned(ctx)
+
+	placed := map[string][]string{}
+	for _, dbc := range ctx.changed {
+		placed[dbc.Minion] = append(placed[dbc.Minion], dbc.BlueprintID)
+	}
+
+	// The two large containers can't share a minion, and there's only room
+	// for one more container next to each of them.  The last is marked
+	// unschedulable.
+	assert.Len(t, ctx.changed, 5)
+	assert.Len(t, placed[""], 1)
+	assert.Equal(t, "no minion satisfies the container's placement "+
+		"constraints and resource requests", ctx.changed[4].Unschedulable)
+	assert.Len(t, placed["1"], 2)
+	assert.Len(t, placed["2"], 2)
+	assert.NotContains(t, placed[""], "1")
+	assert.NotContains(t, placed[""], "2")
+}
+
+func TestHasCapacity(t *testing.T) {
+	t.Parallel()
+
+	m := minion{Minion: db.Minion{Provider: string(db.Vagrant), Size: "2,1"}}
+	peers := []*db.Container{{CPURequest: 0.5, RAMRequest: 1}}
+
+	assert.True(t, hasCapacity(m, peers, &db.Container{CPURequest: 0.5}))
+	assert.False(t, hasCapacity(m, peers, &db.Container{CPURequest: 0.6}))
+	assert.True(t, hasCapacity(m, peers, &db.Container{RAMRequest: 1}))
+	assert.False(t, hasCapacity(m, peers, &db.Container{RAMRequest: 1.5}))
+
+	// Minions of unknown size can fit anything.
+	m.Size = "unknown"
+	assert.True(t, hasCapacity(m, peers, &db.Container{CPURequest: 100}))
+}
+
+func TestPlaceVolumes(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker},
+		{PrivateIP: "2", Role: db.Worker,
+			Volumes: map[string]string{"block": "/dev/xvdf"}},
+	}
+	volumes := []db.Volume{
+		{Name: "host", Type: blueprint.HostVolume, Minion: "gone"},
+		{Name: "block", Type: blueprint.BlockVolume},
+		{Name: "detached", Type: blueprint.BlockVolume, Minion: "1"},
+	}
+	mount := func(name string) []blueprint.VolumeMount {
+		return []blueprint.VolumeMount{{Volume: name, MountPath: "/data"}}
+	}
+	containers := []db.Container{
+		{ID: 1, BlueprintID: "1", VolumeMounts: mount("host")},
+		{ID: 2, BlueprintID: "2", VolumeMounts: mount("host")},
+		{ID: 3, BlueprintID: "3", VolumeMounts: mount("block"), Minion: "1"},
+		{ID: 4, BlueprintID: "4", VolumeMounts: mount("detached")},
+		{ID: 5, BlueprintID: "5", VolumeMounts: mount("undefined")},
+	}
+
+	ctx := makeContext(minions, nil, containers, nil, volumes)
+	cleanupPlacements(ctx)
+	placeUnassigned(ctx)
+
+	// The host volume's minion no longer exists, so it's re-bound to the
+	// minion its first container lands on, and the other container follows.
+	assert.Equal(t, containers[0].Minion, containers[1].Minion)
+	assert.NotEmpty(t, containers[0].Minion)
+	assert.Equal(t, containers[0].Minion, volumes[0].Minion)
+
+	// The block volume is attached to minion 2, so its container moves there.
+	assert.Equal(t, "2", volumes[1].Minion)
+	assert.Equal(t, "2", containers[2].Minion)
+
+	// Containers can't be placed until their block volumes are attached.
+	assert.Empty(t, volumes[2].Minion)
+	assert.Empty(t, containers[3].Minion)
+
+	assert.Empty(t, containers[4].Minion)
+}
+
+func TestPlaceColocated(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker,
+			Provider: string(db.Vagrant), Size: "4,4"},
+		{PrivateIP: "2", Role: db.Worker,
+			Provider: string(db.Vagrant), Size: "4,4"},
+	}
+	placements := []db.Placement{
+		{TargetContainer: "cache", OtherContainer: "app"},
+		{TargetContainer: "proxy", OtherContainer: "cache"},
+	}
+	place := func(placements []db.Placement, containers []db.Container) {
+		ctx := makeContext(minions, placements, containers, nil, nil)
+		cleanupPlacements(ctx)
+		placeUnassigned(ctx)
+	}
+
+	// The app and its cache were split across minions, so the cache is moved
+	// to the app, and the proxy transitively follows them.
+	containers := []db.Container{
+		{ID: 1, Hostname: "app", Minion: "1"},
+		{ID: 2, Hostname: "cache", Minion: "2"},
+		{ID: 3, Hostname: "proxy"},
+		{ID: 4, Hostname: "other", Minion: "2"},
+	}
+	place(placements, containers)
+	assert.Equal(t, "1", containers[0].Minion)
+	assert.Equal(t, "1", containers[1].Minion)
+	assert.Equal(t, "1", containers[2].Minion)
+	assert.Equal(t, "2", containers[3].Minion)
+
+	// Groups are placed all at once, on a minion with room for all of them.
+	containers = []db.Container{
+		{ID: 1, Hostname: "app", CPURequest: 2},
+		{ID: 2, Hostname: "cache", CPURequest: 1},
+		{ID: 3, Hostname: "proxy", CPURequest: 1},
+		{ID: 4, Hostname: "other", CPURequest: 1, Minion: "1"},
+	}
+	place(placements, containers)
+	assert.Equal(t, "2", containers[0].Minion)
+	assert.Equal(t, "2", containers[1].Minion)
+	assert.Equal(t, "2", containers[2].Minion)
+
+	// If no minion can hold the entire group, none of it is placed.
+	containers[3].Minion = "2"
+	for i := range containers[:3] {
+		containers[i].Minion = ""
+	}
+	containers = append(containers, db.Container{
+		ID: 5, Hostname: "another", CPURequest: 1, Minion: "1"})
+	place(placements, containers)
+	assert.Empty(t, containers[0].Minion)
+	assert.Empty(t, containers[1].Minion)
+	assert.Empty(t, containers[2].Minion)
+
+	// Constraints that require containers to be both co-located and kept apart
+	// can't be satisfied.
+	containers = []db.Container{
+		{ID: 1, Hostname: "app"},
+		{ID: 2, Hostname: "cache"},
+		{ID: 3, Hostname: "proxy"},
+	}
+	conflicting := append(placements, db.Placement{
+		Exclusive: true, TargetContainer: "proxy", OtherContainer: "app"})
+	place(conflicting, containers)
+	for _, dbc := range containers {
+		assert.Empty(t, dbc.Minion)
+	}
+}
+
+func TestColocationGroups(t *testing.T) {
+	t.Parallel()
+
+	a := &db.Container{ID: 1, Hostname: "a"}
+	b := &db.Container{ID: 2, Hostname: "b"}
+	c := &db.Container{ID: 3, Hostname: "c"}
+	d := &db.Container{ID: 4, Hostname: "d"}
+
+	// During a rolling update, there may be two containers with the same
+	// hostname, which both need to be co-located with the group.
+	b2 := &db.Container{ID: 5, Hostname: "b"}
+
+	groups := colocationGroups([]db.Placement{
+		{TargetContainer: "a", OtherContainer: "b"},
+		{TargetContainer: "c", OtherContainer: "d", Exclusive: true},
+	}, []*db.Container{a, b, c, d, b2})
+
+	assert.Equal(t, []*db.Container{a, b, b2}, groups[a])
+	assert.Equal(t, groups[a], groups[b])
+	assert.Equal(t, groups[a], groups[b2])
+	assert.NotContains(t, groups, c)
+	assert.NotContains(t, groups, d)
+}
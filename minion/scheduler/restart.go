@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/join"
+	"github.com/kelda/kelda/minion/docker"
+)
+
+// The delay before a container that exited is restarted doubles with each
+// restart, from restartBackoffBase up to restartBackoffMax.  Containers that ran
+// for at least restartBackoffReset before exiting are restarted immediately.
+const (
+	restartBackoffBase  = 10 * time.Second
+	restartBackoffMax   = 5 * time.Minute
+	restartBackoffReset = 10 * time.Minute
+)
+
+// The statuses of containers that have exited, and either won't be restarted,
+// or are waiting for their backoff to expire.
+const (
+	exitedStatus  = db.ContainerExited
+	backoffStatus = "backing off"
+)
+
+// syncExited decides what to do with the containers that syncWorker would boot,
+// given the containers that have exited.  Containers without a matching exited
+// container are booted for the first time.  Otherwise, the exited container is
+// replaced if the restart policy allows it and its backoff has expired.
+// `retry` is how long until the next pending restart is due, or zero if there
+// is none.
+func syncExited(toBoot []interface{}, exited []docker.Container, now time.Time) (
+	changed []db.Container, boot, kill []interface{}, retry time.Duration) {
+
+	pairs, boot, kill := join.Join(toBoot, exited, exitedJoinScore)
+	for _, pair := range pairs {
+		dbc := pair.L.(evaluatedContainer)
+		dkc := pair.R.(docker.Container)
+
+		dbc.DockerID = dkc.ID
+		dbc.EndpointID = ""
+		dbc.ExitCode = dkc.ExitCode
+		dbc.OOMKilled = dkc.OOMKilled
+
+		if !shouldRestart(dbc.RestartPolicy, dkc.ExitCode) ||
+			(dbc.Job != "" && dbc.RestartCount >= dbc.RetryLimit) {
+			dbc.Status = exitedStatus
+			changed = append(changed, dbc.Container)
+			continue
+		}
+
+		wait := restartBackoff(dbc.RestartCount, dkc) - now.Sub(dkc.FinishedAt)
+		if wait > 0 {
+			dbc.Status = backoffStatus
+			changed = append(changed, dbc.Container)
+			if retry == 0 || wait < retry {
+				retry = wait
+			}
+			continue
+		}
+
+		dbc.DockerID = ""
+		dbc.Status = ""
+		dbc.RestartCount++
+		changed = append(changed, dbc.Container)
+		boot = append(boot, dbc)
+		kill = append(kill, dkc)
+	}
+	return changed, boot, kill, retry
+}
+
+// exitedJoinScore matches containers in the same way as syncJoinScore, except
+// that it ignores the IP address, which Docker releases when a container exits.
+func exitedJoinScore(left, right interface{}) int {
+	dkc := right.(docker.Container)
+	dkc.IP = left.(evaluatedContainer).IP
+	return syncJoinScore(left, dkc)
+}
+
+func shouldRestart(policy string, exitCode int) bool {
+	switch policy {
+	case blueprint.RestartNever:
+		return false
+	case blueprint.RestartOnFailure:
+		return exitCode != 0
+	default:
+		return true
+	}
+}
+
+// restartBackoff returns how long after `dkc` exited it should be restarted,
+// given that it has already been restarted `restartCount` times.
+func restartBackoff(restartCount int, dkc docker.Container) time.Duration {
+	if dkc.FinishedAt.Sub(dkc.Created) >= restartBackoffReset {
+		return 0
+	}
+
+	backoff := restartBackoffBase
+	for i := 0; i < restartCount && backoff < restartBackoffMax; i++ {
+		backoff *= 2
+	}
+
+	if backoff > restartBackoffMax {
+		return restartBackoffMax
+	}
+	return backoff
+}
+
+// exited returns whether `dbc` has exited, and is either waiting to be restarted
+// or won't be restarted at all.
+func exited(dbc db.Container) bool {
+	return dbc.Status == exitedStatus || dbc.Status == backoffStatus
+}
+
+// splitRunning partitions `dkcs` into the containers that are running, and
+// those that have exited.
+func splitRunning(dkcs []docker.Container) (running, exited []docker.Container) {
+	for _, dkc := range dkcs {
+		if dkc.Running {
+			running = append(running, dkc)
+		} else {
+			exited = append(exited, dkc)
+		}
+	}
+	return running, exited
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/minion/docker"
+	"github.com/kelda/kelda/minion/network/openflow"
+	"github.com/kelda/kelda/minion/vault"
+	"github.com/kelda/kelda/minion/vault/mocks"
+)
+
+func TestSyncExited(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	dbc := func(hostname, policy string, restarts int) evaluatedContainer {
+		return evaluatedContainer{Container: db.Container{
+			Hostname:      hostname,
+			IP:            "10.0.0.1",
+			Image:         "image",
+			EndpointID:    "endpoint",
+			RestartPolicy: policy,
+			RestartCount:  restarts,
+		}}
+	}
+	// Each exited container ran for a minute, which is too short to reset its
+	// backoff.
+	dkc := func(hostname string, exitCode int,
+		finished time.Duration) docker.Container {
+		return docker.Container{
+			ID:         hostname,
+			Hostname:   hostname + ".q",
+			Image:      "image",
+			ExitCode:   exitCode,
+			Created:    now.Add(-finished - time.Minute),
+			FinishedAt: now.Add(-finished),
+			Labels: map[string]string{
+				filesKey: filesHash(nil),
+			},
+		}
+	}
+
+	toBoot := []interface{}{
+		dbc("new", "", 0),
+		dbc("never", blueprint.RestartNever, 0),
+		dbc("succeeded", blueprint.RestartOnFailure, 0),
+		dbc("backoff", "", 2),
+		dbc("restart", blueprint.RestartOnFailure, 1),
+	}
+	exited := []docker.Container{
+		dkc("never", 1, time.Hour),
+		dkc("succeeded", 0, time.Hour),
+		dkc("backoff", 1, 30*time.Second),
+		dkc("restart", 137, time.Minute),
+		dkc("stale", 0, time.Hour),
+	}
+
+	exited[3].OOMKilled = true
+
+	// Job containers aren't retried more than their retry limit.
+	job := dbc("job", blueprint.RestartOnFailure, 1)
+	job.BlueprintID = "job-1-0"
+	job.Job = "job"
+	job.RetryLimit = 1
+	toBoot = append(toBoot, job)
+
+	jobDKC := dkc("job", 1, time.Hour)
+	jobDKC.Labels[jobKey] = "job-1-0"
+	exited = append(exited, jobDKC)
+
+	changed, boot, kill, retry := syncExited(toBoot, exited, now)
+
+	changedMap := map[string]db.Container{}
+	for _, c := range changed {
+		changedMap[c.Hostname] = c
+	}
+	assert.Len(t, changed, 5)
+
+	assert.Equal(t, exitedStatus, changedMap["never"].Status)
+	assert.Equal(t, "never", changedMap["never"].DockerID)
+	assert.Equal(t, 1, changedMap["never"].ExitCode)
+	assert.Equal(t, "", changedMap["never"].EndpointID)
+
+	assert.Equal(t, exitedStatus, changedMap["succeeded"].Status)
+	assert.Equal(t, exitedStatus, changedMap["job"].Status)
+	assert.Equal(t, 1, changedMap["job"].ExitCode)
+
+	// Two restarts give a 40 second backoff, of which 30 have passed.
+	assert.Equal(t, backoffStatus, changedMap["backoff"].Status)
+	assert.Equal(t, 10*time.Second, retry)
+
+	restarted := changedMap["restart"]
+	assert.Equal(t, 2, restarted.RestartCount)
+	assert.Equal(t, 137, restarted.ExitCode)
+	assert.True(t, restarted.OOMKilled)
+	assert.Equal(t, "", restarted.DockerID)
+
+	var bootHostnames []string
+	for _, b := range boot {
+		bootHostnames = append(bootHostnames, b.(evaluatedContainer).Hostname)
+	}
+	assert.Equal(t, []string{"new", "restart"}, bootHostnames)
+
+	var killIDs []string
+	for _, k := range kill {
+		killIDs = append(killIDs, k.(docker.Container).ID)
+	}
+	assert.Equal(t, []string{"stale", "restart"}, killIDs)
+}
+
+func TestRestartBackoff(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	dkc := docker.Container{Created: now.Add(-time.Minute), FinishedAt: now}
+	assert.Equal(t, 10*time.Second, restartBackoff(0, dkc))
+	assert.Equal(t, 80*time.Second, restartBackoff(3, dkc))
+	assert.Equal(t, restartBackoffMax, restartBackoff(100, dkc))
+
+	dkc.Created = now.Add(-time.Hour)
+	assert.Equal(t, time.Duration(0), restartBackoff(100, dkc))
+}
+
+func TestShouldRestart(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, shouldRestart("", 0))
+	assert.True(t, shouldRestart(blueprint.RestartAlways, 0))
+	assert.True(t, shouldRestart(blueprint.RestartOnFailure, 1))
+	assert.False(t, shouldRestart(blueprint.RestartOnFailure, 0))
+	assert.False(t, shouldRestart(blueprint.RestartNever, 1))
+}
+
+func TestRunWorkerRestart(t *testing.T) {
+	origNewVault := newVault
+	defer func() { newVault = origNewVault }()
+	newVault = func(_ db.Conn) (vault.SecretStore, error) {
+		return &mocks.SecretStore{}, nil
+	}
+
+	md, dk := docker.NewMock()
+	conn := db.New()
+	conn.Txn(db.AllTables...).Run(func(view db.Database) error {
+		container := view.InsertContainer()
+		container.Image = "Image"
+		container.Minion = "1.2.3.4"
+		container.IP = "10.0.0.2"
+		container.RestartPolicy = blueprint.RestartOnFailure
+		view.Commit(container)
+
+		m := view.InsertMinion()
+		m.Self = true
+		m.PrivateIP = "1.2.3.4"
+		view.Commit(m)
+
+		etcd := view.InsertEtcd()
+		etcd.LeaderIP = "leader"
+		view.Commit(etcd)
+		return nil
+	})
+	origReplaceFlows := replaceFlows
+	defer func() { replaceFlows = origReplaceFlows }()
+	replaceFlows = func(ofcs []openflow.Container) error { return nil }
+
+	runWorker(conn, dk, "1.2.3.4", "pubip")
+	dkcs, err := dk.List(nil)
+	assert.NoError(t, err)
+	assert.Len(t, dkcs, 1)
+
+	// The container crashes right after starting, so it's restarted after its
+	// backoff.
+	md.Containers[dkcs[0].ID].Created = time.Now()
+	md.ExitContainer(dkcs[0].ID, 1, false, time.Now())
+	retry := runWorker(conn, dk, "1.2.3.4", "pubip")
+	assert.True(t, retry > 0 && retry <= restartBackoffBase)
+	dbc := conn.SelectFromContainer(nil)[0]
+	assert.Equal(t, backoffStatus, dbc.Status)
+	assert.Equal(t, 1, dbc.ExitCode)
+
+	md.ExitContainer(dkcs[0].ID, 1, false, time.Now().Add(-time.Minute))
+	retry = runWorker(conn, dk, "1.2.3.4", "pubip")
+	assert.Zero(t, retry)
+	dbc = conn.SelectFromContainer(nil)[0]
+	assert.Equal(t, 1, dbc.RestartCount)
+
+	running, err := dk.List(nil)
+	assert.NoError(t, err)
+	assert.Len(t, running, 1)
+	assert.NotEqual(t, dkcs[0].ID, running[0].ID)
+	assert.Equal(t, running[0].ID, dbc.DockerID)
+
+	all, err := dk.ListAll(nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// The container exits successfully, so it isn't restarted.
+	md.Containers[running[0].ID].Created = time.Now()
+	md.ExitContainer(running[0].ID, 0, false, time.Now())
+	runWorker(conn, dk, "1.2.3.4", "pubip")
+	dbc = conn.SelectFromContainer(nil)[0]
+	assert.Equal(t, exitedStatus, dbc.Status)
+	assert.Equal(t, 0, dbc.ExitCode)
+
+	running, err = dk.List(nil)
+	assert.NoError(t, err)
+	assert.Len(t, running, 0)
+}
@@ -27,15 +27,30 @@ func Run(conn db.Conn, dk docker.Client) {
 		log.WithError(err).Fatal("Failed to configure network plugin")
 	}
 
+	go runHealthChecks(conn, dk)
+
 	loopLog := util.NewEventTimer("Scheduler")
 	trig := conn.TriggerTick(60, db.MinionTable, db.ContainerTable,
 		db.PlacementTable, db.EtcdTable, db.ImageTable).C
-	for range trig {
+
+	// The worker asks to be run again once a container that exited is due to
+	// be restarted.
+	var restart <-chan time.Time
+	for {
+		select {
+		case <-trig:
+		case <-restart:
+		}
+
 		loopLog.LogStart()
 		minion := conn.MinionSelf()
 
+		restart = nil
 		if minion.Role == db.Worker {
-			runWorker(conn, dk, minion.PrivateIP, minion.PublicIP)
+			retry := runWorker(conn, dk, minion.PrivateIP, minion.PublicIP)
+			if retry > 0 {
+				restart = time.After(retry)
+			}
 		} else if minion.Role == db.Master {
 			runMaster(conn)
 		}
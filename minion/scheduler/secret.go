@@ -31,11 +31,13 @@ func (entry cacheEntry) isValid() bool {
 // for the provided containers to be run.
 func resolveSecrets(client vault.SecretStore, dbcs []db.Container) map[string]string {
 	secretMap := map[string]string{}
+	attempted := map[string]bool{}
 	for _, dbc := range dbcs {
 		for _, name := range dbc.GetReferencedSecrets() {
-			if _, ok := secretMap[name]; ok {
+			if attempted[name] {
 				continue
 			}
+			attempted[name] = true
 
 			secretVal, err := getSecret(client, name)
 			if err == nil {
@@ -81,36 +83,86 @@ func getSecret(secretStore vault.SecretStore, name string) (string, error) {
 	return secret, nil
 }
 
+// runtimeValues returns the value of each RuntimeValue resource key for `dbc`,
+// which is about to be booted on the minion `self`.
+func runtimeValues(self db.Minion, dbc db.Container) map[string]string {
+	return map[string]string{
+		blueprint.ContainerPubIPKey: self.PublicIP,
+		blueprint.HostPrivateIPKey:  self.PrivateIP,
+		blueprint.HostFloatingIPKey: self.FloatingIP,
+		blueprint.HostProviderKey:   self.Provider,
+		blueprint.HostRegionKey:     self.Region,
+		blueprint.HostSizeKey:       self.Size,
+		blueprint.ContainerIPKey:    dbc.IP,
+	}
+}
+
 // evaluateContainerValues converts a map with ContainerValue values into raw
 // strings. It does so by looking up the value of secrets in the given secretMap,
-// and converting any RuntimeValue values.
+// and RuntimeValues in the map returned by runtimeValues().
 // Any undefined secrets are returned in the `missing` slice.
 func evaluateContainerValues(toEvaluate map[string]blueprint.ContainerValue,
-	secretMap map[string]string, myPubIP string) (map[string]string, []string) {
+	secretMap, runtimeVals map[string]string) (map[string]string, []string) {
 
 	var missing []string
 	resolved := map[string]string{}
-	for key, valIntf := range toEvaluate {
-		switch val := valIntf.Value.(type) {
-		case blueprint.Secret:
-			secret, ok := secretMap[val.NameOfSecret]
-			if !ok {
-				missing = append(missing, val.NameOfSecret)
-				continue
-			}
-			resolved[key] = secret
-		case blueprint.RuntimeValue:
-			if val.ResourceKey == blueprint.ContainerPubIPKey {
-				resolved[key] = myPubIP
-			} else {
-				log.WithField("key", val.ResourceKey).
-					Warn("Unknown RuntimeValue key")
-			}
-		case string:
-			resolved[key] = val
-		default:
-			panic("unexpected container value type")
+	for key, val := range toEvaluate {
+		str, missingSecret, ok := evaluateContainerValue(
+			val, secretMap, runtimeVals)
+		if missingSecret != "" {
+			missing = append(missing, missingSecret)
+		}
+		if ok {
+			resolved[key] = str
 		}
 	}
 	return resolved, missing
 }
+
+// evaluateCommand converts a Command with ContainerValue arguments into raw
+// strings in the same way as evaluateContainerValues.  Arguments referencing
+// unknown RuntimeValues are left empty so that the rest of the arguments keep
+// their positions.
+func evaluateCommand(command []blueprint.ContainerValue,
+	secretMap, runtimeVals map[string]string) ([]string, []string) {
+
+	var resolved, missing []string
+	for _, val := range command {
+		str, missingSecret, _ := evaluateContainerValue(
+			val, secretMap, runtimeVals)
+		if missingSecret != "" {
+			missing = append(missing, missingSecret)
+		}
+		resolved = append(resolved, str)
+	}
+	return resolved, missing
+}
+
+// evaluateContainerValue converts a single ContainerValue into a raw string.
+// If it references a secret that isn't in secretMap, the secret's name is
+// returned as `missingSecret`.  `ok` is false if the value couldn't be
+// evaluated.
+func evaluateContainerValue(valIntf blueprint.ContainerValue,
+	secretMap, runtimeVals map[string]string) (
+	resolved, missingSecret string, ok bool) {
+
+	switch val := valIntf.Value.(type) {
+	case blueprint.Secret:
+		secret, ok := secretMap[val.NameOfSecret]
+		if !ok {
+			return "", val.NameOfSecret, false
+		}
+		return secret, "", true
+	case blueprint.RuntimeValue:
+		runtimeVal, ok := runtimeVals[val.ResourceKey]
+		if !ok {
+			log.WithField("key", val.ResourceKey).
+				Warn("Unknown RuntimeValue key")
+		}
+		return runtimeVal, "", ok
+	case string:
+		return val, "", true
+	default:
+		panic("unexpected container value type")
+	}
+}
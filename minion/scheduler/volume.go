@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"os/exec"
+	"syscall"
+
+	"github.com/kelda/kelda/blueprint"
+
+	dkc "github.com/fsouza/go-dockerclient"
+)
+
+const volumesKey = "volumes"
+const blkidNoFilesystem = 2
+
+// volumeMount describes how a Docker volume is mounted into a container.
+// Device is the path of the block device backing the volume, or empty for host
+// volumes.
+type volumeMount struct {
+	name, device, target string
+}
+
+// volumeMounts resolves the volumes mounted by a container into the Docker
+// volumes that back them.  `devices` maps the names of the block volumes
+// attached to this minion to their device paths.  Volumes that aren't attached
+// as block devices are stored on the host's disk.
+func volumeMounts(vms []blueprint.VolumeMount,
+	devices map[string]string) (mounts []volumeMount) {
+
+	for _, vm := range vms {
+		mounts = append(mounts, volumeMount{
+			name:   "kelda-" + vm.Volume,
+			device: devices[vm.Volume],
+			target: vm.MountPath,
+		})
+	}
+	return mounts
+}
+
+// hostMounts converts `mounts` into Docker's representation.  Block devices
+// are mounted by the local volume driver, which Docker invokes the first time
+// the volume is used.
+func hostMounts(mounts []volumeMount) (hms []dkc.HostMount) {
+	for _, m := range mounts {
+		hm := dkc.HostMount{
+			Type:   "volume",
+			Source: m.name,
+			Target: m.target,
+		}
+
+		if m.device != "" {
+			hm.VolumeOptions = &dkc.VolumeOptions{
+				DriverConfig: dkc.VolumeDriverConfig{
+					Name: "local",
+					Options: map[string]string{
+						"type":   "ext4",
+						"device": m.device,
+					},
+				},
+			}
+		}
+		hms = append(hms, hm)
+	}
+	return hms
+}
+
+// mountsHash returns a label value that changes whenever `mounts` do, so that
+// containers are restarted when their volumes change.
+func mountsHash(mounts []volumeMount) string {
+	if len(mounts) == 0 {
+		return ""
+	}
+
+	toHash := map[string]string{}
+	for _, m := range mounts {
+		toHash[m.target] = m.name + " " + m.device
+	}
+	return filesHash(toHash)
+}
+
+// formatDevice creates an ext4 filesystem on `device` unless it already has a
+// filesystem.  New block volumes are unformatted when first attached.
+var formatDevice = func(device string) error {
+	err := exec.Command("blkid", device).Run()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return err
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || status.ExitStatus() != blkidNoFilesystem {
+		return err
+	}
+	return exec.Command("mkfs.ext4", device).Run()
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	dkc "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/kelda/blueprint"
+	"github.com/kelda/kelda/db"
+	"github.com/kelda/kelda/minion/docker"
+)
+
+func TestVolumeMounts(t *testing.T) {
+	t.Parallel()
+
+	mounts := volumeMounts([]blueprint.VolumeMount{
+		{Volume: "host", MountPath: "/host"},
+		{Volume: "block", MountPath: "/block"},
+	}, map[string]string{"block": "/dev/xvdf"})
+	assert.Equal(t, []volumeMount{
+		{name: "kelda-host", target: "/host"},
+		{name: "kelda-block", device: "/dev/xvdf", target: "/block"},
+	}, mounts)
+
+	assert.Equal(t, []dkc.HostMount{
+		{Type: "volume", Source: "kelda-host", Target: "/host"},
+		{Type: "volume", Source: "kelda-block", Target: "/block",
+			VolumeOptions: &dkc.VolumeOptions{
+				DriverConfig: dkc.VolumeDriverConfig{
+					Name: "local",
+					Options: map[string]string{
+						"type":   "ext4",
+						"device": "/dev/xvdf",
+					},
+				},
+			},
+		},
+	}, hostMounts(mounts))
+
+	assert.Empty(t, mountsHash(nil))
+	assert.NotEmpty(t, mountsHash(mounts))
+	assert.NotEqual(t, mountsHash(mounts), mountsHash(mounts[:1]))
+}
+
+func TestRunVolumes(t *testing.T) {
+	var formatted []string
+	formatDevice = func(device string) error {
+		formatted = append(formatted, device)
+		return nil
+	}
+
+	_, dk := docker.NewMock()
+	mounts := []volumeMount{
+		{name: "kelda-host", target: "/host"},
+		{name: "kelda-block", device: "/dev/xvdf", target: "/block"},
+	}
+	dbcs := []evaluatedContainer{
+		{Container: db.Container{ID: 1, Image: "Image"}, mounts: mounts},
+	}
+
+	runSync(dk, dbcs, nil)
+	dkcs, err := dk.List(nil)
+	assert.NoError(t, err)
+	assert.Len(t, dkcs, 1)
+	assert.Equal(t, mountsHash(mounts), dkcs[0].Labels[volumesKey])
+	assert.Equal(t, []string{"/dev/xvdf"}, formatted)
+
+	// The running container should be left alone until its volumes change.
+	changed := runSync(dk, dbcs, dkcs)
+	assert.Len(t, changed, 1)
+
+	dbcs[0].mounts = mounts[:1]
+	runSync(dk, dbcs, dkcs)
+	newDkcs, err := dk.List(nil)
+	assert.NoError(t, err)
+	assert.Len(t, newDkcs, 1)
+	assert.NotEqual(t, dkcs[0].ID, newDkcs[0].ID)
+
+	// Containers whose block device can't be formatted aren't started.
+	formatDevice = func(device string) error {
+		return errors.New("format error")
+	}
+	dbcs[0].mounts = mounts
+	runSync(dk, dbcs, newDkcs)
+	newDkcs, err = dk.List(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, newDkcs)
+}
@@ -25,15 +25,25 @@ const labelKey = "kelda"
 const labelValue = "scheduler"
 const labelPair = labelKey + "=" + labelValue
 const filesKey = "files"
+const jobKey = "job"
 const concurrencyLimit = 32
 
+// Docker measures CPU shares relative to 1024 per core, CPU quotas in
+// microseconds per 100ms period, and memory in bytes.
+const cpuShares = 1024
+const cpuPeriod = 100000
+const bytesPerGiB = 1 << 30
+
 var once sync.Once
 
 // evaluatedContainer represents a container as specified by the user, but
-// with all references to secrets in Env and FilepathToContent evaluated
-// to simple strings.
+// with all references to secrets in Command, Env, and FilepathToContent
+// evaluated to simple strings, and its volumes resolved to the Docker volumes
+// on this minion.
 type evaluatedContainer struct {
+	resolvedCommand                        []string
 	resolvedEnv, resolvedFilepathToContent map[string]string
+	mounts                                 []volumeMount
 	db.Container
 }
 
@@ -51,18 +61,23 @@ func isWorkerReady(conn db.Conn) bool {
 	return false
 }
 
-func runWorker(conn db.Conn, dk docker.Client, myPrivIP, myPubIP string) {
+// runWorker boots and stops the containers scheduled on this minion, and returns
+// how long until it should run again to restart a container that exited, or zero
+// if there's no restart pending.
+func runWorker(conn db.Conn, dk docker.Client, myPrivIP, myPubIP string) (
+	retry time.Duration) {
+
 	if myPrivIP == "" || myPubIP == "" {
-		return
+		return 0
 	}
-	myContainers := func(dbc db.Container) bool {
-		return dbc.IP != "" && dbc.Minion == myPrivIP
+	hasIP := func(dbc db.Container) bool {
+		return dbc.IP != ""
 	}
 
 	vaultClient, err := newVault(conn)
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to Vault")
-		return
+		return 0
 	}
 
 	// In order for the flows installed by the plugin to work, the basic flows must
@@ -76,11 +91,12 @@ func runWorker(conn db.Conn, dk docker.Client, myPrivIP, myPubIP string) {
 
 	var toBoot, toKill []interface{}
 	for i := 0; i < 2; i++ {
-		dkcs, err := dk.List(filter)
+		dkcs, err := dk.ListAll(filter)
 		if err != nil {
 			log.WithError(err).Warning("Failed to list docker containers.")
-			return
+			return 0
 		}
+		running, exited := splitRunning(dkcs)
 
 		// Get all the secret values referenced by the containers scheduled
 		// for this minion. This must be done outside the database transaction
@@ -89,20 +105,34 @@ func runWorker(conn db.Conn, dk docker.Client, myPrivIP, myPubIP string) {
 		// are used to determine whether any containers have out of date
 		// secret values, and thus need to be restarted.
 		secretMap := resolveSecrets(
-			vaultClient, conn.SelectFromContainer(myContainers))
+			vaultClient, conn.SelectFromContainerByMinion(myPrivIP, hasIP))
 
 		// Join the scheduled containers with the containers actually running
 		// to figure out what containers to boot and stop.
-		conn.Txn(db.ContainerTable).Run(func(view db.Database) error {
+		txn := conn.Txn(db.ContainerTable, db.MinionTable)
+		txn.Run(func(view db.Database) error {
+			self := db.Minion{PrivateIP: myPrivIP}
+			selfs := view.SelectFromMinion(func(m db.Minion) bool {
+				return m.Self
+			})
+			if len(selfs) == 1 {
+				self = selfs[0]
+			}
+			self.PublicIP = myPubIP
+
 			var readyToRun []evaluatedContainer
-			for _, dbc := range view.SelectFromContainer(myContainers) {
+			for _, dbc := range view.SelectFromContainerByMinion(
+				myPrivIP, hasIP) {
+				runtimeVals := runtimeValues(self, dbc)
+				resolvedCmd, missingCmd := evaluateCommand(
+					dbc.Command, secretMap, runtimeVals)
 				resolvedEnv, missingEnv := evaluateContainerValues(
-					dbc.Env, secretMap, myPubIP)
+					dbc.Env, secretMap, runtimeVals)
 				resolvedFiles, missingFiles := evaluateContainerValues(
-					dbc.FilepathToContent, secretMap, myPubIP)
+					dbc.FilepathToContent, secretMap, runtimeVals)
 
-				missingSecrets := uniqueStrings(
-					append(missingEnv, missingFiles...))
+				missingSecrets := uniqueStrings(append(append(
+					missingCmd, missingEnv...), missingFiles...))
 				if len(missingSecrets) != 0 {
 					sort.Strings(missingSecrets)
 					dbc.Status = fmt.Sprintf(
@@ -111,16 +141,23 @@ func runWorker(conn db.Conn, dk docker.Client, myPrivIP, myPubIP string) {
 					continue
 				}
 
+				mounts := volumeMounts(dbc.VolumeMounts, self.Volumes)
 				readyToRun = append(readyToRun, evaluatedContainer{
+					resolvedCommand:           resolvedCmd,
 					resolvedEnv:               resolvedEnv,
 					resolvedFilepathToContent: resolvedFiles,
+					mounts:                    mounts,
 					Container:                 dbc,
 				})
 			}
 
-			var changed []db.Container
-			changed, toBoot, toKill = syncWorker(readyToRun, dkcs)
-			for _, dbc := range changed {
+			var changed, exitedChanged []db.Container
+			var exitedKill []interface{}
+			changed, toBoot, toKill = syncWorker(readyToRun, running)
+			exitedChanged, toBoot, exitedKill, retry = syncExited(
+				toBoot, exited, time.Now())
+			toKill = append(toKill, exitedKill...)
+			for _, dbc := range append(changed, exitedChanged...) {
 				view.Commit(dbc)
 			}
 
@@ -139,6 +176,7 @@ func runWorker(conn db.Conn, dk docker.Client, myPrivIP, myPubIP string) {
 	}
 
 	updateOpenflow(conn, myPrivIP)
+	return retry
 }
 
 func syncWorker(dbcs []evaluatedContainer, dkcs []docker.Container) (
@@ -183,19 +221,44 @@ func dockerRun(dk docker.Client, iface interface{}) {
 	dbc := iface.(evaluatedContainer)
 	log.WithField("container", dbc).Info("Start container")
 
+	for _, m := range dbc.mounts {
+		if m.device == "" {
+			continue
+		}
+
+		if err := formatDevice(m.device); err != nil {
+			log.WithError(err).WithField("device", m.device).Warning(
+				"Failed to format volume")
+			return
+		}
+	}
+
+	labels := map[string]string{
+		labelKey:   labelValue,
+		filesKey:   filesHash(dbc.resolvedFilepathToContent),
+		volumesKey: mountsHash(dbc.mounts),
+	}
+	if label := jobLabel(dbc.Container); label != "" {
+		labels[jobKey] = label
+	}
+
+	shares, quota, mem, memReservation := resourceOptions(dbc.Container)
 	_, err := dk.Run(docker.RunOptions{
 		Hostname:          dbc.Hostname + ".q",
 		Image:             dbc.Image,
-		Args:              dbc.Command,
+		Args:              dbc.resolvedCommand,
 		Env:               dbc.resolvedEnv,
 		FilepathToContent: dbc.resolvedFilepathToContent,
-		Labels: map[string]string{
-			labelKey: labelValue,
-			filesKey: filesHash(dbc.resolvedFilepathToContent),
-		},
-		IP:          dbc.IP,
-		NetworkMode: plugin.NetworkName,
-		DNS:         []string{ipdef.GatewayIP.String()},
+		Labels:            labels,
+		Mounts:            hostMounts(dbc.mounts),
+		IP:                dbc.IP,
+		NetworkMode:       plugin.NetworkName,
+		DNS:               []string{ipdef.GatewayIP.String()},
+
+		CPUShares:         shares,
+		CPUQuota:          quota,
+		Memory:            mem,
+		MemoryReservation: memReservation,
 	})
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -205,6 +268,16 @@ func dockerRun(dk docker.Client, iface interface{}) {
 	}
 }
 
+// resourceOptions converts the resource requests and limits of `dbc` into the
+// units expected by Docker.
+func resourceOptions(dbc db.Container) (shares, quota, mem, memReservation int64) {
+	shares = int64(dbc.CPURequest * cpuShares)
+	quota = int64(dbc.CPULimit * cpuPeriod)
+	mem = int64(dbc.RAMLimit * bytesPerGiB)
+	memReservation = int64(dbc.RAMRequest * bytesPerGiB)
+	return shares, quota, mem, memReservation
+}
+
 func dockerKill(dk docker.Client, iface interface{}) {
 	dkc := iface.(docker.Container)
 	log.WithField("container", dkc.ID).Info("Remove container")
@@ -222,7 +295,9 @@ func syncJoinScore(left, right interface{}) int {
 
 	expFilesHash := filesHash(dbc.resolvedFilepathToContent)
 	if dbc.Hostname+".q" != dkc.Hostname || dbc.IP != dkc.IP ||
-		expFilesHash != dkc.Labels[filesKey] {
+		expFilesHash != dkc.Labels[filesKey] ||
+		mountsHash(dbc.mounts) != dkc.Labels[volumesKey] ||
+		jobLabel(dbc.Container) != dkc.Labels[jobKey] {
 		return -1
 	}
 
@@ -233,6 +308,12 @@ func syncJoinScore(left, right interface{}) int {
 		return -1
 	}
 
+	shares, quota, mem, memReservation := resourceOptions(dbc.Container)
+	if dkc.CPUShares != shares || dkc.CPUQuota != quota ||
+		dkc.Memory != mem || dkc.MemoryReservation != memReservation {
+		return -1
+	}
+
 	for key, value := range dbc.resolvedEnv {
 		if dkc.Env[key] != value {
 			return -1
@@ -244,15 +325,24 @@ func syncJoinScore(left, right interface{}) int {
 	// handle that case, we check both.
 	cmd1 := dkc.Args
 	cmd2 := append([]string{dkc.Path}, dkc.Args...)
-	if len(dbc.Command) != 0 &&
-		!str.SliceEq(dbc.Command, cmd1) &&
-		!str.SliceEq(dbc.Command, cmd2) {
+	if len(dbc.resolvedCommand) != 0 &&
+		!str.SliceEq(dbc.resolvedCommand, cmd1) &&
+		!str.SliceEq(dbc.resolvedCommand, cmd2) {
 		return -1
 	}
 
 	return 0
 }
 
+// jobLabel distinguishes the copies of a job's container, whose specs are
+// otherwise identical, so that a new copy isn't mistaken for one that exited.
+func jobLabel(dbc db.Container) string {
+	if dbc.Job == "" {
+		return ""
+	}
+	return dbc.BlueprintID
+}
+
 func filesHash(filepathToContent map[string]string) string {
 	toHash := str.MapAsString(filepathToContent)
 	return fmt.Sprintf("%x", sha1.Sum([]byte(toHash)))
@@ -264,9 +354,10 @@ func updateOpenflow(conn db.Conn, myIP string) {
 
 	txn := func(view db.Database) error {
 		conns = view.SelectFromConnection(nil)
-		dbcs = view.SelectFromContainer(func(dbc db.Container) bool {
-			return dbc.EndpointID != "" && dbc.IP != "" && dbc.Minion == myIP
-		})
+		dbcs = view.SelectFromContainerByMinion(myIP,
+			func(dbc db.Container) bool {
+				return dbc.EndpointID != "" && dbc.IP != ""
+			})
 		return nil
 	}
 	conn.Txn(db.ConnectionTable, db.ContainerTable).Run(txn)
@@ -277,11 +368,26 @@ func updateOpenflow(conn db.Conn, myIP string) {
 	}
 }
 
+// portRanges returns the OpenFlow port ranges of each protocol that `conn`
+// allows on the ports [minPort, maxPort].  ICMP has no ports.
+func portRanges(conn db.Connection, minPort, maxPort int) (
+	ranges []openflow.PortRange) {
+
+	for _, protocol := range conn.Protocols() {
+		r := openflow.PortRange{Protocol: protocol}
+		if protocol != blueprint.ICMPProtocol {
+			r.Min, r.Max = minPort, maxPort
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
 func openflowContainers(dbcs []db.Container,
 	conns []db.Connection) []openflow.Container {
 
-	fromPubPorts := map[string][]int{}
-	toPubPorts := map[string][]int{}
+	fromPubPorts := map[string][]openflow.PortRange{}
+	toPubPorts := map[string][]openflow.PortRange{}
 	for _, conn := range conns {
 		for _, from := range conn.From {
 			for _, to := range conn.To {
@@ -290,21 +396,18 @@ func openflowContainers(dbcs []db.Container,
 					continue
 				}
 
-				if conn.MinPort != conn.MaxPort {
-					c.Inc("Unsupported Public Port Range")
-					log.WithField("connection", conn).Debug(
-						"Unsupported Public Port Range")
-					continue
-				}
-
+				// Inbound public traffic reaches the container on
+				// the ports it's remapped to.
 				if from == blueprint.PublicInternetLabel {
+					minPort, maxPort := conn.ContainerPorts()
 					fromPubPorts[to] = append(fromPubPorts[to],
-						conn.MinPort)
+						portRanges(conn, minPort, maxPort)...)
 				}
 
 				if to == blueprint.PublicInternetLabel {
 					toPubPorts[from] = append(toPubPorts[from],
-						conn.MinPort)
+						portRanges(conn, conn.MinPort,
+							conn.MaxPort)...)
 				}
 			}
 		}
@@ -320,8 +423,8 @@ func openflowContainers(dbcs []db.Container,
 			Mac:   ipdef.IPStrToMac(dbc.IP),
 			IP:    dbc.IP,
 
-			ToPub:   map[int]struct{}{},
-			FromPub: map[int]struct{}{},
+			ToPub:   map[openflow.PortRange]struct{}{},
+			FromPub: map[openflow.PortRange]struct{}{},
 		}
 
 		for _, p := range toPubPorts[dbc.Hostname] {
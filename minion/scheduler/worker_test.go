@@ -83,6 +83,11 @@ func TestRunWorker(t *testing.T) {
 		dbc.Env = map[string]blueprint.ContainerValue{
 			envKey: blueprint.NewSecret(secretName),
 		}
+		dbc.Command = []blueprint.ContainerValue{
+			blueprint.NewString("--password"),
+			blueprint.NewSecret(secretName),
+			blueprint.NewRuntimeValue(blueprint.ContainerIPKey),
+		}
 		view.Commit(dbc)
 		return nil
 	})
@@ -114,6 +119,8 @@ func TestRunWorker(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, dkcs, 1)
 	assert.Equal(t, map[string]string{envKey: secretVal}, dkcs[0].Env)
+	assert.Equal(t, []string{"--password", secretVal, "10.0.0.2"},
+		dkcs[0].Args)
 
 	// The running container's ID should be committed to the database.
 	assert.Equal(t, dkcs[0].ID, dbc.DockerID)
@@ -146,9 +153,10 @@ func TestSyncWorker(t *testing.T) {
 			Container: db.Container{
 				ID:      1,
 				Image:   "Image1",
-				Command: []string{"Cmd1"},
+				Command: blueprint.NewStrings("Cmd1"),
 			},
-			resolvedEnv: map[string]string{"Env": "1"},
+			resolvedCommand: []string{"Cmd1"},
+			resolvedEnv:     map[string]string{"Env": "1"},
 		},
 	}
 
@@ -185,7 +193,7 @@ func TestSyncWorker(t *testing.T) {
 	// Ensure that the booted container has the attributes specified in the
 	// database.
 	assert.Equal(t, evaluatedDbcs[0].Image, dkcs[0].Image)
-	assert.Equal(t, evaluatedDbcs[0].Command, dkcs[0].Args)
+	assert.Equal(t, evaluatedDbcs[0].resolvedCommand, dkcs[0].Args)
 	assert.Equal(t, evaluatedDbcs[0].resolvedEnv, dkcs[0].Env)
 
 	// Unassign the DockerID, and run the sync again. Even though the DockerID
@@ -267,9 +275,9 @@ func TestSyncJoinScore(t *testing.T) {
 			Hostname: "hostname",
 			IP:       "1.2.3.4",
 			Image:    "Image",
-			Command:  []string{"cmd"},
 			DockerID: "DockerID",
 		},
+		resolvedCommand:           []string{"cmd"},
 		resolvedEnv:               map[string]string{"a": "b"},
 		resolvedFilepathToContent: map[string]string{"c": "d"},
 	}
@@ -277,7 +285,7 @@ func TestSyncJoinScore(t *testing.T) {
 		Hostname: "hostname.q",
 		IP:       "1.2.3.4",
 		Image:    dbc.Image,
-		Args:     dbc.Command,
+		Args:     dbc.resolvedCommand,
 		Env:      dbc.resolvedEnv,
 		Labels: map[string]string{
 			filesKey: filesHash(dbc.resolvedFilepathToContent),
@@ -296,11 +304,11 @@ func TestSyncJoinScore(t *testing.T) {
 	score = syncJoinScore(dbc, dkc)
 	assert.Zero(t, score)
 
-	dbc.Command = []string{"wrong"}
+	dbc.resolvedCommand = []string{"wrong"}
 	score = syncJoinScore(dbc, dkc)
 	assert.Equal(t, -1, score)
 
-	dbc.Command = dkc.Args
+	dbc.resolvedCommand = dkc.Args
 	score = syncJoinScore(dbc, dkc)
 	assert.Zero(t, score)
 
@@ -312,7 +320,7 @@ func TestSyncJoinScore(t *testing.T) {
 	score = syncJoinScore(dbc, dkc)
 	assert.Zero(t, score)
 
-	dbc.Command = dkc.Args
+	dbc.resolvedCommand = dkc.Args
 	dbc.resolvedEnv = map[string]string{"a": "wrong"}
 	score = syncJoinScore(dbc, dkc)
 	assert.Equal(t, -1, score)
@@ -327,7 +335,7 @@ func TestSyncJoinScore(t *testing.T) {
 	assert.Zero(t, score)
 
 	dkc.ImageID = "id"
-	dbc.Command = dkc.Args
+	dbc.resolvedCommand = dkc.Args
 	dbc.resolvedEnv = dkc.Env
 	dbc.ImageID = dkc.ImageID
 	score = syncJoinScore(dbc, dkc)
@@ -336,28 +344,62 @@ func TestSyncJoinScore(t *testing.T) {
 	dbc.ImageID = "wrong"
 	score = syncJoinScore(dbc, dkc)
 	assert.Equal(t, -1, score)
+
+	dbc.ImageID = dkc.ImageID
+	dbc.CPULimit = 0.5
+	dbc.RAMRequest = 1
+	score = syncJoinScore(dbc, dkc)
+	assert.Equal(t, -1, score)
+
+	dkc.CPUQuota = 50000
+	dkc.MemoryReservation = 1 << 30
+	score = syncJoinScore(dbc, dkc)
+	assert.Zero(t, score)
+
+	// Each copy of a job's container is distinct, even though their specs
+	// are the same.
+	dbc.BlueprintID = "job-1-1"
+	dbc.Job = "job"
+	dkc.Labels[jobKey] = "job-1-0"
+	score = syncJoinScore(dbc, dkc)
+	assert.Equal(t, -1, score)
+
+	dkc.Labels[jobKey] = "job-1-1"
+	score = syncJoinScore(dbc, dkc)
+	assert.Zero(t, score)
 }
 
 func TestOpenFlowContainers(t *testing.T) {
 	conns := []db.Connection{
 		{MinPort: 1, MaxPort: 1000},
 		{MinPort: 2, MaxPort: 2, From: []string{blueprint.PublicInternetLabel},
-			To: []string{"red"}},
+			To: []string{"red"}, Protocol: blueprint.UDPProtocol},
 		{MinPort: 3, MaxPort: 3, To: []string{blueprint.PublicInternetLabel},
-			From: []string{"red"}},
+			From: []string{"red"}, Protocol: blueprint.TCPProtocol},
 		{MinPort: 4, MaxPort: 4, To: []string{blueprint.PublicInternetLabel},
-			From: []string{"blue"}}}
+			From: []string{"blue"}},
+		{MinPort: 5, MaxPort: 6, To: []string{blueprint.PublicInternetLabel},
+			From: []string{"red"}, Protocol: blueprint.TCPProtocol},
+		{MinPort: 80, MaxPort: 81, ContainerPort: 8080,
+			From: []string{blueprint.PublicInternetLabel},
+			To:   []string{"red"}}}
 
 	res := openflowContainers([]db.Container{
 		{EndpointID: "f", IP: "1.2.3.4", Hostname: "red"}},
 		conns)
 	exp := []openflow.Container{{
-		Veth:    "f",
-		Patch:   "q_f",
-		IP:      "1.2.3.4",
-		Mac:     "02:00:01:02:03:04",
-		ToPub:   map[int]struct{}{3: {}},
-		FromPub: map[int]struct{}{2: {}},
+		Veth:  "f",
+		Patch: "q_f",
+		IP:    "1.2.3.4",
+		Mac:   "02:00:01:02:03:04",
+		ToPub: map[openflow.PortRange]struct{}{
+			{Min: 3, Max: 3, Protocol: "tcp"}: {},
+			{Min: 5, Max: 6, Protocol: "tcp"}: {}},
+		FromPub: map[openflow.PortRange]struct{}{
+			{Min: 2, Max: 2, Protocol: "udp"}:       {},
+			{Min: 8080, Max: 8081, Protocol: "tcp"}: {},
+			{Min: 8080, Max: 8081, Protocol: "udp"}: {},
+			{Protocol: "icmp"}:                      {}},
 	}}
 	assert.Equal(t, exp, res)
 }
@@ -369,7 +411,13 @@ func TestEvaluateContainerValues(t *testing.T) {
 	rawStringVal := "string"
 	pubIPKey := "pubIPKey"
 	pubIP := "pubIP"
+	regionKey := "regionKey"
+	region := "us-west-1"
 	secretKey := "secretKey"
+	runtimeVals := map[string]string{
+		blueprint.ContainerPubIPKey: pubIP,
+		blueprint.HostRegionKey:     region,
+	}
 
 	secretName := "secretName"
 	secretVal := "secretVal"
@@ -382,12 +430,14 @@ func TestEvaluateContainerValues(t *testing.T) {
 		rawStringKey: blueprint.NewString(rawStringVal),
 		secretKey:    blueprint.NewSecret(secretName),
 		pubIPKey:     blueprint.NewRuntimeValue(blueprint.ContainerPubIPKey),
+		regionKey:    blueprint.NewRuntimeValue(blueprint.HostRegionKey),
 	}
-	resMap, resMissing := evaluateContainerValues(input, secretMap, pubIP)
+	resMap, resMissing := evaluateContainerValues(input, secretMap, runtimeVals)
 	exp := map[string]string{
 		rawStringKey: rawStringVal,
 		secretKey:    secretVal,
 		pubIPKey:     pubIP,
+		regionKey:    region,
 	}
 	assert.Equal(t, exp, resMap)
 	assert.Empty(t, resMissing)
@@ -395,7 +445,7 @@ func TestEvaluateContainerValues(t *testing.T) {
 	// Test when there is an undefined resource key. The undefined key should
 	// be ignored, but the other values should be defined.
 	input[pubIPKey] = blueprint.NewRuntimeValue("undefined")
-	resMap, resMissing = evaluateContainerValues(input, secretMap, pubIP)
+	resMap, resMissing = evaluateContainerValues(input, secretMap, runtimeVals)
 	delete(exp, pubIPKey)
 	assert.Equal(t, exp, resMap)
 	assert.Empty(t, resMissing)
@@ -405,6 +455,56 @@ func TestEvaluateContainerValues(t *testing.T) {
 	undefinedSecretName := "undefined"
 	_, resMissing = evaluateContainerValues(map[string]blueprint.ContainerValue{
 		secretKey: blueprint.NewSecret(undefinedSecretName),
-	}, secretMap, pubIP)
+	}, secretMap, runtimeVals)
 	assert.Equal(t, []string{undefinedSecretName}, resMissing)
 }
+
+func TestEvaluateCommand(t *testing.T) {
+	t.Parallel()
+
+	secretMap := map[string]string{"secret": "secretVal"}
+	runtimeVals := map[string]string{blueprint.ContainerIPKey: "10.0.0.2"}
+
+	cmd, missing := evaluateCommand([]blueprint.ContainerValue{
+		blueprint.NewString("run"),
+		blueprint.NewSecret("secret"),
+		blueprint.NewRuntimeValue(blueprint.ContainerIPKey),
+		blueprint.NewRuntimeValue("undefined"),
+		blueprint.NewString("last"),
+	}, secretMap, runtimeVals)
+	assert.Equal(t, []string{"run", "secretVal", "10.0.0.2", "", "last"}, cmd)
+	assert.Empty(t, missing)
+
+	_, missing = evaluateCommand([]blueprint.ContainerValue{
+		blueprint.NewSecret("undefined"),
+	}, secretMap, runtimeVals)
+	assert.Equal(t, []string{"undefined"}, missing)
+}
+
+func TestRuntimeValues(t *testing.T) {
+	t.Parallel()
+
+	self := db.Minion{
+		PublicIP:   "8.8.8.8",
+		PrivateIP:  "10.0.0.2",
+		FloatingIP: "1.2.3.4",
+		Provider:   "Amazon",
+		Region:     "us-west-1",
+		Size:       "m4.large",
+	}
+	assert.Equal(t, map[string]string{
+		blueprint.ContainerPubIPKey: "8.8.8.8",
+		blueprint.HostPrivateIPKey:  "10.0.0.2",
+		blueprint.HostFloatingIPKey: "1.2.3.4",
+		blueprint.HostProviderKey:   "Amazon",
+		blueprint.HostRegionKey:     "us-west-1",
+		blueprint.HostSizeKey:       "m4.large",
+		blueprint.ContainerIPKey:    "10.1.0.5",
+	}, runtimeValues(self, db.Container{IP: "10.1.0.5"}))
+
+	// Every resource key accepted by the blueprint has a value.
+	for _, key := range blueprint.RuntimeValueKeys {
+		_, ok := runtimeValues(self, db.Container{})[key]
+		assert.True(t, ok, key)
+	}
+}
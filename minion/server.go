@@ -41,6 +41,7 @@ func (s server) GetMinionConfig(cts context.Context,
 	cfg.Region = m.Region
 	cfg.AuthorizedKeys = strings.Split(m.AuthorizedKeys, "\n")
 	cfg.MinionIPToPublicKey = m.MinionIPToPublicKey
+	cfg.Volumes = m.Volumes
 
 	s.Txn(db.EtcdTable).Run(func(view db.Database) error {
 		if etcdRow, err := view.GetEtcd(); err == nil {
@@ -67,6 +68,7 @@ func (s server) SetMinionConfig(ctx context.Context,
 		minion.FloatingIP = msg.FloatingIP
 		minion.AuthorizedKeys = strings.Join(msg.AuthorizedKeys, "\n")
 		minion.MinionIPToPublicKey = msg.MinionIPToPublicKey
+		minion.Volumes = msg.Volumes
 		minion.Self = true
 		view.Commit(minion)
 